@@ -0,0 +1,304 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"flag"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/gorilla/handlers"
+	"github.com/joho/godotenv"
+	"github.com/redis/go-redis/v9"
+
+	"github.com/Entity069/Zesty-Go/pkg/api"
+	"github.com/Entity069/Zesty-Go/pkg/catalog"
+	"github.com/Entity069/Zesty-Go/pkg/config"
+	"github.com/Entity069/Zesty-Go/pkg/health"
+	"github.com/Entity069/Zesty-Go/pkg/logging"
+	"github.com/Entity069/Zesty-Go/pkg/metrics"
+	"github.com/Entity069/Zesty-Go/pkg/middleware"
+	"github.com/Entity069/Zesty-Go/pkg/models"
+	"github.com/Entity069/Zesty-Go/pkg/preorders"
+	"github.com/Entity069/Zesty-Go/pkg/storage"
+	"github.com/Entity069/Zesty-Go/pkg/tracing"
+	"github.com/Entity069/Zesty-Go/pkg/uploadgc"
+	"github.com/Entity069/Zesty-Go/pkg/utils"
+)
+
+func main() {
+	_ = godotenv.Load()
+
+	configPath := flag.String("config", os.Getenv("CONFIG_FILE"), "path to a YAML or TOML config file")
+	printConfig := flag.Bool("print-config", false, "print the resolved configuration with secrets redacted and exit")
+	flag.Usage = usage
+	flag.Parse()
+
+	cfg, err := config.Load(*configPath)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+
+	logger, err := logging.New(cfg.Log, os.Stderr)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	slog.SetDefault(logger)
+
+	if *printConfig {
+		out, err := cfg.Redacted().YAML()
+		if err != nil {
+			fatal("rendering config", err)
+		}
+		os.Stdout.Write(out)
+		return
+	}
+
+	args := flag.Args()
+	command := "serve"
+	if len(args) > 0 {
+		command, args = args[0], args[1:]
+	}
+
+	switch command {
+	case "serve":
+		serve(cfg)
+	case "migrate":
+		os.Exit(runMigrate(cfg, args))
+	default:
+		fmt.Fprintf(os.Stderr, "unknown command %q\n\n", command)
+		usage()
+		os.Exit(2)
+	}
+}
+
+func usage() {
+	fmt.Fprint(os.Stderr, `usage: zesty [flags] [command]
+
+commands:
+  serve                     run the HTTP server (default)
+  migrate [-seeds] up [N]   apply all, or the next N, pending migrations
+  migrate [-seeds] down [N | -all]
+                            revert the last N (default 1) or every migration
+  migrate [-seeds] status   show applied and pending migrations
+  migrate [-seeds] force V  mark version V as applied and clean
+
+flags:
+`)
+	flag.PrintDefaults()
+}
+
+// fatal logs err and exits. Like log.Fatal, deferred calls don't run.
+func fatal(msg string, err error) {
+	slog.Error(msg, "err", err)
+	os.Exit(1)
+}
+
+// openDB retries until the database answers or cfg.DB.ConnectTimeout
+// passes, so the server can start alongside a MySQL that is still booting.
+func openDB(cfg *config.Config) (*sql.DB, error) {
+	deadline := time.Now().Add(cfg.DB.ConnectTimeout)
+	for {
+		db, err := models.Open(cfg.DB)
+		if err == nil || time.Now().After(deadline) {
+			return db, err
+		}
+		slog.Warn("database not reachable yet, retrying", "err", err)
+		time.Sleep(2 * time.Second)
+	}
+}
+
+func serve(cfg *config.Config) {
+	if err := cfg.Email.Validate(); err != nil {
+		fatal("checking the mail settings", err)
+	}
+	db, err := openDB(cfg)
+	if err != nil {
+		fatal("opening database", err)
+	}
+	slog.Info("db connected", "max_open", cfg.DB.MaxOpenConns, "max_idle", cfg.DB.MaxIdleConns, "max_lifetime", cfg.DB.ConnMaxLifetime)
+	defer func() {
+		slog.Info("closing database connection pool")
+		if err := db.Close(); err != nil {
+			slog.Error("closing database", "err", err)
+		}
+	}()
+
+	migrator, err := newMigrator(db, false)
+	if err != nil {
+		fatal("loading migrations", err)
+	}
+	if cfg.DB.AutoMigrate {
+		n, err := migrator.Up(context.Background(), 0)
+		if err != nil {
+			fatal("auto-migrate", err)
+		}
+		slog.Info("auto-migrate finished", "applied", n)
+	}
+
+	var cache models.ItemsCache = models.NewMemoryItemsCache(cfg.Cache.TTL)
+	if cfg.Cache.Backend == "redis" {
+		opts, err := redis.ParseURL(cfg.Cache.RedisURL)
+		if err != nil {
+			fatal("invalid REDIS_URL", err)
+		}
+		client := redis.NewClient(opts)
+		defer client.Close()
+
+		redisCache, err := models.NewRedisItemsCache(context.Background(), client, cfg.Cache.TTL)
+		if err != nil {
+			fatal("connecting the redis cache", err)
+		}
+		defer redisCache.Close()
+		cache = redisCache
+		slog.Info("items cache: redis", "addr", opts.Addr)
+	}
+
+	models.StartCacheCleanup(cache, cfg.Cache.CleanupInterval)
+
+	if err := metrics.RegisterDB(db); err != nil {
+		fatal("registering database metrics", err)
+	}
+
+	shutdownTracing, err := tracing.Setup(context.Background(), cfg.Tracing, os.Stdout)
+	if err != nil {
+		fatal("setting up tracing", err)
+	}
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := shutdownTracing(ctx); err != nil {
+			slog.Error("flushing traces", "err", err)
+		}
+	}()
+	if cfg.Tracing.Exporter != "none" {
+		slog.Info("tracing enabled", "exporter", cfg.Tracing.Exporter, "sample_ratio", cfg.Tracing.SampleRatio)
+	}
+
+	store := models.NewStore(db, cache, models.QueryOptions{
+		Timeout:       cfg.DB.QueryTimeout,
+		SlowThreshold: cfg.DB.SlowQueryThreshold,
+	})
+
+	blobs, err := storage.New(context.Background(), cfg.Uploads)
+	if err != nil {
+		fatal("opening upload storage", err)
+	}
+	slog.Info("upload storage", "backend", cfg.Uploads.Backend)
+
+	hc := health.New(2 * time.Second)
+	hc.Add("database", db.PingContext)
+	hc.Add("migrations", migrator.Check)
+	hc.Add("uploads", blobs.Check)
+	// Mail only holds up sign-ups and resets, so it doesn't take the
+	// server out of rotation, and the SMTP server isn't dialled per probe.
+	hc.AddOptional("email", health.Cached(utils.NewMailer(cfg.Email).Check, time.Minute))
+
+	fetcher := catalog.NewFetcher(store, blobs, cfg.Uploads.MaxBytes, nil)
+	router := api.NewRouter(cfg, store, blobs, fetcher, hc)
+
+	corsHandler := handlers.CORS(
+		handlers.AllowedOrigins([]string{
+			cfg.Server.FrontendURL,
+		}),
+		handlers.AllowedMethods([]string{"GET", "POST", "PUT", "PATCH", "DELETE"}),
+		handlers.AllowedHeaders([]string{
+			"Accept",
+			"Content-Type",
+			"Content-Length",
+			"Accept-Encoding",
+			"X-CSRF-Token",
+			middleware.RequestIDHeader,
+			"traceparent",
+			"tracestate",
+		}),
+		handlers.ExposedHeaders([]string{middleware.RequestIDHeader, "Deprecation", "Link"}),
+		handlers.AllowCredentials(),
+	)(router)
+
+	addr := cfg.Server.Addr
+
+	// Every request context hangs off baseCtx, so cancelling it reaches the
+	// SQL of any handler still running when shutdown gives up waiting.
+	baseCtx, cancelRequests := context.WithCancel(context.Background())
+	defer cancelRequests()
+
+	if gc := cfg.Uploads.GC; gc.Interval > 0 {
+		uploadgc.Start(baseCtx, store, blobs, gc.Interval, uploadgc.Options{Grace: gc.Grace, Retention: gc.Retention})
+		slog.Info("upload collector scheduled", "interval", gc.Interval, "grace", gc.Grace, "retention", gc.Retention)
+	}
+	if pre := cfg.Shop.Preorders; pre.ReleaseInterval > 0 {
+		preorders.Start(baseCtx, store, pre.ReleaseInterval, pre.LeadTime)
+		slog.Info("scheduled order release running", "interval", pre.ReleaseInterval, "lead_time", pre.LeadTime)
+	}
+
+	srv := &http.Server{
+		Addr:              addr,
+		Handler:           middleware.Tracing(middleware.RequestLogger(slog.Default())(corsHandler)),
+		ReadHeaderTimeout: 10 * time.Second,
+		BaseContext:       func(net.Listener) context.Context { return baseCtx },
+		ErrorLog:          slog.NewLogLogger(slog.Default().Handler(), slog.LevelWarn),
+	}
+
+	go func() {
+		slog.Info("app listening", "addr", addr)
+		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			fatal("server error", err)
+		}
+	}()
+
+	// The admin listener is kept off the public address so /metrics is
+	// only reachable from wherever Prometheus scrapes.
+	var adminSrv *http.Server
+	if cfg.Server.AdminAddr != "" {
+		adminMux := http.NewServeMux()
+		adminMux.Handle("GET /metrics", metrics.Handler())
+		adminSrv = &http.Server{
+			Addr:              cfg.Server.AdminAddr,
+			Handler:           adminMux,
+			ReadHeaderTimeout: 10 * time.Second,
+		}
+		go func() {
+			slog.Info("admin listening", "addr", cfg.Server.AdminAddr)
+			if err := adminSrv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+				fatal("admin server error", err)
+			}
+		}()
+	}
+
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+	<-quit
+
+	slog.Info("shutting down")
+	hc.SetDraining()
+	if cfg.Server.DrainDelay > 0 {
+		slog.Info("reporting not ready before closing the listener", "delay", cfg.Server.DrainDelay)
+		time.Sleep(cfg.Server.DrainDelay)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout)
+	defer cancel()
+	if err := srv.Shutdown(ctx); err != nil {
+		slog.Warn("graceful shutdown timed out, cancelling in-flight requests", "err", err)
+	}
+	cancelRequests()
+	if adminSrv != nil {
+		adminSrv.Shutdown(ctx)
+	}
+	// Pictures queued by imports are still saved to their items.
+	slog.Info("waiting for catalog picture fetches")
+	fetcher.Close()
+
+	// db.Close (deferred above) refuses new queries and waits for the ones
+	// already on the server, which the cancellation has cut short.
+	slog.Info("draining database", "in_use", db.Stats().InUse)
+	slog.Info("server exited")
+}
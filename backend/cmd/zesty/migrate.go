@@ -0,0 +1,136 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+
+	schema "github.com/Entity069/Zesty-Go/db"
+	"github.com/Entity069/Zesty-Go/pkg/config"
+	"github.com/Entity069/Zesty-Go/pkg/migrate"
+	"github.com/Entity069/Zesty-Go/pkg/models"
+)
+
+func newMigrator(db *sql.DB, seeds bool) (*migrate.Migrator, error) {
+	fsys, dir, table := schema.Migrations, "migrations", migrate.DefaultTable
+	if seeds {
+		fsys, dir, table = schema.Seeds, "seeds", schema.SeedsTable
+	}
+	m, err := migrate.New(db, fsys, dir, table)
+	if err != nil {
+		return nil, err
+	}
+	m.Log = log.Printf
+	return m, nil
+}
+
+// runMigrate implements `zesty migrate` and returns the exit status.
+func runMigrate(cfg *config.Config, args []string) int {
+	fs := flag.NewFlagSet("migrate", flag.ContinueOnError)
+	seeds := fs.Bool("seeds", false, "operate on the seed data instead of the schema")
+	fs.Usage = usage
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	args = fs.Args()
+	if len(args) == 0 {
+		usage()
+		return 2
+	}
+	action, args := args[0], args[1:]
+
+	count := func(def int) (int, bool) {
+		if len(args) == 0 {
+			return def, true
+		}
+		if action == "down" && args[0] == "-all" {
+			return 0, true
+		}
+		n, err := strconv.Atoi(args[0])
+		if err != nil || n < 1 {
+			fmt.Fprintf(os.Stderr, "migrate %s: %q is not a positive count\n", action, args[0])
+			return 0, false
+		}
+		return n, true
+	}
+
+	db, err := models.Open(cfg.DB)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	defer db.Close()
+
+	m, err := newMigrator(db, *seeds)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	ctx := context.Background()
+
+	switch action {
+	case "up":
+		n, ok := count(0)
+		if !ok {
+			return 2
+		}
+		applied, err := m.Up(ctx, n)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		fmt.Printf("applied %d migration(s)\n", applied)
+	case "down":
+		n, ok := count(1)
+		if !ok {
+			return 2
+		}
+		reverted, err := m.Down(ctx, n)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		fmt.Printf("reverted %d migration(s)\n", reverted)
+	case "status":
+		st, err := m.Status(ctx)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		state := "clean"
+		if st.Dirty {
+			state = "DIRTY"
+		}
+		fmt.Printf("version %d (%s)\n", st.Version, state)
+		for _, mig := range st.Applied {
+			fmt.Printf("  [x] %d_%s\n", mig.Version, mig.Name)
+		}
+		for _, mig := range st.Pending {
+			fmt.Printf("  [ ] %d_%s\n", mig.Version, mig.Name)
+		}
+	case "force":
+		if len(args) != 1 {
+			fmt.Fprintln(os.Stderr, "migrate force: expected exactly one version")
+			return 2
+		}
+		version, err := strconv.Atoi(args[0])
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "migrate force: %q is not a version\n", args[0])
+			return 2
+		}
+		if err := m.Force(ctx, version); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		fmt.Printf("forced version %d\n", version)
+	default:
+		fmt.Fprintf(os.Stderr, "migrate: unknown action %q\n\n", action)
+		usage()
+		return 2
+	}
+	return 0
+}
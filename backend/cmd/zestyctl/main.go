@@ -0,0 +1,204 @@
+// zestyctl runs one-off admin operations against the Zesty database using the
+// same models as the server.
+//
+//	zestyctl [-config file] <group> <action> [flags]
+//
+// Every action accepts -dry-run, which does the work inside a transaction and
+// rolls it back, and -json, which prints the result as a single JSON object.
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/joho/godotenv"
+
+	"github.com/Entity069/Zesty-Go/pkg/config"
+	"github.com/Entity069/Zesty-Go/pkg/models"
+)
+
+type command struct {
+	summary string
+	run     func(e *env, args []string) error
+}
+
+var commands = map[string]command{
+	"user create":         {"create a user (-email, -first, -last, -role, -password)", userCreate},
+	"user promote":        {"change a user's role (-email, -role)", userPromote},
+	"user reset-password": {"set or generate a new password (-email, -password)", userResetPassword},
+	"user verify":         {"mark a user's email as verified (-email)", userVerify},
+	"wallet adjust":       {"credit or debit a balance (-email, -amount, -reason)", walletAdjust},
+	"order list":          {"list placed orders (-status, -email, -limit)", orderList},
+	"order cancel":        {"cancel and refund an order (-id, -reason, -force)", orderCancel},
+	"order release":       {"release scheduled orders that are due to the sellers (-lead)", orderRelease},
+	"cart purge":          {"delete carts untouched for a while (-older-than)", cartPurge},
+	"seed demo":           {"load the demo categories, users and items", seedDemo},
+	"uploads migrate":     {"copy local uploads into the S3 bucket (-from, -delete)", uploadsMigrate},
+	"uploads gc":          {"quarantine and purge files nothing refers to (-grace, -retention)", uploadsGC},
+}
+
+// errUsage means the arguments were wrong; the flag set has already said why.
+var errUsage = errors.New("usage")
+
+// errDryRun rolls back the transaction of a -dry-run command.
+var errDryRun = errors.New("dry run")
+
+func main() {
+	_ = godotenv.Load()
+
+	configPath := flag.String("config", os.Getenv("CONFIG_FILE"), "path to a YAML or TOML config file")
+	flag.Usage = usage
+	flag.Parse()
+
+	args := flag.Args()
+	if len(args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+	cmd, ok := commands[args[0]+" "+args[1]]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "unknown command %q\n\n", strings.Join(args[:2], " "))
+		usage()
+		os.Exit(2)
+	}
+
+	e := &env{ctx: context.Background(), configPath: *configPath}
+	err := cmd.run(e, args[2:])
+	if e.db != nil {
+		e.db.Close()
+	}
+	switch {
+	case errors.Is(err, errUsage):
+		os.Exit(2)
+	case err != nil:
+		e.fail(err)
+		os.Exit(1)
+	}
+}
+
+func usage() {
+	fmt.Fprint(os.Stderr, "usage: zestyctl [-config file] <group> <action> [-dry-run] [-json] [flags]\n\ncommands:\n")
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(os.Stderr, "  %-22s %s\n", name, commands[name].summary)
+	}
+	fmt.Fprint(os.Stderr, "\nRun `zestyctl <group> <action> -h` for an action's flags.\n")
+}
+
+// env is what every command runs with. The configuration and database are
+// only loaded once the command's flags have parsed, so -h works anywhere.
+type env struct {
+	ctx        context.Context
+	configPath string
+	cfg        *config.Config
+	db         *sql.DB
+	store      *models.Store
+	json       bool
+	dryRun     bool
+}
+
+// flags returns a flag set for name with -dry-run and -json already bound.
+func (e *env) flags(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet("zestyctl "+name, flag.ContinueOnError)
+	fs.BoolVar(&e.dryRun, "dry-run", false, "do everything in a transaction, then roll it back")
+	fs.BoolVar(&e.json, "json", false, "print the result as JSON")
+	return fs
+}
+
+func (e *env) parse(fs *flag.FlagSet, args []string) error {
+	if err := fs.Parse(args); err != nil {
+		return errUsage
+	}
+	if fs.NArg() > 0 {
+		fmt.Fprintf(os.Stderr, "unexpected argument %q\n", fs.Arg(0))
+		fs.Usage()
+		return errUsage
+	}
+	return e.connect()
+}
+
+func (e *env) connect() error {
+	cfg, err := config.Load(e.configPath)
+	if err != nil {
+		return err
+	}
+	db, err := models.Open(cfg.DB)
+	if err != nil {
+		return err
+	}
+	e.cfg, e.db = cfg, db
+	e.store = models.NewStore(db, models.NewMemoryItemsCache(cfg.Cache.TTL), models.QueryOptions{Timeout: cfg.DB.QueryTimeout})
+	return nil
+}
+
+// mutate runs fn in a transaction, rolling it back under -dry-run.
+func (e *env) mutate(fn func(tx *models.Store) error) error {
+	err := e.store.WithTx(e.ctx, func(tx *models.Store) error {
+		if err := fn(tx); err != nil {
+			return err
+		}
+		if e.dryRun {
+			return errDryRun
+		}
+		return nil
+	})
+	if errors.Is(err, errDryRun) {
+		return nil
+	}
+	return err
+}
+
+// done reports a successful command. data is included in the JSON output;
+// msg is what a person reading the terminal sees.
+func (e *env) done(msg string, data any) {
+	if e.json {
+		out := map[string]any{"success": true, "dry_run": e.dryRun, "msg": msg}
+		if data != nil {
+			out["data"] = data
+		}
+		writeJSON(out)
+		return
+	}
+	if e.dryRun {
+		msg = "[dry run] " + msg
+	}
+	fmt.Println(msg)
+}
+
+func (e *env) fail(err error) {
+	if e.json {
+		writeJSON(map[string]any{"success": false, "dry_run": e.dryRun, "msg": err.Error()})
+		return
+	}
+	fmt.Fprintln(os.Stderr, "zestyctl:", err)
+}
+
+func writeJSON(v any) {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(v); err != nil {
+		fmt.Fprintln(os.Stderr, "zestyctl: encoding output:", err)
+	}
+}
+
+func required(fs *flag.FlagSet, values map[string]string) error {
+	for name, v := range values {
+		if strings.TrimSpace(v) == "" {
+			fmt.Fprintf(os.Stderr, "-%s is required\n", name)
+			fs.Usage()
+			return errUsage
+		}
+	}
+	return nil
+}
@@ -0,0 +1,197 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	schema "github.com/Entity069/Zesty-Go/db"
+	"github.com/Entity069/Zesty-Go/pkg/migrate"
+	"github.com/Entity069/Zesty-Go/pkg/models"
+)
+
+func orderList(e *env, args []string) error {
+	fs := e.flags("order list")
+	status := fs.String("status", "", "only orders with this status")
+	email := fs.String("email", "", "only orders placed by this user")
+	limit := fs.Int("limit", 50, "at most this many orders, newest first; 0 for all")
+	if err := e.parse(fs, args); err != nil {
+		return err
+	}
+
+	all, err := e.store.Orders.GetAll(e.ctx)
+	if err != nil {
+		return err
+	}
+	orders := []*models.Order{}
+	for _, o := range all {
+		if *status != "" && o.Status != *status {
+			continue
+		}
+		if *email != "" && !strings.EqualFold(o.Email, strings.TrimSpace(*email)) {
+			continue
+		}
+		orders = append(orders, o)
+		if *limit > 0 && len(orders) == *limit {
+			break
+		}
+	}
+
+	if e.json {
+		e.done(fmt.Sprintf("%d order(s)", len(orders)), map[string]any{"orders": orders})
+		return nil
+	}
+	tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tSTATUS\tTOTAL\tCUSTOMER\tPLACED")
+	for _, o := range orders {
+		fmt.Fprintf(tw, "%d\t%s\t%.2f\t%s\t%s\n", o.ID, o.Status, o.TotalAmount, o.Email, o.CreatedAt.Format(time.DateTime))
+	}
+	return tw.Flush()
+}
+
+func orderCancel(e *env, args []string) error {
+	fs := e.flags("order cancel")
+	id := fs.Int("id", 0, "order ID (required)")
+	reason := fs.String("reason", "", "message left on the order")
+	force := fs.Bool("force", false, "cancel even once the seller has started preparing it")
+	if err := e.parse(fs, args); err != nil {
+		return err
+	}
+	if *id <= 0 {
+		fmt.Fprintln(os.Stderr, "-id is required")
+		fs.Usage()
+		return errUsage
+	}
+
+	var order *models.Order
+	var user *models.User
+	txn := &models.WalletTransaction{Reason: fmt.Sprintf("refund for order #%d", *id)}
+	err := e.mutate(func(tx *models.Store) error {
+		var err error
+		if order, err = tx.Orders.GetByID(e.ctx, *id); err != nil {
+			return err
+		}
+		if order == nil {
+			return fmt.Errorf("no order with id %d", *id)
+		}
+		switch {
+		case order.Status == "ordered" || order.Status == "scheduled":
+		case *force && (order.Status == "preparing" || order.Status == "prepared"):
+		default:
+			return fmt.Errorf("order %d is %s and cannot be cancelled", order.ID, order.Status)
+		}
+
+		if err := tx.Orders.Cancel(e.ctx, order); err != nil {
+			return err
+		}
+		if *reason != "" {
+			if err := tx.Orders.UpdateMessage(e.ctx, order, *reason); err != nil {
+				return err
+			}
+		}
+
+		if user, err = tx.Users.GetByID(e.ctx, order.UserID); err != nil {
+			return err
+		}
+		if err := tx.Users.AddBalance(e.ctx, user, order.TotalAmount); err != nil {
+			return err
+		}
+		txn.UserID, txn.Amount, txn.BalanceAfter = user.ID, order.TotalAmount, user.Balance
+		return tx.Wallet.Create(e.ctx, txn)
+	})
+	if err != nil {
+		return err
+	}
+	e.done(fmt.Sprintf("cancelled order %d, refunded %.2f to %s", order.ID, order.TotalAmount, user.Email),
+		map[string]any{"order": order, "transaction": txn})
+	return nil
+}
+
+// orderRelease does what the server's release loop does, for deployments
+// that run it from cron with shop.preorders.release_interval at 0.
+func orderRelease(e *env, args []string) error {
+	fs := e.flags("order release")
+	lead := fs.Duration("lead", -1, "release orders due within this long (default: shop.preorders.lead_time)")
+	if err := e.parse(fs, args); err != nil {
+		return err
+	}
+	if *lead < 0 {
+		*lead = e.cfg.Shop.Preorders.LeadTime
+	}
+
+	dueBy := time.Now().Add(*lead)
+	var released int64
+	err := e.mutate(func(tx *models.Store) error {
+		var err error
+		released, err = tx.Orders.Release(e.ctx, dueBy)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	e.done(fmt.Sprintf("released %d scheduled order(s) due by %s", released, dueBy.Format(time.DateTime)),
+		map[string]any{"released": released, "due_by": dueBy})
+	return nil
+}
+
+func cartPurge(e *env, args []string) error {
+	fs := e.flags("cart purge")
+	olderThan := fs.Duration("older-than", 30*24*time.Hour, "delete carts not updated for this long")
+	if err := e.parse(fs, args); err != nil {
+		return err
+	}
+	if *olderThan <= 0 {
+		return fmt.Errorf("-older-than must be positive")
+	}
+
+	before := time.Now().Add(-*olderThan)
+	var purged int64
+	err := e.mutate(func(tx *models.Store) error {
+		var err error
+		purged, err = tx.Orders.PurgeCarts(e.ctx, before)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	e.done(fmt.Sprintf("purged %d cart(s) last updated before %s", purged, before.Format(time.DateTime)),
+		map[string]any{"purged": purged, "before": before})
+	return nil
+}
+
+// seedDemo applies the embedded seed files. They run through the migration
+// runner rather than a transaction, so -dry-run lists what would be applied.
+func seedDemo(e *env, args []string) error {
+	fs := e.flags("seed demo")
+	if err := e.parse(fs, args); err != nil {
+		return err
+	}
+
+	m, err := migrate.New(e.store.DB(), schema.Seeds, "seeds", schema.SeedsTable)
+	if err != nil {
+		return err
+	}
+
+	if e.dryRun {
+		st, err := m.Status(e.ctx)
+		if err != nil {
+			return err
+		}
+		names := []string{}
+		for _, mig := range st.Pending {
+			names = append(names, fmt.Sprintf("%d_%s", mig.Version, mig.Name))
+		}
+		e.done(fmt.Sprintf("would apply %d seed file(s): %s", len(names), strings.Join(names, ", ")),
+			map[string]any{"pending": names})
+		return nil
+	}
+
+	applied, err := m.Up(e.ctx, 0)
+	if err != nil {
+		return err
+	}
+	e.done(fmt.Sprintf("applied %d seed file(s)", applied), map[string]any{"applied": applied})
+	return nil
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"mime"
+	"path"
+
+	"github.com/Entity069/Zesty-Go/pkg/storage"
+	"github.com/Entity069/Zesty-Go/pkg/uploadgc"
+)
+
+// uploadsMigrate copies files from a local uploads directory into the
+// configured bucket. Stored paths don't name the backend, so nothing in the
+// database changes; once the copy is done, switch the server to s3. Files
+// already in the bucket with the same size are skipped, so an interrupted
+// run can be repeated.
+func uploadsMigrate(e *env, args []string) error {
+	fs := e.flags("uploads migrate")
+	from := fs.String("from", "", "directory holding the files to copy (default: uploads.dir)")
+	remove := fs.Bool("delete", false, "delete each local file once it is in the bucket")
+	if err := e.parse(fs, args); err != nil {
+		return err
+	}
+	if e.cfg.Uploads.Backend != "s3" {
+		return errors.New("uploads.backend is not s3; set UPLOAD_BACKEND=s3 and the S3_* settings to name the destination")
+	}
+	dir := *from
+	if dir == "" {
+		dir = e.cfg.Uploads.Dir
+	}
+
+	dst, err := storage.New(e.ctx, e.cfg.Uploads)
+	if err != nil {
+		return err
+	}
+	if err := dst.Check(e.ctx); err != nil {
+		return fmt.Errorf("checking the bucket: %w", err)
+	}
+	src := storage.NewLocal(dir)
+
+	var copied, skipped int
+	var bytes int64
+	err = src.Walk(e.ctx, "", func(o storage.Object) error {
+		if have, err := dst.Stat(e.ctx, o.Key); err == nil && have.Size == o.Size {
+			skipped++
+			return nil
+		} else if err != nil && !errors.Is(err, storage.ErrNotFound) {
+			return fmt.Errorf("%s: %w", o.Key, err)
+		}
+
+		if e.dryRun {
+			copied++
+			bytes += o.Size
+			return nil
+		}
+		f, err := src.Open(e.ctx, o.Key)
+		if err != nil {
+			return fmt.Errorf("%s: %w", o.Key, err)
+		}
+		err = dst.Put(e.ctx, o.Key, f, o.Size, mime.TypeByExtension(path.Ext(o.Key)))
+		f.Close()
+		if err != nil {
+			return fmt.Errorf("%s: %w", o.Key, err)
+		}
+		copied++
+		bytes += o.Size
+		if *remove {
+			return src.Delete(e.ctx, o.Key)
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("copied %d file(s) before failing: %w", copied, err)
+	}
+
+	e.done(fmt.Sprintf("copied %d file(s) (%d bytes) from %s to bucket %s, skipped %d already there", copied, bytes, dir, e.cfg.Uploads.S3.Bucket, skipped),
+		map[string]any{"copied": copied, "bytes": bytes, "skipped": skipped, "from": dir, "bucket": e.cfg.Uploads.S3.Bucket})
+	return nil
+}
+
+// uploadsGC runs the upload collector once, as the server does every
+// uploads.gc.interval. With -dry-run it only reports what it would move and
+// delete.
+func uploadsGC(e *env, args []string) error {
+	fs := e.flags("uploads gc")
+	grace := fs.Duration("grace", 0, "quarantine unreferenced files older than this (default: uploads.gc.grace)")
+	retention := fs.Duration("retention", -1, "delete quarantined files older than this (default: uploads.gc.retention)")
+	if err := e.parse(fs, args); err != nil {
+		return err
+	}
+	opts := uploadgc.Options{Grace: e.cfg.Uploads.GC.Grace, Retention: e.cfg.Uploads.GC.Retention, DryRun: e.dryRun}
+	if *grace > 0 {
+		opts.Grace = *grace
+	}
+	if *retention >= 0 {
+		opts.Retention = *retention
+	}
+
+	blobs, err := storage.New(e.ctx, e.cfg.Uploads)
+	if err != nil {
+		return err
+	}
+	rep, err := uploadgc.Run(e.ctx, e.store, blobs, opts)
+	if err != nil {
+		return err
+	}
+	e.done(rep.String(), rep)
+	return nil
+}
@@ -0,0 +1,265 @@
+package main
+
+import (
+	"crypto/rand"
+	"database/sql"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/Entity069/Zesty-Go/pkg/models"
+)
+
+var roles = map[string]bool{"user": true, "seller": true, "admin": true}
+
+// userView is a user without the password hash, for output.
+type userView struct {
+	ID         int     `json:"id"`
+	Email      string  `json:"email"`
+	FirstName  string  `json:"first_name"`
+	LastName   string  `json:"last_name"`
+	UserType   string  `json:"user_type"`
+	Balance    float64 `json:"balance"`
+	IsVerified bool    `json:"is_verified"`
+}
+
+func viewUser(u *models.User) userView {
+	return userView{u.ID, u.Email, u.FirstName, u.LastName, u.UserType, u.Balance, u.IsVerified}
+}
+
+func findUser(e *env, tx *models.Store, email string) (*models.User, error) {
+	u, err := tx.Users.GetByEmail(e.ctx, strings.TrimSpace(email))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("no user with email %q", email)
+	}
+	return u, err
+}
+
+func checkRole(role string) error {
+	if !roles[role] {
+		return fmt.Errorf("role must be user, seller or admin, got %q", role)
+	}
+	return nil
+}
+
+// generatePassword returns a random 16-character password.
+func generatePassword() (string, error) {
+	b := make([]byte, 12)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// hashPassword hashes password, generating one first when it's empty. The
+// plaintext is returned only when it was generated, so it can be shown once.
+func hashPassword(e *env, password string) (hash, generated string, err error) {
+	if password == "" {
+		if generated, err = generatePassword(); err != nil {
+			return "", "", err
+		}
+		password = generated
+	}
+	h, err := bcrypt.GenerateFromPassword([]byte(password), e.cfg.Auth.BcryptCost)
+	if err != nil {
+		return "", "", err
+	}
+	return string(h), generated, nil
+}
+
+func userCreate(e *env, args []string) error {
+	fs := e.flags("user create")
+	email := fs.String("email", "", "email address (required)")
+	first := fs.String("first", "", "first name (required)")
+	last := fs.String("last", "", "last name (required)")
+	address := fs.String("address", "", "address")
+	role := fs.String("role", "user", "user, seller or admin")
+	password := fs.String("password", "", "password; generated and printed when empty")
+	verified := fs.Bool("verified", true, "mark the email as already verified")
+	if err := e.parse(fs, args); err != nil {
+		return err
+	}
+	if err := required(fs, map[string]string{"email": *email, "first": *first, "last": *last}); err != nil {
+		return err
+	}
+	if err := checkRole(*role); err != nil {
+		return err
+	}
+
+	hash, generated, err := hashPassword(e, *password)
+	if err != nil {
+		return err
+	}
+	user := &models.User{
+		ProfilePic: "https://s3.tebi.io/zesty-test/80216737.jpeg",
+		FirstName:  *first,
+		LastName:   *last,
+		Password:   hash,
+		Email:      strings.TrimSpace(*email),
+		Address:    *address,
+		UserType:   *role,
+		IsVerified: *verified,
+	}
+
+	err = e.mutate(func(tx *models.Store) error {
+		if _, err := tx.Users.GetByEmail(e.ctx, user.Email); err == nil {
+			return fmt.Errorf("a user with email %q already exists; use `user promote` to change their role", user.Email)
+		} else if !errors.Is(err, sql.ErrNoRows) {
+			return err
+		}
+		return tx.Users.Create(e.ctx, user)
+	})
+	if err != nil {
+		return err
+	}
+
+	msg := fmt.Sprintf("created %s %s (id %d)", user.UserType, user.Email, user.ID)
+	data := map[string]any{"user": viewUser(user)}
+	if generated != "" {
+		msg += "\npassword: " + generated
+		data["password"] = generated
+	}
+	e.done(msg, data)
+	return nil
+}
+
+func userPromote(e *env, args []string) error {
+	fs := e.flags("user promote")
+	email := fs.String("email", "", "email address (required)")
+	role := fs.String("role", "admin", "user, seller or admin")
+	if err := e.parse(fs, args); err != nil {
+		return err
+	}
+	if err := required(fs, map[string]string{"email": *email}); err != nil {
+		return err
+	}
+	if err := checkRole(*role); err != nil {
+		return err
+	}
+
+	var user *models.User
+	var previous string
+	err := e.mutate(func(tx *models.Store) error {
+		var err error
+		if user, err = findUser(e, tx, *email); err != nil {
+			return err
+		}
+		previous = user.UserType
+		user.UserType = *role
+		return tx.Users.Update(e.ctx, user)
+	})
+	if err != nil {
+		return err
+	}
+	e.done(fmt.Sprintf("%s: %s -> %s", user.Email, previous, user.UserType),
+		map[string]any{"user": viewUser(user), "previous_role": previous})
+	return nil
+}
+
+func userResetPassword(e *env, args []string) error {
+	fs := e.flags("user reset-password")
+	email := fs.String("email", "", "email address (required)")
+	password := fs.String("password", "", "new password; generated and printed when empty")
+	if err := e.parse(fs, args); err != nil {
+		return err
+	}
+	if err := required(fs, map[string]string{"email": *email}); err != nil {
+		return err
+	}
+
+	hash, generated, err := hashPassword(e, *password)
+	if err != nil {
+		return err
+	}
+	var user *models.User
+	err = e.mutate(func(tx *models.Store) error {
+		var err error
+		if user, err = findUser(e, tx, *email); err != nil {
+			return err
+		}
+		return tx.Users.UpdatePassword(e.ctx, user, hash)
+	})
+	if err != nil {
+		return err
+	}
+
+	msg := "password reset for " + user.Email
+	data := map[string]any{"user": viewUser(user)}
+	if generated != "" {
+		msg += "\npassword: " + generated
+		data["password"] = generated
+	}
+	e.done(msg, data)
+	return nil
+}
+
+func userVerify(e *env, args []string) error {
+	fs := e.flags("user verify")
+	email := fs.String("email", "", "email address (required)")
+	if err := e.parse(fs, args); err != nil {
+		return err
+	}
+	if err := required(fs, map[string]string{"email": *email}); err != nil {
+		return err
+	}
+
+	var user *models.User
+	err := e.mutate(func(tx *models.Store) error {
+		var err error
+		if user, err = findUser(e, tx, *email); err != nil {
+			return err
+		}
+		return tx.Users.EmailVerify(e.ctx, user)
+	})
+	if err != nil {
+		return err
+	}
+	e.done(user.Email+" is verified", map[string]any{"user": viewUser(user)})
+	return nil
+}
+
+func walletAdjust(e *env, args []string) error {
+	fs := e.flags("wallet adjust")
+	email := fs.String("email", "", "email address (required)")
+	amount := fs.Float64("amount", 0, "amount to add; negative to debit (required)")
+	reason := fs.String("reason", "", "why the balance is changing (required)")
+	if err := e.parse(fs, args); err != nil {
+		return err
+	}
+	if err := required(fs, map[string]string{"email": *email, "reason": *reason}); err != nil {
+		return err
+	}
+	if *amount == 0 || math.IsNaN(*amount) || math.IsInf(*amount, 0) {
+		return fmt.Errorf("-amount must be a non-zero number")
+	}
+
+	var user *models.User
+	txn := &models.WalletTransaction{Amount: math.Round(*amount*100) / 100, Reason: *reason}
+	err := e.mutate(func(tx *models.Store) error {
+		var err error
+		if user, err = findUser(e, tx, *email); err != nil {
+			return err
+		}
+		if user.Balance+txn.Amount < 0 {
+			return fmt.Errorf("%s has %.2f; debiting %.2f would leave the balance negative", user.Email, user.Balance, -txn.Amount)
+		}
+		// Added to the stored balance rather than written over it, so an
+		// order placed meanwhile isn't undone; the schema's CHECK still
+		// refuses a balance that it took below zero.
+		if err := tx.Users.AddBalance(e.ctx, user, txn.Amount); err != nil {
+			return err
+		}
+		txn.UserID, txn.BalanceAfter = user.ID, user.Balance
+		return tx.Wallet.Create(e.ctx, txn)
+	})
+	if err != nil {
+		return err
+	}
+	e.done(fmt.Sprintf("%s: %+.2f (%s), balance now %.2f", user.Email, txn.Amount, txn.Reason, txn.BalanceAfter),
+		map[string]any{"user": viewUser(user), "transaction": txn})
+	return nil
+}
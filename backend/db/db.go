@@ -0,0 +1,22 @@
+// Package db holds the SQL schema migrations and seed data, embedded so the
+// server binary can bring a database up to date on its own.
+package db
+
+import "embed"
+
+// Migrations are the schema changes, named NN_description.up.sql and
+// NN_description.down.sql.
+//
+//go:embed migrations/*.sql
+var Migrations embed.FS
+
+// Seeds are demo data, versioned the same way but tracked in their own
+// table so they can be applied and removed independently of the schema.
+//
+//go:embed seeds/*.sql
+var Seeds embed.FS
+
+// SeedsTable tracks which seed files have run. It keeps the name the
+// Makefile used with golang-migrate so seeds applied before aren't
+// inserted twice.
+const SeedsTable = "seeds_schema"
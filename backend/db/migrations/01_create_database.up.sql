@@ -1 +0,0 @@
-CREATE DATABASE IF NOT EXISTS `zestydb`;
\ No newline at end of file
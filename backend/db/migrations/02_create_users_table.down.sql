@@ -1,2 +1 @@
-USE `zestydb`;
 DROP TABLE IF EXISTS `users`;
\ No newline at end of file
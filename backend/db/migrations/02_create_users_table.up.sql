@@ -1,5 +1,3 @@
-USE `zestydb`;
-
 CREATE TABLE `users` (
   `id` INT AUTO_INCREMENT PRIMARY KEY,
   `profile_pic` VARCHAR(255),
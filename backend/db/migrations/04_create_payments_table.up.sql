@@ -1,5 +1,3 @@
-USE `zestydb`;
-
 CREATE TABLE `payments` (
   `id` INT AUTO_INCREMENT PRIMARY KEY,
   `payee_id` INT NOT NULL,
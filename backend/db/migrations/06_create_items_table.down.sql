@@ -1,2 +1 @@
-USE `zestydb`;
 DROP TABLE IF EXISTS `items`;
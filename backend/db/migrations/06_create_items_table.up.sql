@@ -1,5 +1,3 @@
-USE `zestydb`;
-
 CREATE TABLE `items` (
   `id` INT AUTO_INCREMENT PRIMARY KEY,
   `seller_id` INT NOT NULL,
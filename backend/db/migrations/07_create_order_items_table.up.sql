@@ -1,5 +1,3 @@
-USE `zestydb`;
-
 CREATE TABLE `order_items` (
   `id` INT AUTO_INCREMENT PRIMARY KEY,
   `order_id` INT NOT NULL,
@@ -1,5 +1,3 @@
-USE `zestydb`;
-
 CREATE TABLE `reviews` (
   `id` INT AUTO_INCREMENT PRIMARY KEY,
   `user_id` INT NOT NULL,
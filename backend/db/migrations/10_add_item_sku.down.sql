@@ -0,0 +1,6 @@
+-- MySQL may have dropped the implicit seller_id index once the unique key
+-- could serve the foreign key; give the key its own index back first.
+ALTER TABLE `items` ADD INDEX `items_seller_id` (`seller_id`);
+ALTER TABLE `items`
+  DROP INDEX `items_seller_sku`,
+  DROP COLUMN `sku`;
@@ -0,0 +1,3 @@
+ALTER TABLE `items`
+  ADD COLUMN `sku` VARCHAR(64) NULL AFTER `seller_id`,
+  ADD UNIQUE KEY `items_seller_sku` (`seller_id`, `sku`);
@@ -0,0 +1,30 @@
+-- Archived rows become ordinary rows again; nothing is deleted on the way
+-- down. The keys get back their generated names so the up migration applies
+-- again.
+ALTER TABLE `wallet_transactions` DROP FOREIGN KEY `wallet_transactions_user_fk`;
+ALTER TABLE `wallet_transactions` ADD CONSTRAINT `wallet_transactions_ibfk_1` FOREIGN KEY (`user_id`) REFERENCES `users`(`id`) ON DELETE CASCADE;
+
+ALTER TABLE `reviews` DROP FOREIGN KEY `reviews_user_fk`, DROP FOREIGN KEY `reviews_item_fk`;
+ALTER TABLE `reviews`
+  ADD CONSTRAINT `reviews_ibfk_1` FOREIGN KEY (`user_id`) REFERENCES `users`(`id`) ON DELETE CASCADE,
+  ADD CONSTRAINT `reviews_ibfk_2` FOREIGN KEY (`item_id`) REFERENCES `items`(`id`) ON DELETE CASCADE;
+
+ALTER TABLE `order_items` DROP FOREIGN KEY `order_items_item_fk`;
+ALTER TABLE `order_items` ADD CONSTRAINT `order_items_ibfk_2` FOREIGN KEY (`item_id`) REFERENCES `items`(`id`) ON DELETE CASCADE;
+
+ALTER TABLE `items` DROP FOREIGN KEY `items_category_fk`, DROP FOREIGN KEY `items_seller_fk`;
+ALTER TABLE `items`
+  ADD CONSTRAINT `items_ibfk_1` FOREIGN KEY (`category_id`) REFERENCES `categories`(`id`) ON DELETE CASCADE,
+  ADD CONSTRAINT `items_ibfk_2` FOREIGN KEY (`seller_id`) REFERENCES `users`(`id`) ON DELETE CASCADE;
+
+ALTER TABLE `payments` DROP FOREIGN KEY `payments_payee_fk`, DROP FOREIGN KEY `payments_order_fk`;
+ALTER TABLE `payments`
+  ADD CONSTRAINT `payments_ibfk_1` FOREIGN KEY (`payee_id`) REFERENCES `users`(`id`) ON DELETE CASCADE,
+  ADD CONSTRAINT `payments_ibfk_2` FOREIGN KEY (`order_id`) REFERENCES `orders`(`id`) ON DELETE CASCADE;
+
+ALTER TABLE `orders` DROP FOREIGN KEY `orders_user_fk`;
+ALTER TABLE `orders` ADD CONSTRAINT `orders_ibfk_1` FOREIGN KEY (`user_id`) REFERENCES `users`(`id`) ON DELETE CASCADE;
+
+ALTER TABLE `items` DROP COLUMN `deleted_at`;
+ALTER TABLE `categories` DROP COLUMN `deleted_at`;
+ALTER TABLE `users` DROP COLUMN `deleted_at`;
@@ -0,0 +1,32 @@
+-- Items, categories and users are archived rather than deleted, so the
+-- orders, payments and reviews that point at them keep their history.
+ALTER TABLE `users` ADD COLUMN `deleted_at` DATETIME NULL;
+ALTER TABLE `categories` ADD COLUMN `deleted_at` DATETIME NULL;
+ALTER TABLE `items` ADD COLUMN `deleted_at` DATETIME NULL;
+
+-- A hard delete that would take history with it now fails instead. The old
+-- keys were unnamed, so they carry InnoDB's generated names. Carts are
+-- still purged, so order_items keeps cascading from orders.
+ALTER TABLE `orders` DROP FOREIGN KEY `orders_ibfk_1`;
+ALTER TABLE `orders` ADD CONSTRAINT `orders_user_fk` FOREIGN KEY (`user_id`) REFERENCES `users`(`id`) ON DELETE RESTRICT;
+
+ALTER TABLE `payments` DROP FOREIGN KEY `payments_ibfk_1`, DROP FOREIGN KEY `payments_ibfk_2`;
+ALTER TABLE `payments`
+  ADD CONSTRAINT `payments_payee_fk` FOREIGN KEY (`payee_id`) REFERENCES `users`(`id`) ON DELETE RESTRICT,
+  ADD CONSTRAINT `payments_order_fk` FOREIGN KEY (`order_id`) REFERENCES `orders`(`id`) ON DELETE RESTRICT;
+
+ALTER TABLE `items` DROP FOREIGN KEY `items_ibfk_1`, DROP FOREIGN KEY `items_ibfk_2`;
+ALTER TABLE `items`
+  ADD CONSTRAINT `items_category_fk` FOREIGN KEY (`category_id`) REFERENCES `categories`(`id`) ON DELETE RESTRICT,
+  ADD CONSTRAINT `items_seller_fk` FOREIGN KEY (`seller_id`) REFERENCES `users`(`id`) ON DELETE RESTRICT;
+
+ALTER TABLE `order_items` DROP FOREIGN KEY `order_items_ibfk_2`;
+ALTER TABLE `order_items` ADD CONSTRAINT `order_items_item_fk` FOREIGN KEY (`item_id`) REFERENCES `items`(`id`) ON DELETE RESTRICT;
+
+ALTER TABLE `reviews` DROP FOREIGN KEY `reviews_ibfk_1`, DROP FOREIGN KEY `reviews_ibfk_2`;
+ALTER TABLE `reviews`
+  ADD CONSTRAINT `reviews_user_fk` FOREIGN KEY (`user_id`) REFERENCES `users`(`id`) ON DELETE RESTRICT,
+  ADD CONSTRAINT `reviews_item_fk` FOREIGN KEY (`item_id`) REFERENCES `items`(`id`) ON DELETE RESTRICT;
+
+ALTER TABLE `wallet_transactions` DROP FOREIGN KEY `wallet_transactions_ibfk_1`;
+ALTER TABLE `wallet_transactions` ADD CONSTRAINT `wallet_transactions_user_fk` FOREIGN KEY (`user_id`) REFERENCES `users`(`id`) ON DELETE RESTRICT;
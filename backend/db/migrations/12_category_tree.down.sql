@@ -0,0 +1,7 @@
+ALTER TABLE `categories` DROP FOREIGN KEY `categories_parent_fk`;
+ALTER TABLE `categories`
+  DROP INDEX `categories_parent_position`,
+  DROP COLUMN `is_active`,
+  DROP COLUMN `image`,
+  DROP COLUMN `position`,
+  DROP COLUMN `parent_id`;
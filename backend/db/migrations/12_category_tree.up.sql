@@ -0,0 +1,14 @@
+-- Categories nest under a parent and are shown in the order admins set,
+-- not by name. Inactive ones are hidden from customers.
+ALTER TABLE `categories`
+  ADD COLUMN `parent_id` INT NULL AFTER `id`,
+  ADD COLUMN `position` INT NOT NULL DEFAULT 0,
+  ADD COLUMN `image` VARCHAR(255) NOT NULL DEFAULT '',
+  ADD COLUMN `is_active` BOOLEAN NOT NULL DEFAULT TRUE,
+  ADD INDEX `categories_parent_position` (`parent_id`, `position`),
+  ADD CONSTRAINT `categories_parent_fk` FOREIGN KEY (`parent_id`) REFERENCES `categories`(`id`) ON DELETE RESTRICT;
+
+-- Start from the alphabetical order categories were listed in until now.
+UPDATE `categories` c
+  JOIN (SELECT `id`, ROW_NUMBER() OVER (ORDER BY `name`) AS `n` FROM `categories`) o ON o.`id` = c.`id`
+  SET c.`position` = o.`n`;
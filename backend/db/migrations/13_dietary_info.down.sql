@@ -0,0 +1,6 @@
+ALTER TABLE `users` DROP COLUMN `allergens`;
+ALTER TABLE `items`
+  DROP COLUMN `nutrition`,
+  DROP COLUMN `spice_level`,
+  DROP COLUMN `allergens`,
+  DROP COLUMN `diet_tags`;
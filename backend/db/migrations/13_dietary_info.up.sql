@@ -0,0 +1,11 @@
+-- Dietary information on items: tags and allergens are comma-separated
+-- lists from the vocabularies in models/dietary.go, nutrition a JSON
+-- object of optional facts per serving, calories among them.
+ALTER TABLE `items`
+  ADD COLUMN `diet_tags` VARCHAR(255) NOT NULL DEFAULT '',
+  ADD COLUMN `allergens` VARCHAR(255) NOT NULL DEFAULT '',
+  ADD COLUMN `spice_level` TINYINT NOT NULL DEFAULT 0,
+  ADD COLUMN `nutrition` JSON NULL;
+
+-- The allergens a customer wants to be warned about.
+ALTER TABLE `users` ADD COLUMN `allergens` VARCHAR(255) NOT NULL DEFAULT '';
@@ -0,0 +1,6 @@
+ALTER TABLE `items` DROP FOREIGN KEY `items_menu_fk`;
+ALTER TABLE `items`
+  DROP COLUMN `schedule`,
+  DROP COLUMN `menu_id`;
+
+DROP TABLE `menus`;
@@ -0,0 +1,20 @@
+-- Availability windows. A schedule is a JSON array of windows, each with
+-- optional days of the week, a time range and start and end dates (see
+-- models/schedule.go); items with no schedule, on themselves or their menu,
+-- can be ordered whenever their status allows.
+CREATE TABLE `menus` (
+  `id` INT AUTO_INCREMENT PRIMARY KEY,
+  `seller_id` INT NOT NULL,
+  `name` VARCHAR(255) NOT NULL,
+  `schedule` JSON NOT NULL,
+  `created_at` DATETIME DEFAULT CURRENT_TIMESTAMP,
+  `updated_at` DATETIME DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+  UNIQUE KEY `menus_seller_name` (`seller_id`, `name`),
+  FOREIGN KEY (`seller_id`) REFERENCES `users`(`id`) ON DELETE CASCADE
+);
+
+-- An item's own schedule takes precedence over its menu's.
+ALTER TABLE `items`
+  ADD COLUMN `menu_id` INT NULL,
+  ADD COLUMN `schedule` JSON NULL,
+  ADD CONSTRAINT `items_menu_fk` FOREIGN KEY (`menu_id`) REFERENCES `menus`(`id`) ON DELETE SET NULL;
@@ -0,0 +1,11 @@
+-- Orders still held go straight to the sellers.
+UPDATE `order_items` SET `status` = 'ordered' WHERE `status` = 'scheduled';
+UPDATE `orders` SET `status` = 'ordered' WHERE `status` = 'scheduled';
+
+ALTER TABLE `order_items`
+  MODIFY `status` ENUM('cart', 'ordered', 'preparing', 'prepared', 'cancelled', 'delivered') NOT NULL DEFAULT 'cart';
+
+ALTER TABLE `orders`
+  DROP KEY `orders_deliver_at`,
+  DROP COLUMN `deliver_at`,
+  MODIFY `status` ENUM('cart', 'ordered', 'preparing', 'prepared', 'cancelled', 'delivered') NOT NULL DEFAULT 'cart';
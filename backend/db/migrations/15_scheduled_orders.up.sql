@@ -0,0 +1,11 @@
+-- Pre-orders. A scheduled order is paid for when it is placed but held out
+-- of the sellers' queues, along with its lines, until the release job moves
+-- it to ordered a lead time before deliver_at. deliver_at is UTC and NULL
+-- for an order wanted as soon as possible.
+ALTER TABLE `orders`
+  MODIFY `status` ENUM('cart', 'scheduled', 'ordered', 'preparing', 'prepared', 'cancelled', 'delivered') NOT NULL DEFAULT 'cart',
+  ADD COLUMN `deliver_at` DATETIME NULL,
+  ADD KEY `orders_deliver_at` (`deliver_at`, `status`);
+
+ALTER TABLE `order_items`
+  MODIFY `status` ENUM('cart', 'scheduled', 'ordered', 'preparing', 'prepared', 'cancelled', 'delivered') NOT NULL DEFAULT 'cart';
@@ -0,0 +1,6 @@
+-- Fails while a deleted category shares its name with another; rename it
+-- first.
+ALTER TABLE `categories`
+  DROP KEY `categories_live_name`,
+  DROP COLUMN `live_name`,
+  ADD UNIQUE KEY `name` (`name`);
@@ -0,0 +1,8 @@
+-- A deleted category keeps its name, and a new category may now take it:
+-- names only need to be unique among live categories. live_name is the
+-- name until the category is deleted and NULL after, and a unique key
+-- allows any number of NULLs.
+ALTER TABLE `categories`
+  ADD COLUMN `live_name` VARCHAR(255) AS (IF(`deleted_at` IS NULL, `name`, NULL)) VIRTUAL,
+  DROP KEY `name`,
+  ADD UNIQUE KEY `categories_live_name` (`live_name`);
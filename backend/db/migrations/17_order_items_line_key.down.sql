@@ -0,0 +1 @@
+ALTER TABLE `order_items` DROP KEY `order_items_order_item`;
@@ -0,0 +1,17 @@
+-- A cart holds one line per item, and the key lets adding to a line be a
+-- single upsert. Lines duplicated by requests racing each other before it
+-- are first merged into the earliest; a cart's stay within its cap of 99.
+UPDATE `order_items` oi
+  JOIN (
+    SELECT MIN(`id`) AS `id`, SUM(`quantity`) AS `quantity`
+    FROM `order_items`
+    GROUP BY `order_id`, `item_id`
+    HAVING COUNT(*) > 1
+  ) merged ON merged.`id` = oi.`id`
+  SET oi.`quantity` = IF(oi.`status` = 'cart', LEAST(merged.`quantity`, 99), merged.`quantity`);
+
+DELETE dup FROM `order_items` dup
+  JOIN `order_items` keep
+    ON keep.`order_id` = dup.`order_id` AND keep.`item_id` = dup.`item_id` AND keep.`id` < dup.`id`;
+
+ALTER TABLE `order_items` ADD UNIQUE KEY `order_items_order_item` (`order_id`, `item_id`);
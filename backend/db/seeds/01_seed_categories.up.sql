@@ -1,5 +1,3 @@
-USE `zestydb`;
-
 INSERT INTO `categories` (`id`,`name`,`description`) VALUES
   (1,'Zesty Special','A special set of cuisine curated by Zesty!'),
   (2,'Epstein Island Special','A collection of tender meat procured from the children (of a sheep)!'),
@@ -1,4 +1,2 @@
-USE `zestydb`;
-
 DELETE FROM `users` WHERE `id` BETWEEN 1 AND 22;
  
\ No newline at end of file
@@ -1,3 +1 @@
-USE `zestydb`;
-
 DELETE FROM `items` WHERE `id` BETWEEN 1 AND 12;
@@ -1,5 +1,3 @@
-USE `zestydb`;
-
 INSERT INTO `items` (`id`,`seller_id`,`name`,`image`,`description`,`price`,`category_id`,`status`,`created_at`) VALUES
   (1,22,'Pure Retardium','/uploads/item-images/item-image-1751660387147.jpg','Finally, pure Retardium.',69420.00,2,'available','2025-07-05 01:49:47'),
   (2,22,'Pure Retardium (extra retardness)','/uploads/item-images/item-image-1751660425184.jpg','Pure Retardium but with extra retardness.',694200.00,2,'available','2025-07-05 01:50:25'),
@@ -0,0 +1,62 @@
+package api
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"gopkg.in/yaml.v3"
+)
+
+// openapiYAML is the API's OpenAPI 3 description. It is written by hand next
+// to the routes; TestSpecCoversRoutes fails when the two disagree.
+//
+//go:embed openapi.yaml
+var openapiYAML []byte
+
+// OpenAPISpec returns the OpenAPI document as JSON.
+func OpenAPISpec() ([]byte, error) {
+	var doc any
+	if err := yaml.Unmarshal(openapiYAML, &doc); err != nil {
+		return nil, fmt.Errorf("parsing openapi.yaml: %w", err)
+	}
+	return json.Marshal(doc)
+}
+
+func specHandler() http.HandlerFunc {
+	spec, err := OpenAPISpec()
+	if err != nil {
+		// The document is embedded, so this can only be a bad edit that the
+		// tests would have caught.
+		panic(err)
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(spec)
+	}
+}
+
+// docsPage renders the spec with Swagger UI. Requests made from its "Try it
+// out" buttons carry the browser's session cookie.
+const docsPage = `<!doctype html>
+<html lang="en">
+<head>
+  <meta charset="utf-8">
+  <title>Zesty API</title>
+  <link rel="stylesheet" href="https://unpkg.com/swagger-ui-dist@5/swagger-ui.css">
+</head>
+<body>
+  <div id="docs"></div>
+  <script src="https://unpkg.com/swagger-ui-dist@5/swagger-ui-bundle.js" crossorigin></script>
+  <script>
+    SwaggerUIBundle({ url: "/api/openapi.json", dom_id: "#docs", withCredentials: true });
+  </script>
+</body>
+</html>
+`
+
+func docsHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	fmt.Fprint(w, docsPage)
+}
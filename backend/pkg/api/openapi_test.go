@@ -0,0 +1,264 @@
+package api_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/gorilla/mux"
+
+	"github.com/Entity069/Zesty-Go/pkg/api"
+	"github.com/Entity069/Zesty-Go/pkg/catalog"
+	"github.com/Entity069/Zesty-Go/pkg/config"
+	"github.com/Entity069/Zesty-Go/pkg/health"
+	"github.com/Entity069/Zesty-Go/pkg/middleware"
+	"github.com/Entity069/Zesty-Go/pkg/models"
+	"github.com/Entity069/Zesty-Go/pkg/models/memstore"
+	"github.com/Entity069/Zesty-Go/pkg/storage"
+)
+
+type operation struct {
+	Security []map[string][]string `json:"security"`
+	Role     string                `json:"x-role"`
+}
+
+type spec struct {
+	Paths map[string]map[string]operation `json:"paths"`
+}
+
+func newRouter(t *testing.T) (*mux.Router, *config.Config) {
+	t.Helper()
+	return newRouterOn(t, memstore.New())
+}
+
+func newRouterOn(t *testing.T, store *models.Store) (*mux.Router, *config.Config) {
+	t.Helper()
+	cfg := config.Default()
+	cfg.Auth.JWTSecret = "spec-test"
+	cfg.Uploads.Dir = t.TempDir()
+	blobs := storage.NewLocal(cfg.Uploads.Dir)
+	fetcher := catalog.NewFetcher(store, blobs, cfg.Uploads.MaxBytes, nil)
+	t.Cleanup(fetcher.Close)
+	return api.NewRouter(cfg, store, blobs, fetcher, health.New(time.Second)), cfg
+}
+
+// newRequest builds a request that passes the CSRF checks, so tests reach
+// the auth middleware behind them.
+func newRequest(method, target string) *http.Request {
+	req := httptest.NewRequest(method, target, nil)
+	req.AddCookie(&http.Cookie{Name: middleware.CSRFCookie, Value: "spec-test"})
+	req.Header.Set(middleware.CSRFHeader, "spec-test")
+	return req
+}
+
+func loadSpec(t *testing.T) spec {
+	t.Helper()
+	raw, err := api.OpenAPISpec()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var s spec
+	if err := json.Unmarshal(raw, &s); err != nil {
+		t.Fatalf("decoding spec: %v", err)
+	}
+	return s
+}
+
+// routes lists "METHOD /path/{param}" for every route on r. A path prefix
+// route (the uploads file server) is documented as GET prefix{path}.
+func routes(t *testing.T, r *mux.Router) []string {
+	t.Helper()
+	var out []string
+	err := r.Walk(func(route *mux.Route, _ *mux.Router, _ []*mux.Route) error {
+		tpl, err := route.GetPathTemplate()
+		if err != nil {
+			return nil
+		}
+		methods, err := route.GetMethods()
+		if err != nil {
+			if !strings.HasSuffix(tpl, "/") {
+				return nil
+			}
+			tpl, methods = tpl+"{path}", []string{http.MethodGet}
+		}
+		for _, m := range methods {
+			out = append(out, m+" "+tpl)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sort.Strings(out)
+	return out
+}
+
+func TestSpecCoversRoutes(t *testing.T) {
+	r, _ := newRouter(t)
+	s := loadSpec(t)
+
+	registered := map[string]bool{}
+	for _, route := range routes(t, r) {
+		registered[route] = true
+		method, path, _ := strings.Cut(route, " ")
+		if _, ok := s.Paths[path][strings.ToLower(method)]; !ok {
+			t.Errorf("%s is routed but missing from openapi.yaml", route)
+		}
+	}
+	for path, ops := range s.Paths {
+		for method := range ops {
+			if route := strings.ToUpper(method) + " " + path; !registered[route] {
+				t.Errorf("openapi.yaml documents %s, which isn't routed", route)
+			}
+		}
+	}
+}
+
+var pathParam = regexp.MustCompile(`\{[^}]+\}`)
+
+// TestSpecSecurity checks each operation's documented auth against what the
+// router enforces: secured operations refuse a request without a session,
+// and role-restricted ones refuse a session with a role they don't list.
+func TestSpecSecurity(t *testing.T) {
+	store := memstore.New()
+	r, cfg := newRouterOn(t, store)
+	s := loadSpec(t)
+
+	// Sessions are checked against the users table, so each role needs a
+	// live user behind it.
+	userIDs := map[string]int{}
+	for _, role := range []string{"user", "seller", "admin"} {
+		u := &models.User{FirstName: role, Email: role + "@zes.ty", UserType: role}
+		if err := store.Users.Create(context.Background(), u); err != nil {
+			t.Fatal(err)
+		}
+		userIDs[role] = u.ID
+	}
+
+	for path, ops := range s.Paths {
+		for method, op := range ops {
+			name := strings.ToUpper(method) + " " + path
+			target := pathParam.ReplaceAllString(path, "1")
+
+			if len(op.Security) == 0 {
+				if op.Role != "" {
+					t.Errorf("%s has x-role %q but no security requirement", name, op.Role)
+				}
+				continue
+			}
+
+			rec := httptest.NewRecorder()
+			r.ServeHTTP(rec, newRequest(strings.ToUpper(method), target))
+			if rec.Code != http.StatusUnauthorized {
+				t.Errorf("%s without a session = %d, want 401", name, rec.Code)
+			}
+
+			if op.Role == "" {
+				t.Errorf("%s is secured but has no x-role", name)
+				continue
+			}
+			if op.Role == "any" {
+				continue
+			}
+			allowed := strings.Fields(op.Role)
+			other := ""
+			for _, role := range []string{"user", "seller", "admin"} {
+				if !slices.Contains(allowed, role) {
+					other = role
+					break
+				}
+			}
+			if other == "" {
+				t.Errorf("%s lists every role; use x-role any", name)
+				continue
+			}
+			tok, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+				"id": userIDs[other], "role": other, "exp": time.Now().Add(time.Hour).Unix(),
+			}).SignedString([]byte(cfg.Auth.JWTSecret))
+			if err != nil {
+				t.Fatal(err)
+			}
+			req := newRequest(strings.ToUpper(method), target)
+			req.AddCookie(&http.Cookie{Name: "token", Value: tok})
+			rec = httptest.NewRecorder()
+			r.ServeHTTP(rec, req)
+			if rec.Code != http.StatusForbidden {
+				t.Errorf("%s as %s = %d, want 403 for x-role %s", name, other, rec.Code, op.Role)
+			}
+		}
+	}
+}
+
+// TestMutationsRequireCSRF sends every documented state-changing operation
+// without a CSRF token and expects it refused before any handler runs.
+func TestMutationsRequireCSRF(t *testing.T) {
+	r, _ := newRouter(t)
+	s := loadSpec(t)
+
+	for path, ops := range s.Paths {
+		for method := range ops {
+			method = strings.ToUpper(method)
+			if method == http.MethodGet || method == http.MethodHead {
+				continue
+			}
+			rec := httptest.NewRecorder()
+			r.ServeHTTP(rec, httptest.NewRequest(method, pathParam.ReplaceAllString(path, "1"), nil))
+			if rec.Code != http.StatusForbidden || !strings.Contains(rec.Body.String(), `"code":"csrf_failed"`) {
+				t.Errorf("%s %s without a CSRF token = %d %s, want 403 csrf_failed", method, path, rec.Code, rec.Body)
+			}
+		}
+	}
+}
+
+func TestCSRFTokenRoundTrip(t *testing.T) {
+	r, _ := newRouter(t)
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/auth/csrf", nil))
+	var body struct {
+		Token string `json:"csrf_token"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil || body.Token == "" {
+		t.Fatalf("GET /api/v1/auth/csrf = %d %s", rec.Code, rec.Body)
+	}
+	cookies := rec.Result().Cookies()
+	if len(cookies) != 1 || cookies[0].Name != middleware.CSRFCookie || cookies[0].Value != body.Token {
+		t.Fatalf("cookies = %v, want %s=%s", cookies, middleware.CSRFCookie, body.Token)
+	}
+
+	// The token gets a cross-site-looking request no further than the
+	// origin check; a same-site one reaches the handler's auth.
+	for origin, want := range map[string]int{
+		"https://localhost:3000": http.StatusUnauthorized,
+		"https://evil.example":   http.StatusForbidden,
+	} {
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/orders", nil)
+		req.AddCookie(cookies[0])
+		req.Header.Set(middleware.CSRFHeader, body.Token)
+		req.Header.Set("Origin", origin)
+		rec = httptest.NewRecorder()
+		r.ServeHTTP(rec, req)
+		if rec.Code != want {
+			t.Errorf("POST from %s = %d %s, want %d", origin, rec.Code, rec.Body, want)
+		}
+	}
+}
+
+func TestSpecServed(t *testing.T) {
+	r, _ := newRouter(t)
+	for path, want := range map[string]string{"/api/openapi.json": "application/json", "/api/docs": "text/html"} {
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		if rec.Code != http.StatusOK || !strings.HasPrefix(rec.Header().Get("Content-Type"), want) {
+			t.Errorf("GET %s = %d %q, want 200 %s", path, rec.Code, rec.Header().Get("Content-Type"), want)
+		}
+	}
+}
@@ -2,86 +2,209 @@ package api
 
 import (
 	"net/http"
-	"path/filepath"
+	"time"
 
 	"github.com/gorilla/mux"
 
+	"github.com/Entity069/Zesty-Go/pkg/catalog"
+	"github.com/Entity069/Zesty-Go/pkg/config"
 	"github.com/Entity069/Zesty-Go/pkg/controllers"
+	"github.com/Entity069/Zesty-Go/pkg/health"
 	"github.com/Entity069/Zesty-Go/pkg/middleware"
+	"github.com/Entity069/Zesty-Go/pkg/models"
+	"github.com/Entity069/Zesty-Go/pkg/response"
+	"github.com/Entity069/Zesty-Go/pkg/storage"
+	"github.com/Entity069/Zesty-Go/pkg/utils"
 )
 
-func NewRouter() *mux.Router {
+// legacyDeprecated is when the pre-/api/v1 routes were deprecated, as sent
+// in their Deprecation header.
+var legacyDeprecated = time.Date(2026, time.October, 19, 0, 0, 0, 0, time.UTC)
+
+type handlers struct {
+	auth   *controllers.AuthController
+	admin  *controllers.AdminController
+	order  *controllers.OrderController
+	seller *controllers.SellerController
+	user   *controllers.UserController
+}
+
+// NewRouter routes the API. fetcher takes the pictures catalog imports
+// link to; it is the caller's to close once the server has stopped.
+func NewRouter(cfg *config.Config, store *models.Store, blobs storage.BlobStore, fetcher *catalog.Fetcher, hc *health.Checker) *mux.Router {
+	csrf := middleware.NewCSRF(append([]string{cfg.Server.FrontendURL}, cfg.CSRF.TrustedOrigins...), cfg.CSRF.Exempt)
+
 	r := mux.NewRouter()
+	r.Use(middleware.RouteName, middleware.Metrics, csrf.Protect)
+	r.NotFoundHandler = response.NotFoundHandler()
+	r.MethodNotAllowedHandler = response.MethodNotAllowedHandler()
+
+	r.HandleFunc("/healthz", hc.Liveness).Methods("GET", "HEAD")
+	r.HandleFunc("/readyz", hc.Readiness).Methods("GET", "HEAD")
+	r.HandleFunc("/api/openapi.json", specHandler()).Methods(http.MethodGet)
+	r.HandleFunc("/api/docs", docsHandler).Methods(http.MethodGet)
+
+	r.PathPrefix(storage.URLPrefix).Handler(http.StripPrefix(storage.URLPrefix, storage.Handler(blobs, cfg.Uploads.URLExpiry)))
 
-	uploadsDir := filepath.Join(".", "uploads")
-	r.PathPrefix("/uploads/").Handler(http.StripPrefix("/uploads/", http.FileServer(http.Dir(uploadsDir))))
-
-	authController := controllers.NewAuthController()
-	adminController := controllers.NewAdminController()
-	orderController := controllers.NewOrderController()
-	sellerController := controllers.NewSellerController()
-	userController := controllers.NewUserController()
-
-	r.HandleFunc("/api/auth/register", authController.Register).Methods(http.MethodPost)
-	r.HandleFunc("/api/auth/whoami", authController.WhoAmI).Methods(http.MethodGet)
-	r.HandleFunc("/api/auth/login", authController.Login).Methods(http.MethodPost)
-	r.HandleFunc("/api/auth/logout", authController.Logout).Methods(http.MethodGet)
-	r.HandleFunc("/api/auth/verify", authController.VerifyEmail).Methods(http.MethodPost)
-	r.HandleFunc("/api/auth/forgot-password", authController.ResetPassword).Methods(http.MethodPost)
-	r.HandleFunc("/api/auth/reset-password", authController.PostResetPassword).Methods(http.MethodPost)
-
-	orderSubroute := r.PathPrefix("/api/order").Subrouter()
-	orderSubroute.Use(middleware.VerifyToken, middleware.LoginRequired, middleware.UserRequired)
-	orderSubroute.HandleFunc("/categories", orderController.GetAllCategories).Methods(http.MethodGet)
-	orderSubroute.HandleFunc("/categories/{category_id}", orderController.GetItemsByCategoryID).Methods(http.MethodGet)
-	orderSubroute.HandleFunc("/item/{item_id}", orderController.GetItemByID).Methods(http.MethodGet)
-	orderSubroute.HandleFunc("/add-to-cart", orderController.AddToCart).Methods(http.MethodPost)
-	orderSubroute.HandleFunc("/place-order", orderController.PlaceOrder).Methods(http.MethodPost)
-	orderSubroute.HandleFunc("/cancel-order", orderController.CancelOrder).Methods(http.MethodPost)
-	orderSubroute.HandleFunc("/update-count", orderController.UpdateOrderItemCount).Methods(http.MethodPost)
-	orderSubroute.HandleFunc("/user-cart", orderController.GetUserCart).Methods(http.MethodGet)
-	orderSubroute.HandleFunc("/user-orders", orderController.GetUserOrders).Methods(http.MethodGet)
-	orderSubroute.HandleFunc("/all-items", orderController.GetAllItems).Methods(http.MethodGet)
-	orderSubroute.HandleFunc("/rate", orderController.RateItem).Methods(http.MethodPost)
-
-	homeSubroute := r.PathPrefix("/api/home").Subrouter()
-	homeSubroute.Use(middleware.VerifyToken, middleware.LoginRequired, middleware.UserRequired)
-	homeSubroute.HandleFunc("/categories", orderController.HomePageCategories).Methods(http.MethodGet)
-	homeSubroute.HandleFunc("/items", orderController.HomePageItems).Methods(http.MethodGet)
-	homeSubroute.HandleFunc("/orders", orderController.HomePageOrders).Methods(http.MethodGet)
-
-	userSubroute := r.PathPrefix("/api/user").Subrouter()
-	userSubroute.Use(middleware.VerifyToken, middleware.LoginRequired)
-	userSubroute.HandleFunc("/update-balance", userController.UpdateUserBalance).Methods(http.MethodPost)
-	userSubroute.HandleFunc("/update-address", userController.UpdateUserAddress).Methods(http.MethodPost)
-	userSubroute.HandleFunc("/update-details", userController.UpdateUserDetails).Methods(http.MethodPost)
-
-	adminSubroute := r.PathPrefix("/api/admin").Subrouter()
-	adminSubroute.Use(middleware.VerifyToken, middleware.LoginRequired, middleware.AdminRequired)
-	adminSubroute.HandleFunc("/stats", adminController.GetAdminStats).Methods(http.MethodGet)
-	adminSubroute.HandleFunc("/all-orders", adminController.AllOrders).Methods(http.MethodGet)
-	adminSubroute.HandleFunc("/all-users", adminController.AllUsers).Methods(http.MethodGet)
-	adminSubroute.HandleFunc("/all-categories", adminController.AllCategories).Methods(http.MethodGet)
-	adminSubroute.HandleFunc("/edit-user", adminController.UpdateUserByAdmin).Methods(http.MethodPost)
-	adminSubroute.HandleFunc("/add-category", adminController.AddCategory).Methods(http.MethodPost)
-	adminSubroute.HandleFunc("/edit-category", adminController.EditCategory).Methods(http.MethodPost)
-	adminSubroute.HandleFunc("/all-items", adminController.AllItems).Methods(http.MethodGet)
-	adminSubroute.HandleFunc("/update-item", adminController.UpdateItemStatus).Methods(http.MethodPost)
-
-	adminSubroute.HandleFunc("/cancel-order", orderController.CancelOrder).Methods(http.MethodPost)
-	adminSubroute.HandleFunc("/deliver-order", orderController.DeliverOrder).Methods(http.MethodPost)
-
-	sellerSubroute := r.PathPrefix("/api/seller").Subrouter()
-	sellerSubroute.Use(middleware.VerifyToken, middleware.LoginRequired, middleware.SellerRequired)
-	sellerSubroute.HandleFunc("/stats", sellerController.GetSellerStats).Methods(http.MethodGet)
-	sellerSubroute.HandleFunc("/all-items", sellerController.GetSellerItems).Methods(http.MethodGet)
-	sellerSubroute.HandleFunc("/current-orders", sellerController.GetSellerOrders).Methods(http.MethodGet)
-	sellerSubroute.HandleFunc("/add-item", sellerController.AddItem).Methods(http.MethodPost)
-	sellerSubroute.HandleFunc("/edit-item/{sitem_id}", sellerController.UpdateItem).Methods(http.MethodPost)
-	sellerSubroute.HandleFunc("/update-orders", sellerController.UpdateOrderItemStatus).Methods(http.MethodPost)
-
-	sellerSubroute.HandleFunc("/all-categories", orderController.GetAllCategories).Methods(http.MethodGet)
-	sellerSubroute.HandleFunc("/item/{item_id}", orderController.GetItemByID).Methods(http.MethodGet)
+	auth := middleware.NewAuth([]byte(cfg.Auth.JWTSecret), store.Users)
+	mailer := utils.NewMailer(cfg.Email)
+
+	h := handlers{
+		auth:   controllers.NewAuthController(cfg, store, mailer),
+		admin:  controllers.NewAdminController(cfg, store, blobs),
+		order:  controllers.NewOrderController(cfg, store),
+		seller: controllers.NewSellerController(cfg, store, blobs, fetcher),
+		user:   controllers.NewUserController(cfg, store, blobs),
+	}
+
+	v1Routes(r.PathPrefix("/api/v1").Subrouter(), auth, csrf, h)
+	legacyRoutes(r, auth, h)
 
 	return r
 }
+
+// v1Routes registers the resource-oriented API. Every {id} is the numeric
+// ID of the resource the path names.
+func v1Routes(v1 *mux.Router, auth *middleware.Auth, csrf *middleware.CSRF, h handlers) {
+	v1.HandleFunc("/auth/csrf", csrf.Token).Methods(http.MethodGet)
+	v1.HandleFunc("/auth/register", h.auth.Register).Methods(http.MethodPost)
+	v1.HandleFunc("/auth/login", h.auth.Login).Methods(http.MethodPost)
+	v1.HandleFunc("/auth/logout", h.auth.Logout).Methods(http.MethodPost)
+	v1.HandleFunc("/auth/me", h.auth.WhoAmI).Methods(http.MethodGet)
+	v1.HandleFunc("/auth/verify-email", h.auth.VerifyEmail).Methods(http.MethodPost)
+	v1.HandleFunc("/auth/password/forgot", h.auth.ResetPassword).Methods(http.MethodPost)
+	v1.HandleFunc("/auth/password/reset", h.auth.PostResetPassword).Methods(http.MethodPost)
+
+	anyone := v1.NewRoute().Subrouter()
+	anyone.Use(auth.VerifyToken, auth.LoginRequired)
+	anyone.HandleFunc("/categories", h.order.GetAllCategories).Methods(http.MethodGet)
+	anyone.HandleFunc("/categories/{id}/items", h.order.GetItemsByCategoryID).Methods(http.MethodGet)
+	anyone.HandleFunc("/items", h.order.GetAllItems).Methods(http.MethodGet)
+	anyone.HandleFunc("/items/{id}", h.order.GetItemByID).Methods(http.MethodGet)
+	anyone.HandleFunc("/me", h.user.UpdateUserDetails).Methods(http.MethodPatch)
+	anyone.HandleFunc("/me/address", h.user.UpdateUserAddress).Methods(http.MethodPut)
+	anyone.HandleFunc("/me/allergens", h.user.UpdateUserAllergens).Methods(http.MethodPut)
+	anyone.HandleFunc("/me/balance", h.user.UpdateUserBalance).Methods(http.MethodPost)
+
+	customer := v1.NewRoute().Subrouter()
+	customer.Use(auth.RoleRequired("user"))
+	customer.HandleFunc("/cart", h.order.GetUserCart).Methods(http.MethodGet)
+	customer.HandleFunc("/cart/items", h.order.AddToCart).Methods(http.MethodPost)
+	customer.HandleFunc("/cart/items/{id}", h.order.SetCartItemQuantity).Methods(http.MethodPatch)
+	customer.HandleFunc("/cart/items/{id}", h.order.RemoveCartItem).Methods(http.MethodDelete)
+	customer.HandleFunc("/orders", h.order.GetUserOrders).Methods(http.MethodGet)
+	customer.HandleFunc("/orders", h.order.PlaceOrder).Methods(http.MethodPost)
+	customer.HandleFunc("/orders/slots", h.order.GetSlots).Methods(http.MethodGet)
+	customer.HandleFunc("/orders/{id}/reorder", h.order.Reorder).Methods(http.MethodPost)
+	customer.HandleFunc("/items/{id}/reviews", h.order.RateItem).Methods(http.MethodPost)
+
+	customerOrAdmin := v1.NewRoute().Subrouter()
+	customerOrAdmin.Use(auth.RoleRequired("user", "admin"))
+	customerOrAdmin.HandleFunc("/orders/{id}/cancel", h.order.CancelOrder).Methods(http.MethodPost)
+
+	seller := v1.NewRoute().Subrouter()
+	seller.Use(auth.SellerRequired)
+	seller.HandleFunc("/items", h.seller.AddItem).Methods(http.MethodPost)
+	seller.HandleFunc("/items/{id}", h.seller.UpdateItem).Methods(http.MethodPut)
+	seller.HandleFunc("/seller/stats", h.seller.GetSellerStats).Methods(http.MethodGet)
+	seller.HandleFunc("/seller/items", h.seller.GetSellerItems).Methods(http.MethodGet)
+	seller.HandleFunc("/seller/items/import", h.seller.ImportItems).Methods(http.MethodPost)
+	seller.HandleFunc("/seller/items/export", h.seller.ExportItems).Methods(http.MethodGet)
+	seller.HandleFunc("/seller/menus", h.seller.GetMenus).Methods(http.MethodGet)
+	seller.HandleFunc("/seller/menus", h.seller.AddMenu).Methods(http.MethodPost)
+	seller.HandleFunc("/seller/menus/{id}", h.seller.UpdateMenu).Methods(http.MethodPut)
+	seller.HandleFunc("/seller/menus/{id}", h.seller.DeleteMenu).Methods(http.MethodDelete)
+	seller.HandleFunc("/seller/orders", h.seller.GetSellerOrders).Methods(http.MethodGet)
+	seller.HandleFunc("/seller/order-items/{id}/advance", h.seller.UpdateOrderItemStatus).Methods(http.MethodPost)
+
+	sellerOrAdmin := v1.NewRoute().Subrouter()
+	sellerOrAdmin.Use(auth.RoleRequired("seller", "admin"))
+	sellerOrAdmin.HandleFunc("/items/{id}", h.seller.DeleteItem).Methods(http.MethodDelete)
+	sellerOrAdmin.HandleFunc("/items/{id}/restore", h.seller.RestoreItem).Methods(http.MethodPost)
+
+	admin := v1.NewRoute().Subrouter()
+	admin.Use(auth.AdminRequired)
+	admin.HandleFunc("/items/{id}", h.admin.UpdateItemStatus).Methods(http.MethodPatch)
+	admin.HandleFunc("/categories", h.admin.AddCategory).Methods(http.MethodPost)
+	admin.HandleFunc("/categories/{id}", h.admin.EditCategory).Methods(http.MethodPut)
+	admin.HandleFunc("/categories/{id}", h.admin.DeleteCategory).Methods(http.MethodDelete)
+	admin.HandleFunc("/categories/{id}/restore", h.admin.RestoreCategory).Methods(http.MethodPost)
+	admin.HandleFunc("/orders/{id}/deliver", h.order.DeliverOrder).Methods(http.MethodPost)
+	admin.HandleFunc("/admin/stats", h.admin.GetAdminStats).Methods(http.MethodGet)
+	admin.HandleFunc("/admin/orders", h.admin.AllOrders).Methods(http.MethodGet)
+	admin.HandleFunc("/admin/users", h.admin.AllUsers).Methods(http.MethodGet)
+	admin.HandleFunc("/admin/users/{id}", h.admin.UpdateUserByAdmin).Methods(http.MethodPut)
+	admin.HandleFunc("/admin/users/{id}", h.admin.DeleteUser).Methods(http.MethodDelete)
+	admin.HandleFunc("/admin/users/{id}/restore", h.admin.RestoreUser).Methods(http.MethodPost)
+	admin.HandleFunc("/admin/deleted", h.admin.Deleted).Methods(http.MethodGet)
+	admin.HandleFunc("/admin/categories/order", h.admin.ReorderCategories).Methods(http.MethodPut)
+}
+
+// legacyRoutes registers the routes the web app was built against. They
+// still work but are deprecated; each names its /api/v1 successor.
+func legacyRoutes(r *mux.Router, auth *middleware.Auth, h handlers) {
+	legacy := func(sr *mux.Router, path, method, successor string, fn http.HandlerFunc) {
+		sr.Handle(path, middleware.Deprecated(legacyDeprecated, "/api/v1"+successor)(fn)).Methods(method)
+	}
+	const get, post = http.MethodGet, http.MethodPost
+
+	legacy(r, "/api/auth/register", post, "/auth/register", h.auth.Register)
+	legacy(r, "/api/auth/whoami", get, "/auth/me", h.auth.WhoAmI)
+	legacy(r, "/api/auth/login", post, "/auth/login", h.auth.Login)
+	legacy(r, "/api/auth/logout", get, "/auth/logout", h.auth.Logout)
+	legacy(r, "/api/auth/verify", post, "/auth/verify-email", h.auth.VerifyEmail)
+	legacy(r, "/api/auth/forgot-password", post, "/auth/password/forgot", h.auth.ResetPassword)
+	legacy(r, "/api/auth/reset-password", post, "/auth/password/reset", h.auth.PostResetPassword)
+
+	order := r.PathPrefix("/api/order").Subrouter()
+	order.Use(auth.VerifyToken, auth.LoginRequired, auth.UserRequired)
+	legacy(order, "/categories", get, "/categories", h.order.GetAllCategories)
+	legacy(order, "/categories/{id}", get, "/categories/{id}/items", h.order.GetItemsByCategoryID)
+	legacy(order, "/item/{id}", get, "/items/{id}", h.order.GetItemByID)
+	legacy(order, "/add-to-cart", post, "/cart/items", h.order.AddToCart)
+	legacy(order, "/place-order", post, "/orders", h.order.PlaceOrder)
+	legacy(order, "/cancel-order", post, "/orders/{id}/cancel", h.order.CancelOrder)
+	legacy(order, "/{id}/reorder", post, "/orders/{id}/reorder", h.order.Reorder)
+	legacy(order, "/update-count", post, "/cart/items/{id}", h.order.UpdateOrderItemCount)
+	legacy(order, "/user-cart", get, "/cart", h.order.GetUserCart)
+	legacy(order, "/user-orders", get, "/orders", h.order.GetUserOrders)
+	legacy(order, "/all-items", get, "/items", h.order.GetAllItems)
+	legacy(order, "/rate", post, "/items/{id}/reviews", h.order.RateItem)
+
+	home := r.PathPrefix("/api/home").Subrouter()
+	home.Use(auth.VerifyToken, auth.LoginRequired, auth.UserRequired)
+	legacy(home, "/categories", get, "/categories?parent_id=0&limit=6", h.order.HomePageCategories)
+	legacy(home, "/items", get, "/items?limit=3", h.order.HomePageItems)
+	legacy(home, "/orders", get, "/orders?limit=3", h.order.HomePageOrders)
+
+	user := r.PathPrefix("/api/user").Subrouter()
+	user.Use(auth.VerifyToken, auth.LoginRequired)
+	legacy(user, "/update-balance", post, "/me/balance", h.user.UpdateUserBalance)
+	legacy(user, "/update-address", post, "/me/address", h.user.UpdateUserAddress)
+	legacy(user, "/update-details", post, "/me", h.user.UpdateUserDetails)
+
+	admin := r.PathPrefix("/api/admin").Subrouter()
+	admin.Use(auth.VerifyToken, auth.LoginRequired, auth.AdminRequired)
+	legacy(admin, "/stats", get, "/admin/stats", h.admin.GetAdminStats)
+	legacy(admin, "/all-orders", get, "/admin/orders", h.admin.AllOrders)
+	legacy(admin, "/all-users", get, "/admin/users", h.admin.AllUsers)
+	legacy(admin, "/all-categories", get, "/categories", h.admin.AllCategories)
+	legacy(admin, "/edit-user", post, "/admin/users/{id}", h.admin.UpdateUserByAdmin)
+	legacy(admin, "/add-category", post, "/categories", h.admin.AddCategory)
+	legacy(admin, "/edit-category", post, "/categories/{id}", h.admin.EditCategory)
+	legacy(admin, "/all-items", get, "/items", h.admin.AllItems)
+	legacy(admin, "/update-item", post, "/items/{id}", h.admin.UpdateItemStatus)
+	legacy(admin, "/cancel-order", post, "/orders/{id}/cancel", h.order.CancelOrder)
+	legacy(admin, "/deliver-order", post, "/orders/{id}/deliver", h.order.DeliverOrder)
+
+	seller := r.PathPrefix("/api/seller").Subrouter()
+	seller.Use(auth.VerifyToken, auth.LoginRequired, auth.SellerRequired)
+	legacy(seller, "/stats", get, "/seller/stats", h.seller.GetSellerStats)
+	legacy(seller, "/all-items", get, "/seller/items", h.seller.GetSellerItems)
+	legacy(seller, "/current-orders", get, "/seller/orders", h.seller.GetSellerOrders)
+	legacy(seller, "/add-item", post, "/items", h.seller.AddItem)
+	legacy(seller, "/edit-item/{id}", post, "/items/{id}", h.seller.UpdateItem)
+	legacy(seller, "/update-orders", post, "/seller/order-items/{id}/advance", h.seller.UpdateOrderItemStatus)
+	legacy(seller, "/all-categories", get, "/categories", h.order.GetAllCategories)
+	legacy(seller, "/item/{id}", get, "/items/{id}", h.order.GetItemByID)
+}
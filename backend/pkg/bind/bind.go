@@ -0,0 +1,272 @@
+// Package bind decodes request bodies into structs and validates them.
+//
+// A request struct names its inputs with `json` tags, `form` tags for
+// multipart forms, or both, and states its rules in a `validate` tag (see
+// Validate). A field tagged `path:"id"` is filled from the route's {id}
+// variable when the route has one, so a handler can serve both a legacy
+// route that names the resource in the body and a /api/v1 route that names
+// it in the URL. Every binder rejects fields the struct doesn't declare, caps
+// the body size and reports problems as a *response.Error, so a handler
+// just does:
+//
+//	var body struct {
+//		Name string `json:"name" validate:"required,max=255"`
+//	}
+//	if err := bind.JSON(w, r, &body); err != nil {
+//		response.Fail(w, r, err)
+//		return
+//	}
+package bind
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/mux"
+
+	"github.com/Entity069/Zesty-Go/pkg/response"
+)
+
+// MaxJSONBytes caps a JSON body. None of the API's JSON inputs come close.
+const MaxJSONBytes = 1 << 20
+
+// JSON decodes a single JSON object from the body into dst and validates
+// it. An empty body counts as an empty object, for routes whose only input
+// is in the path.
+func JSON(w http.ResponseWriter, r *http.Request, dst any) error {
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, MaxJSONBytes))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(dst); err != nil && !errors.Is(err, io.EOF) {
+		return decodeError(err, MaxJSONBytes)
+	}
+	if dec.More() {
+		return response.BadRequest("The request body must hold a single JSON object.")
+	}
+	if err := fillPath(r, dst); err != nil {
+		return err
+	}
+	return Validate(dst)
+}
+
+// Multipart parses a multipart form of up to maxBytes into dst and
+// validates it. Values go to fields tagged `form:"name"` of type string,
+// int, float64 or bool; uploaded files go to *multipart.FileHeader fields.
+// A []string field takes every value sent under its name, each of which may
+// be a comma-separated list. A struct field takes a JSON object, and a list
+// of anything else a JSON array.
+func Multipart(w http.ResponseWriter, r *http.Request, maxBytes int64, dst any) error {
+	r.Body = http.MaxBytesReader(w, r.Body, maxBytes)
+	if err := r.ParseMultipartForm(maxBytes); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			return tooLargeError(maxBytes)
+		}
+		return response.BadRequest("The request body is not a valid multipart form.")
+	}
+	if err := fillForm(r.MultipartForm, dst); err != nil {
+		return err
+	}
+	if err := fillPath(r, dst); err != nil {
+		return err
+	}
+	return Validate(dst)
+}
+
+// Body binds a multipart form or a JSON body, whichever the request's
+// Content-Type says it is, for endpoints that accept both.
+func Body(w http.ResponseWriter, r *http.Request, maxBytes int64, dst any) error {
+	if IsMultipart(r) {
+		return Multipart(w, r, maxBytes, dst)
+	}
+	return JSON(w, r, dst)
+}
+
+// IsMultipart reports whether the request carries a multipart form.
+func IsMultipart(r *http.Request) bool {
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	return mediaType == "multipart/form-data"
+}
+
+func decodeError(err error, limit int64) error {
+	var (
+		tooLarge *http.MaxBytesError
+		typeErr  *json.UnmarshalTypeError
+	)
+	switch {
+	case errors.As(err, &tooLarge):
+		return tooLargeError(limit)
+	case errors.As(err, &typeErr):
+		return response.Validation(response.FieldError{
+			Field:   typeErr.Field,
+			Message: "must be " + describeKind(typeErr.Type),
+		})
+	case strings.HasPrefix(err.Error(), "json: unknown field "):
+		field := strings.Trim(strings.TrimPrefix(err.Error(), "json: unknown field "), `"`)
+		return response.Validation(response.FieldError{Field: field, Message: "is not a recognised field"})
+	default:
+		return response.InvalidJSON(err)
+	}
+}
+
+func tooLargeError(limit int64) error {
+	return response.New(http.StatusRequestEntityTooLarge, response.CodeTooLarge,
+		fmt.Sprintf("The request body is larger than the %d KiB limit.", limit>>10))
+}
+
+func describeKind(t reflect.Type) string {
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch k := t.Kind(); k {
+	case reflect.Int, reflect.Int64, reflect.Int32:
+		return "a whole number"
+	case reflect.Float64, reflect.Float32:
+		return "a number"
+	case reflect.Bool:
+		return "true or false"
+	case reflect.String:
+		return "a string"
+	case reflect.Slice:
+		return "a list"
+	case reflect.Struct:
+		return "an object"
+	default:
+		return "a " + k.String()
+	}
+}
+
+var fileHeaderType = reflect.TypeOf((*multipart.FileHeader)(nil))
+
+// fillForm copies form values and files into dst's `form`-tagged fields.
+// Parts the struct doesn't declare are rejected, like unknown JSON fields.
+func fillForm(form *multipart.Form, dst any) error {
+	v := reflect.ValueOf(dst).Elem()
+	t := v.Type()
+
+	known := map[string]bool{}
+	var fields []response.FieldError
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		name := sf.Tag.Get("form")
+		if name == "" || name == "-" {
+			continue
+		}
+		known[name] = true
+		fv := v.Field(i)
+
+		if sf.Type == fileHeaderType {
+			if files := form.File[name]; len(files) > 0 {
+				fv.Set(reflect.ValueOf(files[0]))
+			}
+			continue
+		}
+
+		values := form.Value[name]
+		if len(values) == 0 {
+			continue
+		}
+		if fv.Kind() == reflect.Slice && fv.Type().Elem().Kind() == reflect.String {
+			setFormList(fv, values)
+			continue
+		}
+		if err := setFormValue(fv, values[0]); err != nil {
+			fields = append(fields, response.FieldError{Field: name, Message: "must be " + describeKind(fv.Type())})
+		}
+	}
+
+	for name := range form.Value {
+		if !known[name] {
+			fields = append(fields, response.FieldError{Field: name, Message: "is not a recognised field"})
+		}
+	}
+	for name := range form.File {
+		if !known[name] {
+			fields = append(fields, response.FieldError{Field: name, Message: "is not a recognised file"})
+		}
+	}
+	if len(fields) > 0 {
+		return response.Validation(fields...)
+	}
+	return nil
+}
+
+// fillPath copies route variables into dst's `path`-tagged fields. They win
+// over whatever the body said, so a body can't point /items/7 at item 8.
+func fillPath(r *http.Request, dst any) error {
+	vars := mux.Vars(r)
+	if len(vars) == 0 {
+		return nil
+	}
+	v := reflect.ValueOf(dst).Elem()
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		raw, ok := vars[t.Field(i).Tag.Get("path")]
+		if !ok {
+			continue
+		}
+		if err := setFormValue(v.Field(i), raw); err != nil {
+			return response.NotFound("No such endpoint.")
+		}
+	}
+	return nil
+}
+
+func setFormValue(fv reflect.Value, raw string) error {
+	switch fv.Kind() {
+	case reflect.Pointer:
+		p := reflect.New(fv.Type().Elem())
+		if err := setFormValue(p.Elem(), raw); err != nil {
+			return err
+		}
+		fv.Set(p)
+	case reflect.String:
+		fv.SetString(raw)
+	case reflect.Int, reflect.Int64:
+		n, err := strconv.ParseInt(strings.TrimSpace(raw), 10, 64)
+		if err != nil {
+			return err
+		}
+		fv.SetInt(n)
+	case reflect.Float64:
+		f, err := strconv.ParseFloat(strings.TrimSpace(raw), 64)
+		if err != nil {
+			return err
+		}
+		fv.SetFloat(f)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(strings.TrimSpace(raw))
+		if err != nil {
+			return err
+		}
+		fv.SetBool(b)
+	case reflect.Struct, reflect.Slice:
+		dec := json.NewDecoder(strings.NewReader(raw))
+		dec.DisallowUnknownFields()
+		return dec.Decode(fv.Addr().Interface())
+	default:
+		panic(fmt.Sprintf("bind: unsupported form field kind %s", fv.Kind()))
+	}
+	return nil
+}
+
+// setFormList fills a []string field from every value sent for it,
+// splitting each on commas and dropping blanks.
+func setFormList(fv reflect.Value, values []string) {
+	list := reflect.MakeSlice(fv.Type(), 0, len(values))
+	for _, value := range values {
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				list = reflect.Append(list, reflect.ValueOf(item).Convert(fv.Type().Elem()))
+			}
+		}
+	}
+	fv.Set(list)
+}
@@ -0,0 +1,280 @@
+package bind_test
+
+import (
+	"bytes"
+	"errors"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+
+	"github.com/Entity069/Zesty-Go/pkg/bind"
+	"github.com/Entity069/Zesty-Go/pkg/response"
+)
+
+type signup struct {
+	Name     string  `json:"name" validate:"required,max=5"`
+	Email    string  `json:"email" validate:"required,email"`
+	Password string  `json:"password" validate:"password"`
+	Role     string  `json:"role" validate:"oneof=user seller"`
+	Age      int     `json:"age" validate:"min=18"`
+	Price    float64 `json:"price" validate:"gt=0"`
+}
+
+func bindJSON(t *testing.T, body string, dst any) *response.Error {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+	err := bind.JSON(httptest.NewRecorder(), req, dst)
+	if err == nil {
+		return nil
+	}
+	var e *response.Error
+	if !errors.As(err, &e) {
+		t.Fatalf("error %v is not a *response.Error", err)
+	}
+	return e
+}
+
+func fieldsOf(e *response.Error) map[string]string {
+	m := map[string]string{}
+	for _, f := range e.Fields {
+		m[f.Field] = f.Message
+	}
+	return m
+}
+
+func TestJSONValid(t *testing.T) {
+	var s signup
+	e := bindJSON(t, `{"name":"Bo","email":"bo@zes.ty","password":"hunter22","role":"seller","age":30,"price":1.5}`, &s)
+	if e != nil {
+		t.Fatalf("unexpected error %v %+v", e, e.Fields)
+	}
+	if s.Name != "Bo" || s.Age != 30 || s.Price != 1.5 {
+		t.Fatalf("decoded %+v", s)
+	}
+}
+
+func TestJSONRules(t *testing.T) {
+	var s signup
+	e := bindJSON(t, `{"name":"  ","email":"bo@localhost","password":"password","role":"admin","age":12,"price":0}`, &s)
+	if e == nil || e.Code != response.CodeValidation {
+		t.Fatalf("got %v, want a validation error", e)
+	}
+	got := fieldsOf(e)
+	for _, field := range []string{"name", "email", "password", "role", "age", "price"} {
+		if got[field] == "" {
+			t.Errorf("no error for %s; got %v", field, got)
+		}
+	}
+}
+
+func TestJSONOptionalFieldsSkipRules(t *testing.T) {
+	var s signup
+	if e := bindJSON(t, `{"name":"Bo","email":"bo@zes.ty","age":18,"price":2}`, &s); e != nil {
+		t.Fatalf("unexpected error %v %+v", e, e.Fields)
+	}
+}
+
+func TestJSONDecodeErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		body   string
+		status int
+		code   response.Code
+		field  string
+	}{
+		{"unknown field", `{"name":"Bo","admin":true}`, http.StatusBadRequest, response.CodeValidation, "admin"},
+		{"wrong type", `{"age":"old"}`, http.StatusBadRequest, response.CodeValidation, "age"},
+		{"malformed", `{"name":`, http.StatusBadRequest, response.CodeInvalidJSON, ""},
+		{"empty", ``, http.StatusBadRequest, response.CodeValidation, "email"},
+		{"two objects", `{"name":"Bo"} {"name":"Al"}`, http.StatusBadRequest, response.CodeBadRequest, ""},
+		{"too large", `{"name":"` + strings.Repeat("a", bind.MaxJSONBytes) + `"}`, http.StatusRequestEntityTooLarge, response.CodeTooLarge, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var s signup
+			e := bindJSON(t, tt.body, &s)
+			if e == nil || e.Status != tt.status || e.Code != tt.code {
+				t.Fatalf("got %+v, want %d %s", e, tt.status, tt.code)
+			}
+			if tt.field != "" && fieldsOf(e)[tt.field] == "" {
+				t.Errorf("fields %+v don't mention %s", e.Fields, tt.field)
+			}
+		})
+	}
+}
+
+type upload struct {
+	ID    int                   `json:"id" form:"id" validate:"required"`
+	Price float64               `json:"price" form:"price" validate:"gt=0"`
+	Note  string                `json:"note" form:"-"`
+	Image *multipart.FileHeader `json:"-" form:"image" validate:"required"`
+}
+
+func multipartRequest(t *testing.T, values map[string]string, files map[string]string) *http.Request {
+	t.Helper()
+	var buf bytes.Buffer
+	mw := multipart.NewWriter(&buf)
+	for k, v := range values {
+		mw.WriteField(k, v)
+	}
+	for k, name := range files {
+		fw, err := mw.CreateFormFile(k, name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		fw.Write([]byte("not really a png"))
+	}
+	mw.Close()
+	req := httptest.NewRequest(http.MethodPost, "/", &buf)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	return req
+}
+
+func TestMultipart(t *testing.T) {
+	req := multipartRequest(t, map[string]string{"id": "7", "price": "2.50"}, map[string]string{"image": "dosa.png"})
+	var u upload
+	if err := bind.Body(httptest.NewRecorder(), req, 1<<20, &u); err != nil {
+		t.Fatalf("Body: %v", err)
+	}
+	if u.ID != 7 || u.Price != 2.5 || u.Image == nil || u.Image.Filename != "dosa.png" {
+		t.Fatalf("bound %+v", u)
+	}
+}
+
+func TestMultipartRejects(t *testing.T) {
+	req := multipartRequest(t, map[string]string{"id": "seven", "note": "hi"}, map[string]string{"other": "x.png"})
+	var u upload
+	err := bind.Multipart(httptest.NewRecorder(), req, 1<<20, &u)
+	var e *response.Error
+	if !errors.As(err, &e) || e.Code != response.CodeValidation {
+		t.Fatalf("got %v, want a validation error", err)
+	}
+	got := fieldsOf(e)
+	for _, field := range []string{"id", "note", "other"} {
+		if got[field] == "" {
+			t.Errorf("no error for %s; got %v", field, got)
+		}
+	}
+}
+
+func TestMultipartTooLarge(t *testing.T) {
+	req := multipartRequest(t, map[string]string{"id": strings.Repeat("9", 4096)}, nil)
+	var u upload
+	err := bind.Multipart(httptest.NewRecorder(), req, 1024, &u)
+	var e *response.Error
+	if !errors.As(err, &e) || e.Status != http.StatusRequestEntityTooLarge {
+		t.Fatalf("got %v, want 413", err)
+	}
+}
+
+func TestValidateFieldNames(t *testing.T) {
+	var u upload
+	err := bind.Validate(&u)
+	var e *response.Error
+	if !errors.As(err, &e) {
+		t.Fatalf("got %v, want a validation error", err)
+	}
+	got := fieldsOf(e)
+	if got["id"] != "is required" || got["image"] != "is required" {
+		t.Fatalf("fields = %v, want id and image required", got)
+	}
+}
+
+func TestValidateUnknownRulePanics(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Fatal("expected a panic")
+		}
+	}()
+	bind.Validate(&struct {
+		X string `validate:"shiny"`
+	}{X: "x"})
+}
+
+func TestPathVariableWins(t *testing.T) {
+	var body struct {
+		ID     int    `json:"id" path:"id" validate:"required"`
+		Status string `json:"status"`
+	}
+	req := httptest.NewRequest(http.MethodPut, "/items/7", strings.NewReader(`{"id": 8, "status": "available"}`))
+	req = mux.SetURLVars(req, map[string]string{"id": "7"})
+	if err := bind.JSON(httptest.NewRecorder(), req, &body); err != nil {
+		t.Fatalf("JSON: %v", err)
+	}
+	if body.ID != 7 || body.Status != "available" {
+		t.Fatalf("bound %+v, want id 7 from the path", body)
+	}
+
+	// A body-less action route gets its only input from the path.
+	body.ID = 0
+	req = mux.SetURLVars(httptest.NewRequest(http.MethodPost, "/orders/3/cancel", nil), map[string]string{"id": "3"})
+	if err := bind.JSON(httptest.NewRecorder(), req, &body); err != nil || body.ID != 3 {
+		t.Fatalf("JSON = %v, id %d; want id 3", err, body.ID)
+	}
+}
+
+func TestPointerFieldsTellMissingFromZero(t *testing.T) {
+	type patch struct {
+		Parent *int  `json:"parent" form:"parent" validate:"min=0"`
+		Active *bool `json:"active" form:"active"`
+	}
+	var p patch
+	if e := bindJSON(t, `{"active": false}`, &p); e != nil {
+		t.Fatalf("unexpected error %v %+v", e, e.Fields)
+	}
+	if p.Parent != nil || p.Active == nil || *p.Active {
+		t.Fatalf("bound %+v", p)
+	}
+	if e := bindJSON(t, `{"parent": -1}`, &patch{}); e == nil || fieldsOf(e)["parent"] == "" {
+		t.Fatalf("got %v, want parent rejected", e)
+	}
+
+	p = patch{}
+	req := multipartRequest(t, map[string]string{"parent": "0", "active": "yes"}, nil)
+	err := bind.Multipart(httptest.NewRecorder(), req, 1<<20, &p)
+	var e *response.Error
+	if !errors.As(err, &e) || fieldsOf(e)["active"] != "must be true or false" {
+		t.Fatalf("got %v, want active rejected", err)
+	}
+	if p.Parent == nil || *p.Parent != 0 {
+		t.Fatalf("bound parent %v, want 0", p.Parent)
+	}
+}
+
+func TestListsAndNestedObjects(t *testing.T) {
+	type facts struct {
+		Fat *float64 `json:"fat" validate:"min=0"`
+	}
+	type dish struct {
+		Tags     []string `form:"tags"`
+		Facts    *facts   `json:"facts" form:"facts"`
+		Servings []facts  `json:"servings" form:"servings"`
+	}
+
+	req := multipartRequest(t, map[string]string{"tags": "vegan, ,spicy", "facts": `{"fat": 2.5}`, "servings": `[{"fat": 1}, {}]`}, nil)
+	var d dish
+	if err := bind.Multipart(httptest.NewRecorder(), req, 1<<20, &d); err != nil {
+		t.Fatalf("Multipart: %v", err)
+	}
+	if len(d.Tags) != 2 || d.Tags[1] != "spicy" || d.Facts == nil || *d.Facts.Fat != 2.5 || len(d.Servings) != 2 {
+		t.Fatalf("bound %+v", d)
+	}
+	req = multipartRequest(t, map[string]string{"servings": `{"fat": 1}`}, nil)
+	if err := bind.Multipart(httptest.NewRecorder(), req, 1<<20, &dish{}); err == nil {
+		t.Fatal("an object where a list belongs was accepted")
+	}
+
+	d = dish{}
+	e := bindJSON(t, `{"facts": {"fat": -1}}`, &d)
+	if e == nil || fieldsOf(e)["facts.fat"] == "" {
+		t.Fatalf("got %v, want facts.fat rejected", e)
+	}
+	d = dish{}
+	if e := bindJSON(t, `{}`, &d); e != nil {
+		t.Fatalf("leaving out the object: %v %+v", e, e.Fields)
+	}
+}
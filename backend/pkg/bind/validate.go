@@ -0,0 +1,164 @@
+package bind
+
+import (
+	"fmt"
+	"net/mail"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/Entity069/Zesty-Go/pkg/response"
+)
+
+// Validate checks dst, a pointer to a struct, against the comma-separated
+// rules in its fields' `validate` tags and returns every failure at once:
+//
+//	required      not empty (blank strings count as empty) and not zero
+//	min=N, max=N  bounds on a number, or on a string's length in characters
+//	gt=N          a number strictly above N
+//	oneof=a b c   one of the listed strings
+//	email         a bare email address
+//	password      8 to 72 bytes (bcrypt's limit) with a letter and a digit
+//
+// Rules other than required are skipped for an empty string, so optional
+// fields only need to be valid when they are sent. An untagged struct
+// field, or a pointer to one that was sent, is checked against its own
+// fields' tags, reported as parent.field. An unknown rule is a programming
+// error and panics.
+func Validate(dst any) error {
+	if fields := validateStruct(reflect.Indirect(reflect.ValueOf(dst)), ""); len(fields) > 0 {
+		return response.Validation(fields...)
+	}
+	return nil
+}
+
+func validateStruct(v reflect.Value, prefix string) []response.FieldError {
+	t := v.Type()
+	var fields []response.FieldError
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		tag := sf.Tag.Get("validate")
+		if tag == "" {
+			if nested := reflect.Indirect(v.Field(i)); nested.Kind() == reflect.Struct && sf.IsExported() {
+				fields = append(fields, validateStruct(nested, prefix+fieldName(sf)+".")...)
+			}
+			continue
+		}
+		if msg := check(v.Field(i), tag); msg != "" {
+			fields = append(fields, response.FieldError{Field: prefix + fieldName(sf), Message: msg})
+		}
+	}
+	return fields
+}
+
+// fieldName is how the client knows the field: its JSON name, else its
+// form name.
+func fieldName(sf reflect.StructField) string {
+	for _, key := range []string{"json", "form"} {
+		if name, _, _ := strings.Cut(sf.Tag.Get(key), ","); name != "" && name != "-" {
+			return name
+		}
+	}
+	return sf.Name
+}
+
+// check returns the first rule fv breaks, as a message, or "".
+func check(fv reflect.Value, tag string) string {
+	// A pointer tells a field left out from one sent as its zero value;
+	// the rules apply to what it points at.
+	if fv.Kind() == reflect.Pointer && !fv.IsNil() {
+		fv = fv.Elem()
+	}
+	empty := fv.IsZero() || fv.Kind() == reflect.String && strings.TrimSpace(fv.String()) == ""
+
+	for _, rule := range strings.Split(tag, ",") {
+		name, arg, _ := strings.Cut(rule, "=")
+		if name == "required" {
+			if empty {
+				return "is required"
+			}
+			continue
+		}
+		if empty && (fv.Kind() == reflect.String || fv.Kind() == reflect.Pointer) {
+			continue
+		}
+
+		switch name {
+		case "min", "max", "gt":
+			limit, err := strconv.ParseFloat(arg, 64)
+			if err != nil {
+				panic(fmt.Sprintf("bind: bad %s argument %q", name, arg))
+			}
+			if msg := checkBound(fv, name, limit, arg); msg != "" {
+				return msg
+			}
+		case "oneof":
+			options := strings.Fields(arg)
+			found := false
+			for _, o := range options {
+				if fv.String() == o {
+					found = true
+					break
+				}
+			}
+			if !found {
+				return "must be one of: " + strings.Join(options, ", ")
+			}
+		case "email":
+			addr, err := mail.ParseAddress(fv.String())
+			if err != nil || addr.Address != fv.String() || !strings.Contains(addr.Address[strings.IndexByte(addr.Address, '@'):], ".") {
+				return "must be a valid email address"
+			}
+		case "password":
+			if msg := checkPassword(fv.String()); msg != "" {
+				return msg
+			}
+		default:
+			panic(fmt.Sprintf("bind: unknown validation rule %q", name))
+		}
+	}
+	return ""
+}
+
+func checkBound(fv reflect.Value, rule string, limit float64, arg string) string {
+	var n float64
+	unit := ""
+	switch fv.Kind() {
+	case reflect.String:
+		n = float64(utf8.RuneCountInString(fv.String()))
+		unit = " characters"
+	case reflect.Int, reflect.Int64, reflect.Int32:
+		n = float64(fv.Int())
+	case reflect.Float64, reflect.Float32:
+		n = fv.Float()
+	default:
+		panic(fmt.Sprintf("bind: %s doesn't apply to %s", rule, fv.Kind()))
+	}
+
+	switch {
+	case rule == "min" && n < limit:
+		return "must be at least " + arg + unit
+	case rule == "max" && n > limit:
+		return "must be at most " + arg + unit
+	case rule == "gt" && n <= limit:
+		return "must be greater than " + arg
+	}
+	return ""
+}
+
+func checkPassword(pw string) string {
+	if len(pw) < 8 || len(pw) > 72 {
+		return "must be between 8 and 72 characters"
+	}
+	var letter, digit bool
+	for _, c := range pw {
+		letter = letter || unicode.IsLetter(c)
+		digit = digit || unicode.IsDigit(c)
+	}
+	if !letter || !digit {
+		return "must contain at least one letter and one digit"
+	}
+	return ""
+}
@@ -0,0 +1,265 @@
+// Package catalog imports and exports a seller's items as CSV or JSON, so a
+// large menu can be edited in a spreadsheet and uploaded in one go.
+//
+// A file has one row per item with the columns in Columns. Rows are matched
+// to the seller's items by SKU: a known SKU updates that item, a new one
+// creates an item. An item from before SKUs existed is matched by name
+// instead and given the row's SKU, so exporting, filling in the SKU column
+// and importing adopts the existing menu rather than duplicating it.
+//
+// Import is all or nothing. Prepare checks every row and lists every
+// problem; only a file without problems is applied.
+package catalog
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/Entity069/Zesty-Go/pkg/bind"
+	"github.com/Entity069/Zesty-Go/pkg/response"
+)
+
+const (
+	FormatCSV  = "csv"
+	FormatJSON = "json"
+)
+
+// Columns are the fields of a row, in the order Export writes them.
+var Columns = []string{"sku", "name", "description", "price", "category", "status", "image"}
+
+// required are the columns an import must have. Leaving out status or
+// image keeps the item's current value.
+var required = []string{"sku", "name", "description", "price", "category"}
+
+// MaxRows bounds the size of one import.
+const MaxRows = 2000
+
+// Placeholder is the image of an item created without one.
+const Placeholder = "/placeholder.svg"
+
+// Row is one item as read from a file.
+type Row struct {
+	// Row is the CSV line number, or the position in a JSON array, counted
+	// from 1.
+	Row         int     `json:"-"`
+	SKU         string  `json:"sku" validate:"required,max=64"`
+	Name        string  `json:"name" validate:"required,max=255"`
+	Description string  `json:"description" validate:"required,max=255"`
+	Price       float64 `json:"price" validate:"required,gt=0,max=99999999"`
+	Category    string  `json:"category" validate:"required"`
+	Status      string  `json:"status" validate:"oneof=available unavailable discontinued"`
+	// Image is an http(s) URL to fetch the picture from, or the path of an
+	// image already stored, as Export writes it.
+	Image string `json:"image" validate:"max=255"`
+}
+
+// Problem is one thing wrong with a row.
+type Problem struct {
+	Row     int    `json:"row"`
+	SKU     string `json:"sku,omitempty"`
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// Parse reads the rows of a CSV or JSON file. Values that can't be read
+// (a price that isn't a number, say) come back as problems alongside the
+// rows; an error means the file as a whole is unusable.
+func Parse(r io.Reader, format string) ([]Row, []Problem, error) {
+	var records []record
+	var err error
+	switch format {
+	case FormatCSV:
+		records, err = readCSV(r)
+	case FormatJSON:
+		records, err = readJSON(r)
+	default:
+		return nil, nil, fmt.Errorf("unknown format %q; use csv or json", format)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(records) == 0 {
+		return nil, nil, errors.New("the file has no rows")
+	}
+	if len(records) > MaxRows {
+		return nil, nil, fmt.Errorf("the file has %d rows; import at most %d at a time", len(records), MaxRows)
+	}
+
+	rows := make([]Row, 0, len(records))
+	var problems []Problem
+	for _, rec := range records {
+		row, probs := rec.parse()
+		rows = append(rows, row)
+		problems = append(problems, probs...)
+	}
+	return rows, problems, nil
+}
+
+// record is a row's raw values by column.
+type record struct {
+	row    int
+	values map[string]string
+}
+
+func (rec record) parse() (Row, []Problem) {
+	v := rec.values
+	row := Row{
+		Row:         rec.row,
+		SKU:         strings.TrimSpace(v["sku"]),
+		Name:        strings.TrimSpace(v["name"]),
+		Description: strings.TrimSpace(v["description"]),
+		Category:    strings.TrimSpace(v["category"]),
+		Status:      strings.ToLower(strings.TrimSpace(v["status"])),
+		Image:       strings.TrimSpace(v["image"]),
+	}
+	problem := func(field, msg string) Problem {
+		return Problem{Row: rec.row, SKU: row.SKU, Field: field, Message: msg}
+	}
+
+	var problems []Problem
+	if price := strings.TrimSpace(v["price"]); price != "" {
+		p, err := strconv.ParseFloat(price, 64)
+		if err != nil {
+			problems = append(problems, problem("price", fmt.Sprintf("%q is not a number", price)))
+		} else {
+			row.Price = p
+		}
+	}
+	if err := bind.Validate(&row); err != nil {
+		var e *response.Error
+		if errors.As(err, &e) {
+			for _, f := range e.Fields {
+				// An unreadable price is already reported.
+				if f.Field == "price" && len(problems) > 0 {
+					continue
+				}
+				problems = append(problems, problem(f.Field, f.Message))
+			}
+		}
+	}
+	if math.Round(row.Price*100)/100 != row.Price {
+		problems = append(problems, problem("price", "must have at most two decimal places"))
+	}
+	if row.Image != "" && !isURL(row.Image) && !strings.HasPrefix(row.Image, "/") {
+		problems = append(problems, problem("image", "must be an http(s) URL or the path of an uploaded image"))
+	}
+	return row, problems
+}
+
+func isURL(s string) bool {
+	return strings.HasPrefix(s, "https://") || strings.HasPrefix(s, "http://")
+}
+
+func readCSV(r io.Reader) ([]record, error) {
+	cr := csv.NewReader(r)
+	cr.TrimLeadingSpace = true
+	header, err := cr.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, errors.New("the file is empty")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading the header: %w", err)
+	}
+	if len(header) > 0 {
+		// Spreadsheets like to start UTF-8 files with a byte order mark.
+		header[0] = strings.TrimPrefix(header[0], "\ufeff")
+	}
+	for i := range header {
+		header[i] = strings.ToLower(strings.TrimSpace(header[i]))
+	}
+	if err := checkColumns(header); err != nil {
+		return nil, err
+	}
+
+	var records []record
+	for {
+		fields, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			return records, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		line, _ := cr.FieldPos(0)
+		rec := record{row: line, values: make(map[string]string, len(header))}
+		for i, col := range header {
+			rec.values[col] = fields[i]
+		}
+		records = append(records, rec)
+		if len(records) > MaxRows {
+			return records, nil
+		}
+	}
+}
+
+func checkColumns(header []string) error {
+	seen := map[string]bool{}
+	for _, col := range header {
+		if !slices.Contains(Columns, col) {
+			return fmt.Errorf("unknown column %q; the columns are %s", col, strings.Join(Columns, ", "))
+		}
+		if seen[col] {
+			return fmt.Errorf("column %q appears twice", col)
+		}
+		seen[col] = true
+	}
+	for _, col := range required {
+		if !seen[col] {
+			return fmt.Errorf("missing column %q", col)
+		}
+	}
+	return nil
+}
+
+// readJSON accepts an array of objects, or an object holding one under
+// "items" as Export writes it. Numbers may be given as numbers or strings.
+func readJSON(r io.Reader) ([]record, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	var objects []map[string]any
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '{' {
+		var wrapper struct {
+			Items []map[string]any `json:"items"`
+		}
+		err = dec.Decode(&wrapper)
+		objects = wrapper.Items
+	} else {
+		err = dec.Decode(&objects)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("the file is not a JSON array of items: %w", err)
+	}
+
+	records := make([]record, 0, len(objects))
+	for i, obj := range objects {
+		rec := record{row: i + 1, values: make(map[string]string, len(obj))}
+		for key, val := range obj {
+			if !slices.Contains(Columns, key) {
+				return nil, fmt.Errorf("item %d: unknown field %q; the fields are %s", i+1, key, strings.Join(Columns, ", "))
+			}
+			switch val := val.(type) {
+			case nil:
+			case string:
+				rec.values[key] = val
+			case json.Number:
+				rec.values[key] = val.String()
+			default:
+				return nil, fmt.Errorf("item %d: %s must be a string or a number", i+1, key)
+			}
+		}
+		records = append(records, rec)
+	}
+	return records, nil
+}
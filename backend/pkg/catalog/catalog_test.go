@@ -0,0 +1,165 @@
+package catalog_test
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Entity069/Zesty-Go/pkg/catalog"
+	"github.com/Entity069/Zesty-Go/pkg/models"
+)
+
+func TestParse(t *testing.T) {
+	csvFile := "\ufeffSKU,Name,Description,Price,Category\n" +
+		"D1,Dosa,Crisp,40,Breakfast\n" +
+		"D2,Idli,Steamed,abc,Breakfast\n" +
+		"D3,Vada,Fried,12.345,Breakfast\n" +
+		",Upma,,10,Breakfast\n"
+	rows, problems, err := catalog.Parse(strings.NewReader(csvFile), catalog.FormatCSV)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 4 || rows[0] != (catalog.Row{Row: 2, SKU: "D1", Name: "Dosa", Description: "Crisp", Price: 40, Category: "Breakfast"}) {
+		t.Fatalf("rows = %+v", rows)
+	}
+	want := map[string]bool{"3 price": true, "4 price": true, "5 sku": true, "5 description": true}
+	for _, p := range problems {
+		key := fmt.Sprintf("%d %s", p.Row, p.Field)
+		if !want[key] {
+			t.Errorf("unexpected problem %+v", p)
+		}
+		delete(want, key)
+	}
+	if len(want) > 0 {
+		t.Errorf("missing problems %v", want)
+	}
+
+	jsonFile := `{"items": [{"sku": "D1", "name": "Dosa", "description": "Crisp", "price": 40, "category": "Breakfast", "status": "unavailable"},
+		{"sku": "D2", "name": "Idli", "description": "Steamed", "price": "30.50", "category": "Breakfast", "image": "ftp://x/idli.png"}]}`
+	rows, problems, err = catalog.Parse(strings.NewReader(jsonFile), catalog.FormatJSON)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rows[0].Status != "unavailable" || rows[1].Price != 30.5 {
+		t.Fatalf("rows = %+v", rows)
+	}
+	if len(problems) != 1 || problems[0].Row != 2 || problems[0].Field != "image" {
+		t.Fatalf("problems = %+v, want the ftp image", problems)
+	}
+
+	for name, tc := range map[string]struct{ file, format string }{
+		"unknown column":  {"sku,name,description,price,category,colour\n", catalog.FormatCSV},
+		"missing column":  {"sku,name,price,category\nD1,Dosa,40,Breakfast\n", catalog.FormatCSV},
+		"no rows":         {"sku,name,description,price,category\n", catalog.FormatCSV},
+		"unknown field":   {`[{"sku": "D1", "colour": "red"}]`, catalog.FormatJSON},
+		"nested value":    {`[{"sku": ["D1"]}]`, catalog.FormatJSON},
+		"not an array":    {`"items"`, catalog.FormatJSON},
+		"unknown format":  {"", "xlsx"},
+		"too many fields": {"sku,name,description,price,category\nD1,Dosa,Crisp,40,Breakfast,extra\n", catalog.FormatCSV},
+	} {
+		if _, _, err := catalog.Parse(strings.NewReader(tc.file), tc.format); err == nil {
+			t.Errorf("%s: no error", name)
+		}
+	}
+}
+
+func TestPrepare(t *testing.T) {
+	categories := []*models.Category{{ID: 1, Name: "Breakfast"}, {ID: 2, Name: "Snacks"}}
+	existing := []*models.Item{
+		{ID: 10, SellerID: 7, SKU: "D1", Name: "Dosa", Description: "Crisp", Price: 40, CategoryID: 1, Status: "available", Image: "/uploads/item-images/dosa.jpg"},
+		{ID: 11, SellerID: 7, Name: "Idli", Description: "Steamed", Price: 30, CategoryID: 1, Status: "unavailable", Image: catalog.Placeholder},
+		{ID: 12, SellerID: 7, SKU: "S1", Name: "Samosa", Description: "Fried", Price: 15, CategoryID: 2, Status: "available", Image: catalog.Placeholder},
+	}
+	rows := []catalog.Row{
+		{Row: 2, SKU: "D1", Name: "Dosa", Description: "Crisp", Price: 40, Category: "breakfast"},
+		{Row: 3, SKU: "I1", Name: "idli", Description: "Steamed", Price: 35, Category: "Breakfast"},
+		{Row: 4, SKU: "V1", Name: "Vada", Description: "Fried", Price: 20, Category: " Snacks ", Image: "https://img.example/vada.png"},
+		{Row: 5, SKU: "S1", Name: "Samosa", Description: "Fried", Price: 15, Category: "Snacks", Status: "discontinued", Image: catalog.Placeholder},
+	}
+	rep := catalog.Prepare(7, rows, nil, existing, categories)
+	if !rep.OK() {
+		t.Fatalf("problems: %+v", rep.Problems)
+	}
+	if rep.Created != 1 || rep.Updated != 2 || rep.Unchanged != 1 || rep.ImagesQueued != 1 {
+		t.Fatalf("report = %+v", rep)
+	}
+	for i, want := range []struct {
+		action string
+		id     int
+	}{{catalog.ActionUnchanged, 10}, {catalog.ActionUpdate, 11}, {catalog.ActionCreate, 0}, {catalog.ActionUpdate, 12}} {
+		if p := rep.Plan[i]; p.Action != want.action || p.Item.ID != want.id {
+			t.Errorf("row %d: %s item %d, want %s item %d", p.Row, p.Action, p.Item.ID, want.action, want.id)
+		}
+	}
+	// The adopted item takes the SKU and keeps the status the row left out.
+	if idli := rep.Plan[1].Item; idli.SKU != "I1" || idli.Price != 35 || idli.Status != "unavailable" {
+		t.Errorf("idli = %+v", idli)
+	}
+	if vada := rep.Plan[2].Item; vada.SellerID != 7 || vada.CategoryID != 2 || vada.Status != "available" || vada.Image != catalog.Placeholder || rep.Plan[2].ImageURL == "" {
+		t.Errorf("vada = %+v, %+v", vada, rep.Plan[2])
+	}
+	if existing[1].SKU != "" {
+		t.Error("Prepare changed an existing item")
+	}
+
+	rows = []catalog.Row{
+		{Row: 2, SKU: "D1", Name: "Masala Dosa", Description: "Crisp", Price: 50, Category: "Breakfast"},
+		{Row: 3, SKU: "D9", Name: "Dosa", Description: "Plain", Price: 30, Category: "Breakfast"}, // D1 is renamed, so free
+		{Row: 4, SKU: "D9", Name: "Samosa", Description: "x", Price: 1, Category: "Lunch"},
+		{Row: 5, SKU: "X1", Name: "masala dosa", Description: "x", Price: 1, Category: "Breakfast"},
+	}
+	rep = catalog.Prepare(7, rows, []catalog.Problem{{Row: 9, Field: "price", Message: "from Parse"}}, existing, categories)
+	got := map[string]bool{}
+	for _, p := range rep.Problems {
+		got[fmt.Sprintf("%d %s", p.Row, p.Field)] = true
+	}
+	want := map[string]bool{"9 price": true, "4 sku": true, "4 name": true, "4 category": true, "5 name": true}
+	if len(got) != len(want) {
+		t.Fatalf("problems = %+v", rep.Problems)
+	}
+	for k := range want {
+		if !got[k] {
+			t.Errorf("missing problem %q in %+v", k, rep.Problems)
+		}
+	}
+	// A deleted item keeps its SKU but not its name.
+	deleted := time.Now()
+	existing = append(existing, &models.Item{ID: 13, SellerID: 7, SKU: "U1", Name: "Upma", CategoryID: 1, Status: "available", DeletedAt: &deleted})
+	rows = []catalog.Row{
+		{Row: 2, SKU: "U1", Name: "Rava Upma", Description: "x", Price: 10, Category: "Breakfast"},
+		{Row: 3, SKU: "U2", Name: "upma", Description: "x", Price: 10, Category: "Breakfast"},
+	}
+	rep = catalog.Prepare(7, rows, nil, existing, categories)
+	if len(rep.Problems) != 1 || rep.Problems[0].Row != 2 || rep.Problems[0].Field != "sku" {
+		t.Fatalf("problems = %+v, want only row 2's SKU", rep.Problems)
+	}
+	if rep.Plan[1].Action != catalog.ActionCreate {
+		t.Errorf("row 3 = %+v, want a new item", rep.Plan[1])
+	}
+}
+
+func TestExportRoundTrip(t *testing.T) {
+	items := []*models.Item{
+		{SKU: "D1", Name: "Dosa, masala", Description: `The "best"`, Price: 40.5, CategoryName: "Breakfast", Status: "available", Image: "/uploads/item-images/d.jpg"},
+		{SKU: "I1", Name: "Idli", Description: "Steamed", Price: 30, CategoryName: "Breakfast", Status: "unavailable", Image: catalog.Placeholder},
+	}
+	for _, format := range []string{catalog.FormatCSV, catalog.FormatJSON} {
+		var buf bytes.Buffer
+		if err := catalog.Export(&buf, items, format); err != nil {
+			t.Fatal(err)
+		}
+		rows, problems, err := catalog.Parse(&buf, format)
+		if err != nil || len(problems) > 0 {
+			t.Fatalf("%s: %v %+v", format, err, problems)
+		}
+		for i, r := range rows {
+			it := items[i]
+			if r.SKU != it.SKU || r.Name != it.Name || r.Description != it.Description || r.Price != it.Price ||
+				r.Category != it.CategoryName || r.Status != it.Status || r.Image != it.Image {
+				t.Errorf("%s: row %+v, want %+v", format, r, it)
+			}
+		}
+	}
+}
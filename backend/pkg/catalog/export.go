@@ -0,0 +1,47 @@
+package catalog
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/Entity069/Zesty-Go/pkg/models"
+)
+
+// Export writes items as a file Parse reads back unchanged. The items must
+// carry their category names, as ItemRepo.GetBySellerID returns them.
+func Export(w io.Writer, items []*models.Item, format string) error {
+	rows := make([]Row, 0, len(items))
+	for _, it := range items {
+		rows = append(rows, Row{
+			SKU:         it.SKU,
+			Name:        it.Name,
+			Description: it.Description,
+			Price:       it.Price,
+			Category:    it.CategoryName,
+			Status:      it.Status,
+			Image:       it.Image,
+		})
+	}
+
+	switch format {
+	case FormatCSV:
+		cw := csv.NewWriter(w)
+		cw.Write(Columns)
+		for _, r := range rows {
+			cw.Write([]string{r.SKU, r.Name, r.Description, strconv.FormatFloat(r.Price, 'f', 2, 64), r.Category, r.Status, r.Image})
+		}
+		cw.Flush()
+		return cw.Error()
+	case FormatJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(struct {
+			Items []Row `json:"items"`
+		}{rows})
+	default:
+		return fmt.Errorf("unknown format %q; use csv or json", format)
+	}
+}
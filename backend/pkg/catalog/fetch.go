@@ -0,0 +1,189 @@
+package catalog
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/Entity069/Zesty-Go/pkg/images"
+	"github.com/Entity069/Zesty-Go/pkg/logging"
+	"github.com/Entity069/Zesty-Go/pkg/metrics"
+	"github.com/Entity069/Zesty-Go/pkg/models"
+	"github.com/Entity069/Zesty-Go/pkg/storage"
+)
+
+const (
+	fetchWorkers = 2
+	fetchQueue   = 1000
+)
+
+// Fetcher downloads the pictures named in imports in the background, so a
+// large import doesn't wait on dozens of remote servers. Each picture goes
+// through the same pipeline as an upload and is then set on its item.
+//
+// Fetches are best effort: one that fails, or is still queued when the
+// server stops, leaves the item with its previous image, and the seller can
+// import the row again.
+type Fetcher struct {
+	store    *models.Store
+	blobs    storage.BlobStore
+	maxBytes int64
+	client   *http.Client
+
+	start sync.Once
+	mu    sync.Mutex
+	queue chan fetch
+	wg    sync.WaitGroup
+}
+
+type fetch struct {
+	ctx    context.Context
+	itemID int
+	url    string
+	// image is the item's picture when the fetch was queued. If the seller
+	// changes it before the fetch completes, theirs wins.
+	image string
+}
+
+// NewFetcher returns a fetcher that saves pictures of at most maxBytes. A
+// nil client gets one that refuses to connect to private, loopback and
+// link-local addresses, so an import can't be used to probe the network the
+// server runs in.
+func NewFetcher(store *models.Store, blobs storage.BlobStore, maxBytes int64, client *http.Client) *Fetcher {
+	if client == nil {
+		client = publicClient()
+	}
+	return &Fetcher{store: store, blobs: blobs, maxBytes: maxBytes, client: client}
+}
+
+// Enqueue queues the picture at url for the item. It doesn't block; false
+// means the queue is full or closed and the picture won't be fetched.
+func (f *Fetcher) Enqueue(ctx context.Context, item *models.Item, url string) bool {
+	f.start.Do(func() {
+		f.queue = make(chan fetch, fetchQueue)
+		for range fetchWorkers {
+			f.wg.Add(1)
+			go f.work(f.queue)
+		}
+	})
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.queue == nil {
+		return false
+	}
+	select {
+	case f.queue <- fetch{ctx: context.WithoutCancel(ctx), itemID: item.ID, url: url, image: item.Image}:
+		metrics.CatalogImageFetches.WithLabelValues("queued").Inc()
+		return true
+	default:
+		metrics.CatalogImageFetches.WithLabelValues("dropped").Inc()
+		return false
+	}
+}
+
+// Close stops taking pictures and waits for the queued ones.
+func (f *Fetcher) Close() {
+	f.start.Do(func() {})
+	f.mu.Lock()
+	if f.queue != nil {
+		close(f.queue)
+		f.queue = nil
+	}
+	f.mu.Unlock()
+	f.wg.Wait()
+}
+
+func (f *Fetcher) work(queue <-chan fetch) {
+	defer f.wg.Done()
+	for job := range queue {
+		log := logging.FromContext(job.ctx).With(slog.Int("item_id", job.itemID), slog.String("url", job.url))
+		if err := f.fetch(job); err != nil {
+			metrics.CatalogImageFetches.WithLabelValues("failed").Inc()
+			log.Warn("fetching an imported item's picture", slog.Any("error", err))
+			continue
+		}
+		metrics.CatalogImageFetches.WithLabelValues("saved").Inc()
+	}
+}
+
+func (f *Fetcher) fetch(job fetch) error {
+	ctx, cancel := context.WithTimeout(job.ctx, time.Minute)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, job.url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("the server answered %s", resp.Status)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, f.maxBytes+1))
+	if err != nil {
+		return err
+	}
+	if int64(len(data)) > f.maxBytes {
+		return fmt.Errorf("the picture is larger than %d bytes", f.maxBytes)
+	}
+
+	files, err := images.Process(bytes.NewReader(data), "item-images")
+	if err != nil {
+		return err
+	}
+	for _, file := range files {
+		if err := f.blobs.Put(ctx, file.Key, bytes.NewReader(file.Data), int64(len(file.Data)), file.ContentType); err != nil {
+			return err
+		}
+	}
+
+	// The files are stored before the item points at them; if the item is
+	// gone or has a new picture, the upload collector reclaims them.
+	item, err := f.store.Items.GetByID(ctx, job.itemID)
+	if err != nil {
+		return err
+	}
+	if item.Image != job.image {
+		return nil
+	}
+	item.Image = storage.Path(files[len(files)-1].Key)
+	return f.store.Items.Update(ctx, item)
+}
+
+var errPrivateAddress = errors.New("refusing to fetch from a private address")
+
+func publicClient() *http.Client {
+	dialer := &net.Dialer{
+		Timeout: 10 * time.Second,
+		// Control sees the resolved address of every connection, redirects
+		// included, so a public name pointing at a private address is
+		// caught too.
+		Control: func(_, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			ip := net.ParseIP(host)
+			if ip == nil || !ip.IsGlobalUnicast() || ip.IsPrivate() {
+				return errPrivateAddress
+			}
+			return nil
+		},
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+	return &http.Client{Transport: transport, Timeout: 30 * time.Second}
+}
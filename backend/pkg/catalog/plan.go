@@ -0,0 +1,193 @@
+package catalog
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Entity069/Zesty-Go/pkg/models"
+)
+
+const (
+	ActionCreate    = "create"
+	ActionUpdate    = "update"
+	ActionUnchanged = "unchanged"
+)
+
+// Planned is what importing a row would do.
+type Planned struct {
+	Row    int    `json:"row"`
+	SKU    string `json:"sku"`
+	Name   string `json:"name"`
+	Action string `json:"action"`
+	// ImageURL is the picture to fetch once the row is saved. Until it
+	// arrives the item keeps its current image, or the placeholder.
+	ImageURL string `json:"image_url,omitempty"`
+	// Item is the item to create or update, with the row applied.
+	Item *models.Item `json:"-"`
+}
+
+// Report is the outcome of checking a file: what importing it would do, or
+// why it can't be imported.
+type Report struct {
+	Rows         int       `json:"rows"`
+	Created      int       `json:"created"`
+	Updated      int       `json:"updated"`
+	Unchanged    int       `json:"unchanged"`
+	ImagesQueued int       `json:"images_queued"`
+	Problems     []Problem `json:"problems"`
+	Plan         []Planned `json:"plan"`
+}
+
+// OK reports whether the file can be imported.
+func (r *Report) OK() bool {
+	return len(r.Problems) == 0
+}
+
+// Prepare matches rows to the seller's existing items and checks them
+// against each other and the categories. existing may include deleted
+// items: their SKUs stay taken, so a row with one is a problem until the
+// item is restored, but their names are free. problems are those Parse
+// found; the report lists them with the rest. Nothing is saved: applying
+// the plan is up to the caller, and only when the report is OK.
+func Prepare(sellerID int, rows []Row, problems []Problem, existing []*models.Item, categories []*models.Category) *Report {
+	rep := &Report{Rows: len(rows), Problems: append([]Problem(nil), problems...), Plan: []Planned{}}
+	problem := func(row Row, field, format string, args ...any) {
+		rep.Problems = append(rep.Problems, Problem{Row: row.Row, SKU: row.SKU, Field: field, Message: fmt.Sprintf(format, args...)})
+	}
+
+	categoryIDs := make(map[string]int, len(categories))
+	for _, c := range categories {
+		categoryIDs[fold(c.Name)] = c.ID
+	}
+	bySKU := map[string]*models.Item{}
+	byName := map[string][]*models.Item{}
+	deletedSKU := map[string]bool{}
+	for _, it := range existing {
+		if it.DeletedAt != nil {
+			if it.SKU != "" {
+				deletedSKU[it.SKU] = true
+			}
+			continue
+		}
+		if it.SKU != "" {
+			bySKU[it.SKU] = it
+		}
+		byName[fold(it.Name)] = append(byName[fold(it.Name)], it)
+	}
+
+	// Match every row first: whether a name is taken depends on which
+	// items the file renames.
+	targets := make([]*models.Item, len(rows))
+	targeted := map[int]bool{}
+	seenSKU := map[string]int{}
+	seenName := map[string]int{}
+	for i, row := range rows {
+		if row.SKU != "" {
+			if first, ok := seenSKU[row.SKU]; ok {
+				problem(row, "sku", "duplicates the SKU on row %d", first)
+			} else {
+				seenSKU[row.SKU] = row.Row
+			}
+		}
+		if row.Name != "" {
+			if first, ok := seenName[fold(row.Name)]; ok {
+				problem(row, "name", "duplicates the name on row %d", first)
+			} else {
+				seenName[fold(row.Name)] = row.Row
+			}
+		}
+
+		if deletedSKU[row.SKU] {
+			problem(row, "sku", "belongs to a deleted item; restore it to import this row")
+		}
+
+		target := bySKU[row.SKU]
+		if target == nil && row.SKU != "" && !deletedSKU[row.SKU] {
+			// An item from before SKUs is adopted by its name.
+			var unnamed []*models.Item
+			for _, it := range byName[fold(row.Name)] {
+				if it.SKU == "" {
+					unnamed = append(unnamed, it)
+				}
+			}
+			if len(unnamed) == 1 {
+				target = unnamed[0]
+			}
+		}
+		if target != nil && targeted[target.ID] {
+			// Two rows adopting one item already have the same name.
+			target = nil
+		}
+		if target != nil {
+			targeted[target.ID] = true
+		}
+		targets[i] = target
+	}
+
+	for i, row := range rows {
+		target := targets[i]
+		for _, it := range byName[fold(row.Name)] {
+			if it != target && !targeted[it.ID] {
+				problem(row, "name", "is already the name of your item %s", describe(it))
+				break
+			}
+		}
+		categoryID, ok := categoryIDs[fold(row.Category)]
+		if !ok && row.Category != "" {
+			problem(row, "category", "%q is not a known category", row.Category)
+		}
+
+		item := &models.Item{SellerID: sellerID, Status: "available", Image: Placeholder}
+		if target != nil {
+			cp := *target
+			item = &cp
+		}
+		item.SKU = row.SKU
+		item.Name = row.Name
+		item.Description = row.Description
+		item.Price = row.Price
+		item.CategoryID = categoryID
+		if row.Status != "" {
+			item.Status = row.Status
+		}
+
+		p := Planned{Row: row.Row, SKU: row.SKU, Name: row.Name, Item: item}
+		switch {
+		case isURL(row.Image):
+			p.ImageURL = row.Image
+			rep.ImagesQueued++
+		case row.Image != "":
+			item.Image = row.Image
+		}
+
+		switch {
+		case target == nil:
+			p.Action = ActionCreate
+			rep.Created++
+		case p.ImageURL == "" && same(target, item):
+			p.Action = ActionUnchanged
+			rep.Unchanged++
+		default:
+			p.Action = ActionUpdate
+			rep.Updated++
+		}
+		rep.Plan = append(rep.Plan, p)
+	}
+	return rep
+}
+
+func same(a, b *models.Item) bool {
+	return a.SKU == b.SKU && a.Name == b.Name && a.Description == b.Description && a.Price == b.Price &&
+		a.CategoryID == b.CategoryID && a.Status == b.Status && a.Image == b.Image
+}
+
+func describe(it *models.Item) string {
+	if it.SKU != "" {
+		return it.SKU
+	}
+	return fmt.Sprintf("#%d", it.ID)
+}
+
+func fold(s string) string {
+	return strings.ToLower(strings.TrimSpace(s))
+}
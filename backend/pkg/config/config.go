@@ -1,79 +1,623 @@
 package config
 
 import (
+	"errors"
 	"fmt"
+	"net/url"
 	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	// The runtime image has no zoneinfo for shop.timezone to load from.
+	_ "time/tzdata"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
 )
 
+// Config is the whole runtime configuration. It is loaded and validated once
+// at boot and handed to whatever needs it; nothing reads the environment
+// after that.
+//
+// Values are resolved in order: defaults, then the optional config file,
+// then environment variables named by the `env` tags.
+type Config struct {
+	Server  ServerConfig  `yaml:"server" toml:"server"`
+	DB      DBConfig      `yaml:"db" toml:"db"`
+	Cache   CacheConfig   `yaml:"cache" toml:"cache"`
+	Uploads UploadsConfig `yaml:"uploads" toml:"uploads"`
+	Auth    AuthConfig    `yaml:"auth" toml:"auth"`
+	CSRF    CSRFConfig    `yaml:"csrf" toml:"csrf"`
+	Email   EmailConfig   `yaml:"email" toml:"email"`
+	Log     LogConfig     `yaml:"log" toml:"log"`
+	Tracing TracingConfig `yaml:"tracing" toml:"tracing"`
+	Shop    ShopConfig    `yaml:"shop" toml:"shop"`
+}
+
+type ServerConfig struct {
+	Addr            string        `yaml:"addr" toml:"addr" env:"SITE_NAME"`
+	FrontendURL     string        `yaml:"frontend_url" toml:"frontend_url" env:"FRONTEND_URL"`
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout" toml:"shutdown_timeout" env:"SHUTDOWN_TIMEOUT"`
+	// AdminAddr serves /metrics apart from the public API. Leave it on a
+	// private interface; empty disables the admin listener.
+	AdminAddr string `yaml:"admin_addr" toml:"admin_addr" env:"ADMIN_ADDR"`
+	// DrainDelay is how long /readyz reports not-ready before the listener
+	// closes on shutdown, so load balancers stop routing to it first.
+	DrainDelay time.Duration `yaml:"drain_delay" toml:"drain_delay" env:"DRAIN_DELAY"`
+}
+
+type DBConfig struct {
+	Host            string        `yaml:"host" toml:"host" env:"DB_HOST"`
+	Port            string        `yaml:"port" toml:"port" env:"DB_PORT"`
+	User            string        `yaml:"user" toml:"user" env:"DB_USER"`
+	Password        string        `yaml:"password" toml:"password" env:"DB_PASS" secret:"true"`
+	Name            string        `yaml:"name" toml:"name" env:"DB_NAME"`
+	MaxOpenConns    int           `yaml:"max_open_conns" toml:"max_open_conns" env:"DB_MAX_OPEN_CONNS"`
+	MaxIdleConns    int           `yaml:"max_idle_conns" toml:"max_idle_conns" env:"DB_MAX_IDLE_CONNS"`
+	ConnMaxLifetime time.Duration `yaml:"conn_max_lifetime" toml:"conn_max_lifetime" env:"DB_CONN_MAX_LIFETIME"`
+	// ConnectTimeout is how long the server keeps retrying the database at
+	// boot before giving up.
+	ConnectTimeout time.Duration `yaml:"connect_timeout" toml:"connect_timeout" env:"DB_CONNECT_TIMEOUT"`
+	// QueryTimeout caps each statement; SlowQueryThreshold logs any that
+	// take longer. Zero disables either.
+	QueryTimeout       time.Duration `yaml:"query_timeout" toml:"query_timeout" env:"DB_QUERY_TIMEOUT"`
+	SlowQueryThreshold time.Duration `yaml:"slow_query_threshold" toml:"slow_query_threshold" env:"DB_SLOW_QUERY_THRESHOLD"`
+	// AutoMigrate applies pending schema migrations before serving.
+	AutoMigrate bool `yaml:"auto_migrate" toml:"auto_migrate" env:"DB_AUTO_MIGRATE"`
+}
+
+// DSN is the go-sql-driver/mysql connection string.
+func (c DBConfig) DSN() string {
+	return fmt.Sprintf("%s:%s@tcp(%s:%s)/%s?parseTime=true", c.User, c.Password, c.Host, c.Port, c.Name)
+}
+
+type CacheConfig struct {
+	// Backend selects the items cache implementation: "memory" or "redis".
+	Backend         string        `yaml:"backend" toml:"backend" env:"CACHE_BACKEND"`
+	RedisURL        string        `yaml:"redis_url" toml:"redis_url" env:"REDIS_URL" secret:"true"`
+	TTL             time.Duration `yaml:"ttl" toml:"ttl" env:"CACHE_TTL"`
+	CleanupInterval time.Duration `yaml:"cleanup_interval" toml:"cleanup_interval" env:"CACHE_CLEANUP_INTERVAL"`
+}
+
+type UploadsConfig struct {
+	// Backend is "local" to keep files under Dir, or "s3" for the bucket
+	// described by S3.
+	Backend  string `yaml:"backend" toml:"backend" env:"UPLOAD_BACKEND"`
+	Dir      string `yaml:"dir" toml:"dir" env:"UPLOAD_DIR"`
+	MaxBytes int64  `yaml:"max_bytes" toml:"max_bytes" env:"UPLOAD_MAX_BYTES"`
+	// URLExpiry is how long the presigned links /uploads/ redirects to stay
+	// valid when files are in a bucket.
+	URLExpiry time.Duration  `yaml:"url_expiry" toml:"url_expiry" env:"UPLOAD_URL_EXPIRY"`
+	S3        S3Config       `yaml:"s3" toml:"s3"`
+	GC        UploadGCConfig `yaml:"gc" toml:"gc"`
+}
+
+// UploadGCConfig schedules the collector that reclaims files no item or
+// user points at any more.
+type UploadGCConfig struct {
+	// Interval between runs in the server; 0 leaves it to `zestyctl uploads
+	// gc`.
+	Interval time.Duration `yaml:"interval" toml:"interval" env:"UPLOAD_GC_INTERVAL"`
+	// Grace is how old an unreferenced file must be before it is moved to
+	// quarantine. It covers the gap between storing an upload and saving
+	// the record that points at it.
+	Grace time.Duration `yaml:"grace" toml:"grace" env:"UPLOAD_GC_GRACE"`
+	// Retention is how long quarantined files are kept before deletion. A
+	// file referenced again in that time is put back.
+	Retention time.Duration `yaml:"retention" toml:"retention" env:"UPLOAD_GC_RETENTION"`
+}
+
+type S3Config struct {
+	// Endpoint is the service's host[:port], e.g. s3.tebi.io or minio:9000.
+	Endpoint  string `yaml:"endpoint" toml:"endpoint" env:"S3_ENDPOINT"`
+	Region    string `yaml:"region" toml:"region" env:"S3_REGION"`
+	Bucket    string `yaml:"bucket" toml:"bucket" env:"S3_BUCKET"`
+	AccessKey string `yaml:"access_key" toml:"access_key" env:"S3_ACCESS_KEY" secret:"true"`
+	SecretKey string `yaml:"secret_key" toml:"secret_key" env:"S3_SECRET_KEY" secret:"true"`
+	UseSSL    bool   `yaml:"use_ssl" toml:"use_ssl" env:"S3_USE_SSL"`
+}
+
+type AuthConfig struct {
+	JWTSecret  string        `yaml:"jwt_secret" toml:"jwt_secret" env:"JWT_SECRET" secret:"true"`
+	JWTTTL     time.Duration `yaml:"jwt_ttl" toml:"jwt_ttl" env:"JWT_TTL"`
+	BcryptCost int           `yaml:"bcrypt_cost" toml:"bcrypt_cost" env:"BCRYPT_COST"`
+}
+
+type CSRFConfig struct {
+	// TrustedOrigins may send state-changing requests alongside
+	// server.frontend_url, e.g. https://admin.example.com. The environment
+	// variable takes a comma-separated list.
+	TrustedOrigins []string `yaml:"trusted_origins" toml:"trusted_origins" env:"CSRF_TRUSTED_ORIGINS"`
+	// Exempt lists path prefixes that skip the CSRF checks, for callers that
+	// prove themselves some other way, such as signed webhooks.
+	Exempt []string `yaml:"exempt" toml:"exempt" env:"CSRF_EXEMPT"`
+}
+
 type EmailConfig struct {
-	Address  string
-	Password string
-	Port     string
-	Host     string
+	// Backend is "smtp" to deliver mail, or "log" to print it to stdout
+	// during local development.
+	Backend  string `yaml:"backend" toml:"backend" env:"EMAIL_BACKEND"`
+	Address  string `yaml:"address" toml:"address" env:"EMAIL_ADDRESS"`
+	Password string `yaml:"password" toml:"password" env:"EMAIL_APP_PASSWORD" secret:"true"`
+	Port     string `yaml:"port" toml:"port" env:"EMAIL_PORT"`
+	Host     string `yaml:"host" toml:"host" env:"EMAIL_SMTP_HOST"`
+}
+
+// Validate reports every SMTP setting the smtp backend is missing. Only the
+// server sends mail, so Config.Validate leaves this to it: migrations,
+// -print-config and zestyctl run without them.
+func (e EmailConfig) Validate() error {
+	if e.Backend != "smtp" {
+		return nil
+	}
+	var errs []error
+	for _, s := range []struct{ value, env string }{
+		{e.Address, "EMAIL_ADDRESS"},
+		{e.Password, "EMAIL_APP_PASSWORD"},
+		{e.Port, "EMAIL_PORT"},
+		{e.Host, "EMAIL_SMTP_HOST"},
+	} {
+		if s.value == "" {
+			errs = append(errs, fmt.Errorf("%s environment variable is not set", s.env))
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("config: invalid email configuration:\n%w", errors.Join(errs...))
+	}
+	return nil
 }
 
-func getEnv(key, fallback string) string {
-	if v := os.Getenv(key); v != "" {
-		return v
+type LogConfig struct {
+	// Level is debug, info, warn or error; Format is json or text.
+	Level  string `yaml:"level" toml:"level" env:"LOG_LEVEL"`
+	Format string `yaml:"format" toml:"format" env:"LOG_FORMAT"`
+}
+
+type TracingConfig struct {
+	// Exporter is "none", "stdout" to print spans for local debugging, or
+	// "otlp" to ship them to a collector over OTLP/HTTP at Endpoint (e.g.
+	// http://otel-collector:4318). An empty endpoint falls back to the
+	// standard OTEL_EXPORTER_OTLP_* variables.
+	Exporter    string `yaml:"exporter" toml:"exporter" env:"TRACING_EXPORTER"`
+	Endpoint    string `yaml:"endpoint" toml:"endpoint" env:"TRACING_OTLP_ENDPOINT"`
+	ServiceName string `yaml:"service_name" toml:"service_name" env:"TRACING_SERVICE_NAME"`
+	// SampleRatio is the fraction of new traces recorded. Requests that
+	// arrive with a sampled traceparent are always recorded.
+	SampleRatio float64 `yaml:"sample_ratio" toml:"sample_ratio" env:"TRACING_SAMPLE_RATIO"`
+}
+
+type ShopConfig struct {
+	// Timezone is the IANA zone, e.g. Asia/Kolkata, that item availability
+	// windows are read in.
+	Timezone string `yaml:"timezone" toml:"timezone" env:"SHOP_TIMEZONE"`
+	// Preorders governs orders placed for a later delivery slot.
+	Preorders PreorderConfig `yaml:"preorders" toml:"preorders"`
+}
+
+// PreorderConfig sets the delivery slots customers can schedule an order
+// for, and when held orders are released to the sellers.
+type PreorderConfig struct {
+	// Hours is when the shop delivers, every day, as HH:MM-HH:MM in
+	// Timezone. A range that ends before it starts runs past midnight.
+	Hours string `yaml:"hours" toml:"hours" env:"PREORDER_HOURS"`
+	// Slot is the length of a delivery slot. Slots start at midnight and
+	// every Slot after it, so it must divide a day.
+	Slot time.Duration `yaml:"slot" toml:"slot" env:"PREORDER_SLOT"`
+	// Capacity is how many orders one slot takes; 0 means no limit.
+	Capacity int `yaml:"capacity" toml:"capacity" env:"PREORDER_CAPACITY"`
+	// LeadTime is how long before its slot a scheduled order is released
+	// to the sellers' queues. Slots closer than that can't be booked.
+	LeadTime time.Duration `yaml:"lead_time" toml:"lead_time" env:"PREORDER_LEAD_TIME"`
+	// Horizon is how far ahead a slot can be booked.
+	Horizon time.Duration `yaml:"horizon" toml:"horizon" env:"PREORDER_HORIZON"`
+	// ReleaseInterval is how often the server releases orders that are
+	// due; 0 leaves it to `zestyctl order release`.
+	ReleaseInterval time.Duration `yaml:"release_interval" toml:"release_interval" env:"PREORDER_RELEASE_INTERVAL"`
+}
+
+var hoursPattern = regexp.MustCompile(`^(([01][0-9]|2[0-3]):[0-5][0-9])-(([01][0-9]|2[0-3]):[0-5][0-9]|24:00)$`)
+
+// OpenHours splits Hours into its opening and closing times. Validate has
+// already checked it is well formed.
+func (c PreorderConfig) OpenHours() (from, until string) {
+	m := hoursPattern.FindStringSubmatch(c.Hours)
+	if m == nil {
+		return "", ""
 	}
-	return fallback
+	return m[1], m[3]
 }
 
-func DBConnString() string {
-	host := getEnv("DB_HOST", "localhost")
-	port := getEnv("DB_PORT", "3306")
-	user := getEnv("DB_USER", "rootroot")
-	pass := getEnv("DB_PASS", "rootroot")
-	name := getEnv("DB_NAME", "zestydb")
-	return fmt.Sprintf("%s:%s@tcp(%s:%s)/%s?parseTime=true", user, pass, host, port, name)
+// Location loads Timezone. Validate has already checked it does.
+func (c ShopConfig) Location() *time.Location {
+	loc, err := time.LoadLocation(c.Timezone)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
 }
 
-func JWTSecret() []byte {
-	secret := getEnv("JWT_SECRET", "thisisnotaproductionkey")
-	return []byte(secret)
+func Default() *Config {
+	return &Config{
+		Server: ServerConfig{
+			Addr:            "0.0.0.0:3001",
+			FrontendURL:     "https://localhost:3000",
+			ShutdownTimeout: 10 * time.Second,
+			AdminAddr:       "127.0.0.1:9091",
+		},
+		DB: DBConfig{
+			Host:               "localhost",
+			Port:               "3306",
+			User:               "rootroot",
+			Password:           "rootroot",
+			Name:               "zestydb",
+			MaxOpenConns:       25,
+			MaxIdleConns:       5,
+			ConnMaxLifetime:    5 * time.Minute,
+			ConnectTimeout:     30 * time.Second,
+			QueryTimeout:       5 * time.Second,
+			SlowQueryThreshold: 250 * time.Millisecond,
+		},
+		Cache: CacheConfig{
+			Backend:         "memory",
+			RedisURL:        "redis://localhost:6379/0",
+			TTL:             5 * time.Minute,
+			CleanupInterval: 5 * time.Minute,
+		},
+		Uploads: UploadsConfig{
+			Backend:   "local",
+			Dir:       "uploads",
+			MaxBytes:  10 << 20,
+			URLExpiry: time.Hour,
+			S3: S3Config{
+				UseSSL: true,
+			},
+			GC: UploadGCConfig{
+				Interval:  6 * time.Hour,
+				Grace:     24 * time.Hour,
+				Retention: 7 * 24 * time.Hour,
+			},
+		},
+		Auth: AuthConfig{
+			JWTSecret:  "thisisnotaproductionkey",
+			JWTTTL:     24 * time.Hour,
+			BcryptCost: 10,
+		},
+		Email: EmailConfig{
+			Backend: "smtp",
+		},
+		Log: LogConfig{
+			Level:  "info",
+			Format: "json",
+		},
+		Tracing: TracingConfig{
+			Exporter:    "none",
+			ServiceName: "zesty",
+			SampleRatio: 1,
+		},
+		Shop: ShopConfig{
+			Timezone: "UTC",
+			Preorders: PreorderConfig{
+				Hours:           "09:00-22:00",
+				Slot:            30 * time.Minute,
+				Capacity:        20,
+				LeadTime:        45 * time.Minute,
+				Horizon:         7 * 24 * time.Hour,
+				ReleaseInterval: time.Minute,
+			},
+		},
+	}
 }
 
-func LoadEmailConfig() EmailConfig {
-	address := getEnv("EMAIL_ADDRESS", "")
-	if address == "" {
-		panic("EMAIL_ADDRESS environment variable is not set!")
+// Load builds the configuration from defaults, the file at path (skipped
+// when path is empty) and the environment, then validates it.
+func Load(path string) (*Config, error) {
+	cfg := Default()
+
+	if path != "" {
+		if err := cfg.loadFile(path); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := applyEnv(reflect.ValueOf(cfg).Elem()); err != nil {
+		return nil, err
 	}
 
-	password := getEnv("EMAIL_APP_PASSWORD", "")
-	if password == "" {
-		panic("EMAIL_APP_PASSWORD environment variable is not set!")
+	if err := cfg.Validate(); err != nil {
+		return nil, err
 	}
+	return cfg, nil
+}
 
-	port := getEnv("EMAIL_PORT", "")
-	if port == "" {
-		panic("EMAIL_PORT environment variable is not set!")
+func (c *Config) loadFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("config: reading %s: %w", path, err)
 	}
 
-	host := getEnv("EMAIL_SMTP_HOST", "")
-	if host == "" {
-		panic("EMAIL_SMTP_HOST environment variable is not set!")
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, c)
+	case ".toml":
+		err = toml.Unmarshal(data, c)
+	default:
+		return fmt.Errorf("config: %s: unsupported file type (want .yaml, .yml or .toml)", path)
 	}
+	if err != nil {
+		return fmt.Errorf("config: parsing %s: %w", path, err)
+	}
+	return nil
+}
 
-	return EmailConfig{
-		Address:  address,
-		Password: password,
-		Port:     port,
-		Host:     host,
+func applyEnv(v reflect.Value) error {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field, fv := t.Field(i), v.Field(i)
+
+		if field.Type.Kind() == reflect.Struct {
+			if err := applyEnv(fv); err != nil {
+				return err
+			}
+			continue
+		}
+
+		key := field.Tag.Get("env")
+		if key == "" {
+			continue
+		}
+		raw := strings.TrimSpace(os.Getenv(key))
+		if raw == "" {
+			continue
+		}
+		if err := setField(fv, raw); err != nil {
+			return fmt.Errorf("config: %s=%q: %w", key, raw, err)
+		}
 	}
+	return nil
 }
 
-func SiteName() string {
-	site := getEnv("SITE_NAME", "0.0.0.0:3001")
-	if site == "" {
-		panic("SITE_NAME environment variable is not set!")
+func setField(fv reflect.Value, raw string) error {
+	if fv.Type() == reflect.TypeOf(time.Duration(0)) {
+		d, err := time.ParseDuration(raw)
+		if err != nil {
+			return err
+		}
+		fv.SetInt(int64(d))
+		return nil
 	}
-	return site
+
+	switch fv.Kind() {
+	case reflect.String:
+		fv.SetString(raw)
+	case reflect.Int, reflect.Int64:
+		n, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			return err
+		}
+		fv.SetInt(n)
+	case reflect.Float64:
+		f, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return err
+		}
+		fv.SetFloat(f)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return err
+		}
+		fv.SetBool(b)
+	case reflect.Slice:
+		if fv.Type().Elem().Kind() != reflect.String {
+			return fmt.Errorf("unsupported field type %s", fv.Type())
+		}
+		var list []string
+		for part := range strings.SplitSeq(raw, ",") {
+			if part = strings.TrimSpace(part); part != "" {
+				list = append(list, part)
+			}
+		}
+		fv.Set(reflect.ValueOf(list))
+	default:
+		return fmt.Errorf("unsupported field kind %s", fv.Kind())
+	}
+	return nil
 }
 
-func FrontendUrl() string {
-	site := getEnv("FRONTEND_URL", "https://localhost:3000")
-	if site == "" {
-		panic("FRONTEND_URL environment variable is not set!")
+// Validate reports every problem at once so a bad deployment can be fixed in
+// one go.
+func (c *Config) Validate() error {
+	var errs []error
+	bad := func(format string, args ...any) {
+		errs = append(errs, fmt.Errorf(format, args...))
+	}
+
+	if c.Server.Addr == "" {
+		bad("server.addr (SITE_NAME) is required")
+	}
+	if c.Server.FrontendURL == "" {
+		bad("server.frontend_url (FRONTEND_URL) is required")
+	}
+	if c.Server.ShutdownTimeout <= 0 {
+		bad("server.shutdown_timeout must be positive")
+	}
+	if c.Server.DrainDelay < 0 || c.Server.DrainDelay >= c.Server.ShutdownTimeout {
+		bad("server.drain_delay must be between 0 and server.shutdown_timeout")
 	}
-	return site
+	if c.Server.AdminAddr != "" && c.Server.AdminAddr == c.Server.Addr {
+		bad("server.admin_addr (ADMIN_ADDR) must differ from server.addr so metrics stay off the public listener")
+	}
+
+	if c.DB.Host == "" || c.DB.Port == "" || c.DB.User == "" || c.DB.Name == "" {
+		bad("db.host, db.port, db.user and db.name are required")
+	}
+	if c.DB.MaxOpenConns < 1 {
+		bad("db.max_open_conns must be at least 1")
+	}
+	if c.DB.MaxIdleConns < 0 || c.DB.MaxIdleConns > c.DB.MaxOpenConns {
+		bad("db.max_idle_conns must be between 0 and db.max_open_conns")
+	}
+	if c.DB.ConnectTimeout < 0 {
+		bad("db.connect_timeout must not be negative")
+	}
+	if c.DB.QueryTimeout < 0 || c.DB.SlowQueryThreshold < 0 {
+		bad("db.query_timeout and db.slow_query_threshold must not be negative")
+	}
+
+	switch c.Cache.Backend {
+	case "memory":
+	case "redis":
+		if c.Cache.RedisURL == "" {
+			bad("cache.redis_url (REDIS_URL) is required when cache.backend is redis")
+		}
+	default:
+		bad("cache.backend (CACHE_BACKEND) must be memory or redis, got %q", c.Cache.Backend)
+	}
+	if c.Cache.TTL <= 0 || c.Cache.CleanupInterval <= 0 {
+		bad("cache.ttl and cache.cleanup_interval must be positive")
+	}
+
+	switch c.Uploads.Backend {
+	case "local":
+		if c.Uploads.Dir == "" {
+			bad("uploads.dir (UPLOAD_DIR) is required")
+		}
+	case "s3":
+		if c.Uploads.S3.Endpoint == "" || c.Uploads.S3.Bucket == "" {
+			bad("uploads.s3.endpoint (S3_ENDPOINT) and uploads.s3.bucket (S3_BUCKET) are required when uploads.backend is s3")
+		}
+		if c.Uploads.S3.AccessKey == "" || c.Uploads.S3.SecretKey == "" {
+			bad("S3_ACCESS_KEY and S3_SECRET_KEY are required when uploads.backend is s3")
+		}
+	default:
+		bad("uploads.backend (UPLOAD_BACKEND) must be local or s3, got %q", c.Uploads.Backend)
+	}
+	// S3 presigned URLs are valid for at most a week.
+	if c.Uploads.URLExpiry <= 0 || c.Uploads.URLExpiry > 7*24*time.Hour {
+		bad("uploads.url_expiry (UPLOAD_URL_EXPIRY) must be between 0 and 168h, got %s", c.Uploads.URLExpiry)
+	}
+	if c.Uploads.MaxBytes <= 0 {
+		bad("uploads.max_bytes must be positive")
+	}
+	if c.Uploads.GC.Interval < 0 {
+		bad("uploads.gc.interval (UPLOAD_GC_INTERVAL) must not be negative")
+	}
+	// Uploads are stored before the record pointing at them is saved.
+	if c.Uploads.GC.Grace < time.Hour {
+		bad("uploads.gc.grace (UPLOAD_GC_GRACE) must be at least 1h, got %s", c.Uploads.GC.Grace)
+	}
+	if c.Uploads.GC.Retention < 0 {
+		bad("uploads.gc.retention (UPLOAD_GC_RETENTION) must not be negative")
+	}
+
+	if c.Auth.JWTSecret == "" {
+		bad("auth.jwt_secret (JWT_SECRET) is required")
+	}
+	if c.Auth.JWTTTL <= 0 {
+		bad("auth.jwt_ttl must be positive")
+	}
+	// bcrypt.MinCost and bcrypt.MaxCost
+	if c.Auth.BcryptCost < 4 || c.Auth.BcryptCost > 31 {
+		bad("auth.bcrypt_cost must be between 4 and 31, got %d", c.Auth.BcryptCost)
+	}
+
+	for _, origin := range c.CSRF.TrustedOrigins {
+		if u, err := url.Parse(origin); err != nil || u.Scheme == "" || u.Host == "" || strings.TrimSuffix(u.Path, "/") != "" {
+			bad("csrf.trusted_origins (CSRF_TRUSTED_ORIGINS) entries must be scheme://host[:port], got %q", origin)
+		}
+	}
+	for _, prefix := range c.CSRF.Exempt {
+		if !strings.HasPrefix(prefix, "/") {
+			bad("csrf.exempt (CSRF_EXEMPT) entries must be paths starting with /, got %q", prefix)
+		}
+	}
+
+	// The SMTP settings are only needed to send mail; see EmailConfig.Validate.
+	switch c.Email.Backend {
+	case "log", "smtp":
+	default:
+		bad("email.backend (EMAIL_BACKEND) must be smtp or log, got %q", c.Email.Backend)
+	}
+
+	switch strings.ToLower(c.Log.Level) {
+	case "debug", "info", "warn", "error":
+	default:
+		bad("log.level (LOG_LEVEL) must be debug, info, warn or error, got %q", c.Log.Level)
+	}
+	switch strings.ToLower(c.Log.Format) {
+	case "json", "text":
+	default:
+		bad("log.format (LOG_FORMAT) must be json or text, got %q", c.Log.Format)
+	}
+
+	switch c.Tracing.Exporter {
+	case "none", "stdout", "otlp":
+	default:
+		bad("tracing.exporter (TRACING_EXPORTER) must be none, stdout or otlp, got %q", c.Tracing.Exporter)
+	}
+	if c.Tracing.Exporter != "none" && c.Tracing.ServiceName == "" {
+		bad("tracing.service_name (TRACING_SERVICE_NAME) is required when tracing is on")
+	}
+	if c.Tracing.SampleRatio < 0 || c.Tracing.SampleRatio > 1 {
+		bad("tracing.sample_ratio (TRACING_SAMPLE_RATIO) must be between 0 and 1, got %g", c.Tracing.SampleRatio)
+	}
+
+	if _, err := time.LoadLocation(c.Shop.Timezone); err != nil || c.Shop.Timezone == "" {
+		bad("shop.timezone (SHOP_TIMEZONE) must be an IANA time zone such as Asia/Kolkata, got %q", c.Shop.Timezone)
+	}
+	pre := c.Shop.Preorders
+	if from, until := pre.OpenHours(); from == "" || from == until {
+		bad("shop.preorders.hours (PREORDER_HOURS) must be a range like 09:00-22:00, got %q", pre.Hours)
+	}
+	if pre.Slot < time.Minute || pre.Slot%time.Minute != 0 || 24*time.Hour%pre.Slot != 0 {
+		bad("shop.preorders.slot (PREORDER_SLOT) must be whole minutes that divide a day, got %s", pre.Slot)
+	}
+	if pre.Capacity < 0 {
+		bad("shop.preorders.capacity (PREORDER_CAPACITY) must not be negative")
+	}
+	if pre.LeadTime < 0 {
+		bad("shop.preorders.lead_time (PREORDER_LEAD_TIME) must not be negative")
+	}
+	if pre.Horizon <= pre.LeadTime {
+		bad("shop.preorders.horizon (PREORDER_HORIZON) must be longer than the lead time, got %s", pre.Horizon)
+	}
+	if pre.ReleaseInterval < 0 {
+		bad("shop.preorders.release_interval (PREORDER_RELEASE_INTERVAL) must not be negative")
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("config: invalid configuration:\n%w", errors.Join(errs...))
+	}
+	return nil
+}
+
+const redacted = "[redacted]"
+
+// Redacted returns a copy with every `secret` field masked, safe to print.
+func (c *Config) Redacted() *Config {
+	out := *c
+	redact(reflect.ValueOf(&out).Elem())
+	return &out
+}
+
+func redact(v reflect.Value) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field, fv := t.Field(i), v.Field(i)
+		if field.Type.Kind() == reflect.Struct {
+			redact(fv)
+			continue
+		}
+		if field.Tag.Get("secret") == "true" && fv.Kind() == reflect.String && fv.String() != "" {
+			fv.SetString(redacted)
+		}
+	}
+}
+
+// YAML renders the configuration in the same shape the config file takes.
+func (c *Config) YAML() ([]byte, error) {
+	return yaml.Marshal(c)
 }
@@ -2,7 +2,9 @@ package config_test
 
 import (
 	"os"
+	"path/filepath"
 	"reflect"
+	"strings"
 	"testing"
 	"time"
 
@@ -21,54 +23,218 @@ func createToken(t *testing.T, claims jwt.MapClaims, secret []byte) string {
 	return s
 }
 
+func setEmailEnv(t *testing.T) {
+	t.Helper()
+	t.Setenv("EMAIL_ADDRESS", "heyjude@dontmake.it")
+	t.Setenv("EMAIL_APP_PASSWORD", "superpassword69")
+	t.Setenv("EMAIL_PORT", "587")
+	t.Setenv("EMAIL_SMTP_HOST", "smtp.dontmake.it")
+}
+
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("failed to write %s: %v", name, err)
+	}
+	return path
+}
+
 func TestDBConnString(t *testing.T) {
-	os.Setenv("DB_HOST", "srv")
-	os.Setenv("DB_PORT", "3307")
-	os.Setenv("DB_USER", "zee")
-	os.Setenv("DB_PASS", "secret")
-	os.Setenv("DB_NAME", "zesty")
+	setEmailEnv(t)
+	t.Setenv("DB_HOST", "srv")
+	t.Setenv("DB_PORT", "3307")
+	t.Setenv("DB_USER", "zee")
+	t.Setenv("DB_PASS", "secret")
+	t.Setenv("DB_NAME", "zesty")
 
 	want := "zee:secret@tcp(srv:3307)/zesty?parseTime=true"
 
-	got := config.DBConnString()
+	cfg, err := config.Load("")
+	if err != nil {
+		t.Fatalf("Load() error: %v", err)
+	}
 
-	if got != want {
-		t.Fatalf("DBConnString() = %s, want %s", got, want)
+	if got := cfg.DB.DSN(); got != want {
+		t.Fatalf("DSN() = %s, want %s", got, want)
 	}
 }
 
 func TestJWTSecret(t *testing.T) {
-	os.Setenv("JWT_SECRET", "topsecret")
-	got := config.JWTSecret()
-	want := []byte("topsecret")
+	setEmailEnv(t)
+	t.Setenv("JWT_SECRET", "topsecret")
+	t.Setenv("JWT_TTL", "90m")
+
+	cfg, err := config.Load("")
+	if err != nil {
+		t.Fatalf("Load() error: %v", err)
+	}
 
-	if !reflect.DeepEqual(got, want) {
-		t.Fatalf("JWTSecret() = %v, want %v", got, want)
+	if cfg.Auth.JWTSecret != "topsecret" {
+		t.Fatalf("Auth.JWTSecret = %q, want %q", cfg.Auth.JWTSecret, "topsecret")
+	}
+	if cfg.Auth.JWTTTL != 90*time.Minute {
+		t.Fatalf("Auth.JWTTTL = %s, want 90m", cfg.Auth.JWTTTL)
+	}
+}
+
+func TestCSRFListsFromEnv(t *testing.T) {
+	setEmailEnv(t)
+	t.Setenv("CSRF_TRUSTED_ORIGINS", "https://admin.zes.ty, https://m.zes.ty")
+	t.Setenv("CSRF_EXEMPT", "/api/v1/webhooks/,")
+
+	cfg, err := config.Load("")
+	if err != nil {
+		t.Fatalf("Load() error: %v", err)
+	}
+	if want := []string{"https://admin.zes.ty", "https://m.zes.ty"}; !reflect.DeepEqual(cfg.CSRF.TrustedOrigins, want) {
+		t.Errorf("CSRF.TrustedOrigins = %q, want %q", cfg.CSRF.TrustedOrigins, want)
+	}
+	if want := []string{"/api/v1/webhooks/"}; !reflect.DeepEqual(cfg.CSRF.Exempt, want) {
+		t.Errorf("CSRF.Exempt = %q, want %q", cfg.CSRF.Exempt, want)
+	}
+
+	t.Setenv("CSRF_TRUSTED_ORIGINS", "admin.zes.ty")
+	if _, err := config.Load(""); err == nil || !strings.Contains(err.Error(), "CSRF_TRUSTED_ORIGINS") {
+		t.Fatalf("Load() with a bare host = %v, want a CSRF_TRUSTED_ORIGINS error", err)
 	}
 }
 
 func TestLoadEmailConfig(t *testing.T) {
-	os.Setenv("EMAIL_ADDRESS", "heyjude@dontmake.it")
-	os.Setenv("EMAIL_APP_PASSWORD", "superpassword69")
-	os.Setenv("EMAIL_PORT", "587")
-	os.Setenv("EMAIL_SMTP_HOST", "smtp.dontmake.it")
+	setEmailEnv(t)
 
 	want := config.EmailConfig{
+		Backend:  "smtp",
 		Address:  "heyjude@dontmake.it",
 		Password: "superpassword69",
 		Port:     "587",
 		Host:     "smtp.dontmake.it",
 	}
 
-	got := config.LoadEmailConfig()
+	cfg, err := config.Load("")
+	if err != nil {
+		t.Fatalf("Load() error: %v", err)
+	}
+
+	if !reflect.DeepEqual(cfg.Email, want) {
+		t.Fatalf("Email = %+v, want %+v", cfg.Email, want)
+	}
+}
+
+func TestMissingEmailOnlyFailsServing(t *testing.T) {
+	t.Setenv("EMAIL_ADDRESS", "")
+	t.Setenv("EMAIL_APP_PASSWORD", "")
+	t.Setenv("EMAIL_PORT", "")
+	t.Setenv("EMAIL_SMTP_HOST", "")
+
+	// Migrations, -print-config and zestyctl don't send mail.
+	cfg, err := config.Load("")
+	if err != nil {
+		t.Fatalf("Load without email settings: %v", err)
+	}
+	err = cfg.Email.Validate()
+	if err == nil {
+		t.Fatal("expected a validation error, got nil")
+	}
+	if !strings.Contains(err.Error(), "EMAIL_ADDRESS") || !strings.Contains(err.Error(), "EMAIL_SMTP_HOST") {
+		t.Fatalf("error should list every missing email variable, got: %v", err)
+	}
+
+	cfg.Email.Backend = "log"
+	if err := cfg.Email.Validate(); err != nil {
+		t.Errorf("the log backend needs no SMTP settings, got: %v", err)
+	}
+}
 
-	if !reflect.DeepEqual(got, want) {
-		t.Fatalf("LoadEmailConfig() = %+v, want %+v", got, want)
+func TestLoadFileWithEnvOverride(t *testing.T) {
+	setEmailEnv(t)
+	t.Setenv("DB_MAX_OPEN_CONNS", "40")
+
+	yamlPath := writeFile(t, "zesty.yaml", `
+db:
+  max_open_conns: 10
+  max_idle_conns: 8
+cache:
+  ttl: 30s
+uploads:
+  max_bytes: 2097152
+auth:
+  bcrypt_cost: 12
+`)
+	tomlPath := writeFile(t, "zesty.toml", `
+[db]
+max_open_conns = 10
+max_idle_conns = 8
+
+[cache]
+ttl = "30s"
+
+[uploads]
+max_bytes = 2097152
+
+[auth]
+bcrypt_cost = 12
+`)
+
+	for _, path := range []string{yamlPath, tomlPath} {
+		cfg, err := config.Load(path)
+		if err != nil {
+			t.Fatalf("Load(%s) error: %v", filepath.Base(path), err)
+		}
+		if cfg.DB.MaxOpenConns != 40 {
+			t.Errorf("%s: DB.MaxOpenConns = %d, want env override 40", filepath.Base(path), cfg.DB.MaxOpenConns)
+		}
+		if cfg.DB.MaxIdleConns != 8 || cfg.Cache.TTL != 30*time.Second || cfg.Uploads.MaxBytes != 2<<20 || cfg.Auth.BcryptCost != 12 {
+			t.Errorf("%s: file values not applied: %+v", filepath.Base(path), cfg)
+		}
+	}
+}
+
+func TestLoadRejectsBadValues(t *testing.T) {
+	setEmailEnv(t)
+	t.Setenv("BCRYPT_COST", "99")
+	t.Setenv("CACHE_BACKEND", "memcached")
+	t.Setenv("DB_QUERY_TIMEOUT", "-1s")
+	t.Setenv("TRACING_SAMPLE_RATIO", "1.5")
+	t.Setenv("SHOP_TIMEZONE", "Mars/Olympus_Mons")
+	t.Setenv("PREORDER_SLOT", "7m")
+
+	_, err := config.Load("")
+	if err == nil {
+		t.Fatal("expected a validation error, got nil")
+	}
+	if !strings.Contains(err.Error(), "bcrypt_cost") || !strings.Contains(err.Error(), "memcached") ||
+		!strings.Contains(err.Error(), "query_timeout") || !strings.Contains(err.Error(), "sample_ratio") ||
+		!strings.Contains(err.Error(), "Mars/Olympus_Mons") || !strings.Contains(err.Error(), "PREORDER_SLOT") {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestRedactedHidesSecrets(t *testing.T) {
+	setEmailEnv(t)
+	t.Setenv("JWT_SECRET", "topsecret")
+
+	cfg, err := config.Load("")
+	if err != nil {
+		t.Fatalf("Load() error: %v", err)
+	}
+
+	out, err := cfg.Redacted().YAML()
+	if err != nil {
+		t.Fatalf("YAML() error: %v", err)
+	}
+	for _, secret := range []string{"topsecret", "superpassword69"} {
+		if strings.Contains(string(out), secret) {
+			t.Fatalf("printed config leaks %q:\n%s", secret, out)
+		}
+	}
+	if cfg.Auth.JWTSecret != "topsecret" {
+		t.Fatal("Redacted() modified the original config")
 	}
 }
 
 func TestValidateTokenSuccess(t *testing.T) {
-	secret := config.JWTSecret()
+	secret := []byte("topsecret")
 	claims := jwt.MapClaims{
 		"id":   69,
 		"role": "admin",
@@ -76,7 +242,7 @@ func TestValidateTokenSuccess(t *testing.T) {
 	}
 	tokenStr := createToken(t, claims, secret)
 
-	userClaims, err := middleware.ValidateToken(tokenStr)
+	userClaims, err := middleware.ValidateToken(tokenStr, secret)
 	if err != nil {
 		t.Fatalf("expected no error, got %v", err)
 	}
@@ -86,7 +252,7 @@ func TestValidateTokenSuccess(t *testing.T) {
 }
 
 func TestValidateTokenExpired(t *testing.T) {
-	secret := config.JWTSecret()
+	secret := []byte("topsecret")
 	claims := jwt.MapClaims{
 		"id":   69,
 		"role": "user",
@@ -94,7 +260,7 @@ func TestValidateTokenExpired(t *testing.T) {
 	}
 	tokenStr := createToken(t, claims, secret)
 
-	_, err := middleware.ValidateToken(tokenStr)
+	_, err := middleware.ValidateToken(tokenStr, secret)
 	if err == nil {
 		t.Fatal("expected expiration error, got nil")
 	}
@@ -109,7 +275,7 @@ func TestValidateTokenWrong(t *testing.T) {
 	}
 	tokenStr := createToken(t, claims, badSecret)
 
-	_, err := middleware.ValidateToken(tokenStr)
+	_, err := middleware.ValidateToken(tokenStr, []byte("topsecret"))
 	if err == nil {
 		t.Fatal("expected signature error, got nil")
 	}
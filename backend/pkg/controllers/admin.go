@@ -1,65 +1,71 @@
 package controllers
 
 import (
-	"encoding/json"
+	"database/sql"
+	"errors"
 	"fmt"
+	"mime/multipart"
 	"net/http"
+	"strings"
+	"time"
 
+	"github.com/Entity069/Zesty-Go/pkg/bind"
+	"github.com/Entity069/Zesty-Go/pkg/config"
 	"github.com/Entity069/Zesty-Go/pkg/middleware"
 	"github.com/Entity069/Zesty-Go/pkg/models"
+	"github.com/Entity069/Zesty-Go/pkg/response"
+	"github.com/Entity069/Zesty-Go/pkg/storage"
 )
 
-type AdminController struct{}
-
-func NewAdminController() *AdminController {
-	return &AdminController{}
+type AdminController struct {
+	cfg   *config.Config
+	store *models.Store
+	blobs storage.BlobStore
+	loc   *time.Location
 }
 
-func (ac *AdminController) jsonResp(w http.ResponseWriter, status int, payload any) {
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(status)
-	_ = json.NewEncoder(w).Encode(payload)
+func NewAdminController(cfg *config.Config, store *models.Store, blobs storage.BlobStore) *AdminController {
+	return &AdminController{cfg: cfg, store: store, blobs: blobs, loc: cfg.Shop.Location()}
 }
 
 func (ac *AdminController) AllOrders(w http.ResponseWriter, r *http.Request) {
-	orders, err := models.GetAllOrders()
+	orders, err := ac.store.Orders.GetAll(r.Context())
 	if err != nil {
-		fmt.Println("Error fetching orders:", err)
-		ac.jsonResp(w, http.StatusInternalServerError, map[string]any{"success": false, "msg": "Failed to fetch orders"})
+		response.Fail(w, r, response.Internal("Failed to fetch orders", err))
 		return
 	}
 
-	ac.jsonResp(w, http.StatusOK, map[string]any{"success": true, "msg": "All orders fetched successfully.", "orders": orders})
+	response.OK(w, "All orders fetched successfully.", response.Data{"orders": orders})
 }
 
 func (ac *AdminController) AllUsers(w http.ResponseWriter, r *http.Request) {
-	users, err := models.GetAllUsers()
+	users, err := ac.store.Users.GetAll(r.Context())
 	if err != nil {
-		ac.jsonResp(w, http.StatusInternalServerError, map[string]any{"success": false, "msg": "Failed to fetch users"})
+		response.Fail(w, r, response.Internal("Failed to fetch users", err))
 		return
 	}
 
-	ac.jsonResp(w, http.StatusOK, map[string]any{"success": true, "msg": "All users fetched successfully.", "users": users})
+	response.OK(w, "All users fetched successfully.", response.Data{"users": users})
 }
 
 func (ac *AdminController) UpdateUserByAdmin(w http.ResponseWriter, r *http.Request) {
 	type reqBody struct {
-		ID        int    `json:"id"`
-		FirstName string `json:"first_name"`
-		LastName  string `json:"last_name"`
-		Email     string `json:"email"`
-		UserType  string `json:"user_type"`
+		ID        int    `json:"id" path:"id" validate:"required"`
+		FirstName string `json:"first_name" validate:"required,max=255"`
+		LastName  string `json:"last_name" validate:"required,max=255"`
+		Email     string `json:"email" validate:"email"`
+		UserType  string `json:"user_type" validate:"required,oneof=user seller admin"`
 	}
 
 	var body reqBody
-	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
-		ac.jsonResp(w, http.StatusBadRequest, map[string]any{"success": false, "msg": "Invalid JSON"})
+	if err := bind.JSON(w, r, &body); err != nil {
+		response.Fail(w, r, err)
 		return
 	}
 
-	user, err := models.GetUserByID(body.ID)
+	user, err := ac.store.Users.GetByID(r.Context(), body.ID)
 	if err != nil {
-		ac.jsonResp(w, http.StatusNotFound, map[string]any{"success": false, "msg": "User not found"})
+		response.Fail(w, r, response.NotFound("User not found"))
 		return
 	}
 
@@ -67,127 +73,460 @@ func (ac *AdminController) UpdateUserByAdmin(w http.ResponseWriter, r *http.Requ
 	user.LastName = body.LastName
 	user.UserType = body.UserType
 
-	if err := user.Update(); err != nil {
-		ac.jsonResp(w, http.StatusInternalServerError, map[string]any{"success": false, "msg": "Update failed"})
+	if err := ac.store.Users.Update(r.Context(), user); err != nil {
+		response.Fail(w, r, response.Internal("Update failed", err))
 		return
 	}
 
-	ac.jsonResp(w, http.StatusOK, map[string]any{"success": true, "msg": "User updated successfully."})
+	response.OK(w, "User updated successfully.", nil)
 }
 
+// AllCategories lists every category, inactive ones included, depth first.
 func (ac *AdminController) AllCategories(w http.ResponseWriter, r *http.Request) {
-	categories, err := models.GetAllCategories(0)
+	categories, err := ac.store.Categories.GetAll(r.Context(), 0)
 	if err != nil {
-		ac.jsonResp(w, http.StatusInternalServerError, map[string]any{"success": false, "msg": "Failed to fetch categories"})
+		response.Fail(w, r, response.Internal("Failed to fetch categories", err))
 		return
 	}
 
-	ac.jsonResp(w, http.StatusOK, map[string]any{"success": true, "msg": "All categories fetched successfully.", "categories": categories})
+	response.OK(w, "All categories fetched successfully.", response.Data{"categories": models.NewCategoryTree(categories).Walk(0, false)})
 }
 
+// AddCategory creates a category at the end of its parent's list. Its
+// picture can be uploaded as the "image" part of a multipart form.
 func (ac *AdminController) AddCategory(w http.ResponseWriter, r *http.Request) {
-	type reqBody struct {
-		Name        string `json:"name"`
-		Description string `json:"description"`
+	body := struct {
+		Name        string                `json:"name" form:"name" validate:"required,max=255"`
+		Description string                `json:"description" form:"description" validate:"max=255"`
+		ParentID    int                   `json:"parent_id" form:"parent_id" validate:"min=0"`
+		IsActive    bool                  `json:"is_active" form:"is_active"`
+		Image       string                `json:"image" form:"-" validate:"max=255"`
+		ImageFile   *multipart.FileHeader `json:"-" form:"image"`
+	}{IsActive: true}
+	if err := bind.Body(w, r, ac.cfg.Uploads.MaxBytes, &body); err != nil {
+		response.Fail(w, r, err)
+		return
 	}
 
-	var body reqBody
-	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
-		ac.jsonResp(w, http.StatusBadRequest, map[string]any{"success": false, "msg": "Invalid JSON"})
+	tree, err := ac.categoryTree(r)
+	if err != nil {
+		response.Fail(w, r, err)
+		return
+	}
+	if err := categoryNameFree(tree, 0, body.Name); err != nil {
+		response.Fail(w, r, err)
 		return
 	}
-
 	category := &models.Category{
 		Name:        body.Name,
 		Description: body.Description,
+		Image:       body.Image,
+		IsActive:    body.IsActive,
+	}
+	if body.ParentID != 0 {
+		if tree.Get(body.ParentID) == nil {
+			response.Fail(w, r, response.Validation(response.FieldError{Field: "parent_id", Message: "is not a known category"}))
+			return
+		}
+		category.ParentID = &body.ParentID
+	}
+	category.Position = nextPosition(tree, body.ParentID)
+
+	if body.ImageFile != nil {
+		if category.Image, err = saveImage(r.Context(), ac.blobs, "category-images", body.ImageFile); err != nil {
+			response.Fail(w, r, err)
+			return
+		}
 	}
 
-	if err := category.Create(); err != nil {
-		ac.jsonResp(w, http.StatusInternalServerError, map[string]any{"success": false, "msg": "Failed to create category"})
+	if err := ac.store.Categories.Create(r.Context(), category); err != nil {
+		response.Fail(w, r, response.Internal("Failed to create category", err))
 		return
 	}
 
-	ac.jsonResp(w, http.StatusOK, map[string]any{"success": true, "msg": "Category added successfully.", "category": category})
+	response.OK(w, "Category added successfully.", response.Data{"category": category})
 }
 
+// EditCategory updates a category. parent_id and is_active are left as they
+// are unless sent; a category moved to another parent goes to the end of
+// its list.
 func (ac *AdminController) EditCategory(w http.ResponseWriter, r *http.Request) {
-	type reqBody struct {
-		ID          int    `json:"id"`
-		Name        string `json:"name"`
-		Description string `json:"description"`
+	var body struct {
+		ID          int                   `json:"id" form:"id" path:"id" validate:"required"`
+		Name        string                `json:"name" form:"name" validate:"required,max=255"`
+		Description string                `json:"description" form:"description" validate:"max=255"`
+		ParentID    *int                  `json:"parent_id" form:"parent_id" validate:"min=0"`
+		IsActive    *bool                 `json:"is_active" form:"is_active"`
+		Image       string                `json:"image" form:"-" validate:"max=255"`
+		ImageFile   *multipart.FileHeader `json:"-" form:"image"`
 	}
-
-	var body reqBody
-	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
-		ac.jsonResp(w, http.StatusBadRequest, map[string]any{"success": false, "msg": "Invalid JSON"})
+	if err := bind.Body(w, r, ac.cfg.Uploads.MaxBytes, &body); err != nil {
+		response.Fail(w, r, err)
 		return
 	}
 
-	category, err := models.GetCategoryByID(body.ID)
+	tree, err := ac.categoryTree(r)
 	if err != nil {
-		ac.jsonResp(w, http.StatusNotFound, map[string]any{"success": false, "msg": "Category not found"})
+		response.Fail(w, r, err)
+		return
+	}
+	category := tree.Get(body.ID)
+	if category == nil {
+		response.Fail(w, r, response.NotFound("Category not found"))
+		return
+	}
+	if err := categoryNameFree(tree, category.ID, body.Name); err != nil {
+		response.Fail(w, r, err)
 		return
 	}
 
+	if body.ParentID != nil && parentOf(category) != *body.ParentID {
+		parentID := *body.ParentID
+		switch {
+		case parentID != 0 && tree.Get(parentID) == nil:
+			response.Fail(w, r, response.Validation(response.FieldError{Field: "parent_id", Message: "is not a known category"}))
+			return
+		case parentID != 0 && tree.Within(parentID, category.ID):
+			response.Fail(w, r, response.Validation(response.FieldError{Field: "parent_id", Message: "can't be the category itself or one under it"}))
+			return
+		}
+		category.ParentID = nil
+		if parentID != 0 {
+			category.ParentID = &parentID
+		}
+		category.Position = nextPosition(tree, parentID)
+	}
 	category.Name = body.Name
 	category.Description = body.Description
+	if body.IsActive != nil {
+		category.IsActive = *body.IsActive
+	}
+	switch {
+	case body.ImageFile != nil:
+		if category.Image, err = saveImage(r.Context(), ac.blobs, "category-images", body.ImageFile); err != nil {
+			response.Fail(w, r, err)
+			return
+		}
+	case body.Image != "":
+		category.Image = body.Image
+	}
+
+	if err := ac.store.Categories.Update(r.Context(), category); err != nil {
+		response.Fail(w, r, response.Internal("Update failed", err))
+		return
+	}
+
+	response.OK(w, "Category updated successfully.", nil)
+}
+
+// ReorderCategories sets the display order of the categories under one
+// parent, 0 for the top level. ids must list each of them exactly once.
+func (ac *AdminController) ReorderCategories(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		ParentID int   `json:"parent_id" validate:"min=0"`
+		IDs      []int `json:"ids" validate:"required"`
+	}
+	if err := bind.JSON(w, r, &body); err != nil {
+		response.Fail(w, r, err)
+		return
+	}
 
-	if err := category.Update(); err != nil {
-		ac.jsonResp(w, http.StatusInternalServerError, map[string]any{"success": false, "msg": "Update failed"})
+	ctx := r.Context()
+	err := ac.store.WithTx(ctx, func(tx *models.Store) error {
+		all, err := tx.Categories.GetAll(ctx, 0)
+		if err != nil {
+			return response.Internal("Failed to fetch categories", err)
+		}
+		tree := models.NewCategoryTree(all)
+		if body.ParentID != 0 && tree.Get(body.ParentID) == nil {
+			return response.NotFound("Category not found")
+		}
+
+		siblings := tree.Children(body.ParentID)
+		listed := map[int]bool{}
+		for _, id := range body.IDs {
+			c := tree.Get(id)
+			if c == nil || parentOf(c) != body.ParentID || listed[id] {
+				break
+			}
+			listed[id] = true
+		}
+		if len(listed) != len(body.IDs) || len(listed) != len(siblings) {
+			return response.Validation(response.FieldError{Field: "ids", Message: "must list each category under the parent exactly once"})
+		}
+
+		for i, id := range body.IDs {
+			c := tree.Get(id)
+			if c.Position == i+1 {
+				continue
+			}
+			c.Position = i + 1
+			if err := tx.Categories.Update(ctx, c); err != nil {
+				return response.Internal("Update failed", err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		response.Fail(w, r, err)
+		return
+	}
+
+	response.OK(w, "Categories reordered.", nil)
+}
+
+func (ac *AdminController) categoryTree(r *http.Request) (*models.CategoryTree, error) {
+	all, err := ac.store.Categories.GetAll(r.Context(), 0)
+	if err != nil {
+		return nil, response.Internal("Failed to fetch categories", err)
+	}
+	return models.NewCategoryTree(all), nil
+}
+
+// parentOf is the category's parent ID, 0 at the top level.
+func parentOf(c *models.Category) int {
+	if c.ParentID == nil {
+		return 0
+	}
+	return *c.ParentID
+}
+
+// categoryNameFree reports a conflict if a live category other than
+// exceptID already has the name. Deleted categories don't hold on to theirs.
+func categoryNameFree(tree *models.CategoryTree, exceptID int, name string) error {
+	for _, c := range tree.Walk(0, false) {
+		// the column's collation ignores case
+		if c.ID != exceptID && strings.EqualFold(c.Name, name) {
+			return response.Conflict(response.CodeConflict, "There is already a category called "+c.Name+".")
+		}
+	}
+	return nil
+}
+
+// nextPosition puts a category after the ones already under parentID.
+func nextPosition(tree *models.CategoryTree, parentID int) int {
+	position := 0
+	for _, c := range tree.Children(parentID) {
+		position = max(position, c.Position)
+	}
+	return position + 1
+}
+
+// DeleteUser soft-deletes an account: it can't sign in and leaves the
+// listings, but its orders, payments and reviews are kept.
+func (ac *AdminController) DeleteUser(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserClaims(r)
+	if !ok {
+		response.Fail(w, r, response.Unauthenticated("Please log in to continue."))
+		return
+	}
+	var body struct {
+		ID int `json:"-" path:"id" validate:"required"`
+	}
+	if err := bind.JSON(w, r, &body); err != nil {
+		response.Fail(w, r, err)
+		return
+	}
+	if body.ID == claims.ID {
+		response.Fail(w, r, response.BadRequest("You can't delete your own account."))
+		return
+	}
+
+	if err := ac.store.Users.Delete(r.Context(), body.ID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			response.Fail(w, r, response.NotFound("User not found"))
+			return
+		}
+		response.Fail(w, r, response.Internal("Delete failed", err))
+		return
+	}
+
+	response.OK(w, "User deleted.", nil)
+}
+
+func (ac *AdminController) RestoreUser(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		ID int `json:"-" path:"id" validate:"required"`
+	}
+	if err := bind.JSON(w, r, &body); err != nil {
+		response.Fail(w, r, err)
+		return
+	}
+
+	if err := ac.store.Users.Restore(r.Context(), body.ID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			response.Fail(w, r, response.NotFound("No deleted user has this ID."))
+			return
+		}
+		response.Fail(w, r, response.Internal("Restore failed", err))
+		return
+	}
+
+	response.OK(w, "User restored.", nil)
+}
+
+// DeleteCategory soft-deletes a category. One that still has subcategories
+// or items in the catalog can't be deleted; they must be moved or deleted
+// first.
+func (ac *AdminController) DeleteCategory(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		ID int `json:"-" path:"id" validate:"required"`
+	}
+	if err := bind.JSON(w, r, &body); err != nil {
+		response.Fail(w, r, err)
+		return
+	}
+
+	tree, err := ac.categoryTree(r)
+	if err != nil {
+		response.Fail(w, r, err)
+		return
+	}
+	if children := tree.Children(body.ID); len(children) > 0 {
+		response.Fail(w, r, response.Conflict(response.CodeConflict,
+			fmt.Sprintf("The category still has %d subcategories. Move or delete them first.", len(children))))
+		return
+	}
+
+	items, err := ac.store.Items.GetByCategoryID(r.Context(), body.ID)
+	if err != nil {
+		response.Fail(w, r, response.Internal("Failed to fetch items", err))
+		return
+	}
+	if len(items) > 0 {
+		response.Fail(w, r, response.Conflict(response.CodeConflict,
+			fmt.Sprintf("The category still has %d item(s). Move or delete them first.", len(items))))
+		return
+	}
+
+	if err := ac.store.Categories.Delete(r.Context(), body.ID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			response.Fail(w, r, response.NotFound("Category not found"))
+			return
+		}
+		response.Fail(w, r, response.Internal("Delete failed", err))
+		return
+	}
+
+	response.OK(w, "Category deleted.", nil)
+}
+
+// RestoreCategory brings back a deleted category, under the parent it had.
+func (ac *AdminController) RestoreCategory(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		ID int `json:"-" path:"id" validate:"required"`
+	}
+	if err := bind.JSON(w, r, &body); err != nil {
+		response.Fail(w, r, err)
+		return
+	}
+
+	deleted, err := ac.store.Categories.GetDeleted(r.Context())
+	if err != nil {
+		response.Fail(w, r, response.Internal("Failed to fetch categories", err))
+		return
+	}
+	tree, err := ac.categoryTree(r)
+	if err != nil {
+		response.Fail(w, r, err)
+		return
+	}
+	for _, c := range deleted {
+		if c.ID != body.ID {
+			continue
+		}
+		if c.ParentID != nil && tree.Get(*c.ParentID) == nil {
+			response.Fail(w, r, response.Conflict(response.CodeInvalidState, "The category's parent is deleted. Restore the parent first."))
+			return
+		}
+		if err := categoryNameFree(tree, c.ID, c.Name); err != nil {
+			response.Fail(w, r, err)
+			return
+		}
+	}
+
+	if err := ac.store.Categories.Restore(r.Context(), body.ID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			response.Fail(w, r, response.NotFound("No deleted category has this ID."))
+			return
+		}
+		response.Fail(w, r, response.Internal("Restore failed", err))
+		return
+	}
+
+	response.OK(w, "Category restored.", nil)
+}
+
+// Deleted lists everything that has been deleted and can be restored, most
+// recently deleted first.
+func (ac *AdminController) Deleted(w http.ResponseWriter, r *http.Request) {
+	users, err := ac.store.Users.GetDeleted(r.Context())
+	if err != nil {
+		response.Fail(w, r, response.Internal("Failed to fetch users", err))
+		return
+	}
+	categories, err := ac.store.Categories.GetDeleted(r.Context())
+	if err != nil {
+		response.Fail(w, r, response.Internal("Failed to fetch categories", err))
+		return
+	}
+	items, err := ac.store.Items.GetDeleted(r.Context(), 0)
+	if err != nil {
+		response.Fail(w, r, response.Internal("Failed to fetch items", err))
 		return
 	}
 
-	ac.jsonResp(w, http.StatusOK, map[string]any{"success": true, "msg": "Category updated successfully."})
+	response.OK(w, "Deleted records fetched successfully.", response.Data{"users": users, "categories": categories, "items": items})
 }
 
-func (ac *AdminController) AllItems(w http.ResponseWriter, _ *http.Request) {
-	items, err := models.GetAllItems(0)
+func (ac *AdminController) AllItems(w http.ResponseWriter, r *http.Request) {
+	items, err := ac.store.Items.GetAll(r.Context(), 0)
 	if err != nil {
-		ac.jsonResp(w, http.StatusInternalServerError, map[string]any{"success": false, "msg": "Failed to fetch items"})
+		response.Fail(w, r, response.Internal("Failed to fetch items", err))
 		return
 	}
-	ac.jsonResp(w, http.StatusOK, map[string]any{"success": true, "msg": "All items fetched successfully.", "items": items})
+	response.OK(w, "All items fetched successfully.", response.Data{"items": withAvailability(items, shopNow(ac.loc))})
 }
 
 func (ac *AdminController) UpdateItemStatus(w http.ResponseWriter, r *http.Request) {
 	type reqBody struct {
-		ItemID int    `json:"itemId"`
-		Status string `json:"status"`
+		ItemID int    `json:"itemId" path:"id" validate:"required"`
+		Status string `json:"status" validate:"required,oneof=available unavailable discontinued"`
 	}
 	var body reqBody
-	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
-		ac.jsonResp(w, http.StatusBadRequest, map[string]any{"success": false, "msg": "Bad json data"})
+	if err := bind.JSON(w, r, &body); err != nil {
+		response.Fail(w, r, err)
 		return
 	}
-	item, err := models.GetItemByID(body.ItemID)
+	item, err := ac.store.Items.GetByID(r.Context(), body.ItemID)
 	if err != nil {
-		ac.jsonResp(w, http.StatusNotFound, map[string]any{"success": false, "msg": "Item not found"})
+		response.Fail(w, r, response.NotFound("Item not found"))
 		return
 	}
 	item.Status = body.Status
-	if err := item.Update(); err != nil {
-		ac.jsonResp(w, http.StatusInternalServerError, map[string]any{"success": false, "msg": "Update failed"})
+	if err := ac.store.Items.Update(r.Context(), item); err != nil {
+		response.Fail(w, r, response.Internal("Update failed", err))
 		return
 	}
-	ac.jsonResp(w, http.StatusOK, map[string]any{"success": true, "msg": "Item status updated successfully."})
+	response.OK(w, "Item status updated successfully.", nil)
 }
 
 func (ac *AdminController) GetAdminStats(w http.ResponseWriter, r *http.Request) {
 	_, ok := middleware.GetUserClaims(r)
 	if !ok {
-		ac.jsonResp(w, http.StatusUnauthorized, map[string]any{"success": false, "msg": "Unauthorized"})
+		response.Fail(w, r, response.Unauthenticated("Please log in to continue."))
 		return
 	}
 
-	revenue, _ := models.GetTotalRevenue()
-	orders, _ := models.GetTotalOrders()
-	users, _ := models.GetTotalCustomers()
-	sellers, _ := models.GetTotalSellers()
+	revenue, _ := ac.store.Stats.GetTotalRevenue(r.Context())
+	orders, _ := ac.store.Stats.GetTotalOrders(r.Context())
+	users, _ := ac.store.Stats.GetTotalCustomers(r.Context())
+	sellers, _ := ac.store.Stats.GetTotalSellers(r.Context())
 
-	items, _ := models.GetTotalItems()
-	categories, _ := models.GetTotalCategories()
-	pending, _ := models.GetPendingOrdersCount()
-	reviews, _ := models.GetTotalReviews()
+	items, _ := ac.store.Stats.GetTotalItems(r.Context())
+	categories, _ := ac.store.Stats.GetTotalCategories(r.Context())
+	pending, _ := ac.store.Stats.GetPendingOrdersCount(r.Context())
+	reviews, _ := ac.store.Stats.GetTotalReviews(r.Context())
 
 	stats := map[string]any{
 		"revenue":    revenue,
@@ -200,5 +539,5 @@ func (ac *AdminController) GetAdminStats(w http.ResponseWriter, r *http.Request)
 		"reviews":    reviews,
 	}
 
-	ac.jsonResp(w, http.StatusOK, map[string]any{"success": true, "msg": "Admin stats fetched successfully.", "data": stats})
+	response.OK(w, "Admin stats fetched successfully.", response.Data{"data": stats})
 }
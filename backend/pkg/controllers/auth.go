@@ -1,47 +1,49 @@
 package controllers
 
 import (
-	"encoding/json"
 	"errors"
 	"fmt"
 	"net/http"
 	"time"
 
+	"github.com/Entity069/Zesty-Go/pkg/bind"
 	"github.com/Entity069/Zesty-Go/pkg/config"
+	"github.com/Entity069/Zesty-Go/pkg/images"
 	"github.com/Entity069/Zesty-Go/pkg/models"
+	"github.com/Entity069/Zesty-Go/pkg/response"
 	"github.com/Entity069/Zesty-Go/pkg/utils"
 	"github.com/golang-jwt/jwt/v5"
 	"golang.org/x/crypto/bcrypt"
 )
 
-type AuthController struct{}
+var (
+	errInvalidCredentials = response.New(http.StatusUnauthorized, response.CodeInvalidCredentials, "Invalid email or password.")
+	errInvalidLink        = response.New(http.StatusBadRequest, response.CodeInvalidToken, "This link is invalid or has expired.")
+)
 
-func NewAuthController() *AuthController {
-	return &AuthController{}
+type AuthController struct {
+	cfg    *config.Config
+	store  *models.Store
+	mailer *utils.Mailer
 }
 
-func (ac *AuthController) jsonResp(w http.ResponseWriter, status int, payload any) {
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(status)
-	_ = json.NewEncoder(w).Encode(payload)
+func NewAuthController(cfg *config.Config, store *models.Store, mailer *utils.Mailer) *AuthController {
+	return &AuthController{cfg: cfg, store: store, mailer: mailer}
 }
 
 func (ac *AuthController) GetEmailFromJwt(tokenStr string) (string, error) {
 	token, _, err := jwt.NewParser().ParseUnverified(tokenStr, jwt.MapClaims{})
 	if err != nil {
-		fmt.Println("Error 1:", err)
 		return "", err
 	}
 
 	claims, ok := token.Claims.(jwt.MapClaims)
 	if !ok {
-		fmt.Println("Error 2: Invalid claims", ok)
 		return "", errors.New("invalid claims")
 	}
 
 	email, ok := claims["email"].(string)
 	if !ok {
-		fmt.Println("Error 3: Email not found in token", ok)
 		return "", errors.New("email not found in token")
 	}
 
@@ -50,32 +52,27 @@ func (ac *AuthController) GetEmailFromJwt(tokenStr string) (string, error) {
 
 func (ac *AuthController) Register(w http.ResponseWriter, r *http.Request) {
 	type reqBody struct {
-		FirstName string `json:"first_name"`
-		LastName  string `json:"last_name"`
-		Password  string `json:"password"`
-		Email     string `json:"email"`
-		Address   string `json:"address"`
+		FirstName string `json:"first_name" validate:"required,max=255"`
+		LastName  string `json:"last_name" validate:"required,max=255"`
+		Password  string `json:"password" validate:"required,password"`
+		Email     string `json:"email" validate:"required,email,max=255"`
+		Address   string `json:"address" validate:"required,max=255"`
 	}
 
 	var body reqBody
-	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
-		ac.jsonResp(w, http.StatusBadRequest, map[string]any{"success": false, "msg": "Invalid JSON"})
-		return
-	}
-
-	if body.FirstName == "" || body.LastName == "" || body.Email == "" || body.Password == "" || body.Address == "" {
-		ac.jsonResp(w, http.StatusBadRequest, map[string]any{"success": false, "msg": "Some fields are missing!"})
+	if err := bind.JSON(w, r, &body); err != nil {
+		response.Fail(w, r, err)
 		return
 	}
 
-	if existingUser, _ := models.GetUserByEmail(body.Email); existingUser != nil {
-		ac.jsonResp(w, http.StatusBadRequest, map[string]any{"success": false, "msg": "User already exists!"})
+	if existingUser, _ := ac.store.Users.GetByEmail(r.Context(), body.Email); existingUser != nil {
+		response.Fail(w, r, response.Conflict(response.CodeConflict, "An account with this email already exists."))
 		return
 	}
 
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(body.Password), 10)
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(body.Password), ac.cfg.Auth.BcryptCost)
 	if err != nil {
-		ac.jsonResp(w, http.StatusInternalServerError, map[string]any{"success": false, "msg": "Hashing failed"})
+		response.Fail(w, r, response.Internal("Hashing failed", err))
 		return
 	}
 
@@ -83,13 +80,13 @@ func (ac *AuthController) Register(w http.ResponseWriter, r *http.Request) {
 		"email": body.Email,
 		"exp":   time.Now().Add(24 * time.Hour).Unix(),
 	})
-	tokenString, _ := token.SignedString(config.JWTSecret())
+	tokenString, _ := token.SignedString([]byte(ac.cfg.Auth.JWTSecret))
 
-	activationURL := fmt.Sprintf("http://%s/verified?token=%s", config.FrontendUrl(), tokenString)
+	activationURL := fmt.Sprintf("http://%s/verified?token=%s", ac.cfg.Server.FrontendURL, tokenString)
 
 	emailData := map[string]string{"activation_url": activationURL}
-	if err := utils.SendEmail(body.Email, "Action Required [Zesty]", "templates/email/confirm.html", emailData); err != nil {
-		ac.jsonResp(w, http.StatusInternalServerError, map[string]any{"success": false, "msg": "Failed to send confirmation email."})
+	if err := ac.mailer.SendEmail(r.Context(), body.Email, "Action Required [Zesty]", "templates/email/confirm.html", emailData); err != nil {
+		response.Fail(w, r, response.Internal("Failed to send confirmation email.", err))
 		return
 	}
 
@@ -105,125 +102,115 @@ func (ac *AuthController) Register(w http.ResponseWriter, r *http.Request) {
 		IsVerified: false,
 	}
 
-	if err := user.Create(); err != nil {
-		ac.jsonResp(w, http.StatusInternalServerError, map[string]any{"success": false, "msg": "Registration failed"})
+	if err := ac.store.Users.Create(r.Context(), user); err != nil {
+		response.Fail(w, r, response.Internal("Registration failed", err))
 		return
 	}
 
-	ac.jsonResp(w, http.StatusCreated, map[string]any{"success": true, "msg": "User registered successfully! Please check your email for a confirmation email."})
+	response.Created(w, "User registered successfully! Please check your email for a confirmation email.", nil)
 }
 
 func (ac *AuthController) WhoAmI(w http.ResponseWriter, r *http.Request) {
 	cookie, err := r.Cookie("token")
 	if err != nil || cookie.Value == "" {
-		ac.jsonResp(w, http.StatusUnauthorized, map[string]any{"success": false, "msg": "Missing or invalid token"})
+		response.Fail(w, r, response.Unauthenticated("Please log in to continue."))
 		return
 	}
 
 	token, err := jwt.Parse(cookie.Value, func(token *jwt.Token) (any, error) {
-		return config.JWTSecret(), nil
+		return []byte(ac.cfg.Auth.JWTSecret), nil
 	})
 	if err != nil || !token.Valid {
-		ac.jsonResp(w, http.StatusUnauthorized, map[string]any{"success": false, "msg": "Invalid token"})
+		response.Fail(w, r, response.Unauthenticated("Your session is invalid. Please log in again."))
 		return
 	}
 
 	claims, ok := token.Claims.(jwt.MapClaims)
 	if !ok {
-		ac.jsonResp(w, http.StatusUnauthorized, map[string]any{"success": false, "msg": "Invalid token"})
+		response.Fail(w, r, response.Unauthenticated("Your session is invalid. Please log in again."))
 		return
 	}
 
 	id, ok := claims["id"].(float64)
 	if !ok {
-		ac.jsonResp(w, http.StatusUnauthorized, map[string]any{"success": false, "msg": "ID not found in token"})
+		response.Fail(w, r, response.Unauthenticated("Your session is invalid. Please log in again."))
 		return
 	}
 
-	user, err := models.GetUserByID(int(id))
-	if err != nil {
-		ac.jsonResp(w, http.StatusUnauthorized, map[string]any{"success": false, "msg": "User not found"})
+	user, err := ac.store.Users.GetByID(r.Context(), int(id))
+	if err != nil || user.DeletedAt != nil {
+		response.Fail(w, r, response.Unauthenticated("Your account no longer exists."))
 		return
 	}
 
-	ac.jsonResp(w, http.StatusOK, map[string]any{
-		"success": true,
-		"user": map[string]any{
-			"id":          user.ID,
-			"first_name":  user.FirstName,
-			"last_name":   user.LastName,
-			"email":       user.Email,
-			"address":     user.Address,
-			"user_type":   user.UserType,
-			"balance":     user.Balance,
-			"is_verified": user.IsVerified,
-			"profile_pic": user.ProfilePic,
-		},
-	})
+	response.OK(w, "", response.Data{"user": sessionUser(user)})
+}
+
+// sessionUser is the view of the logged-in user that login and whoami
+// return.
+func sessionUser(user *models.User) map[string]any {
+	return map[string]any{
+		"id":                 user.ID,
+		"first_name":         user.FirstName,
+		"last_name":          user.LastName,
+		"email":              user.Email,
+		"address":            user.Address,
+		"allergens":          user.Allergens,
+		"user_type":          user.UserType,
+		"balance":            user.Balance,
+		"is_verified":        user.IsVerified,
+		"profile_pic":        user.ProfilePic,
+		"profile_pic_srcset": images.Srcset(user.ProfilePic),
+	}
 }
 
 func (ac *AuthController) Login(w http.ResponseWriter, r *http.Request) {
 	type reqBody struct {
-		Email    string `json:"email"`
-		Password string `json:"password"`
+		Email    string `json:"email" validate:"required"`
+		Password string `json:"password" validate:"required"`
 	}
 
 	var body reqBody
-	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
-		ac.jsonResp(w, http.StatusBadRequest, map[string]any{"success": false, "msg": "Invalid JSON"})
-		return
-	}
-
-	if body.Email == "" || body.Password == "" {
-		ac.jsonResp(w, http.StatusBadRequest, map[string]any{"success": false, "msg": "Email and password are required!"})
+	if err := bind.JSON(w, r, &body); err != nil {
+		response.Fail(w, r, err)
 		return
 	}
 
-	user, err := models.GetUserByEmail(body.Email)
-	if err != nil {
-		ac.jsonResp(w, http.StatusBadRequest, map[string]any{"success": false, "msg": "Invalid Credentials!"})
+	// A deleted account keeps its email, so it can be restored, but can't
+	// be signed in to.
+	user, err := ac.store.Users.GetByEmail(r.Context(), body.Email)
+	if err != nil || user.DeletedAt != nil {
+		response.Fail(w, r, errInvalidCredentials)
 		return
 	}
 
 	if !user.IsVerified {
-		ac.jsonResp(w, http.StatusBadRequest, map[string]any{"success": false, "msg": "You need to activate your account first."})
+		response.Fail(w, r, response.New(http.StatusForbidden, response.CodeEmailNotVerified, "You need to activate your account first."))
 		return
 	}
 
 	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(body.Password)); err != nil {
-		ac.jsonResp(w, http.StatusBadRequest, map[string]any{"success": false, "msg": "Invalid Credentials!"})
+		response.Fail(w, r, errInvalidCredentials)
 		return
 	}
 
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
 		"id":   user.ID,
 		"role": user.UserType,
-		"exp":  time.Now().Add(24 * time.Hour).Unix(),
+		"exp":  time.Now().Add(ac.cfg.Auth.JWTTTL).Unix(),
 	})
-	tokenString, _ := token.SignedString(config.JWTSecret())
+	tokenString, _ := token.SignedString([]byte(ac.cfg.Auth.JWTSecret))
 
 	http.SetCookie(w, &http.Cookie{
 		Name:     "token",
 		Value:    tokenString,
 		Path:     "/",
 		HttpOnly: true,
-		MaxAge:   24 * 60 * 60,
+		MaxAge:   int(ac.cfg.Auth.JWTTTL.Seconds()),
 		SameSite: http.SameSiteLaxMode,
 	})
 
-	ac.jsonResp(w, http.StatusCreated, map[string]any{"success": true,
-		"msg": "You will be redirected in a minute...",
-		"user": map[string]any{
-			"id":          user.ID,
-			"first_name":  user.FirstName,
-			"last_name":   user.LastName,
-			"email":       user.Email,
-			"address":     user.Address,
-			"user_type":   user.UserType,
-			"balance":     user.Balance,
-			"is_verified": user.IsVerified,
-			"profile_pic": user.ProfilePic,
-		}})
+	response.Created(w, "You will be redirected in a minute...", response.Data{"user": sessionUser(user)})
 }
 
 func (ac *AuthController) Logout(w http.ResponseWriter, r *http.Request) {
@@ -233,26 +220,22 @@ func (ac *AuthController) Logout(w http.ResponseWriter, r *http.Request) {
 
 func (ac *AuthController) VerifyEmail(w http.ResponseWriter, r *http.Request) {
 	type reqBody struct {
-		Token string `json:"token"`
+		Token string `json:"token" validate:"required"`
 	}
 	var body reqBody
-	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
-		ac.jsonResp(w, http.StatusBadRequest, map[string]any{"success": false, "msg": "Invalid JSON"})
+	if err := bind.JSON(w, r, &body); err != nil {
+		response.Fail(w, r, err)
 		return
 	}
 
 	tokenString := body.Token
-	if tokenString == "" {
-		ac.jsonResp(w, http.StatusBadRequest, map[string]any{"success": false, "msg": "Missing token"})
-		return
-	}
 
 	// validate the token
 	_, err := jwt.Parse(tokenString, func(token *jwt.Token) (any, error) {
-		return config.JWTSecret(), nil
+		return []byte(ac.cfg.Auth.JWTSecret), nil
 	})
 	if err != nil {
-		ac.jsonResp(w, http.StatusBadRequest, map[string]any{"success": false, "msg": "Invalid token"})
+		response.Fail(w, r, errInvalidLink)
 		return
 	}
 	// extract email from token WITHOUT verifying. we can do this because the
@@ -260,38 +243,38 @@ func (ac *AuthController) VerifyEmail(w http.ResponseWriter, r *http.Request) {
 
 	email, err := ac.GetEmailFromJwt(tokenString)
 	if err != nil {
-		ac.jsonResp(w, http.StatusBadRequest, map[string]any{"success": false, "msg": "Invalid token"})
+		response.Fail(w, r, errInvalidLink)
 		return
 	}
 
-	user, err := models.GetUserByEmail(email)
+	user, err := ac.store.Users.GetByEmail(r.Context(), email)
 	if err != nil {
-		ac.jsonResp(w, http.StatusBadRequest, map[string]any{"success": false, "msg": "User not found"})
+		response.Fail(w, r, response.BadRequest("User not found"))
 		return
 	}
 
-	if err := user.EmailVerify(); err != nil {
-		ac.jsonResp(w, http.StatusInternalServerError, map[string]any{"success": false, "msg": "Verification failed"})
+	if err := ac.store.Users.EmailVerify(r.Context(), user); err != nil {
+		response.Fail(w, r, response.Internal("Verification failed", err))
 		return
 	}
 
-	ac.jsonResp(w, http.StatusCreated, map[string]any{"success": true, "msg": "Your email has been verified successfully!"})
+	response.Created(w, "Your email has been verified successfully!", nil)
 }
 
 func (ac *AuthController) ResetPassword(w http.ResponseWriter, r *http.Request) {
 	type reqBody struct {
-		Email string `json:"email"`
+		Email string `json:"email" validate:"required,email"`
 	}
 
 	var body reqBody
-	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
-		ac.jsonResp(w, http.StatusBadRequest, map[string]any{"success": false, "msg": "Invalid JSON"})
+	if err := bind.JSON(w, r, &body); err != nil {
+		response.Fail(w, r, err)
 		return
 	}
 
-	user, err := models.GetUserByEmail(body.Email)
-	if err != nil {
-		ac.jsonResp(w, http.StatusBadRequest, map[string]any{"success": false, "msg": "Invalid user!"})
+	user, err := ac.store.Users.GetByEmail(r.Context(), body.Email)
+	if err != nil || user.DeletedAt != nil {
+		response.Fail(w, r, response.NotFound("No account uses this email."))
 		return
 	}
 
@@ -305,48 +288,36 @@ func (ac *AuthController) ResetPassword(w http.ResponseWriter, r *http.Request)
 	})
 	tokenString, _ := token.SignedString(key)
 
-	resetURL := fmt.Sprintf("http://%s/reset-password?token=%s", config.FrontendUrl(), tokenString)
+	resetURL := fmt.Sprintf("http://%s/reset-password?token=%s", ac.cfg.Server.FrontendURL, tokenString)
 	emailData := map[string]string{"reset_url": resetURL}
 
-	if err := utils.SendEmail(body.Email, "Action Required [Zesty]", "templates/email/forgot.html", emailData); err != nil {
-		ac.jsonResp(w, http.StatusInternalServerError, map[string]any{"success": false, "msg": "Failed to send password reset email."})
+	if err := ac.mailer.SendEmail(r.Context(), body.Email, "Action Required [Zesty]", "templates/email/forgot.html", emailData); err != nil {
+		response.Fail(w, r, response.Internal("Failed to send password reset email.", err))
 		return
 	}
 
-	ac.jsonResp(w, http.StatusOK, map[string]any{"success": true, "msg": "An email has been send to your email."})
+	response.OK(w, "A password reset link has been sent to your email.", nil)
 }
 
 func (ac *AuthController) PostResetPassword(w http.ResponseWriter, r *http.Request) {
 	var reqBody struct {
-		Token    string `json:"token"`
-		Password string `json:"password"`
+		Token    string `json:"token" validate:"required"`
+		Password string `json:"password" validate:"required,password"`
 	}
-	if err := json.NewDecoder(r.Body).Decode(&reqBody); err != nil {
-		fmt.Println("Error decoding JSON:", err)
-		ac.jsonResp(w, http.StatusBadRequest, map[string]any{"success": false, "msg": "Invalid payload"})
-		return
-	}
-
-	if reqBody.Token == "" {
-		ac.jsonResp(w, http.StatusBadRequest, map[string]any{"success": false, "msg": "Missing token"})
-		return
-	}
-
-	if reqBody.Password == "" {
-		ac.jsonResp(w, http.StatusBadRequest, map[string]any{"success": false, "msg": "Missing password"})
+	if err := bind.JSON(w, r, &reqBody); err != nil {
+		response.Fail(w, r, err)
 		return
 	}
 
 	email, err := ac.GetEmailFromJwt(reqBody.Token)
 	if err != nil {
-		fmt.Println("Error getting email from JWT:", err)
-		ac.jsonResp(w, http.StatusBadRequest, map[string]any{"success": false, "msg": "Invalid token"})
+		response.Fail(w, r, errInvalidLink)
 		return
 	}
 
-	user, err := models.GetUserByEmail(email)
+	user, err := ac.store.Users.GetByEmail(r.Context(), email)
 	if err != nil {
-		ac.jsonResp(w, http.StatusBadRequest, map[string]any{"success": false, "msg": "Invalid user!"})
+		response.Fail(w, r, response.NotFound("No account uses this email."))
 		return
 	}
 
@@ -355,21 +326,20 @@ func (ac *AuthController) PostResetPassword(w http.ResponseWriter, r *http.Reque
 		return key, nil
 	})
 	if err != nil {
-		fmt.Println("Error parsing JWT:", err)
-		ac.jsonResp(w, http.StatusUnauthorized, map[string]any{"success": false, "msg": "Invalid token"})
+		response.Fail(w, r, errInvalidLink)
 		return
 	}
 
-	newHash, err := bcrypt.GenerateFromPassword([]byte(reqBody.Password), 10)
+	newHash, err := bcrypt.GenerateFromPassword([]byte(reqBody.Password), ac.cfg.Auth.BcryptCost)
 	if err != nil {
-		ac.jsonResp(w, http.StatusInternalServerError, map[string]any{"success": false, "msg": "Hashing failed"})
+		response.Fail(w, r, response.Internal("Hashing failed", err))
 		return
 	}
 
-	if err := user.UpdatePassword(string(newHash)); err != nil {
-		ac.jsonResp(w, http.StatusInternalServerError, map[string]any{"success": false, "msg": "Update failed"})
+	if err := ac.store.Users.UpdatePassword(r.Context(), user, string(newHash)); err != nil {
+		response.Fail(w, r, response.Internal("Update failed", err))
 		return
 	}
 
-	ac.jsonResp(w, http.StatusOK, map[string]any{"success": true, "msg": "Password Updated Successfully!"})
+	response.OK(w, "Password Updated Successfully!", nil)
 }
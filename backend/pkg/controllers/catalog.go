@@ -0,0 +1,206 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/Entity069/Zesty-Go/pkg/bind"
+	"github.com/Entity069/Zesty-Go/pkg/catalog"
+	"github.com/Entity069/Zesty-Go/pkg/logging"
+	"github.com/Entity069/Zesty-Go/pkg/middleware"
+	"github.com/Entity069/Zesty-Go/pkg/models"
+	"github.com/Entity069/Zesty-Go/pkg/response"
+)
+
+// ImportItems creates and updates the seller's items from a CSV or JSON
+// file, sent as the body or as the "file" field of a multipart form. With
+// ?dry_run=1 it only reports what the import would do and what is wrong
+// with the file. Otherwise a file with any problem is rejected whole.
+func (sc *SellerController) ImportItems(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserClaims(r)
+	if !ok {
+		response.Fail(w, r, response.Unauthenticated("Please log in to continue."))
+		return
+	}
+	ctx := r.Context()
+
+	body, format, err := sc.importFile(w, r)
+	if err != nil {
+		response.Fail(w, r, err)
+		return
+	}
+	defer body.Close()
+
+	rows, problems, err := catalog.Parse(body, format)
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			response.Fail(w, r, tooLargeImport(sc.cfg.Uploads.MaxBytes))
+			return
+		}
+		response.Fail(w, r, response.BadRequest(fmt.Sprintf("The file can't be read: %v.", err)))
+		return
+	}
+
+	existing, err := sc.store.Items.GetBySellerID(ctx, claims.ID)
+	if err != nil {
+		response.Fail(w, r, response.Internal("Failed to fetch items", err))
+		return
+	}
+	deleted, err := sc.store.Items.GetDeleted(ctx, claims.ID)
+	if err != nil {
+		response.Fail(w, r, response.Internal("Failed to fetch items", err))
+		return
+	}
+	existing = append(existing, deleted...)
+	categories, err := sc.store.Categories.GetAll(ctx, 0)
+	if err != nil {
+		response.Fail(w, r, response.Internal("Failed to fetch categories", err))
+		return
+	}
+	rep := catalog.Prepare(claims.ID, rows, problems, existing, categories)
+
+	if r.URL.Query().Get("dry_run") == "1" {
+		msg := fmt.Sprintf("The file is ready to import: %d to create, %d to update, %d unchanged.", rep.Created, rep.Updated, rep.Unchanged)
+		if !rep.OK() {
+			msg = fmt.Sprintf("The file has %d problem(s) to fix before it can be imported.", len(rep.Problems))
+		}
+		response.OK(w, msg, response.Data{"report": rep})
+		return
+	}
+	if !rep.OK() {
+		fields := make([]response.FieldError, 0, len(rep.Problems))
+		for _, p := range rep.Problems {
+			fields = append(fields, response.FieldError{Field: fmt.Sprintf("rows[%d].%s", p.Row, p.Field), Message: p.Message})
+		}
+		response.Fail(w, r, response.Validation(fields...))
+		return
+	}
+
+	err = sc.store.WithTx(ctx, func(tx *models.Store) error {
+		for _, p := range rep.Plan {
+			var err error
+			switch p.Action {
+			case catalog.ActionCreate:
+				err = tx.Items.Create(ctx, p.Item)
+			case catalog.ActionUpdate:
+				err = tx.Items.Update(ctx, p.Item)
+			}
+			if err != nil {
+				return fmt.Errorf("row %d: %w", p.Row, err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		response.Fail(w, r, response.Internal("Import failed", err))
+		return
+	}
+
+	// Queue the pictures only once the items they belong to are saved.
+	for _, p := range rep.Plan {
+		if p.ImageURL != "" && !sc.fetcher.Enqueue(ctx, p.Item, p.ImageURL) {
+			rep.ImagesQueued--
+		}
+	}
+
+	response.OK(w, fmt.Sprintf("Imported %d item(s): %d created, %d updated, %d unchanged.", rep.Rows, rep.Created, rep.Updated, rep.Unchanged),
+		response.Data{"report": rep})
+}
+
+// importFile returns the uploaded file and its format, csv or json.
+func (sc *SellerController) importFile(w http.ResponseWriter, r *http.Request) (io.ReadCloser, string, error) {
+	limit := sc.cfg.Uploads.MaxBytes
+	if bind.IsMultipart(r) {
+		var form struct {
+			File *multipart.FileHeader `form:"file"`
+		}
+		if err := bind.Multipart(w, r, limit, &form); err != nil {
+			return nil, "", err
+		}
+		if form.File == nil {
+			return nil, "", response.Validation(response.FieldError{Field: "file", Message: "is required"})
+		}
+		format := formatOf(form.File.Header.Get("Content-Type"), form.File.Filename)
+		if format == "" {
+			return nil, "", response.BadRequest("Upload a .csv or .json file.")
+		}
+		f, err := form.File.Open()
+		if err != nil {
+			return nil, "", response.Internal("Failed to read the file", err)
+		}
+		return f, format, nil
+	}
+
+	format := formatOf(r.Header.Get("Content-Type"), "")
+	if format == "" {
+		return nil, "", response.New(http.StatusUnsupportedMediaType, response.CodeBadRequest,
+			"Send the file as text/csv, application/json or a multipart form.")
+	}
+	return http.MaxBytesReader(w, r.Body, limit), format, nil
+}
+
+// formatOf tells a CSV from a JSON file by its type, or failing that its
+// name's extension.
+func formatOf(contentType, filename string) string {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	switch {
+	case mediaType == "text/csv":
+		return catalog.FormatCSV
+	case mediaType == "application/json":
+		return catalog.FormatJSON
+	}
+	switch strings.ToLower(path.Ext(filename)) {
+	case ".csv":
+		return catalog.FormatCSV
+	case ".json":
+		return catalog.FormatJSON
+	}
+	return ""
+}
+
+func tooLargeImport(limit int64) error {
+	return response.New(http.StatusRequestEntityTooLarge, response.CodeTooLarge,
+		fmt.Sprintf("The file is larger than the %d KiB limit.", limit>>10))
+}
+
+// ExportItems downloads the seller's items as a file ImportItems accepts,
+// CSV unless ?format=json.
+func (sc *SellerController) ExportItems(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserClaims(r)
+	if !ok {
+		response.Fail(w, r, response.Unauthenticated("Please log in to continue."))
+		return
+	}
+
+	format := r.URL.Query().Get("format")
+	contentType := "application/json"
+	switch format {
+	case "", catalog.FormatCSV:
+		format, contentType = catalog.FormatCSV, "text/csv; charset=utf-8"
+	case catalog.FormatJSON:
+	default:
+		response.Fail(w, r, response.Validation(response.FieldError{Field: "format", Message: "must be one of csv json"}))
+		return
+	}
+
+	items, err := sc.store.Items.GetBySellerID(r.Context(), claims.ID)
+	if err != nil {
+		response.Fail(w, r, response.Internal("Failed to fetch items", err))
+		return
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="zesty-items-%s.%s"`, time.Now().Format("2006-01-02"), format))
+	if err := catalog.Export(w, items, format); err != nil {
+		// The status is already sent; all that's left is to log it.
+		logging.FromContext(r.Context()).Error("exporting items", "err", err)
+	}
+}
@@ -0,0 +1,117 @@
+package controllers_test
+
+import (
+	"context"
+	"encoding/json"
+	"image/color"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Entity069/Zesty-Go/pkg/catalog"
+	"github.com/Entity069/Zesty-Go/pkg/config"
+	"github.com/Entity069/Zesty-Go/pkg/controllers"
+	"github.com/Entity069/Zesty-Go/pkg/models"
+	"github.com/Entity069/Zesty-Go/pkg/storage"
+)
+
+func TestCatalogImportExport(t *testing.T) {
+	f := newFixture(t, 0)
+	ctx := context.Background()
+	blobs := storage.NewLocal(t.TempDir())
+	cfg := config.Default()
+
+	picture := pngOf(t, 300, 200, color.RGBA{G: 200, A: 255})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(picture)
+	}))
+	defer srv.Close()
+	fetcher := catalog.NewFetcher(f.store, blobs, cfg.Uploads.MaxBytes, srv.Client())
+	sc := controllers.NewSellerController(cfg, f.store, blobs, fetcher)
+
+	post := func(query, contentType, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/"+query, strings.NewReader(body))
+		req.Header.Set("Content-Type", contentType)
+		return f.asSeller(t, sc.ImportItems, req)
+	}
+	var got struct {
+		Report catalog.Report `json:"report"`
+	}
+
+	// The existing Dosa is adopted by name; Vada is new.
+	file := "sku,name,description,price,category,image\n" +
+		"D1,Dosa,Crisp,45,Breakfast,\n" +
+		"V1,Vada,Fried,20,Breakfast," + srv.URL + "/vada.png\n"
+	bad := file + "V2,Vada,Again,abc,Lunch,\n"
+
+	rec := post("?dry_run=1", "text/csv", bad)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("dry run: %d %s", rec.Code, rec.Body)
+	}
+	json.Unmarshal(rec.Body.Bytes(), &got)
+	if len(got.Report.Problems) != 3 {
+		t.Fatalf("dry run problems = %+v, want the name, price and category of row 4", got.Report.Problems)
+	}
+	if rec := post("", "text/csv", bad); rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), `"rows[4].price"`) {
+		t.Fatalf("import with problems: %d %s", rec.Code, rec.Body)
+	}
+	if items, _ := f.store.Items.GetBySellerID(ctx, f.item.SellerID); len(items) != 1 {
+		t.Fatalf("a rejected import saved items: %+v", items)
+	}
+	if rec := post("", "application/xml", file); rec.Code != http.StatusUnsupportedMediaType {
+		t.Fatalf("xml: %d %s", rec.Code, rec.Body)
+	}
+
+	rec = post("", "text/csv; charset=utf-8", file)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("import: %d %s", rec.Code, rec.Body)
+	}
+	json.Unmarshal(rec.Body.Bytes(), &got)
+	if r := got.Report; r.Created != 1 || r.Updated != 1 || r.ImagesQueued != 1 {
+		t.Fatalf("report = %+v", r)
+	}
+	fetcher.Close()
+
+	dosa, _ := f.store.Items.GetByID(ctx, f.item.ID)
+	if dosa.SKU != "D1" || dosa.Price != 45 {
+		t.Fatalf("dosa = %+v", dosa)
+	}
+	items, _ := f.store.Items.GetBySellerID(ctx, f.item.SellerID)
+	var vada string
+	for _, it := range items {
+		if it.SKU == "V1" {
+			vada = it.Image
+		}
+	}
+	if !strings.HasPrefix(vada, "/uploads/item-images/") || !strings.HasSuffix(vada, "-300w.jpg") {
+		t.Fatalf("vada's image = %q, want the fetched picture", vada)
+	}
+
+	// Importing the export changes nothing.
+	req := httptest.NewRequest(http.MethodGet, "/?format=json", nil)
+	rec = f.asSeller(t, sc.ExportItems, req)
+	if rec.Code != http.StatusOK || !strings.HasPrefix(rec.Header().Get("Content-Disposition"), "attachment;") {
+		t.Fatalf("export: %d %v", rec.Code, rec.Header())
+	}
+	rec = post("", "application/json", rec.Body.String())
+	json.Unmarshal(rec.Body.Bytes(), &got)
+	if r := got.Report; rec.Code != http.StatusOK || r.Unchanged != 2 || r.Created+r.Updated != 0 {
+		t.Fatalf("re-import: %d %+v", rec.Code, r)
+	}
+
+	// Items left in a deleted category are still matched by SKU, so an
+	// import moves them rather than adding them again.
+	lunch := &models.Category{Name: "Lunch", IsActive: true}
+	if err := f.store.Categories.Create(ctx, lunch); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.store.Categories.Delete(ctx, f.item.CategoryID); err != nil {
+		t.Fatal(err)
+	}
+	rec = post("", "text/csv", strings.ReplaceAll(file, "Breakfast", "Lunch"))
+	json.Unmarshal(rec.Body.Bytes(), &got)
+	if r := got.Report; rec.Code != http.StatusOK || r.Updated != 2 || r.Created != 0 {
+		t.Fatalf("import into a new category: %d %+v", rec.Code, r)
+	}
+}
@@ -0,0 +1,228 @@
+package controllers_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+
+	"github.com/Entity069/Zesty-Go/pkg/config"
+	"github.com/Entity069/Zesty-Go/pkg/controllers"
+	"github.com/Entity069/Zesty-Go/pkg/models"
+)
+
+func TestCategoryHierarchy(t *testing.T) {
+	f := newFixture(t, 0)
+	ctx := context.Background()
+	ac := controllers.NewAdminController(config.Default(), f.store, nil)
+	const adminID = 1000
+	breakfast := f.item.CategoryID
+
+	call := func(role string, h http.HandlerFunc, method, target, id, body string) *httptest.ResponseRecorder {
+		t.Helper()
+		req := httptest.NewRequest(method, target, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		if id != "" {
+			req = mux.SetURLVars(req, map[string]string{"id": id})
+		}
+		userID := f.buyer.ID
+		if role == "admin" {
+			userID = adminID
+		}
+		return serveAs(t, userID, role, h, req)
+	}
+	add := func(body string) int {
+		t.Helper()
+		rec := call("admin", ac.AddCategory, http.MethodPost, "/", "", body)
+		var got struct {
+			Category models.Category `json:"category"`
+		}
+		if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil || rec.Code != http.StatusOK {
+			t.Fatalf("AddCategory(%s) = %d %s", body, rec.Code, rec.Body)
+		}
+		return got.Category.ID
+	}
+	list := func(role, target string) []int {
+		t.Helper()
+		rec := call(role, f.orders.GetAllCategories, http.MethodGet, target, "", "")
+		var got struct {
+			Categories []models.Category `json:"categories"`
+		}
+		json.Unmarshal(rec.Body.Bytes(), &got)
+		var ids []int
+		for _, c := range got.Categories {
+			ids = append(ids, c.ID)
+		}
+		return ids
+	}
+	itemsIn := func(role string, id int, query string) (int, int) {
+		t.Helper()
+		rec := call(role, f.orders.GetItemsByCategoryID, http.MethodGet, "/"+query, strconv.Itoa(id), "")
+		var got struct {
+			Items []models.Item `json:"items"`
+		}
+		json.Unmarshal(rec.Body.Bytes(), &got)
+		return rec.Code, len(got.Items)
+	}
+	same := slices.Equal[[]int]
+
+	south := add(`{"name": "South Indian"}`)
+	dosas := add(`{"name": "Dosas", "parent_id": ` + strconv.Itoa(breakfast) + `}`)
+	hidden := add(`{"name": "Hidden", "parent_id": ` + strconv.Itoa(breakfast) + `, "is_active": false}`)
+	for _, it := range []*models.Item{
+		{SellerID: f.item.SellerID, Name: "Masala Dosa", Price: 30, CategoryID: dosas, Status: "available"},
+		{SellerID: f.item.SellerID, Name: "Secret", Price: 30, CategoryID: hidden, Status: "available"},
+	} {
+		if err := f.store.Items.Create(ctx, it); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if got := list("user", "/"); !same(got, []int{breakfast, dosas, south}) {
+		t.Errorf("customer listing = %v, want breakfast, dosas, south indian", got)
+	}
+	if got := list("admin", "/"); !same(got, []int{breakfast, dosas, hidden, south}) {
+		t.Errorf("admin listing = %v, want the hidden category too", got)
+	}
+	if code, n := itemsIn("user", breakfast, "?descendants=1"); code != http.StatusOK || n != 2 {
+		t.Errorf("breakfast with descendants for a customer = %d, %d items; want 2", code, n)
+	}
+	if _, n := itemsIn("admin", breakfast, "?descendants=1"); n != 3 {
+		t.Errorf("breakfast with descendants for an admin = %d items, want 3", n)
+	}
+	if _, n := itemsIn("user", breakfast, ""); n != 1 {
+		t.Errorf("breakfast alone = %d items, want 1", n)
+	}
+	if code, _ := itemsIn("user", hidden, ""); code != http.StatusNotFound {
+		t.Errorf("inactive category for a customer = %d, want 404", code)
+	}
+
+	order := func(parent int, ids ...int) int {
+		t.Helper()
+		raw, _ := json.Marshal(map[string]any{"parent_id": parent, "ids": ids})
+		return call("admin", ac.ReorderCategories, http.MethodPut, "/", "", string(raw)).Code
+	}
+	if code := order(0, south); code != http.StatusBadRequest {
+		t.Errorf("reordering without every sibling = %d, want 400", code)
+	}
+	if code := order(0, south, breakfast); code != http.StatusOK {
+		t.Fatalf("reorder = %d", code)
+	}
+	if got := list("user", "/?parent_id=0"); !same(got, []int{south, breakfast}) {
+		t.Errorf("top level after reorder = %v, want south indian, breakfast", got)
+	}
+
+	edit := func(id int, body string) *httptest.ResponseRecorder {
+		return call("admin", ac.EditCategory, http.MethodPut, "/", strconv.Itoa(id), body)
+	}
+	if rec := edit(breakfast, `{"name": "Breakfast", "parent_id": `+strconv.Itoa(dosas)+`}`); rec.Code != http.StatusBadRequest {
+		t.Errorf("moving a category under its own child = %d %s, want 400", rec.Code, rec.Body)
+	}
+	if rec := edit(dosas, `{"name": "Dosas", "parent_id": 0}`); rec.Code != http.StatusOK {
+		t.Fatalf("moving to the top level = %d %s", rec.Code, rec.Body)
+	}
+	if got := list("user", "/?parent_id=0"); !same(got, []int{south, breakfast, dosas}) {
+		t.Errorf("top level after the move = %v, want dosas last", got)
+	}
+	// A plain rename keeps the parent and the active flag.
+	if rec := edit(hidden, `{"name": "Still hidden"}`); rec.Code != http.StatusOK {
+		t.Fatalf("rename = %d %s", rec.Code, rec.Body)
+	}
+	if c, _ := f.store.Categories.GetByID(ctx, hidden); c.IsActive || c.ParentID == nil || *c.ParentID != breakfast {
+		t.Errorf("renamed category = %+v", c)
+	}
+
+	if rec := call("admin", ac.DeleteCategory, http.MethodDelete, "/", strconv.Itoa(breakfast), ""); rec.Code != http.StatusConflict {
+		t.Errorf("deleting a category with subcategories = %d %s, want 409", rec.Code, rec.Body)
+	}
+}
+
+func TestCategoryNamesAndListings(t *testing.T) {
+	f := newFixture(t, 0)
+	ctx := context.Background()
+	ac := controllers.NewAdminController(config.Default(), f.store, nil)
+	const adminID = 1000
+
+	call := func(h http.HandlerFunc, method, id, body string) *httptest.ResponseRecorder {
+		t.Helper()
+		req := httptest.NewRequest(method, "/", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		if id != "" {
+			req = mux.SetURLVars(req, map[string]string{"id": id})
+		}
+		return serveAs(t, adminID, "admin", h, req)
+	}
+	catalog := func() []int {
+		t.Helper()
+		items, err := f.store.Items.GetAll(ctx, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var ids []int
+		for _, i := range items {
+			ids = append(ids, i.ID)
+		}
+		return ids
+	}
+
+	rec := call(ac.AddCategory, http.MethodPost, "", `{"name": "Snacks"}`)
+	var got struct {
+		Category models.Category `json:"category"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil || rec.Code != http.StatusOK {
+		t.Fatalf("AddCategory = %d %s", rec.Code, rec.Body)
+	}
+	snacks := strconv.Itoa(got.Category.ID)
+	vada := &models.Item{SellerID: f.item.SellerID, Name: "Vada", Price: 10, CategoryID: got.Category.ID, Status: "available"}
+	if err := f.store.Items.Create(ctx, vada); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, body := range []string{`{"name": "breakfast"}`, `{"name": "Snacks"}`} {
+		if rec := call(ac.AddCategory, http.MethodPost, "", body); rec.Code != http.StatusConflict || !strings.Contains(rec.Body.String(), "already a category") {
+			t.Errorf("AddCategory(%s) = %d %s, want 409", body, rec.Code, rec.Body)
+		}
+	}
+	if rec := call(ac.EditCategory, http.MethodPut, snacks, `{"name": "Breakfast"}`); rec.Code != http.StatusConflict {
+		t.Errorf("renaming onto a live name = %d %s, want 409", rec.Code, rec.Body)
+	}
+
+	// Hidden from customers, but still the seller's to manage.
+	if rec := call(ac.EditCategory, http.MethodPut, snacks, `{"name": "Snacks", "is_active": false}`); rec.Code != http.StatusOK {
+		t.Fatalf("EditCategory = %d %s", rec.Code, rec.Body)
+	}
+	if ids := catalog(); slices.Contains(ids, vada.ID) || !slices.Contains(ids, f.item.ID) {
+		t.Errorf("catalog %v, want the vada in the inactive category left out", ids)
+	}
+	if mine, _ := f.store.Items.GetBySellerID(ctx, f.item.SellerID); len(mine) != 2 {
+		t.Errorf("seller's items = %d, want both", len(mine))
+	}
+
+	// A deleted category takes its items out of every customer listing and
+	// gives up its name. The seller still has them, flagged.
+	if err := f.store.Categories.Delete(ctx, got.Category.ID); err != nil {
+		t.Fatal(err)
+	}
+	if ids := catalog(); slices.Contains(ids, vada.ID) {
+		t.Errorf("catalog %v, want the vada in the deleted category left out", ids)
+	}
+	mine, _ := f.store.Items.GetBySellerID(ctx, f.item.SellerID)
+	if len(mine) != 2 || mine[0].CategoryDeleted || mine[1].ID != vada.ID || !mine[1].CategoryDeleted {
+		t.Errorf("seller's items %+v, want both with the vada's category flagged deleted", mine)
+	}
+	if items, _ := f.store.Items.GetByCategoryID(ctx, got.Category.ID); len(items) != 0 {
+		t.Errorf("items of the deleted category = %+v", items)
+	}
+	if rec := call(ac.AddCategory, http.MethodPost, "", `{"name": "Snacks"}`); rec.Code != http.StatusOK {
+		t.Fatalf("reusing a deleted category's name = %d %s", rec.Code, rec.Body)
+	}
+	if rec := call(ac.RestoreCategory, http.MethodPost, snacks, ``); rec.Code != http.StatusConflict {
+		t.Errorf("restoring onto a name taken since = %d %s, want 409", rec.Code, rec.Body)
+	}
+}
@@ -0,0 +1,154 @@
+package controllers
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/Entity069/Zesty-Go/pkg/models"
+	"github.com/Entity069/Zesty-Go/pkg/response"
+)
+
+// dietInput is the dietary information AddItem and UpdateItem take. Fields
+// left out keep the item's current value; an empty list or nutrition object
+// clears it.
+type dietInput struct {
+	DietTags   models.Tags
+	Allergens  models.Tags
+	SpiceLevel *int
+	Nutrition  *models.Nutrition
+}
+
+// check reports tags outside the vocabularies.
+func (d dietInput) check() error {
+	var fields []response.FieldError
+	if tag := unknownTag(d.DietTags, models.DietTags); tag != "" {
+		fields = append(fields, response.FieldError{Field: "diet_tags", Message: unknownTagMessage(tag, models.DietTags)})
+	}
+	if tag := unknownTag(d.Allergens, models.Allergens); tag != "" {
+		fields = append(fields, response.FieldError{Field: "allergens", Message: unknownTagMessage(tag, models.Allergens)})
+	}
+	if len(fields) > 0 {
+		return response.Validation(fields...)
+	}
+	return nil
+}
+
+// apply copies what was sent onto the item.
+func (d dietInput) apply(item *models.Item) {
+	if d.DietTags != nil {
+		item.DietTags = models.NewTags(d.DietTags...)
+	}
+	if d.Allergens != nil {
+		item.Allergens = models.NewTags(d.Allergens...)
+	}
+	if d.SpiceLevel != nil {
+		item.SpiceLevel = *d.SpiceLevel
+	}
+	if d.Nutrition != nil {
+		item.Nutrition = d.Nutrition
+		if *d.Nutrition == (models.Nutrition{}) {
+			item.Nutrition = nil
+		}
+	}
+}
+
+func unknownTag(tags, vocabulary []string) string {
+	for _, tag := range tags {
+		if !slices.Contains(vocabulary, tag) {
+			return tag
+		}
+	}
+	return ""
+}
+
+func unknownTagMessage(tag string, vocabulary []string) string {
+	return strconv.Quote(tag) + " is not one of: " + strings.Join(vocabulary, ", ")
+}
+
+// dietFilter reads the dietary filters an item listing takes:
+// ?diet=vegan,gluten_free for items with all those tags,
+// ?exclude_allergens=peanuts,milk for items with none of them, and
+// ?max_spice= and ?max_calories= caps.
+func dietFilter(r *http.Request) (models.DietFilter, error) {
+	q := r.URL.Query()
+	f := models.DietFilter{
+		Diet:             queryTags(q.Get("diet")),
+		WithoutAllergens: queryTags(q.Get("exclude_allergens")),
+	}
+
+	var fields []response.FieldError
+	if tag := unknownTag(f.Diet, models.DietTags); tag != "" {
+		fields = append(fields, response.FieldError{Field: "diet", Message: unknownTagMessage(tag, models.DietTags)})
+	}
+	if tag := unknownTag(f.WithoutAllergens, models.Allergens); tag != "" {
+		fields = append(fields, response.FieldError{Field: "exclude_allergens", Message: unknownTagMessage(tag, models.Allergens)})
+	}
+	for _, p := range []struct {
+		name string
+		dst  **int
+	}{{"max_spice", &f.MaxSpice}, {"max_calories", &f.MaxCalories}} {
+		raw := q.Get(p.name)
+		if raw == "" {
+			continue
+		}
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 0 {
+			fields = append(fields, response.FieldError{Field: p.name, Message: "must be a whole number, 0 or more"})
+			continue
+		}
+		*p.dst = &n
+	}
+	if len(fields) > 0 {
+		return f, response.Validation(fields...)
+	}
+	return f, nil
+}
+
+func queryTags(raw string) models.Tags {
+	var tags models.Tags
+	for _, tag := range strings.Split(raw, ",") {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
+// allergenWarning names an item in the cart that contains allergens the
+// user flagged.
+type allergenWarning struct {
+	ItemID    int         `json:"item_id"`
+	Name      string      `json:"name"`
+	Allergens models.Tags `json:"allergens"`
+}
+
+// allergenWarnings lists the items, of those given, that contain any of the
+// user's flagged allergens. Items that are no longer sold are skipped.
+func (oc *OrderController) allergenWarnings(ctx context.Context, userID int, itemIDs ...int) ([]allergenWarning, error) {
+	warnings := []allergenWarning{}
+	user, err := oc.store.Users.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if len(user.Allergens) == 0 {
+		return warnings, nil
+	}
+	for _, id := range itemIDs {
+		item, err := oc.store.Items.GetByID(ctx, id)
+		if errors.Is(err, sql.ErrNoRows) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if found := item.Allergens.Intersect(user.Allergens); len(found) > 0 {
+			warnings = append(warnings, allergenWarning{ItemID: item.ID, Name: item.Name, Allergens: found})
+		}
+	}
+	return warnings, nil
+}
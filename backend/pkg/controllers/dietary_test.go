@@ -0,0 +1,127 @@
+package controllers_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+
+	"github.com/Entity069/Zesty-Go/pkg/config"
+	"github.com/Entity069/Zesty-Go/pkg/controllers"
+	"github.com/Entity069/Zesty-Go/pkg/models"
+)
+
+func TestDietaryInformation(t *testing.T) {
+	f := newFixture(t, 0)
+	ctx := context.Background()
+	sc := controllers.NewSellerController(config.Default(), f.store, nil, nil)
+	uc := controllers.NewUserController(config.Default(), f.store, nil)
+	fields := func(extra map[string]string) map[string]string {
+		m := map[string]string{"name": "Satay", "description": "Grilled", "price": "60", "category": strconv.Itoa(f.item.CategoryID), "status": "available"}
+		for k, v := range extra {
+			m[k] = v
+		}
+		return m
+	}
+
+	if rec := f.postItem(t, sc.AddItem, fields(map[string]string{"diet_tags": "vegan,paleo"}), "", nil); rec.Code != http.StatusBadRequest {
+		t.Fatalf("unknown diet tag = %d %s, want 400", rec.Code, rec.Body)
+	}
+	if rec := f.postItem(t, sc.AddItem, fields(map[string]string{"nutrition": `{"calories": -5}`}), "", nil); rec.Code != http.StatusBadRequest {
+		t.Fatalf("negative calories = %d %s, want 400", rec.Code, rec.Body)
+	}
+	rec := f.postItem(t, sc.AddItem, fields(map[string]string{
+		"diet_tags":   "vegan, gluten_free",
+		"allergens":   "peanuts,soy",
+		"spice_level": "2",
+		"nutrition":   `{"calories": 420, "protein_g": 18}`,
+	}), "", nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("AddItem = %d %s", rec.Code, rec.Body)
+	}
+	items, _ := f.store.Items.GetBySellerID(ctx, f.item.SellerID)
+	satay := items[len(items)-1]
+	if strings.Join(satay.DietTags, ",") != "gluten_free,vegan" || strings.Join(satay.Allergens, ",") != "peanuts,soy" ||
+		satay.SpiceLevel != 2 || satay.Nutrition == nil || *satay.Nutrition.Calories != 420 {
+		t.Fatalf("stored %+v", satay)
+	}
+
+	// An edit that leaves the dietary fields out keeps them.
+	edit := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPut, "/", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req = mux.SetURLVars(req, map[string]string{"id": strconv.Itoa(satay.ID)})
+		return f.asSeller(t, sc.UpdateItem, req)
+	}
+	base := `"name": "Satay", "description": "Grilled", "price": 65, "category": ` + strconv.Itoa(f.item.CategoryID) + `, "status": "available"`
+	if rec := edit(`{` + base + `}`); rec.Code != http.StatusOK {
+		t.Fatalf("UpdateItem = %d %s", rec.Code, rec.Body)
+	}
+	if got, _ := f.store.Items.GetByID(ctx, satay.ID); len(got.Allergens) != 2 || got.Nutrition == nil {
+		t.Fatalf("after a plain edit %+v, want the dietary fields kept", got)
+	}
+	if rec := edit(`{` + base + `, "spice_level": 4}`); rec.Code != http.StatusBadRequest {
+		t.Fatalf("spice level 4 = %d %s, want 400", rec.Code, rec.Body)
+	}
+
+	list := func(query string) (int, []int) {
+		t.Helper()
+		rec := as(t, f.buyer.ID, "user", f.orders.GetAllItems, http.MethodGet, "/"+query, "")
+		var got struct {
+			Items []models.Item `json:"items"`
+		}
+		json.Unmarshal(rec.Body.Bytes(), &got)
+		var ids []int
+		for _, it := range got.Items {
+			ids = append(ids, it.ID)
+		}
+		return rec.Code, ids
+	}
+	for query, want := range map[string]int{
+		"?diet=vegan":                     1,
+		"?diet=vegan,halal":               0,
+		"?exclude_allergens=peanuts":      1,
+		"?max_spice=1":                    1,
+		"?max_calories=500":               1,
+		"?max_calories=400":               0,
+		"?exclude_allergens=milk&limit=1": 1,
+	} {
+		if code, ids := list(query); code != http.StatusOK || len(ids) != want {
+			t.Errorf("items%s = %d %v, want %d items", query, code, ids, want)
+		}
+	}
+	if code, _ := list("?exclude_allergens=dust"); code != http.StatusBadRequest {
+		t.Errorf("unknown allergen filter = %d, want 400", code)
+	}
+
+	// The cart warns about allergens the buyer flagged.
+	if rec := f.do(t, uc.UpdateUserAllergens, `{"allergens": ["gluten"]}`); rec.Code != http.StatusOK {
+		t.Fatalf("UpdateUserAllergens = %d %s", rec.Code, rec.Body)
+	}
+	if rec := f.do(t, uc.UpdateUserAllergens, `{"allergens": ["peanuts", "pollen"]}`); rec.Code != http.StatusBadRequest {
+		t.Fatalf("unknown allergen = %d %s, want 400", rec.Code, rec.Body)
+	}
+	f.do(t, uc.UpdateUserAllergens, `{"allergens": ["soy", "peanuts"]}`)
+	warnings := func(rec *httptest.ResponseRecorder) []map[string]any {
+		var got struct {
+			Warnings []map[string]any `json:"allergen_warnings"`
+		}
+		json.Unmarshal(rec.Body.Bytes(), &got)
+		return got.Warnings
+	}
+	if w := warnings(f.do(t, f.orders.AddToCart, `{"itemId": `+strconv.Itoa(f.item.ID)+`, "quantity": 1}`)); len(w) != 0 {
+		t.Errorf("adding the dosa warned %v", w)
+	}
+	if w := warnings(f.do(t, f.orders.AddToCart, `{"itemId": `+strconv.Itoa(satay.ID)+`, "quantity": 1}`)); len(w) != 1 {
+		t.Errorf("adding the satay warned %v, want one warning", w)
+	}
+	w := warnings(f.do(t, f.orders.GetUserCart, ``))
+	if len(w) != 1 || w[0]["name"] != "Satay" || len(w[0]["allergens"].([]any)) != 2 {
+		t.Errorf("cart warnings = %v, want the satay's peanuts and soy", w)
+	}
+}
@@ -0,0 +1,172 @@
+package controllers
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+
+	"github.com/Entity069/Zesty-Go/pkg/bind"
+	"github.com/Entity069/Zesty-Go/pkg/middleware"
+	"github.com/Entity069/Zesty-Go/pkg/models"
+	"github.com/Entity069/Zesty-Go/pkg/response"
+)
+
+// menuBody is what AddMenu and UpdateMenu take. A menu needs at least one
+// window; an item that should always be available can just leave its menu.
+type menuBody struct {
+	ID       int             `json:"-" path:"id"`
+	Name     string          `json:"name" validate:"required,max=255"`
+	Schedule models.Schedule `json:"schedule"`
+}
+
+func (b menuBody) check() error {
+	if len(b.Schedule) == 0 {
+		return response.Validation(response.FieldError{Field: "schedule", Message: "needs at least one window"})
+	}
+	if err := b.Schedule.Validate(); err != nil {
+		return response.Validation(response.FieldError{Field: "schedule", Message: err.Error()})
+	}
+	return nil
+}
+
+// GetMenus lists the seller's menus by name.
+func (sc *SellerController) GetMenus(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserClaims(r)
+	if !ok {
+		response.Fail(w, r, response.Unauthenticated("Please log in to continue."))
+		return
+	}
+
+	menus, err := sc.store.Menus.GetBySellerID(r.Context(), claims.ID)
+	if err != nil {
+		response.Fail(w, r, response.Internal("Failed to fetch menus", err))
+		return
+	}
+	if menus == nil {
+		menus = []*models.Menu{}
+	}
+	response.OK(w, "Menus fetched successfully.", response.Data{"menus": menus})
+}
+
+// AddMenu creates a menu. Items join it through AddItem and UpdateItem.
+func (sc *SellerController) AddMenu(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserClaims(r)
+	if !ok {
+		response.Fail(w, r, response.Unauthenticated("Please log in to continue."))
+		return
+	}
+
+	var body menuBody
+	if err := bind.JSON(w, r, &body); err != nil {
+		response.Fail(w, r, err)
+		return
+	}
+	if err := body.check(); err != nil {
+		response.Fail(w, r, err)
+		return
+	}
+	if err := sc.menuNameFree(r, claims.ID, 0, body.Name); err != nil {
+		response.Fail(w, r, err)
+		return
+	}
+
+	menu := &models.Menu{SellerID: claims.ID, Name: body.Name, Schedule: body.Schedule}
+	if err := sc.store.Menus.Create(r.Context(), menu); err != nil {
+		response.Fail(w, r, response.Internal("Failed to add menu", err))
+		return
+	}
+	response.Created(w, "Menu added.", response.Data{"menu": menu})
+}
+
+// UpdateMenu renames a menu and replaces its schedule, for every item on
+// it at once.
+func (sc *SellerController) UpdateMenu(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserClaims(r)
+	if !ok {
+		response.Fail(w, r, response.Unauthenticated("Please log in to continue."))
+		return
+	}
+
+	var body menuBody
+	if err := bind.JSON(w, r, &body); err != nil {
+		response.Fail(w, r, err)
+		return
+	}
+	if err := body.check(); err != nil {
+		response.Fail(w, r, err)
+		return
+	}
+	menu, err := sc.ownMenu(r, claims.ID, body.ID)
+	if err != nil {
+		response.Fail(w, r, err)
+		return
+	}
+	if err := sc.menuNameFree(r, claims.ID, menu.ID, body.Name); err != nil {
+		response.Fail(w, r, err)
+		return
+	}
+
+	menu.Name = body.Name
+	menu.Schedule = body.Schedule
+	if err := sc.store.Menus.Update(r.Context(), menu); err != nil {
+		response.Fail(w, r, response.Internal("Failed to update menu", err))
+		return
+	}
+	response.OK(w, "Menu updated.", response.Data{"menu": menu})
+}
+
+// DeleteMenu removes a menu. Its items stay, available at any time unless
+// they have a schedule of their own.
+func (sc *SellerController) DeleteMenu(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserClaims(r)
+	if !ok {
+		response.Fail(w, r, response.Unauthenticated("Please log in to continue."))
+		return
+	}
+
+	var body struct {
+		ID int `json:"-" path:"id" validate:"required"`
+	}
+	if err := bind.JSON(w, r, &body); err != nil {
+		response.Fail(w, r, err)
+		return
+	}
+	menu, err := sc.ownMenu(r, claims.ID, body.ID)
+	if err != nil {
+		response.Fail(w, r, err)
+		return
+	}
+	if err := sc.store.Menus.Delete(r.Context(), menu.ID); err != nil {
+		response.Fail(w, r, response.Internal("Failed to delete menu", err))
+		return
+	}
+	response.OK(w, "Menu deleted.", nil)
+}
+
+// ownMenu fetches one of the seller's menus. Other sellers' menus are
+// reported as missing.
+func (sc *SellerController) ownMenu(r *http.Request, sellerID, id int) (*models.Menu, error) {
+	menu, err := sc.store.Menus.GetByID(r.Context(), id)
+	if errors.Is(err, sql.ErrNoRows) || err == nil && menu.SellerID != sellerID {
+		return nil, response.NotFound("Menu not found.")
+	}
+	if err != nil {
+		return nil, response.Internal("Failed to fetch the menu", err)
+	}
+	return menu, nil
+}
+
+// menuNameFree reports a conflict if another of the seller's menus, other
+// than the one with exceptID, already has the name.
+func (sc *SellerController) menuNameFree(r *http.Request, sellerID, exceptID int, name string) error {
+	menus, err := sc.store.Menus.GetBySellerID(r.Context(), sellerID)
+	if err != nil {
+		return response.Internal("Failed to fetch menus", err)
+	}
+	for _, m := range menus {
+		if m.ID != exceptID && m.Name == name {
+			return response.Conflict(response.CodeConflict, "You already have a menu called "+name+".")
+		}
+	}
+	return nil
+}
@@ -2,407 +2,785 @@ package controllers
 
 import (
 	"database/sql"
-	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
+	"github.com/Entity069/Zesty-Go/pkg/bind"
+	"github.com/Entity069/Zesty-Go/pkg/config"
+	"github.com/Entity069/Zesty-Go/pkg/metrics"
 	"github.com/Entity069/Zesty-Go/pkg/middleware"
 	"github.com/Entity069/Zesty-Go/pkg/models"
+	"github.com/Entity069/Zesty-Go/pkg/response"
 	"github.com/gorilla/mux"
 )
 
-type OrderController struct{}
+type OrderController struct {
+	store *models.Store
+	// loc is the shop's time zone, which item schedules are read in.
+	loc *time.Location
+	// slots are what a scheduled order can be placed for.
+	slots slots
+}
 
-func NewOrderController() *OrderController {
-	return &OrderController{}
+func NewOrderController(cfg *config.Config, store *models.Store) *OrderController {
+	loc := cfg.Shop.Location()
+	return &OrderController{store: store, loc: loc, slots: newSlots(cfg.Shop.Preorders, loc)}
 }
 
-func (oc *OrderController) jsonResp(w http.ResponseWriter, status int, payload any) {
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(status)
-	_ = json.NewEncoder(w).Encode(payload)
+// maxCartQuantity is the most of one item a cart line holds. The max=99 in
+// the quantity validate tags below must match it.
+const maxCartQuantity = 99
+
+// tooMany is the error for a change that would take a cart line past
+// maxCartQuantity.
+func tooMany() error {
+	return response.Conflict(response.CodeInvalidState, fmt.Sprintf("A cart holds at most %d of each item.", maxCartQuantity))
 }
 
 func (oc *OrderController) AddToCart(w http.ResponseWriter, r *http.Request) {
 	claims, ok := middleware.GetUserClaims(r)
 	if !ok {
-		oc.jsonResp(w, http.StatusUnauthorized, map[string]any{"success": false, "msg": "Unauthorized"})
+		response.Fail(w, r, response.Unauthenticated("Please log in to continue."))
 		return
 	}
 
 	userID := claims.ID
 
 	type reqBody struct {
-		ItemID   int `json:"itemId"`
-		Quantity int `json:"quantity"`
+		ItemID   int `json:"itemId" validate:"required,min=1"`
+		Quantity int `json:"quantity" validate:"required,min=1,max=99"`
 	}
 	var body reqBody
-	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
-		oc.jsonResp(w, http.StatusBadRequest, map[string]any{"success": false, "msg": "Invalid JSON"})
+	if err := bind.JSON(w, r, &body); err != nil {
+		response.Fail(w, r, err)
+		return
+	}
+
+	item, err := oc.store.Items.GetByID(r.Context(), body.ItemID)
+	if err != nil {
+		response.Fail(w, r, response.NotFound("Item not found."))
+		return
+	}
+	if !item.AvailableAt(shopNow(oc.loc)) {
+		response.Fail(w, r, notAvailable(item))
 		return
 	}
 
-	_, err := models.CreateOrGetCart(userID)
+	cart, err := oc.store.Orders.CreateOrGetCart(r.Context(), userID)
 	if err != nil {
-		oc.jsonResp(w, http.StatusInternalServerError, map[string]any{"success": false, "msg": "Cart creation failed"})
+		response.Fail(w, r, response.Internal("Cart creation failed", err))
 		return
 	}
+	for _, line := range cart.Items {
+		if line.ItemID == body.ItemID && line.Quantity+body.Quantity > maxCartQuantity {
+			response.Fail(w, r, tooMany())
+			return
+		}
+	}
 
-	_, err = models.AddItemToCart(userID, body.ItemID, body.Quantity)
+	_, err = oc.store.Orders.AddItemToCart(r.Context(), userID, body.ItemID, body.Quantity)
 	if err != nil {
-		fmt.Println("Error adding item to cart:", body.ItemID, "Quantity:", body.Quantity, "Error:", err)
-		oc.jsonResp(w, http.StatusInternalServerError, map[string]any{"success": false, "msg": "Failed to add item to cart"})
+		response.Fail(w, r, response.Internal("Failed to add item to cart", err))
 		return
 	}
 
-	oc.jsonResp(w, http.StatusOK, map[string]any{"success": true, "msg": "Item added to cart."})
+	warnings, err := oc.allergenWarnings(r.Context(), userID, body.ItemID)
+	if err != nil {
+		response.Fail(w, r, response.Internal("Failed to check allergens", err))
+		return
+	}
+	message := "Item added to cart."
+	if len(warnings) > 0 {
+		message = "Item added to cart. It contains allergens you flagged: " + strings.Join(warnings[0].Allergens, ", ") + "."
+	}
+	response.OK(w, message, response.Data{"allergen_warnings": warnings})
 }
 
 func (oc *OrderController) PlaceOrder(w http.ResponseWriter, r *http.Request) {
 	claims, ok := middleware.GetUserClaims(r)
 	if !ok {
-		oc.jsonResp(w, http.StatusUnauthorized, map[string]any{"success": false, "msg": "Unauthorized"})
+		response.Fail(w, r, response.Unauthenticated("Please log in to continue."))
 		return
 	}
 
 	userID := claims.ID
 
-	cart, err := models.GetCartByUserID(userID)
-	if err != nil {
-		oc.jsonResp(w, http.StatusBadRequest, map[string]any{"success": false, "msg": "No active cart found"})
-		return
+	// An order with deliver_at is scheduled for that slot and held from the
+	// sellers until its lead time; without, it goes to them straight away.
+	var body struct {
+		DeliverAt *time.Time `json:"deliver_at"`
 	}
-
-	// change status to "ordered" of all order_items in the cart
-	if len(cart.Items) == 0 {
-		oc.jsonResp(w, http.StatusBadRequest, map[string]any{"success": false, "msg": "Your cart is empty"})
+	if err := bind.JSON(w, r, &body); err != nil {
+		response.Fail(w, r, err)
 		return
 	}
-
-	for _, item := range cart.Items {
-		if err := item.UpdateStatus("ordered"); err != nil {
-			oc.jsonResp(w, http.StatusInternalServerError, map[string]any{"success": false, "msg": "Failed to update item status"})
+	now := shopNow(oc.loc)
+	if body.DeliverAt != nil {
+		if problem := oc.slots.check(*body.DeliverAt, now); problem != "" {
+			response.Fail(w, r, slotError(problem))
 			return
 		}
 	}
 
-	total, err := models.CalculateCartTotal(cart.ID)
+	cart, err := oc.store.Orders.GetCartByUserID(r.Context(), userID)
 	if err != nil {
-		oc.jsonResp(w, http.StatusInternalServerError, map[string]any{"success": false, "msg": "Failed to calculate total"})
+		response.Fail(w, r, response.BadRequest("No active cart found"))
 		return
 	}
 
-	user, err := models.GetUserByID(userID)
-	if err != nil {
-		oc.jsonResp(w, http.StatusInternalServerError, map[string]any{"success": false, "msg": "Failed to get user info"})
-		return
-	}
+	if len(cart.Items) == 0 {
+		response.Fail(w, r, response.BadRequest("Your cart is empty"))
+		return
+	}
+
+	// everything below either happens together or not at all, so a failed
+	// balance update can't leave the cart half-ordered
+	ctx := r.Context()
+	var total float64
+	lineStatus := "ordered"
+	if body.DeliverAt != nil {
+		lineStatus = "scheduled"
+	}
+	// a scheduled order's items have to be sold at the slot, not now
+	availableAt := now
+	if body.DeliverAt != nil {
+		availableAt = body.DeliverAt.In(oc.loc)
+	}
+	// Two orders for the same empty slot both lock the gap where its rows
+	// would go, and one of them then deadlocks moving its order into it.
+	// Run again, it counts the other's order, so it is retried.
+	err = oc.store.RetryTx(ctx, 3, func(tx *models.Store) error {
+		// an item deleted since it was added to the cart can't be ordered,
+		// nor one whose schedule has closed since
+		for _, line := range cart.Items {
+			item, err := tx.Items.GetByID(ctx, line.ItemID)
+			if err != nil {
+				if errors.Is(err, sql.ErrNoRows) {
+					return response.Conflict(response.CodeInvalidState,
+						fmt.Sprintf("Item %d in your cart is no longer sold. Remove it to continue.", line.ItemID))
+				}
+				return response.Internal("Failed to fetch item", err)
+			}
+			if !item.AvailableAt(availableAt) {
+				if body.DeliverAt != nil {
+					return notAvailableAt(item, availableAt)
+				}
+				return notAvailable(item)
+			}
+		}
 
-	if user.Balance < total {
-		oc.jsonResp(w, http.StatusBadRequest, map[string]any{"success": false, "msg": "Insufficient balance"})
-		return
-	}
+		// counting the slot's orders locks them, so the last place in it
+		// can't be taken twice
+		if at := body.DeliverAt; at != nil {
+			counts, err := tx.Orders.LockSlotCounts(ctx, *at, at.Add(time.Second))
+			if err != nil {
+				return response.Internal("Failed to count booked orders", err)
+			}
+			if oc.slots.full(counts[at.Unix()]) {
+				return slotTaken(at.In(oc.loc))
+			}
+		}
+
+		// change status of all order_items in the cart to "ordered", or
+		// "scheduled" until the order is released
+		for i := range cart.Items {
+			if err := tx.OrderItems.UpdateStatus(ctx, &cart.Items[i], lineStatus); err != nil {
+				return response.Internal("Failed to update item status", err)
+			}
+		}
 
-	if err := user.UpdateBalance(user.Balance - total); err != nil {
-		oc.jsonResp(w, http.StatusInternalServerError, map[string]any{"success": false, "msg": "Failed to update balance"})
+		var err error
+		total, err = tx.Orders.CalculateCartTotal(ctx, cart.ID)
+		if err != nil {
+			return response.Internal("Failed to calculate total", err)
+		}
+
+		user, err := tx.Users.GetByID(ctx, userID)
+		if err != nil {
+			return response.Internal("Failed to get user info", err)
+		}
+
+		if user.Balance < total {
+			return response.New(http.StatusBadRequest, response.CodeInsufficientBalance, "Insufficient balance")
+		}
+
+		if err := tx.Users.AddBalance(ctx, user, -total); err != nil {
+			return response.Internal("Failed to update balance", err)
+		}
+
+		if body.DeliverAt != nil {
+			err = tx.Orders.Schedule(ctx, cart, *body.DeliverAt)
+		} else {
+			err = tx.Orders.UpdateStatus(ctx, cart, "ordered")
+		}
+		if err != nil {
+			return response.Internal("Order failed", err)
+		}
+		return nil
+	})
+	if err != nil {
+		response.Fail(w, r, err)
 		return
 	}
+	metrics.OrdersPlaced.Inc()
+	metrics.Revenue.Add(total)
 
-	if err := cart.UpdateStatus("ordered"); err != nil {
-		oc.jsonResp(w, http.StatusInternalServerError, map[string]any{"success": false, "msg": "Order failed"})
+	if body.DeliverAt != nil {
+		response.OK(w, "Your order is scheduled for "+body.DeliverAt.In(oc.loc).Format("Mon 2 Jan 15:04")+".", nil)
 		return
 	}
-
-	oc.jsonResp(w, http.StatusOK, map[string]any{"success": true, "msg": "Your order was placed."})
+	response.OK(w, "Your order was placed.", nil)
 }
 
 func (oc *OrderController) CancelOrder(w http.ResponseWriter, r *http.Request) {
 	type reqBody struct {
-		OrderID int `json:"orderId"`
+		OrderID int `json:"orderId" path:"id" validate:"required"`
 	}
 	var body reqBody
-	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
-		oc.jsonResp(w, http.StatusBadRequest, map[string]any{"success": false, "msg": "Invalid JSON"})
+	if err := bind.JSON(w, r, &body); err != nil {
+		response.Fail(w, r, err)
 		return
 	}
 
-	order, err := models.GetOrderByID(body.OrderID)
-	if err != nil {
-		oc.jsonResp(w, http.StatusNotFound, map[string]any{"success": false, "msg": "Order not found"})
+	claims, ok := middleware.GetUserClaims(r)
+	if !ok {
+		response.Fail(w, r, response.Unauthenticated("Please log in to continue."))
 		return
 	}
 
-	if order.Status != "ordered" {
-		oc.jsonResp(w, http.StatusBadRequest, map[string]any{"success": false, "msg": "This order cannot be cancelled!"})
+	// Admins can cancel any order; customers only their own, and someone
+	// else's order looks the same as one that doesn't exist.
+	order, err := oc.store.Orders.GetByID(r.Context(), body.OrderID)
+	if err != nil || order == nil || claims.Role != "admin" && order.UserID != claims.ID {
+		response.Fail(w, r, response.NotFound("Order not found"))
 		return
 	}
 
-	if err := order.Cancel(); err != nil {
-		oc.jsonResp(w, http.StatusInternalServerError, map[string]any{"success": false, "msg": "Cancellation failed"})
+	// A scheduled order can be cancelled until it is released, and then
+	// like any other until the seller starts on it.
+	if order.Status != "ordered" && order.Status != "scheduled" {
+		response.Fail(w, r, response.Conflict(response.CodeInvalidState, "This order can no longer be cancelled."))
 		return
 	}
 
-	user, err := models.GetUserByID(order.UserID)
+	ctx := r.Context()
+	err = oc.store.WithTx(ctx, func(tx *models.Store) error {
+		// Cancel only changes the order if it is still as read above, so
+		// of two cancellations racing each other only one refunds.
+		if err := tx.Orders.Cancel(ctx, order); errors.Is(err, sql.ErrNoRows) {
+			return response.Conflict(response.CodeInvalidState, "This order can no longer be cancelled.")
+		} else if err != nil {
+			return response.Internal("Cancellation failed", err)
+		}
+
+		user := &models.User{ID: order.UserID}
+		if err := tx.Users.AddBalance(ctx, user, order.TotalAmount); err != nil {
+			return response.Internal("Failed to refund", err)
+		}
+
+		txn := &models.WalletTransaction{
+			UserID:       user.ID,
+			Amount:       order.TotalAmount,
+			BalanceAfter: user.Balance,
+			Reason:       fmt.Sprintf("refund for order #%d", order.ID),
+		}
+		if err := tx.Wallet.Create(ctx, txn); err != nil {
+			return response.Internal("Failed to record the refund", err)
+		}
+		return nil
+	})
 	if err != nil {
-		oc.jsonResp(w, http.StatusInternalServerError, map[string]any{"success": false, "msg": "Internal server error!"})
+		response.Fail(w, r, err)
 		return
 	}
+	metrics.OrdersCancelled.Inc()
+	metrics.Refunds.Add(order.TotalAmount)
 
-	if err := user.UpdateBalance(user.Balance + order.TotalAmount); err != nil {
-		oc.jsonResp(w, http.StatusInternalServerError, map[string]any{"success": false, "msg": "Failed to refund"})
-		return
-	}
-	oc.jsonResp(w, http.StatusOK, map[string]any{"success": true, "msg": "Your order was cancelled. AND you WILL be refunded."})
+	response.OK(w, "Your order was cancelled and the amount refunded to your balance.", nil)
 }
 
 func (oc *OrderController) GetUserOrders(w http.ResponseWriter, r *http.Request) {
 	claims, ok := middleware.GetUserClaims(r)
 	if !ok {
-		oc.jsonResp(w, http.StatusUnauthorized, map[string]any{"success": false, "msg": "Unauthorized"})
+		response.Fail(w, r, response.Unauthenticated("Please log in to continue."))
 		return
 	}
 
 	userID := claims.ID
 
-	orders, err := models.GetOrdersByUserID(userID, 0)
+	limit, err := limitParam(r)
+	if err != nil {
+		response.Fail(w, r, err)
+		return
+	}
+
+	orders, err := oc.store.Orders.GetByUserID(r.Context(), userID, limit)
 	if err != nil {
-		oc.jsonResp(w, http.StatusInternalServerError, map[string]any{"success": false, "msg": "Failed to fetch orders"})
+		response.Fail(w, r, response.Internal("Failed to fetch orders", err))
 		return
 	}
 
-	oc.jsonResp(w, http.StatusOK, map[string]any{"success": true, "msg": "Orders fetched successfully", "orders": orders})
+	response.OK(w, "Orders fetched successfully", response.Data{"orders": orders})
 }
 
 func (oc *OrderController) GetUserCart(w http.ResponseWriter, r *http.Request) {
 	claims, ok := middleware.GetUserClaims(r)
 	if !ok {
-		oc.jsonResp(w, http.StatusUnauthorized, map[string]any{"success": false, "msg": "Unauthorized"})
+		response.Fail(w, r, response.Unauthenticated("Please log in to continue."))
 		return
 	}
 
 	userID := claims.ID
 
-	cart, err := models.GetCartByUserID(userID)
+	cart, err := oc.store.Orders.GetCartByUserID(r.Context(), userID)
 	if err != nil {
 		if err == sql.ErrNoRows {
-			oc.jsonResp(w, http.StatusOK, map[string]any{"success": true, "msg": "No active cart", "cart": map[string]any{"id": 0, "total_amount": 0, "items": []any{}, "status": "empty"}})
+			response.OK(w, "No active cart", response.Data{
+				"cart":              map[string]any{"id": 0, "total_amount": 0, "items": []any{}, "status": "empty"},
+				"allergen_warnings": []allergenWarning{},
+			})
 			return
 		}
-		oc.jsonResp(w, http.StatusInternalServerError, map[string]any{"success": false, "msg": "Failed to fetch cart"})
+		response.Fail(w, r, response.Internal("Failed to fetch cart", err))
 		return
 	}
 
-	oc.jsonResp(w, http.StatusOK, map[string]any{"success": true, "msg": "Cart fetched successfully", "cart": cart})
+	itemIDs := make([]int, len(cart.Items))
+	for i, line := range cart.Items {
+		itemIDs[i] = line.ItemID
+	}
+	warnings, err := oc.allergenWarnings(r.Context(), userID, itemIDs...)
+	if err != nil {
+		response.Fail(w, r, response.Internal("Failed to check allergens", err))
+		return
+	}
+
+	response.OK(w, "Cart fetched successfully", response.Data{"cart": cart, "allergen_warnings": warnings})
 }
 
 func (oc *OrderController) UpdateOrderItemCount(w http.ResponseWriter, r *http.Request) {
 	claims, ok := middleware.GetUserClaims(r)
 	if !ok {
-		oc.jsonResp(w, http.StatusUnauthorized, map[string]any{"success": false, "msg": "Unauthorized"})
+		response.Fail(w, r, response.Unauthenticated("Please log in to continue."))
 		return
 	}
 	userID := claims.ID
 
 	type reqBody struct {
-		ItemID int    `json:"itemId"`
-		Action string `json:"action"`
+		ItemID int    `json:"itemId" validate:"required"`
+		Action string `json:"action" validate:"required,oneof=increase decrease"`
 	}
 	var body reqBody
-	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
-		oc.jsonResp(w, http.StatusBadRequest, map[string]any{"success": false, "msg": "Invalid JSON"})
-		return
-	}
-	if body.ItemID == 0 || (body.Action != "increase" && body.Action != "decrease") {
-		oc.jsonResp(w, http.StatusBadRequest, map[string]any{"success": false, "msg": "Bad json data"})
+	if err := bind.JSON(w, r, &body); err != nil {
+		response.Fail(w, r, err)
 		return
 	}
 
-	cart, err := models.CreateOrGetCart(userID)
+	cart, err := oc.store.Orders.CreateOrGetCart(r.Context(), userID)
 	if err != nil {
-		oc.jsonResp(w, http.StatusInternalServerError, map[string]any{"success": false, "msg": "Cart error"})
+		response.Fail(w, r, response.Internal("Cart error", err))
 		return
 	}
 
 	switch body.Action {
 	case "increase":
-		if err := models.IncrementCartItem(cart.ID, body.ItemID, 1); err != nil {
-			oc.jsonResp(w, http.StatusInternalServerError, map[string]any{"success": false, "msg": "Update failed"})
+		for _, line := range cart.Items {
+			if line.ItemID == body.ItemID && line.Quantity >= maxCartQuantity {
+				response.Fail(w, r, tooMany())
+				return
+			}
+		}
+		if err := oc.store.Orders.IncrementCartItem(r.Context(), cart.ID, body.ItemID, 1); err != nil {
+			response.Fail(w, r, response.Internal("Update failed", err))
 			return
 		}
 	case "decrease":
-		if err := models.DecrementCartItem(cart.ID, body.ItemID, 1); err != nil {
-			oc.jsonResp(w, http.StatusInternalServerError, map[string]any{"success": false, "msg": "Update failed"})
+		if err := oc.store.Orders.DecrementCartItem(r.Context(), cart.ID, body.ItemID, 1); err != nil {
+			response.Fail(w, r, response.Internal("Update failed", err))
 			return
 		}
 	}
 
-	oc.jsonResp(w, http.StatusOK, map[string]any{"success": true})
+	response.OK(w, "", nil)
+}
+
+// SetCartItemQuantity sets how many of an item already in the cart the user
+// wants.
+func (oc *OrderController) SetCartItemQuantity(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserClaims(r)
+	if !ok {
+		response.Fail(w, r, response.Unauthenticated("Please log in to continue."))
+		return
+	}
+
+	var body struct {
+		ItemID   int `json:"-" path:"id" validate:"required"`
+		Quantity int `json:"quantity" validate:"required,min=1,max=99"`
+	}
+	if err := bind.JSON(w, r, &body); err != nil {
+		response.Fail(w, r, err)
+		return
+	}
+
+	cart, line, err := oc.cartLine(r, claims.ID, body.ItemID)
+	if err != nil {
+		response.Fail(w, r, err)
+		return
+	}
+
+	switch delta := body.Quantity - line.Quantity; {
+	case delta > 0:
+		err = oc.store.Orders.IncrementCartItem(r.Context(), cart.ID, body.ItemID, delta)
+	case delta < 0:
+		err = oc.store.Orders.DecrementCartItem(r.Context(), cart.ID, body.ItemID, -delta)
+	}
+	if err != nil {
+		response.Fail(w, r, response.Internal("Update failed", err))
+		return
+	}
+
+	response.OK(w, "Cart updated.", nil)
+}
+
+// RemoveCartItem drops an item from the cart whatever its quantity.
+func (oc *OrderController) RemoveCartItem(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserClaims(r)
+	if !ok {
+		response.Fail(w, r, response.Unauthenticated("Please log in to continue."))
+		return
+	}
+
+	itemID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		response.Fail(w, r, response.BadRequest("Invalid item ID"))
+		return
+	}
+
+	cart, line, err := oc.cartLine(r, claims.ID, itemID)
+	if err != nil {
+		response.Fail(w, r, err)
+		return
+	}
+
+	if err := oc.store.Orders.DecrementCartItem(r.Context(), cart.ID, itemID, line.Quantity); err != nil {
+		response.Fail(w, r, response.Internal("Update failed", err))
+		return
+	}
+
+	response.OK(w, "Item removed from cart.", nil)
+}
+
+// cartLine finds the user's cart and its line for itemID.
+func (oc *OrderController) cartLine(r *http.Request, userID, itemID int) (*models.Order, *models.OrderItem, error) {
+	cart, err := oc.store.Orders.GetCartByUserID(r.Context(), userID)
+	if err == sql.ErrNoRows {
+		return nil, nil, response.NotFound("This item isn't in your cart.")
+	}
+	if err != nil {
+		return nil, nil, response.Internal("Failed to fetch cart", err)
+	}
+	for i := range cart.Items {
+		if cart.Items[i].ItemID == itemID {
+			return cart, &cart.Items[i], nil
+		}
+	}
+	return nil, nil, response.NotFound("This item isn't in your cart.")
 }
 
-func (ac *OrderController) GetAllItems(w http.ResponseWriter, _ *http.Request) {
-	items, err := models.GetAllItems(0)
+// GetAllItems lists the catalog, narrowed by the dietary filters (see
+// dietFilter) and then capped by ?limit=.
+func (oc *OrderController) GetAllItems(w http.ResponseWriter, r *http.Request) {
+	limit, err := limitParam(r)
+	if err != nil {
+		response.Fail(w, r, err)
+		return
+	}
+	filter, err := dietFilter(r)
+	if err != nil {
+		response.Fail(w, r, err)
+		return
+	}
+
+	// The cache holds whole listings, so a filtered one is cut from all of it.
+	fetch := limit
+	if !filter.IsZero() {
+		fetch = 0
+	}
+	items, err := oc.store.Items.GetAll(r.Context(), fetch)
 	if err != nil {
-		ac.jsonResp(w, http.StatusInternalServerError, map[string]any{"success": false, "msg": "Failed to fetch items"})
+		response.Fail(w, r, response.Internal("Failed to fetch items", err))
 		return
 	}
-	ac.jsonResp(w, http.StatusOK, map[string]any{"success": true, "msg": "All items fetched successfully.", "items": items})
+	items = filter.Apply(items)
+	if limit > 0 && len(items) > limit {
+		items = items[:limit]
+	}
+	response.OK(w, "All items fetched successfully.", response.Data{"items": withAvailability(items, shopNow(oc.loc))})
 }
 
 func (oc *OrderController) RateItem(w http.ResponseWriter, r *http.Request) {
 	claims, ok := middleware.GetUserClaims(r)
 	if !ok {
-		oc.jsonResp(w, http.StatusUnauthorized, map[string]any{"success": false, "msg": "Unauthorized"})
+		response.Fail(w, r, response.Unauthenticated("Please log in to continue."))
 		return
 	}
 	userID := claims.ID
 
 	type reqBody struct {
-		ItemID int `json:"itemId"`
-		Rating int `json:"rating"`
+		ItemID int `json:"itemId" path:"id" validate:"required"`
+		Rating int `json:"rating" validate:"required,min=1,max=5"`
 	}
 	var body reqBody
-	if err := json.NewDecoder(r.Body).Decode(&body); err != nil || body.ItemID == 0 || body.Rating < 1 || body.Rating > 5 {
-		oc.jsonResp(w, http.StatusBadRequest, map[string]any{"success": false, "msg": "Bad json data"})
+	if err := bind.JSON(w, r, &body); err != nil {
+		response.Fail(w, r, err)
 		return
 	}
 
-	eligible, err := models.UserBought(userID, body.ItemID)
-	if err != nil || !eligible {
-		oc.jsonResp(w, http.StatusBadRequest, map[string]any{"success": false, "msg": "Have some shame. You are reviewing an item which you didn't even bought. No wonder you are a brokie."})
+	eligible, err := oc.store.Reviews.UserBought(r.Context(), userID, body.ItemID)
+	if err != nil {
+		response.Fail(w, r, response.Internal("Failed to check your orders", err))
+		return
+	}
+	if !eligible {
+		response.Fail(w, r, response.Forbidden("You can only review items you have ordered."))
 		return
 	}
-	if already, _ := models.UserReviewed(userID, body.ItemID); already {
-		oc.jsonResp(w, http.StatusBadRequest, map[string]any{"success": false, "msg": "You've already submitted a review for this item."})
+	if already, _ := oc.store.Reviews.UserReviewed(r.Context(), userID, body.ItemID); already {
+		response.Fail(w, r, response.Conflict(response.CodeConflict, "You've already submitted a review for this item."))
 		return
 	}
-	if err := models.InsertReview(userID, body.ItemID, body.Rating); err != nil {
-		oc.jsonResp(w, http.StatusInternalServerError, map[string]any{"success": false, "msg": "Internal server error."})
+	if err := oc.store.Reviews.Insert(r.Context(), userID, body.ItemID, body.Rating); err != nil {
+		response.Fail(w, r, response.Internal("Failed to save your review", err))
 		return
 	}
-	oc.jsonResp(w, http.StatusCreated, map[string]any{"success": true, "msg": "Your review was submitted!"})
+	response.Created(w, "Your review was submitted!", nil)
 }
 
 func (oc *OrderController) DeliverOrder(w http.ResponseWriter, r *http.Request) {
 	type reqBody struct {
-		OrderID int `json:"orderId"`
+		OrderID int `json:"orderId" path:"id" validate:"required"`
 	}
 	var body reqBody
-	if err := json.NewDecoder(r.Body).Decode(&body); err != nil || body.OrderID == 0 {
-		oc.jsonResp(w, http.StatusBadRequest, map[string]any{"success": false, "msg": "Bad json data"})
+	if err := bind.JSON(w, r, &body); err != nil {
+		response.Fail(w, r, err)
 		return
 	}
-	order, err := models.GetOrderByID(body.OrderID)
-	if err != nil {
-		oc.jsonResp(w, http.StatusNotFound, map[string]any{"success": false, "msg": "Order not found"})
+	order, err := oc.store.Orders.GetByID(r.Context(), body.OrderID)
+	if err != nil || order == nil {
+		response.Fail(w, r, response.NotFound("Order not found"))
 		return
 	}
 
 	if order.Status != "prepared" {
-		oc.jsonResp(w, http.StatusBadRequest, map[string]any{"success": false, "msg": "This order is not prepared yet!"})
+		response.Fail(w, r, response.Conflict(response.CodeInvalidState, "This order is not prepared yet."))
 		return
 	}
-	if err := order.UpdateStatus("delivered"); err != nil {
-		oc.jsonResp(w, http.StatusInternalServerError, map[string]any{"success": false, "msg": "Failed"})
+	if err := oc.store.Orders.UpdateStatus(r.Context(), order, "delivered"); err != nil {
+		response.Fail(w, r, response.Internal("Failed to update the order", err))
 		return
 	}
-	_ = models.MarkDelivered(order.ID)
-	oc.jsonResp(w, http.StatusOK, map[string]any{"success": true, "msg": "Order marked as delivered."})
+	_ = oc.store.Orders.MarkDelivered(r.Context(), order.ID)
+	response.OK(w, "Order marked as delivered.", nil)
 }
 
+// GetAllCategories lists categories depth first, each followed by the ones
+// under it, or with ?parent_id= only those directly under one category (0
+// for the top level). Admins also see inactive categories.
 func (oc *OrderController) GetAllCategories(w http.ResponseWriter, r *http.Request) {
-	categories, err := models.GetAllCategories(0)
+	limit, err := limitParam(r)
+	if err != nil {
+		response.Fail(w, r, err)
+		return
+	}
+
+	tree, activeOnly, err := oc.categoryTree(r)
 	if err != nil {
-		oc.jsonResp(w, http.StatusInternalServerError, map[string]any{"success": false, "msg": "Failed to fetch categories"})
+		response.Fail(w, r, err)
 		return
 	}
 
-	oc.jsonResp(w, http.StatusOK, map[string]any{"success": true, "msg": "Categories fetched successfully", "categories": categories})
+	var categories []*models.Category
+	if raw := r.URL.Query().Get("parent_id"); raw != "" {
+		parentID, err := strconv.Atoi(raw)
+		if err != nil || parentID < 0 {
+			response.Fail(w, r, response.Validation(response.FieldError{Field: "parent_id", Message: "must be a category ID, or 0 for the top level"}))
+			return
+		}
+		if parentID != 0 && !visible(tree, parentID, activeOnly) {
+			response.Fail(w, r, response.NotFound("Category not found"))
+			return
+		}
+		categories = children(tree, parentID, activeOnly)
+	} else {
+		categories = tree.Walk(0, activeOnly)
+	}
+	if limit > 0 && len(categories) > limit {
+		categories = categories[:limit]
+	}
+
+	response.OK(w, "Categories fetched successfully", response.Data{"categories": categories})
 }
 
+// GetItemsByCategoryID lists the items in a category, and with
+// ?descendants=1 those in every category under it too, narrowed by the
+// dietary filters. The categories directly under it come back as
+// subcategories.
 func (oc *OrderController) GetItemsByCategoryID(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
-	categoryID, err := strconv.Atoi(vars["category_id"])
+	categoryID, err := strconv.Atoi(vars["id"])
 	if err != nil {
-		oc.jsonResp(w, http.StatusBadRequest, map[string]any{"success": false, "msg": "Invalid category ID"})
+		response.Fail(w, r, response.BadRequest("Invalid category ID"))
+		return
+	}
+	filter, err := dietFilter(r)
+	if err != nil {
+		response.Fail(w, r, err)
 		return
 	}
 
-	items, err := models.GetItemsByCategoryID(categoryID)
+	tree, activeOnly, err := oc.categoryTree(r)
 	if err != nil {
-		oc.jsonResp(w, http.StatusInternalServerError, map[string]any{"success": false, "msg": "Failed to fetch items"})
+		response.Fail(w, r, err)
+		return
+	}
+	if !visible(tree, categoryID, activeOnly) {
+		response.Fail(w, r, response.NotFound("Category not found"))
 		return
 	}
 
-	category, err := models.GetCategoryByID(categoryID)
+	ids := []int{categoryID}
+	if r.URL.Query().Get("descendants") == "1" {
+		for _, c := range tree.Walk(categoryID, activeOnly) {
+			ids = append(ids, c.ID)
+		}
+	}
+	items, err := oc.store.Items.GetByCategoryID(r.Context(), ids...)
 	if err != nil {
-		oc.jsonResp(w, http.StatusInternalServerError, map[string]any{"success": false, "msg": "Failed to fetch items"})
+		response.Fail(w, r, response.Internal("Failed to fetch items", err))
 		return
 	}
 
-	oc.jsonResp(w, http.StatusOK, map[string]any{"success": true, "msg": "Items fetched successfully", "items": items, "category": category})
+	response.OK(w, "Items fetched successfully", response.Data{
+		"items":         withAvailability(filter.Apply(items), shopNow(oc.loc)),
+		"category":      tree.Get(categoryID),
+		"subcategories": children(tree, categoryID, activeOnly),
+	})
+}
+
+// categoryTree loads every category. activeOnly says whether the caller
+// should only be shown active ones: everyone but admins.
+func (oc *OrderController) categoryTree(r *http.Request) (*models.CategoryTree, bool, error) {
+	all, err := oc.store.Categories.GetAll(r.Context(), 0)
+	if err != nil {
+		return nil, false, response.Internal("Failed to fetch categories", err)
+	}
+	claims, ok := middleware.GetUserClaims(r)
+	return models.NewCategoryTree(all), !ok || claims.Role != "admin", nil
+}
+
+func visible(tree *models.CategoryTree, id int, activeOnly bool) bool {
+	if activeOnly {
+		return tree.Active(id)
+	}
+	return tree.Get(id) != nil
+}
+
+// children never returns nil, so an empty list is sent as [].
+func children(tree *models.CategoryTree, parentID int, activeOnly bool) []*models.Category {
+	out := []*models.Category{}
+	for _, c := range tree.Children(parentID) {
+		if c.IsActive || !activeOnly {
+			out = append(out, c)
+		}
+	}
+	return out
 }
 
 func (oc *OrderController) GetItemByID(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
-	itemID, err := strconv.Atoi(vars["item_id"])
+	itemID, err := strconv.Atoi(vars["id"])
 	if err != nil {
-		oc.jsonResp(w, http.StatusBadRequest, map[string]any{"success": false, "msg": "Invalid item ID"})
+		response.Fail(w, r, response.BadRequest("Invalid item ID"))
 		return
 	}
 
-	item, err := models.GetItemByID(itemID)
+	item, err := oc.store.Items.GetByID(r.Context(), itemID)
 	if err != nil {
 		if err == sql.ErrNoRows {
-			oc.jsonResp(w, http.StatusNotFound, map[string]any{"success": false, "msg": "Item not found"})
+			response.Fail(w, r, response.NotFound("Item not found"))
 			return
 		}
-		oc.jsonResp(w, http.StatusInternalServerError, map[string]any{"success": false, "msg": "Failed to fetch item"})
+		response.Fail(w, r, response.Internal("Failed to fetch item", err))
 		return
 	}
 
-	oc.jsonResp(w, http.StatusOK, map[string]any{"success": true, "msg": "Item fetched successfully", "item": item})
+	item.Available = item.AvailableAt(shopNow(oc.loc))
+	response.OK(w, "Item fetched successfully", response.Data{"item": item})
 }
 
 func (oc *OrderController) HomePageItems(w http.ResponseWriter, r *http.Request) {
 	_, ok := middleware.GetUserClaims(r)
 	if !ok {
-		oc.jsonResp(w, http.StatusUnauthorized, map[string]any{"success": false, "msg": "Unauthorized"})
+		response.Fail(w, r, response.Unauthenticated("Please log in to continue."))
 		return
 	}
 
-	items, err := models.GetAllItems(3)
+	items, err := oc.store.Items.GetAll(r.Context(), 3)
 	if err != nil {
-		oc.jsonResp(w, http.StatusInternalServerError, map[string]any{"success": false, "msg": "Failed to fetch items"})
+		response.Fail(w, r, response.Internal("Failed to fetch items", err))
 		return
 	}
 
-	oc.jsonResp(w, http.StatusOK, map[string]any{"success": true, "msg": "Homepage items fetched successfully", "items": items})
+	response.OK(w, "Homepage items fetched successfully", response.Data{"items": withAvailability(items, shopNow(oc.loc))})
 }
 
 func (oc *OrderController) HomePageOrders(w http.ResponseWriter, r *http.Request) {
 	claims, ok := middleware.GetUserClaims(r)
 	if !ok {
-		oc.jsonResp(w, http.StatusUnauthorized, map[string]any{"success": false, "msg": "Unauthorized"})
+		response.Fail(w, r, response.Unauthenticated("Please log in to continue."))
 		return
 	}
 
 	userID := claims.ID
-	orders, err := models.GetOrdersByUserID(userID, 3)
+	orders, err := oc.store.Orders.GetByUserID(r.Context(), userID, 3)
 	if err != nil {
-		oc.jsonResp(w, http.StatusInternalServerError, map[string]any{"success": false, "msg": "Failed to fetch orders"})
+		response.Fail(w, r, response.Internal("Failed to fetch orders", err))
 		return
 	}
 
-	oc.jsonResp(w, http.StatusOK, map[string]any{"success": true, "msg": "Homepage orders fetched successfully", "orders": orders})
+	response.OK(w, "Homepage orders fetched successfully", response.Data{"orders": orders})
 }
 
+// HomePageCategories returns the first six top-level categories customers
+// can see.
 func (oc *OrderController) HomePageCategories(w http.ResponseWriter, r *http.Request) {
-	categories, err := models.GetAllCategories(6)
+	tree, activeOnly, err := oc.categoryTree(r)
 	if err != nil {
-		oc.jsonResp(w, http.StatusInternalServerError, map[string]any{"success": false, "msg": "Failed to fetch categories"})
+		response.Fail(w, r, err)
 		return
 	}
+	categories := children(tree, 0, activeOnly)
+	if len(categories) > 6 {
+		categories = categories[:6]
+	}
 
-	oc.jsonResp(w, http.StatusOK, map[string]any{"success": true, "msg": "All categories fetched successfully.", "categories": categories})
+	response.OK(w, "All categories fetched successfully.", response.Data{"categories": categories})
+}
+
+// limitParam reads the optional ?limit= cap on a listing. Zero means no
+// limit.
+func limitParam(r *http.Request) (int, error) {
+	raw := r.URL.Query().Get("limit")
+	if raw == "" {
+		return 0, nil
+	}
+	limit, err := strconv.Atoi(raw)
+	if err != nil || limit < 0 {
+		return 0, response.Validation(response.FieldError{Field: "limit", Message: "must be a whole number of 0 or more"})
+	}
+	return limit, nil
 }
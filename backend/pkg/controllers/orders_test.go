@@ -0,0 +1,281 @@
+package controllers_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/gorilla/mux"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	"github.com/Entity069/Zesty-Go/pkg/config"
+	"github.com/Entity069/Zesty-Go/pkg/controllers"
+	"github.com/Entity069/Zesty-Go/pkg/metrics"
+	"github.com/Entity069/Zesty-Go/pkg/middleware"
+	"github.com/Entity069/Zesty-Go/pkg/models"
+	"github.com/Entity069/Zesty-Go/pkg/models/memstore"
+)
+
+var testSecret = []byte("topsecret")
+
+type fixture struct {
+	store  *models.Store
+	buyer  *models.User
+	item   *models.Item
+	orders *controllers.OrderController
+}
+
+func newFixture(t *testing.T, balance float64) *fixture {
+	t.Helper()
+	ctx := context.Background()
+	store := memstore.New()
+
+	seller := &models.User{FirstName: "Sam", LastName: "Seller", Email: "sam@zes.ty", UserType: "seller"}
+	buyer := &models.User{FirstName: "Bo", LastName: "Buyer", Email: "bo@zes.ty", UserType: "user", Balance: balance}
+	category := &models.Category{Name: "Breakfast", IsActive: true}
+	for _, err := range []error{
+		store.Users.Create(ctx, seller),
+		store.Users.Create(ctx, buyer),
+		store.Categories.Create(ctx, category),
+	} {
+		if err != nil {
+			t.Fatalf("seeding: %v", err)
+		}
+	}
+
+	item := &models.Item{SellerID: seller.ID, Name: "Dosa", Price: 20, CategoryID: category.ID, Status: "available"}
+	if err := store.Items.Create(ctx, item); err != nil {
+		t.Fatalf("seeding item: %v", err)
+	}
+
+	return &fixture{store: store, buyer: buyer, item: item, orders: controllers.NewOrderController(config.Default(), store)}
+}
+
+func (f *fixture) do(t *testing.T, h http.HandlerFunc, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	return f.doID(t, h, "", body)
+}
+
+// doID is do for a route with an {id} path variable.
+func (f *fixture) doID(t *testing.T, h http.HandlerFunc, id, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	tok, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"id":   f.buyer.ID,
+		"role": f.buyer.UserType,
+		"exp":  time.Now().Add(time.Hour).Unix(),
+	}).SignedString(testSecret)
+	if err != nil {
+		t.Fatalf("signing token: %v", err)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+	req.AddCookie(&http.Cookie{Name: "token", Value: tok})
+	if id != "" {
+		req = mux.SetURLVars(req, map[string]string{"id": id})
+	}
+	rec := httptest.NewRecorder()
+	middleware.NewAuth(testSecret, nil).UserRequired(h).ServeHTTP(rec, req)
+	return rec
+}
+
+func TestPlaceOrderChargesBalance(t *testing.T) {
+	f := newFixture(t, 50)
+	ctx := context.Background()
+	placed, revenue := testutil.ToFloat64(metrics.OrdersPlaced), testutil.ToFloat64(metrics.Revenue)
+
+	if rec := f.do(t, f.orders.AddToCart, `{"itemId": `+strconv.Itoa(f.item.ID)+`, "quantity": 2}`); rec.Code != http.StatusOK {
+		t.Fatalf("AddToCart status = %d, body %s", rec.Code, rec.Body)
+	}
+	if rec := f.do(t, f.orders.PlaceOrder, ``); rec.Code != http.StatusOK {
+		t.Fatalf("PlaceOrder status = %d, body %s", rec.Code, rec.Body)
+	}
+
+	buyer, _ := f.store.Users.GetByID(ctx, f.buyer.ID)
+	if buyer.Balance != 10 {
+		t.Fatalf("balance = %v, want 10", buyer.Balance)
+	}
+	orders, _ := f.store.Orders.GetByUserID(ctx, f.buyer.ID, 0)
+	if len(orders) != 1 || orders[0].Status != "ordered" || orders[0].TotalAmount != 40 {
+		t.Fatalf("orders = %+v, want one ordered order of 40", orders)
+	}
+	if got := testutil.ToFloat64(metrics.OrdersPlaced) - placed; got != 1 {
+		t.Errorf("orders_placed_total went up by %v, want 1", got)
+	}
+	if got := testutil.ToFloat64(metrics.Revenue) - revenue; got != 40 {
+		t.Errorf("revenue_total went up by %v, want 40", got)
+	}
+}
+
+func TestPlaceOrderInsufficientBalance(t *testing.T) {
+	f := newFixture(t, 5)
+	ctx := context.Background()
+
+	f.do(t, f.orders.AddToCart, `{"itemId": `+strconv.Itoa(f.item.ID)+`, "quantity": 1}`)
+	rec := f.do(t, f.orders.PlaceOrder, ``)
+	if rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), `"code":"insufficient_balance"`) {
+		t.Fatalf("PlaceOrder = %d %s, want 400 insufficient_balance", rec.Code, rec.Body)
+	}
+
+	if _, err := f.store.Orders.GetCartByUserID(ctx, f.buyer.ID); err != nil {
+		t.Fatalf("cart should still be open: %v", err)
+	}
+}
+
+func TestAddToCartRejectsZeroQuantity(t *testing.T) {
+	f := newFixture(t, 50)
+
+	rec := f.do(t, f.orders.AddToCart, `{"itemId": `+strconv.Itoa(f.item.ID)+`, "quantity": 0}`)
+	if rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), `"field":"quantity"`) {
+		t.Fatalf("AddToCart = %d %s, want 400 naming quantity", rec.Code, rec.Body)
+	}
+	if _, err := f.store.Orders.GetCartByUserID(context.Background(), f.buyer.ID); err == nil {
+		t.Fatal("a rejected request should not open a cart")
+	}
+}
+
+func TestSetCartItemQuantity(t *testing.T) {
+	f := newFixture(t, 50)
+	ctx := context.Background()
+	id := strconv.Itoa(f.item.ID)
+
+	if rec := f.doID(t, f.orders.SetCartItemQuantity, id, `{"quantity": 3}`); rec.Code != http.StatusNotFound {
+		t.Fatalf("SetCartItemQuantity without a cart = %d %s, want 404", rec.Code, rec.Body)
+	}
+
+	f.do(t, f.orders.AddToCart, `{"itemId": `+id+`, "quantity": 2}`)
+	for _, want := range []int{5, 1} {
+		if rec := f.doID(t, f.orders.SetCartItemQuantity, id, `{"quantity": `+strconv.Itoa(want)+`}`); rec.Code != http.StatusOK {
+			t.Fatalf("SetCartItemQuantity(%d) = %d %s", want, rec.Code, rec.Body)
+		}
+		cart, err := f.store.Orders.GetCartByUserID(ctx, f.buyer.ID)
+		if err != nil || len(cart.Items) != 1 || cart.Items[0].Quantity != want {
+			t.Fatalf("cart = %+v (%v), want one line of %d", cart, err, want)
+		}
+	}
+}
+
+func TestRemoveCartItem(t *testing.T) {
+	f := newFixture(t, 50)
+	id := strconv.Itoa(f.item.ID)
+
+	f.do(t, f.orders.AddToCart, `{"itemId": `+id+`, "quantity": 4}`)
+	if rec := f.doID(t, f.orders.RemoveCartItem, id, ``); rec.Code != http.StatusOK {
+		t.Fatalf("RemoveCartItem = %d %s", rec.Code, rec.Body)
+	}
+	if cart, err := f.store.Orders.GetCartByUserID(context.Background(), f.buyer.ID); err == nil && len(cart.Items) != 0 {
+		t.Fatalf("cart = %+v, want no lines", cart)
+	}
+	if rec := f.doID(t, f.orders.RemoveCartItem, id, ``); rec.Code != http.StatusNotFound {
+		t.Fatalf("second RemoveCartItem = %d %s, want 404", rec.Code, rec.Body)
+	}
+}
+
+func TestCancelOrderOnlyOwnOrders(t *testing.T) {
+	f := newFixture(t, 50)
+	ctx := context.Background()
+
+	other := &models.User{FirstName: "Oz", LastName: "Other", Email: "oz@zes.ty", UserType: "user", Balance: 50}
+	if err := f.store.Users.Create(ctx, other); err != nil {
+		t.Fatal(err)
+	}
+	owner := f.buyer
+	f.buyer = other
+	f.do(t, f.orders.AddToCart, `{"itemId": `+strconv.Itoa(f.item.ID)+`, "quantity": 1}`)
+	f.do(t, f.orders.PlaceOrder, ``)
+	orders, _ := f.store.Orders.GetByUserID(ctx, other.ID, 0)
+	if len(orders) != 1 {
+		t.Fatalf("orders = %+v, want one", orders)
+	}
+	id := strconv.Itoa(orders[0].ID)
+
+	f.buyer = owner
+	if rec := f.doID(t, f.orders.CancelOrder, id, ``); rec.Code != http.StatusNotFound {
+		t.Fatalf("cancelling someone else's order = %d %s, want 404", rec.Code, rec.Body)
+	}
+	f.buyer = other
+	if rec := f.doID(t, f.orders.CancelOrder, id, ``); rec.Code != http.StatusOK {
+		t.Fatalf("cancelling one's own order = %d %s", rec.Code, rec.Body)
+	}
+	if u, _ := f.store.Users.GetByID(ctx, other.ID); u.Balance != 50 {
+		t.Errorf("balance after refund = %v, want 50", u.Balance)
+	}
+}
+
+func TestPurgeCartsKeepsChangedCarts(t *testing.T) {
+	f := newFixture(t, 50)
+	ctx := context.Background()
+	dosa := strconv.Itoa(f.item.ID)
+
+	f.do(t, f.orders.AddToCart, `{"itemId": `+dosa+`, "quantity": 1}`)
+	cutoff := time.Now()
+	// Only the cart's lines change; the cart itself must count as touched.
+	if rec := f.do(t, f.orders.UpdateOrderItemCount, `{"itemId": `+dosa+`, "action": "increase"}`); rec.Code != http.StatusOK {
+		t.Fatalf("UpdateOrderItemCount = %d %s", rec.Code, rec.Body)
+	}
+
+	if n, err := f.store.Orders.PurgeCarts(ctx, cutoff); err != nil || n != 0 {
+		t.Fatalf("PurgeCarts = %d, %v; want the cart changed since kept", n, err)
+	}
+	if _, err := f.store.Orders.GetCartByUserID(ctx, f.buyer.ID); err != nil {
+		t.Fatalf("cart after purge: %v", err)
+	}
+}
+
+func TestCancelOrderRefundsOnce(t *testing.T) {
+	f := newFixture(t, 50)
+	ctx := context.Background()
+
+	f.do(t, f.orders.AddToCart, `{"itemId": `+strconv.Itoa(f.item.ID)+`, "quantity": 1}`)
+	f.do(t, f.orders.PlaceOrder, ``)
+	orders, _ := f.store.Orders.GetByUserID(ctx, f.buyer.ID, 0)
+	if len(orders) != 1 {
+		t.Fatalf("orders = %+v, want one", orders)
+	}
+	stale := orders[0]
+	id := strconv.Itoa(stale.ID)
+
+	if rec := f.doID(t, f.orders.CancelOrder, id, ``); rec.Code != http.StatusOK {
+		t.Fatalf("CancelOrder = %d %s", rec.Code, rec.Body)
+	}
+	if rec := f.doID(t, f.orders.CancelOrder, id, ``); rec.Code != http.StatusConflict {
+		t.Fatalf("second CancelOrder = %d %s, want 409", rec.Code, rec.Body)
+	}
+	// A cancellation that read the order before the first one committed
+	// must not get to refund it again.
+	if err := f.store.Orders.Cancel(ctx, stale); !errors.Is(err, sql.ErrNoRows) {
+		t.Fatalf("Cancel of a stale order = %v, want sql.ErrNoRows", err)
+	}
+
+	if u, _ := f.store.Users.GetByID(ctx, f.buyer.ID); u.Balance != 50 {
+		t.Errorf("balance after refund = %v, want 50", u.Balance)
+	}
+	txns, _ := f.store.Wallet.GetByUserID(ctx, f.buyer.ID, 0)
+	if len(txns) != 1 || txns[0].Amount != 20 || txns[0].BalanceAfter != 50 {
+		t.Errorf("wallet transactions = %+v, want one refund of 20", txns)
+	}
+}
+
+func TestAddToCartCap(t *testing.T) {
+	f := newFixture(t, 0)
+	dosa := strconv.Itoa(f.item.ID)
+
+	if rec := f.do(t, f.orders.AddToCart, `{"itemId": `+dosa+`, "quantity": 98}`); rec.Code != http.StatusOK {
+		t.Fatalf("AddToCart = %d %s", rec.Code, rec.Body)
+	}
+	if rec := f.do(t, f.orders.AddToCart, `{"itemId": `+dosa+`, "quantity": 2}`); rec.Code != http.StatusConflict {
+		t.Errorf("adding past 99 = %d %s, want 409", rec.Code, rec.Body)
+	}
+	if rec := f.do(t, f.orders.AddToCart, `{"itemId": `+dosa+`, "quantity": 1}`); rec.Code != http.StatusOK {
+		t.Errorf("adding up to 99 = %d %s", rec.Code, rec.Body)
+	}
+	if rec := f.do(t, f.orders.UpdateOrderItemCount, `{"itemId": `+dosa+`, "action": "increase"}`); rec.Code != http.StatusConflict {
+		t.Errorf("increasing past 99 = %d %s, want 409", rec.Code, rec.Body)
+	}
+}
@@ -0,0 +1,138 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Entity069/Zesty-Go/pkg/config"
+	"github.com/Entity069/Zesty-Go/pkg/middleware"
+	"github.com/Entity069/Zesty-Go/pkg/models"
+	"github.com/Entity069/Zesty-Go/pkg/response"
+)
+
+// slots are the delivery slots a scheduled order can be placed for: every
+// Slot from midnight in the shop's zone, within its hours, no sooner than
+// the lead time and no further ahead than the horizon.
+type slots struct {
+	cfg   config.PreorderConfig
+	loc   *time.Location
+	hours models.Schedule
+}
+
+func newSlots(cfg config.PreorderConfig, loc *time.Location) slots {
+	from, until := cfg.OpenHours()
+	return slots{cfg: cfg, loc: loc, hours: models.Schedule{{From: from, Until: until}}}
+}
+
+// check says why at can't be booked at now, or returns "" if it can. It
+// leaves capacity to the caller.
+func (s slots) check(at, now time.Time) string {
+	local := at.In(s.loc)
+	step := int(s.cfg.Slot / time.Minute)
+	switch {
+	case at.Before(now.Add(s.cfg.LeadTime)):
+		return "must be at least " + shortDuration(s.cfg.LeadTime) + " from now"
+	case at.After(now.Add(s.cfg.Horizon)):
+		return "must be within " + shortDuration(s.cfg.Horizon) + " from now"
+	case local.Second() != 0 || local.Nanosecond() != 0 || (local.Hour()*60+local.Minute())%step != 0:
+		return "must be the start of a slot; slots are " + shortDuration(s.cfg.Slot) + " long from midnight"
+	case !s.hours.OpenAt(local):
+		return "is outside delivery hours, " + s.cfg.Hours + " " + s.loc.String()
+	}
+	return ""
+}
+
+// on lists the slots on the shop's calendar day holding day that can be
+// booked at now.
+func (s slots) on(day, now time.Time) []time.Time {
+	y, m, d := day.In(s.loc).Date()
+	step := int(s.cfg.Slot / time.Minute)
+	var out []time.Time
+	for minute := 0; minute < 24*60; minute += step {
+		at := time.Date(y, m, d, 0, minute, 0, 0, s.loc)
+		if s.check(at, now) == "" {
+			out = append(out, at)
+		}
+	}
+	return out
+}
+
+// full reports whether a slot already holding taken orders has no room.
+func (s slots) full(taken int) bool {
+	return s.cfg.Capacity > 0 && taken >= s.cfg.Capacity
+}
+
+// shortDuration is d without the zero units time.Duration.String adds:
+// 45m rather than 45m0s.
+func shortDuration(d time.Duration) string {
+	out := d.String()
+	if strings.HasSuffix(out, "m0s") {
+		out = strings.TrimSuffix(out, "0s")
+	}
+	if strings.HasSuffix(out, "h0m") {
+		out = strings.TrimSuffix(out, "0m")
+	}
+	return out
+}
+
+// slotError is the error for a deliver_at that can't be booked.
+func slotError(problem string) error {
+	return response.Validation(response.FieldError{Field: "deliver_at", Message: problem})
+}
+
+type slotView struct {
+	DeliverAt time.Time `json:"deliver_at"`
+	// Remaining is how many more orders the slot takes, or null when slots
+	// are unlimited.
+	Remaining *int `json:"remaining"`
+}
+
+// GetSlots lists the delivery slots a scheduled order can be placed for on
+// ?date= (YYYY-MM-DD, default today in the shop's zone), with how much room
+// each has left. Full slots are listed with none.
+func (oc *OrderController) GetSlots(w http.ResponseWriter, r *http.Request) {
+	if _, ok := middleware.GetUserClaims(r); !ok {
+		response.Fail(w, r, response.Unauthenticated("Please log in to continue."))
+		return
+	}
+
+	now := shopNow(oc.loc)
+	day := now
+	if raw := r.URL.Query().Get("date"); raw != "" {
+		var err error
+		if day, err = time.ParseInLocation(time.DateOnly, raw, oc.loc); err != nil {
+			response.Fail(w, r, response.Validation(response.FieldError{Field: "date", Message: "must be a date like 2026-01-31"}))
+			return
+		}
+	}
+
+	open := oc.slots.on(day, now)
+	views := []slotView{}
+	if len(open) > 0 {
+		counts, err := oc.store.Orders.SlotCounts(r.Context(), open[0], open[len(open)-1].Add(time.Second))
+		if err != nil {
+			response.Fail(w, r, response.Internal("Failed to count booked orders", err))
+			return
+		}
+		for _, at := range open {
+			view := slotView{DeliverAt: at}
+			if oc.slots.cfg.Capacity > 0 {
+				left := max(oc.slots.cfg.Capacity-counts[at.Unix()], 0)
+				view.Remaining = &left
+			}
+			views = append(views, view)
+		}
+	}
+	response.OK(w, "", response.Data{
+		"slots":     views,
+		"lead_time": shortDuration(oc.slots.cfg.LeadTime),
+		"timezone":  oc.loc.String(),
+	})
+}
+
+// slotTaken is the error for booking a slot with no room left.
+func slotTaken(at time.Time) error {
+	return response.Conflict(response.CodeConflict, fmt.Sprintf("The %s slot is fully booked. Please pick another.", at.Format("Mon 2 Jan 15:04")))
+}
@@ -0,0 +1,162 @@
+package controllers_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"github.com/Entity069/Zesty-Go/pkg/config"
+	"github.com/Entity069/Zesty-Go/pkg/controllers"
+	"github.com/Entity069/Zesty-Go/pkg/models"
+)
+
+func TestScheduledOrder(t *testing.T) {
+	f := newFixture(t, 100)
+	ctx := context.Background()
+	cfg := config.Default()
+	cfg.Shop.Preorders.Hours = "00:00-24:00"
+	cfg.Shop.Preorders.Capacity = 1
+	f.orders = controllers.NewOrderController(cfg, f.store)
+	dosa := strconv.Itoa(f.item.ID)
+
+	place := func(oc *controllers.OrderController, at time.Time) *httptest.ResponseRecorder {
+		t.Helper()
+		return f.do(t, oc.PlaceOrder, `{"deliver_at": "`+at.Format(time.RFC3339)+`"}`)
+	}
+	remaining := func(at time.Time) int {
+		t.Helper()
+		rec := as(t, f.buyer.ID, "user", f.orders.GetSlots, http.MethodGet, "/?date="+at.Format(time.DateOnly), "")
+		var got struct {
+			Slots []struct {
+				DeliverAt time.Time `json:"deliver_at"`
+				Remaining *int      `json:"remaining"`
+			} `json:"slots"`
+		}
+		if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil || rec.Code != http.StatusOK {
+			t.Fatalf("GetSlots = %d %s", rec.Code, rec.Body)
+		}
+		for _, s := range got.Slots {
+			if s.DeliverAt.Equal(at) {
+				return *s.Remaining
+			}
+		}
+		t.Fatalf("%s isn't among the slots %+v", at, got.Slots)
+		return 0
+	}
+
+	f.do(t, f.orders.AddToCart, `{"itemId": `+dosa+`, "quantity": 1}`)
+	slot := time.Now().UTC().Add(2 * time.Hour).Truncate(30 * time.Minute)
+	// The default hours are 09:00-22:00 UTC.
+	small := time.Now().UTC().AddDate(0, 0, 2).Truncate(24 * time.Hour).Add(3 * time.Hour)
+	for _, tc := range []struct {
+		oc   *controllers.OrderController
+		at   time.Time
+		want string
+	}{
+		{f.orders, slot.Add(-3 * time.Hour), "at least 45m from now"},
+		{f.orders, slot.AddDate(0, 0, 8), "within 168h"},
+		{f.orders, slot.Add(10 * time.Minute), "start of a slot"},
+		{f.orders, slot.Add(time.Second), "start of a slot"},
+		{controllers.NewOrderController(config.Default(), f.store), small, "outside delivery hours"},
+	} {
+		if rec := place(tc.oc, tc.at); rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), tc.want) {
+			t.Errorf("scheduling for %s = %d %s, want 400 %q", tc.at, rec.Code, rec.Body, tc.want)
+		}
+	}
+
+	if n := remaining(slot); n != 1 {
+		t.Fatalf("remaining before booking = %d, want 1", n)
+	}
+	if rec := place(f.orders, slot); rec.Code != http.StatusOK {
+		t.Fatalf("scheduling for %s = %d %s", slot, rec.Code, rec.Body)
+	}
+	orders, _ := f.store.Orders.GetByUserID(ctx, f.buyer.ID, 0)
+	if len(orders) != 1 || orders[0].Status != "scheduled" || orders[0].DeliverAt == nil || !orders[0].DeliverAt.Equal(slot) {
+		t.Fatalf("orders = %+v, want one scheduled for %s", orders, slot)
+	}
+	if buyer, _ := f.store.Users.GetByID(ctx, f.buyer.ID); buyer.Balance != 80 {
+		t.Errorf("balance = %v, want 80 charged up front", buyer.Balance)
+	}
+	if queue, _ := f.store.Orders.GetBySellerID(ctx, f.item.SellerID, 0); len(queue) != 0 {
+		t.Errorf("the seller already sees the held order: %+v", queue)
+	}
+	sc := controllers.NewSellerController(config.Default(), f.store, nil, nil)
+	req := mux.SetURLVars(httptest.NewRequest(http.MethodPost, "/", nil), map[string]string{"id": strconv.Itoa(orders[0].Items[0].ID)})
+	if rec := f.asSeller(t, sc.UpdateOrderItemStatus, req); rec.Code != http.StatusConflict {
+		t.Errorf("advancing a held line = %d %s, want 409", rec.Code, rec.Body)
+	}
+
+	if n := remaining(slot); n != 0 {
+		t.Errorf("remaining after booking = %d, want 0", n)
+	}
+	f.do(t, f.orders.AddToCart, `{"itemId": `+dosa+`, "quantity": 1}`)
+	if rec := place(f.orders, slot); rec.Code != http.StatusConflict {
+		t.Errorf("booking a full slot = %d %s, want 409", rec.Code, rec.Body)
+	}
+
+	if rec := f.doID(t, f.orders.CancelOrder, strconv.Itoa(orders[0].ID), ``); rec.Code != http.StatusOK {
+		t.Fatalf("cancelling a scheduled order = %d %s", rec.Code, rec.Body)
+	}
+	if buyer, _ := f.store.Users.GetByID(ctx, f.buyer.ID); buyer.Balance != 100 {
+		t.Errorf("balance after cancelling = %v, want 100 back", buyer.Balance)
+	}
+	if n := remaining(slot); n != 1 {
+		t.Errorf("remaining after cancelling = %d, want the place back", n)
+	}
+	if rec := f.do(t, f.orders.PlaceOrder, ``); rec.Code != http.StatusOK {
+		t.Errorf("the cart left over from the full slot = %d %s, want it placed as soon as possible", rec.Code, rec.Body)
+	}
+	if orders, _ := f.store.Orders.GetByUserID(ctx, f.buyer.ID, 0); orders[0].Status != "ordered" || orders[0].DeliverAt != nil {
+		t.Errorf("order placed without deliver_at = %+v", orders[0])
+	}
+}
+
+func TestScheduledOrderAvailability(t *testing.T) {
+	f := newFixture(t, 100)
+	ctx := context.Background()
+	cfg := config.Default()
+	cfg.Shop.Preorders.Hours = "00:00-24:00"
+	f.orders = controllers.NewOrderController(cfg, f.store)
+
+	slot := time.Now().UTC().Add(2 * time.Hour).Truncate(30 * time.Minute)
+	from, until := slot.Format("15:04"), slot.Add(30*time.Minute).Format("15:04")
+	// onlyAtSlot is open for the slot's half hour and closed now;
+	// allButSlot is open now and closed for the slot.
+	onlyAtSlot := models.Schedule{{From: from, Until: until}}
+	allButSlot := models.Schedule{{From: until, Until: from}}
+	if until == "00:00" {
+		onlyAtSlot[0].Until = "24:00"
+		allButSlot = models.Schedule{{From: "00:00", Until: from}}
+	}
+
+	order := func(schedule models.Schedule) *httptest.ResponseRecorder {
+		t.Helper()
+		item := &models.Item{SellerID: f.item.SellerID, Name: "Poha", Price: 10, CategoryID: f.item.CategoryID, Status: "available", Schedule: schedule}
+		if err := f.store.Items.Create(ctx, item); err != nil {
+			t.Fatal(err)
+		}
+		// Straight into the cart: AddToCart checks availability now.
+		if _, err := f.store.Orders.AddItemToCart(ctx, f.buyer.ID, item.ID, 1); err != nil {
+			t.Fatal(err)
+		}
+		rec := f.do(t, f.orders.PlaceOrder, `{"deliver_at": "`+slot.Format(time.RFC3339)+`"}`)
+		if cart, err := f.store.Orders.GetCartByUserID(ctx, f.buyer.ID); err == nil {
+			f.store.Orders.DecrementCartItem(ctx, cart.ID, item.ID, 1)
+		}
+		return rec
+	}
+
+	if rec := order(onlyAtSlot); rec.Code != http.StatusOK {
+		t.Errorf("preordering an item for a slot it is sold in = %d %s, want 200", rec.Code, rec.Body)
+	}
+	if rec := order(allButSlot); rec.Code != http.StatusConflict || !strings.Contains(rec.Body.String(), "isn't available at") {
+		t.Errorf("preordering an item for a slot it isn't sold in = %d %s, want 409", rec.Code, rec.Body)
+	}
+}
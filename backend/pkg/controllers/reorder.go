@@ -0,0 +1,146 @@
+package controllers
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/Entity069/Zesty-Go/pkg/bind"
+	"github.com/Entity069/Zesty-Go/pkg/middleware"
+	"github.com/Entity069/Zesty-Go/pkg/models"
+	"github.com/Entity069/Zesty-Go/pkg/response"
+)
+
+// reorderLine is a line of the past order that went into the cart, at the
+// item's current price. Quantity is how many went in, which is fewer than
+// were ordered if the cart line would otherwise pass maxCartQuantity.
+type reorderLine struct {
+	ItemID    int     `json:"item_id"`
+	Name      string  `json:"name"`
+	Quantity  int     `json:"quantity"`
+	UnitPrice float64 `json:"unit_price"`
+}
+
+// priceChange is an added line whose price differs from what was paid.
+type priceChange struct {
+	ItemID   int     `json:"item_id"`
+	Name     string  `json:"name"`
+	OldPrice float64 `json:"old_price"`
+	NewPrice float64 `json:"new_price"`
+}
+
+// droppedLine is a line that couldn't go into the cart, and why.
+type droppedLine struct {
+	ItemID   int    `json:"item_id"`
+	Name     string `json:"name"`
+	Quantity int    `json:"quantity"`
+	Reason   string `json:"reason"`
+}
+
+type reorderReport struct {
+	Added        []reorderLine `json:"added"`
+	PriceChanged []priceChange `json:"price_changed"`
+	Dropped      []droppedLine `json:"dropped"`
+}
+
+// Reorder copies the lines of one of the customer's past orders into their
+// cart at today's prices. Lines already in the cart have their quantity
+// raised, up to maxCartQuantity, and are repriced too. Items that are no
+// longer sold, or aren't available right now by their status or schedule,
+// are left out; the report lists them, and the lines whose price has
+// changed since the order.
+func (oc *OrderController) Reorder(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserClaims(r)
+	if !ok {
+		response.Fail(w, r, response.Unauthenticated("Please log in to continue."))
+		return
+	}
+
+	var body struct {
+		OrderID int `json:"-" path:"id" validate:"required"`
+	}
+	if err := bind.JSON(w, r, &body); err != nil {
+		response.Fail(w, r, err)
+		return
+	}
+
+	ctx := r.Context()
+	// someone else's order looks the same as one that doesn't exist
+	order, err := oc.store.Orders.GetByID(ctx, body.OrderID)
+	if err != nil || order == nil || order.UserID != claims.ID {
+		response.Fail(w, r, response.NotFound("Order not found"))
+		return
+	}
+
+	report := reorderReport{Added: []reorderLine{}, PriceChanged: []priceChange{}, Dropped: []droppedLine{}}
+	now := shopNow(oc.loc)
+	for _, line := range order.Items {
+		item, err := oc.store.Items.GetByID(ctx, line.ItemID)
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			report.Dropped = append(report.Dropped, droppedLine{ItemID: line.ItemID, Name: line.Name, Quantity: line.Quantity, Reason: "no longer sold"})
+			continue
+		case err != nil:
+			response.Fail(w, r, response.Internal("Failed to fetch item", err))
+			return
+		case !item.AvailableAt(now):
+			report.Dropped = append(report.Dropped, droppedLine{ItemID: item.ID, Name: item.Name, Quantity: line.Quantity, Reason: "not available right now"})
+			continue
+		}
+		report.Added = append(report.Added, reorderLine{ItemID: item.ID, Name: item.Name, Quantity: line.Quantity, UnitPrice: item.Price})
+		if item.Price != line.UnitPrice {
+			report.PriceChanged = append(report.PriceChanged, priceChange{ItemID: item.ID, Name: item.Name, OldPrice: line.UnitPrice, NewPrice: item.Price})
+		}
+	}
+
+	if len(report.Added) == 0 {
+		response.OK(w, "None of the items in this order can be ordered right now.", response.Data{"report": report})
+		return
+	}
+
+	// the whole order goes into the cart or none of it does
+	err = oc.store.WithTx(ctx, func(tx *models.Store) error {
+		cart, err := tx.Orders.CreateOrGetCart(ctx, claims.ID)
+		if err != nil {
+			return response.Internal("Cart creation failed", err)
+		}
+		inCart := map[int]*models.OrderItem{}
+		for i := range cart.Items {
+			inCart[cart.Items[i].ItemID] = &cart.Items[i]
+		}
+		for i := range report.Added {
+			line := &report.Added[i]
+			existing, ok := inCart[line.ItemID]
+			if !ok {
+				line.Quantity = min(line.Quantity, maxCartQuantity)
+				err = tx.OrderItems.Create(ctx, &models.OrderItem{
+					OrderID: cart.ID, ItemID: line.ItemID, Quantity: line.Quantity, UnitPrice: line.UnitPrice, Status: "cart",
+				})
+			} else {
+				// a line already in the cart is charged today's price too
+				quantity := min(existing.Quantity+line.Quantity, maxCartQuantity)
+				line.Quantity = quantity - existing.Quantity
+				err = tx.OrderItems.Reprice(ctx, existing, quantity, line.UnitPrice)
+			}
+			if err != nil {
+				return response.Internal("Failed to add item to cart", err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		response.Fail(w, r, err)
+		return
+	}
+
+	message := "The items from this order were added to your cart."
+	if n := len(report.Dropped); n > 0 {
+		message = fmt.Sprintf("%d of %d items from this order were added to your cart; the rest can't be ordered right now.",
+			len(report.Added), len(report.Added)+n)
+	}
+	if len(report.PriceChanged) > 0 {
+		message += " Some prices have changed since."
+	}
+	response.OK(w, message, response.Data{"report": report})
+}
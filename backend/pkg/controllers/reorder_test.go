@@ -0,0 +1,132 @@
+package controllers_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"testing"
+
+	"github.com/Entity069/Zesty-Go/pkg/models"
+)
+
+func TestReorder(t *testing.T) {
+	f := newFixture(t, 200)
+	ctx := context.Background()
+
+	idli := &models.Item{SellerID: f.item.SellerID, Name: "Idli", Price: 15, CategoryID: f.item.CategoryID, Status: "available"}
+	vada := &models.Item{SellerID: f.item.SellerID, Name: "Vada", Price: 10, CategoryID: f.item.CategoryID, Status: "available"}
+	for _, item := range []*models.Item{idli, vada} {
+		if err := f.store.Items.Create(ctx, item); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, line := range []struct{ item, quantity int }{{f.item.ID, 2}, {idli.ID, 1}, {vada.ID, 3}} {
+		f.do(t, f.orders.AddToCart, `{"itemId": `+strconv.Itoa(line.item)+`, "quantity": `+strconv.Itoa(line.quantity)+`}`)
+	}
+	if rec := f.do(t, f.orders.PlaceOrder, ``); rec.Code != http.StatusOK {
+		t.Fatalf("PlaceOrder = %d %s", rec.Code, rec.Body)
+	}
+	orders, _ := f.store.Orders.GetByUserID(ctx, f.buyer.ID, 0)
+	id := strconv.Itoa(orders[0].ID)
+
+	// Since the order the dosa went up, the idli was discontinued and the
+	// vada ran out.
+	dosa, _ := f.store.Items.GetByID(ctx, f.item.ID)
+	dosa.Price = 25
+	vada.Status = "unavailable"
+	for _, err := range []error{f.store.Items.Update(ctx, dosa), f.store.Items.Update(ctx, vada), f.store.Items.Delete(ctx, idli.ID)} {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	// The dosa is already in the new cart once.
+	f.do(t, f.orders.AddToCart, `{"itemId": `+strconv.Itoa(f.item.ID)+`, "quantity": 1}`)
+
+	rec := f.doID(t, f.orders.Reorder, id, ``)
+	var got struct {
+		Report struct {
+			Added []struct {
+				ItemID    int     `json:"item_id"`
+				Quantity  int     `json:"quantity"`
+				UnitPrice float64 `json:"unit_price"`
+			} `json:"added"`
+			PriceChanged []struct {
+				ItemID   int     `json:"item_id"`
+				OldPrice float64 `json:"old_price"`
+				NewPrice float64 `json:"new_price"`
+			} `json:"price_changed"`
+			Dropped []struct {
+				ItemID int    `json:"item_id"`
+				Reason string `json:"reason"`
+			} `json:"dropped"`
+		} `json:"report"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil || rec.Code != http.StatusOK {
+		t.Fatalf("Reorder = %d %s", rec.Code, rec.Body)
+	}
+	report := got.Report
+	if len(report.Added) != 1 || report.Added[0].ItemID != f.item.ID || report.Added[0].Quantity != 2 || report.Added[0].UnitPrice != 25 {
+		t.Errorf("added %+v, want the two dosas at 25", report.Added)
+	}
+	if len(report.PriceChanged) != 1 || report.PriceChanged[0].OldPrice != 20 || report.PriceChanged[0].NewPrice != 25 {
+		t.Errorf("price_changed %+v, want the dosa from 20 to 25", report.PriceChanged)
+	}
+	dropped := map[int]string{}
+	for _, d := range report.Dropped {
+		dropped[d.ItemID] = d.Reason
+	}
+	if len(dropped) != 2 || dropped[idli.ID] != "no longer sold" || dropped[vada.ID] != "not available right now" {
+		t.Errorf("dropped %+v, want the idli and the vada", report.Dropped)
+	}
+	cart, err := f.store.Orders.GetCartByUserID(ctx, f.buyer.ID)
+	if err != nil || len(cart.Items) != 1 || cart.Items[0].Quantity != 3 {
+		t.Errorf("cart %+v (%v), want the one dosa line raised to 3", cart, err)
+	}
+
+	other := &models.User{FirstName: "Oz", Email: "oz@zes.ty", UserType: "user"}
+	f.store.Users.Create(ctx, other)
+	f.buyer = other
+	if rec := f.doID(t, f.orders.Reorder, id, ``); rec.Code != http.StatusNotFound {
+		t.Errorf("reordering someone else's order = %d, want 404", rec.Code)
+	}
+}
+
+func TestReorderIntoCart(t *testing.T) {
+	f := newFixture(t, 200)
+	ctx := context.Background()
+	dosa := strconv.Itoa(f.item.ID)
+
+	f.do(t, f.orders.AddToCart, `{"itemId": `+dosa+`, "quantity": 2}`)
+	if rec := f.do(t, f.orders.PlaceOrder, ``); rec.Code != http.StatusOK {
+		t.Fatalf("PlaceOrder = %d %s", rec.Code, rec.Body)
+	}
+	orders, _ := f.store.Orders.GetByUserID(ctx, f.buyer.ID, 0)
+
+	// A nearly full line, put in the cart at the old price.
+	f.do(t, f.orders.AddToCart, `{"itemId": `+dosa+`, "quantity": 98}`)
+	item, _ := f.store.Items.GetByID(ctx, f.item.ID)
+	item.Price = 25
+	if err := f.store.Items.Update(ctx, item); err != nil {
+		t.Fatal(err)
+	}
+
+	rec := f.doID(t, f.orders.Reorder, strconv.Itoa(orders[0].ID), ``)
+	var got struct {
+		Report struct {
+			Added []struct {
+				Quantity int `json:"quantity"`
+			} `json:"added"`
+		} `json:"report"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil || rec.Code != http.StatusOK {
+		t.Fatalf("Reorder = %d %s", rec.Code, rec.Body)
+	}
+	if added := got.Report.Added; len(added) != 1 || added[0].Quantity != 1 {
+		t.Errorf("added %+v, want the one dosa that fit", added)
+	}
+	cart, err := f.store.Orders.GetCartByUserID(ctx, f.buyer.ID)
+	if err != nil || len(cart.Items) != 1 || cart.Items[0].Quantity != 99 || cart.Items[0].UnitPrice != 25 {
+		t.Errorf("cart %+v (%v), want one line of 99 at today's 25", cart, err)
+	}
+}
@@ -0,0 +1,84 @@
+package controllers
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/Entity069/Zesty-Go/pkg/models"
+	"github.com/Entity069/Zesty-Go/pkg/response"
+)
+
+// scheduleInput is when an item can be ordered, as AddItem and UpdateItem
+// take it. Fields left out keep the item's current value; menu_id 0 takes
+// the item off its menu and an empty schedule clears its own.
+type scheduleInput struct {
+	MenuID   *int
+	Schedule *models.Schedule
+}
+
+// check reports a malformed schedule or a menu that isn't the seller's.
+func (s scheduleInput) check(ctx context.Context, store *models.Store, sellerID int) error {
+	if s.Schedule != nil {
+		if err := s.Schedule.Validate(); err != nil {
+			return response.Validation(response.FieldError{Field: "schedule", Message: err.Error()})
+		}
+	}
+	if s.MenuID != nil && *s.MenuID != 0 {
+		menu, err := store.Menus.GetByID(ctx, *s.MenuID)
+		if errors.Is(err, sql.ErrNoRows) || err == nil && menu.SellerID != sellerID {
+			return response.Validation(response.FieldError{Field: "menu_id", Message: "is not one of your menus"})
+		}
+		if err != nil {
+			return response.Internal("Failed to fetch the menu", err)
+		}
+	}
+	return nil
+}
+
+// apply copies what was sent onto the item.
+func (s scheduleInput) apply(item *models.Item) {
+	if s.MenuID != nil {
+		item.MenuID = s.MenuID
+		if *s.MenuID == 0 {
+			item.MenuID = nil
+		}
+	}
+	if s.Schedule != nil {
+		item.Schedule = *s.Schedule
+	}
+}
+
+// shopNow is the time in the zone availability windows are read in.
+func shopNow(loc *time.Location) time.Time {
+	return time.Now().In(loc)
+}
+
+// withAvailability returns copies of items with Available worked out for
+// now. Listings can come from the shared items cache, so the items
+// themselves are left alone, and nothing cached goes stale when a window
+// opens or closes.
+func withAvailability(items []*models.Item, now time.Time) []*models.Item {
+	if items == nil {
+		return nil
+	}
+	out := make([]*models.Item, len(items))
+	for n, i := range items {
+		cp := *i
+		cp.Available = cp.AvailableAt(now)
+		out[n] = &cp
+	}
+	return out
+}
+
+// notAvailable is the error for ordering an item outside its schedule, or
+// one its seller has marked unavailable.
+func notAvailable(item *models.Item) error {
+	return response.Conflict(response.CodeInvalidState, item.Name+" isn't available right now.")
+}
+
+// notAvailableAt is notAvailable for an order scheduled for at.
+func notAvailableAt(item *models.Item, at time.Time) error {
+	return response.Conflict(response.CodeInvalidState, item.Name+" isn't available at "+at.Format("Mon 2 Jan 15:04")+".")
+}
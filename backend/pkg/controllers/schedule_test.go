@@ -0,0 +1,142 @@
+package controllers_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"github.com/Entity069/Zesty-Go/pkg/config"
+	"github.com/Entity069/Zesty-Go/pkg/controllers"
+	"github.com/Entity069/Zesty-Go/pkg/models"
+)
+
+func TestScheduledAvailability(t *testing.T) {
+	f := newFixture(t, 100)
+	ctx := context.Background()
+	sc := controllers.NewSellerController(config.Default(), f.store, nil, nil)
+	dosa := strconv.Itoa(f.item.ID)
+
+	send := func(h http.HandlerFunc, method, id, body string) *httptest.ResponseRecorder {
+		t.Helper()
+		req := httptest.NewRequest(method, "/", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		if id != "" {
+			req = mux.SetURLVars(req, map[string]string{"id": id})
+		}
+		return f.asSeller(t, h, req)
+	}
+	// Windows bounded by dates, so the test doesn't depend on the clock.
+	twoDaysAgo := time.Now().UTC().AddDate(0, 0, -2).Format("2006-01-02")
+	ended := `[{"ends_on": "` + twoDaysAgo + `"}]`
+	running := `[{"starts_on": "` + twoDaysAgo + `"}]`
+	available := func() bool {
+		t.Helper()
+		rec := as(t, f.buyer.ID, "user", f.orders.GetItemByID, http.MethodGet, "/", dosa)
+		var got struct {
+			Item models.Item `json:"item"`
+		}
+		json.Unmarshal(rec.Body.Bytes(), &got)
+		return got.Item.Available
+	}
+	editDosa := func(extra string) *httptest.ResponseRecorder {
+		t.Helper()
+		return send(sc.UpdateItem, http.MethodPut, dosa, `{"name": "Dosa", "description": "Crisp", "price": 20, "category": `+
+			strconv.Itoa(f.item.CategoryID)+`, "status": "available"`+extra+`}`)
+	}
+	addDosa := func() int {
+		t.Helper()
+		return f.do(t, f.orders.AddToCart, `{"itemId": `+dosa+`, "quantity": 1}`).Code
+	}
+
+	if rec := send(sc.AddMenu, http.MethodPost, "", `{"name": "Breakfast", "schedule": []}`); rec.Code != http.StatusBadRequest {
+		t.Fatalf("a menu without windows = %d %s, want 400", rec.Code, rec.Body)
+	}
+	rec := send(sc.AddMenu, http.MethodPost, "", `{"name": "Breakfast", "schedule": `+ended+`}`)
+	var added struct {
+		Menu models.Menu `json:"menu"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &added); err != nil || rec.Code != http.StatusCreated {
+		t.Fatalf("AddMenu = %d %s", rec.Code, rec.Body)
+	}
+	menu := strconv.Itoa(added.Menu.ID)
+	if rec := send(sc.AddMenu, http.MethodPost, "", `{"name": "Breakfast", "schedule": `+running+`}`); rec.Code != http.StatusConflict {
+		t.Errorf("a second menu with the same name = %d, want 409", rec.Code)
+	}
+
+	if !available() {
+		t.Fatal("an item with no schedule should be available")
+	}
+	if rec := editDosa(`, "menu_id": ` + menu); rec.Code != http.StatusOK {
+		t.Fatalf("putting the dosa on the menu = %d %s", rec.Code, rec.Body)
+	}
+	if available() {
+		t.Error("an item on a menu whose dates have passed is available")
+	}
+	if code := addDosa(); code != http.StatusConflict {
+		t.Errorf("adding an item outside its window = %d, want 409", code)
+	}
+
+	if rec := send(sc.UpdateMenu, http.MethodPut, menu, `{"name": "Breakfast", "schedule": `+running+`}`); rec.Code != http.StatusOK {
+		t.Fatalf("UpdateMenu = %d %s", rec.Code, rec.Body)
+	}
+	if !available() {
+		t.Error("the menu's new schedule wasn't picked up")
+	}
+	if code := addDosa(); code != http.StatusOK {
+		t.Fatalf("adding an item inside its window = %d", code)
+	}
+
+	// The item's own schedule closes after it went in the cart.
+	if rec := editDosa(`, "schedule": ` + ended); rec.Code != http.StatusOK {
+		t.Fatalf("giving the dosa its own schedule = %d %s", rec.Code, rec.Body)
+	}
+	if rec := f.do(t, f.orders.PlaceOrder, ``); rec.Code != http.StatusConflict || !strings.Contains(rec.Body.String(), "Dosa isn't available") {
+		t.Fatalf("ordering an item outside its window = %d %s, want 409", rec.Code, rec.Body)
+	}
+	if rec := editDosa(`, "schedule": []`); rec.Code != http.StatusOK {
+		t.Fatalf("clearing the dosa's schedule = %d %s", rec.Code, rec.Body)
+	}
+	if rec := f.do(t, f.orders.PlaceOrder, ``); rec.Code != http.StatusOK {
+		t.Fatalf("PlaceOrder back on the menu's schedule = %d %s", rec.Code, rec.Body)
+	}
+
+	other := &models.Menu{SellerID: f.buyer.ID, Name: "Not Sam's", Schedule: models.Schedule{{From: "09:00"}}}
+	f.store.Menus.Create(ctx, other)
+	fields := map[string]string{"name": "Idli", "description": "Steamed", "price": "15", "category": strconv.Itoa(f.item.CategoryID), "status": "available"}
+	for field, value := range map[string]string{
+		"schedule": `[{"days": ["funday"]}]`,
+		"menu_id":  strconv.Itoa(other.ID),
+	} {
+		fields[field] = value
+		if rec := f.postItem(t, sc.AddItem, fields, "", nil); rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), `"field":"`+field+`"`) {
+			t.Errorf("AddItem with %s %s = %d %s, want 400", field, value, rec.Code, rec.Body)
+		}
+		delete(fields, field)
+	}
+	fields["schedule"] = `[{"days": ["sat", "sun"], "from": "08:00", "until": "12:00"}]`
+	if rec := f.postItem(t, sc.AddItem, fields, "", nil); rec.Code != http.StatusOK {
+		t.Fatalf("AddItem with a schedule = %d %s", rec.Code, rec.Body)
+	}
+	items, _ := f.store.Items.GetBySellerID(ctx, f.item.SellerID)
+	if idli := items[len(items)-1]; len(idli.Schedule) != 1 || idli.Schedule[0].Until != "12:00" {
+		t.Errorf("stored schedule %+v", idli.Schedule)
+	}
+
+	if rec := send(sc.DeleteMenu, http.MethodDelete, strconv.Itoa(other.ID), ""); rec.Code != http.StatusNotFound {
+		t.Errorf("deleting another seller's menu = %d, want 404", rec.Code)
+	}
+	editDosa(`, "schedule": ` + ended)
+	if rec := send(sc.DeleteMenu, http.MethodDelete, menu, ""); rec.Code != http.StatusOK {
+		t.Fatalf("DeleteMenu = %d %s", rec.Code, rec.Body)
+	}
+	if item, _ := f.store.Items.GetByID(ctx, f.item.ID); item.MenuID != nil || len(item.Schedule) != 1 {
+		t.Errorf("after deleting its menu the dosa is %+v, want its own schedule kept", item)
+	}
+}
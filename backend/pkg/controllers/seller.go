@@ -1,356 +1,363 @@
 package controllers
 
 import (
-	"encoding/json"
-	"fmt"
-	"io"
+	"context"
 	"mime/multipart"
 	"net/http"
-	"os"
-	"path/filepath"
+	"slices"
 	"strconv"
-	"strings"
 	"time"
 
+	"github.com/Entity069/Zesty-Go/pkg/bind"
+	"github.com/Entity069/Zesty-Go/pkg/catalog"
+	"github.com/Entity069/Zesty-Go/pkg/config"
 	"github.com/Entity069/Zesty-Go/pkg/middleware"
 	"github.com/Entity069/Zesty-Go/pkg/models"
+	"github.com/Entity069/Zesty-Go/pkg/response"
+	"github.com/Entity069/Zesty-Go/pkg/storage"
+	"github.com/gorilla/mux"
 )
 
-type SellerController struct{}
-
-func NewSellerController() *SellerController {
-	return &SellerController{}
+type SellerController struct {
+	cfg     *config.Config
+	store   *models.Store
+	blobs   storage.BlobStore
+	fetcher *catalog.Fetcher
+	loc     *time.Location
 }
 
-func (sc *SellerController) jsonResp(w http.ResponseWriter, status int, payload any) {
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(status)
-	_ = json.NewEncoder(w).Encode(payload)
+// NewSellerController returns the seller handlers. fetcher downloads the
+// pictures named in catalog imports.
+func NewSellerController(cfg *config.Config, store *models.Store, blobs storage.BlobStore, fetcher *catalog.Fetcher) *SellerController {
+	return &SellerController{cfg: cfg, store: store, blobs: blobs, fetcher: fetcher, loc: cfg.Shop.Location()}
 }
 
-func (sc *SellerController) saveUploadedFile(file multipart.File, header *multipart.FileHeader) (string, error) {
-	uploadsDir := filepath.Join(".", "uploads", "item-images")
-	if err := os.MkdirAll(uploadsDir, 0755); err != nil {
-		return "", fmt.Errorf("failed to create upload directory: %v", err)
-	}
-
-	allowed := map[string]bool{
-		".jpg":  true,
-		".jpeg": true,
-		".png":  true,
-		".gif":  true,
-		".webp": true,
-	}
-
-	ext := strings.ToLower(filepath.Ext(header.Filename))
-	if !allowed[ext] {
-		return "", fmt.Errorf("invalid file type. Only images are allowed")
-	}
-
-	timestamp := time.Now().Unix()
-	filename := fmt.Sprintf("%d_%s", timestamp, header.Filename)
-	filePath := filepath.Join(uploadsDir, filename)
-
-	dst, err := os.Create(filePath)
-	if err != nil {
-		return "", fmt.Errorf("failed to create file: %v", err)
-	}
-	defer dst.Close()
-
-	if _, err := io.Copy(dst, file); err != nil {
-		return "", fmt.Errorf("failed to save file: %v", err)
-	}
-
-	return "/uploads/item-images/" + filename, nil
+func (sc *SellerController) saveUploadedFile(ctx context.Context, header *multipart.FileHeader) (string, error) {
+	return saveImage(ctx, sc.blobs, "item-images", header)
 }
 
 func (sc *SellerController) AddItem(w http.ResponseWriter, r *http.Request) {
 	claims, ok := middleware.GetUserClaims(r)
 	if !ok {
-		sc.jsonResp(w, http.StatusUnauthorized, map[string]any{"success": false, "msg": "Unauthorized"})
+		response.Fail(w, r, response.Unauthenticated("Please log in to continue."))
 		return
 	}
 
 	sellerID := claims.ID
 
-	if err := r.ParseMultipartForm(10 << 20); err != nil { // 10MB max
-		sc.jsonResp(w, http.StatusBadRequest, map[string]any{"success": false, "msg": "Failed to parse form data"})
-		return
-	}
-
-	name := r.FormValue("name")
-	description := r.FormValue("description")
-	priceStr := r.FormValue("price")
-	categoryIDStr := r.FormValue("category")
-	status := r.FormValue("status")
-
-	if name == "" || description == "" || priceStr == "" || categoryIDStr == "" || status == "" {
-		sc.jsonResp(w, http.StatusBadRequest, map[string]any{"success": false, "msg": "Please input all the fields"})
+	var body struct {
+		Name        string                `form:"name" validate:"required,max=255"`
+		Description string                `form:"description" validate:"required,max=255"`
+		Price       float64               `form:"price" validate:"required,gt=0,max=99999999"`
+		CategoryID  int                   `form:"category" validate:"required,min=1"`
+		Status      string                `form:"status" validate:"required,oneof=available unavailable discontinued"`
+		Image       *multipart.FileHeader `form:"itemImage"`
+		DietTags    models.Tags           `form:"diet_tags"`
+		Allergens   models.Tags           `form:"allergens"`
+		SpiceLevel  int                   `form:"spice_level" validate:"min=0,max=3"`
+		Nutrition   *models.Nutrition     `form:"nutrition"`
+		MenuID      int                   `form:"menu_id" validate:"min=0"`
+		Schedule    models.Schedule       `form:"schedule"`
+	}
+	if err := bind.Multipart(w, r, sc.cfg.Uploads.MaxBytes, &body); err != nil {
+		response.Fail(w, r, err)
 		return
 	}
-
-	price, err := strconv.ParseFloat(priceStr, 64)
-	if err != nil || price <= 0 {
-		sc.jsonResp(w, http.StatusBadRequest, map[string]any{"success": false, "msg": "Invalid price"})
+	diet := dietInput{DietTags: body.DietTags, Allergens: body.Allergens, SpiceLevel: &body.SpiceLevel, Nutrition: body.Nutrition}
+	if err := diet.check(); err != nil {
+		response.Fail(w, r, err)
 		return
 	}
-
-	categoryID, err := strconv.Atoi(categoryIDStr)
-	if err != nil || categoryID == 0 {
-		sc.jsonResp(w, http.StatusBadRequest, map[string]any{"success": false, "msg": "Invalid category"})
+	schedule := scheduleInput{MenuID: &body.MenuID, Schedule: &body.Schedule}
+	if err := schedule.check(r.Context(), sc.store, sellerID); err != nil {
+		response.Fail(w, r, err)
 		return
 	}
 
-	if _, err := models.GetCategoryByID(categoryID); err != nil {
-		sc.jsonResp(w, http.StatusBadRequest, map[string]any{"success": false, "msg": "Invalid category"})
+	if _, err := sc.store.Categories.GetByID(r.Context(), body.CategoryID); err != nil {
+		response.Fail(w, r, response.Validation(response.FieldError{Field: "category", Message: "is not a known category"}))
 		return
 	}
 
-	var imagePath string
-	file, header, err := r.FormFile("itemImage")
-	if err != nil {
-		imagePath = "/placeholder.svg"
-	} else {
-		defer file.Close()
-
-		imagePath, err = sc.saveUploadedFile(file, header)
+	imagePath := catalog.Placeholder
+	if body.Image != nil {
+		var err error
+		imagePath, err = sc.saveUploadedFile(r.Context(), body.Image)
 		if err != nil {
-			sc.jsonResp(w, http.StatusBadRequest, map[string]any{"success": false, "msg": err.Error()})
+			response.Fail(w, r, err)
 			return
 		}
 	}
 
 	item := &models.Item{
 		SellerID:    sellerID,
-		Name:        name,
-		Description: description,
-		Price:       price,
-		CategoryID:  categoryID,
-		Status:      status,
+		Name:        body.Name,
+		Description: body.Description,
+		Price:       body.Price,
+		CategoryID:  body.CategoryID,
+		Status:      body.Status,
 		Image:       imagePath,
 	}
+	diet.apply(item)
+	schedule.apply(item)
 
-	if err := item.Create(); err != nil {
-		if imagePath != "/placeholder.svg" {
-			cleanupPath := filepath.Join(".", strings.TrimPrefix(imagePath, "/"))
-			os.Remove(cleanupPath)
-		}
-		sc.jsonResp(w, http.StatusInternalServerError, map[string]any{"success": false, "msg": "Failed to add item"})
+	if err := sc.store.Items.Create(r.Context(), item); err != nil {
+		response.Fail(w, r, response.Internal("Failed to add item", err))
 		return
 	}
 
-	sc.jsonResp(w, http.StatusOK, map[string]any{"success": true, "msg": "Item added successfully."})
+	response.OK(w, "Item added successfully.", nil)
 }
 
 func (sc *SellerController) GetSellerItems(w http.ResponseWriter, r *http.Request) {
 	claims, ok := middleware.GetUserClaims(r)
 	if !ok {
-		sc.jsonResp(w, http.StatusUnauthorized, map[string]any{"success": false, "msg": "Unauthorized"})
+		response.Fail(w, r, response.Unauthenticated("Please log in to continue."))
 		return
 	}
 
 	sellerID := claims.ID
 
-	items, err := models.GetItemsBySellerID(sellerID)
+	list := sc.store.Items.GetBySellerID
+	if r.URL.Query().Get("deleted") == "1" {
+		list = sc.store.Items.GetDeleted
+	}
+	items, err := list(r.Context(), sellerID)
 	if err != nil {
-		sc.jsonResp(w, http.StatusInternalServerError, map[string]any{"success": false, "msg": "Failed to fetch items"})
+		response.Fail(w, r, response.Internal("Failed to fetch items", err))
 		return
 	}
 
-	sc.jsonResp(w, http.StatusOK, map[string]any{
-		"success": true,
-		"msg":     "All items fetched successfully!",
-		"items":   items,
-	})
+	response.OK(w, "All items fetched successfully!", response.Data{"items": withAvailability(items, shopNow(sc.loc))})
 }
 
 func (sc *SellerController) UpdateItem(w http.ResponseWriter, r *http.Request) {
 	claims, ok := middleware.GetUserClaims(r)
 	if !ok {
-		sc.jsonResp(w, http.StatusUnauthorized, map[string]any{"success": false, "msg": "Unauthorized"})
+		response.Fail(w, r, response.Unauthenticated("Please log in to continue."))
 		return
 	}
 
 	sellerID := claims.ID
 
-	contentType := r.Header.Get("Content-Type")
-
-	var itemID int
-	var name, description, priceStr, categoryIDStr, status string
-	var imagePath string
-	var updateImage bool
-
-	if strings.Contains(contentType, "multipart/form-data") {
-		if err := r.ParseMultipartForm(10 << 20); err != nil {
-			sc.jsonResp(w, http.StatusBadRequest, map[string]any{"success": false, "msg": "Failed to parse form data"})
-			return
-		}
-
-		idStr := r.FormValue("id")
-		name = r.FormValue("name")
-		description = r.FormValue("description")
-		priceStr = r.FormValue("price")
-		categoryIDStr = r.FormValue("category")
-		status = r.FormValue("status")
+	// The dashboard sends a multipart form, with a new image or without;
+	// API clients may send JSON and point at an existing image by path.
+	var body struct {
+		ID          int                   `json:"id" form:"id" path:"id" validate:"required"`
+		Name        string                `json:"name" form:"name" validate:"required,max=255"`
+		Description string                `json:"description" form:"description" validate:"required,max=255"`
+		Price       float64               `json:"price" form:"price" validate:"required,gt=0,max=99999999"`
+		CategoryID  int                   `json:"category" form:"category" validate:"required,min=1"`
+		Status      string                `json:"status" form:"status" validate:"required,oneof=available unavailable discontinued"`
+		Image       string                `json:"image" form:"-" validate:"max=255"`
+		ImageFile   *multipart.FileHeader `json:"-" form:"itemImage"`
+		DietTags    models.Tags           `json:"diet_tags" form:"diet_tags"`
+		Allergens   models.Tags           `json:"allergens" form:"allergens"`
+		SpiceLevel  *int                  `json:"spice_level" form:"spice_level" validate:"min=0,max=3"`
+		Nutrition   *models.Nutrition     `json:"nutrition" form:"nutrition"`
+		MenuID      *int                  `json:"menu_id" form:"menu_id" validate:"min=0"`
+		Schedule    *models.Schedule      `json:"schedule" form:"schedule"`
+	}
+	if err := bind.Body(w, r, sc.cfg.Uploads.MaxBytes, &body); err != nil {
+		response.Fail(w, r, err)
+		return
+	}
+	diet := dietInput{DietTags: body.DietTags, Allergens: body.Allergens, SpiceLevel: body.SpiceLevel, Nutrition: body.Nutrition}
+	if err := diet.check(); err != nil {
+		response.Fail(w, r, err)
+		return
+	}
+	schedule := scheduleInput{MenuID: body.MenuID, Schedule: body.Schedule}
+	if err := schedule.check(r.Context(), sc.store, sellerID); err != nil {
+		response.Fail(w, r, err)
+		return
+	}
 
+	uploaded := body.ImageFile != nil
+	imagePath := body.Image
+	if uploaded {
 		var err error
-		itemID, err = strconv.Atoi(idStr)
+		imagePath, err = sc.saveUploadedFile(r.Context(), body.ImageFile)
 		if err != nil {
-			sc.jsonResp(w, http.StatusBadRequest, map[string]any{"success": false, "msg": "Invalid item ID"})
+			response.Fail(w, r, err)
 			return
 		}
+	}
+	updateImage := imagePath != ""
 
-		file, header, err := r.FormFile("itemImage")
-		if err == nil {
-			defer file.Close()
-
-			imagePath, err = sc.saveUploadedFile(file, header)
-			if err != nil {
-				sc.jsonResp(w, http.StatusBadRequest, map[string]any{"success": false, "msg": err.Error()})
-				return
-			}
-			updateImage = true
-		}
-	} else {
-		type reqBody struct {
-			ID          int     `json:"id"`
-			Name        string  `json:"name"`
-			Description string  `json:"description"`
-			Price       float64 `json:"price"`
-			CategoryID  int     `json:"category"`
-			Status      string  `json:"status"`
-			Image       string  `json:"image,omitempty"`
-		}
+	item, err := sc.store.Items.GetByID(r.Context(), body.ID)
+	if err != nil {
+		response.Fail(w, r, response.NotFound("Item not found."))
+		return
+	}
 
-		var body reqBody
-		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
-			sc.jsonResp(w, http.StatusBadRequest, map[string]any{"success": false, "msg": "Invalid JSON"})
-			return
-		}
+	if item.SellerID != sellerID {
+		response.Fail(w, r, response.Forbidden("You can only edit your own items."))
+		return
+	}
 
-		itemID = body.ID
-		name = body.Name
-		description = body.Description
-		priceStr = fmt.Sprintf("%.2f", body.Price)
-		categoryIDStr = fmt.Sprintf("%d", body.CategoryID)
-		status = body.Status
-		if body.Image != "" {
-			imagePath = body.Image
-			updateImage = true
-		}
+	item.Name = body.Name
+	item.Description = body.Description
+	item.Price = body.Price
+	item.CategoryID = body.CategoryID
+	item.Status = body.Status
+	if updateImage {
+		item.Image = imagePath
 	}
+	diet.apply(item)
+	schedule.apply(item)
 
-	if name == "" || description == "" || priceStr == "" || categoryIDStr == "" || status == "" {
-		sc.jsonResp(w, http.StatusBadRequest, map[string]any{"success": false, "msg": "Please input all the fields"})
+	if err := sc.store.Items.Update(r.Context(), item); err != nil {
+		response.Fail(w, r, response.Internal("Update failed", err))
 		return
 	}
 
-	price, err := strconv.ParseFloat(priceStr, 64)
-	if err != nil || price <= 0 {
-		sc.jsonResp(w, http.StatusBadRequest, map[string]any{"success": false, "msg": "Invalid price"})
+	response.OK(w, "Item edited successfully.", nil)
+}
+
+// DeleteItem soft-deletes an item: it leaves the catalog and can't be
+// bought, but stays on the orders that include it and can be restored.
+// Sellers can delete their own items, admins anyone's.
+func (sc *SellerController) DeleteItem(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserClaims(r)
+	if !ok {
+		response.Fail(w, r, response.Unauthenticated("Please log in to continue."))
 		return
 	}
 
-	categoryID, err := strconv.Atoi(categoryIDStr)
-	if err != nil || categoryID == 0 {
-		sc.jsonResp(w, http.StatusBadRequest, map[string]any{"success": false, "msg": "Invalid category"})
+	itemID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		response.Fail(w, r, response.BadRequest("Invalid item ID"))
 		return
 	}
 
-	item, err := models.GetItemByID(itemID)
+	item, err := sc.store.Items.GetByID(r.Context(), itemID)
 	if err != nil {
-		sc.jsonResp(w, http.StatusBadRequest, map[string]any{"success": false, "msg": "No such item exists!"})
+		response.Fail(w, r, response.NotFound("Item not found."))
+		return
+	}
+	if !canManageItem(claims, item) {
+		response.Fail(w, r, response.Forbidden("You can only delete your own items."))
 		return
 	}
 
-	if item.SellerID != sellerID {
-		sc.jsonResp(w, http.StatusForbidden, map[string]any{"success": false, "msg": "Go away and never show your face!"})
+	if err := sc.store.Items.Delete(r.Context(), item.ID); err != nil {
+		response.Fail(w, r, response.Internal("Delete failed", err))
 		return
 	}
 
-	oldImagePath := item.Image
+	response.OK(w, "Item deleted.", nil)
+}
 
-	item.Name = name
-	item.Description = description
-	item.Price = price
-	item.CategoryID = categoryID
-	item.Status = status
-	if updateImage {
-		item.Image = imagePath
+// RestoreItem puts a deleted item back in the catalog, with the status it
+// had. An item can't be restored into a deleted category.
+func (sc *SellerController) RestoreItem(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserClaims(r)
+	if !ok {
+		response.Fail(w, r, response.Unauthenticated("Please log in to continue."))
+		return
 	}
 
-	if err := item.Update(); err != nil {
-		if updateImage && imagePath != "/placeholder.svg" && strings.Contains(contentType, "multipart/form-data") {
-			os.Remove(strings.TrimPrefix(imagePath, "/"))
-		}
-		sc.jsonResp(w, http.StatusInternalServerError, map[string]any{"success": false, "msg": "Update failed"})
+	itemID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		response.Fail(w, r, response.BadRequest("Invalid item ID"))
+		return
+	}
+
+	sellerID := claims.ID
+	if claims.Role == "admin" {
+		sellerID = 0
+	}
+	deleted, err := sc.store.Items.GetDeleted(r.Context(), sellerID)
+	if err != nil {
+		response.Fail(w, r, response.Internal("Failed to fetch items", err))
+		return
+	}
+	i := slices.IndexFunc(deleted, func(it *models.Item) bool { return it.ID == itemID })
+	if i < 0 {
+		response.Fail(w, r, response.NotFound("No deleted item has this ID."))
 		return
 	}
+	item := deleted[i]
 
-	if updateImage && oldImagePath != "/placeholder.svg" && oldImagePath != imagePath && strings.Contains(contentType, "multipart/form-data") {
-		go func() {
-			os.Remove(strings.TrimPrefix(oldImagePath, "/"))
-		}()
+	if _, err := sc.store.Categories.GetByID(r.Context(), item.CategoryID); err != nil {
+		response.Fail(w, r, response.Conflict(response.CodeInvalidState, "The item's category is deleted. Restore the category first."))
+		return
+	}
+
+	if err := sc.store.Items.Restore(r.Context(), item.ID); err != nil {
+		response.Fail(w, r, response.Internal("Restore failed", err))
+		return
 	}
 
-	sc.jsonResp(w, http.StatusOK, map[string]any{"success": true, "msg": "Item edited successfully."})
+	response.OK(w, "Item restored.", nil)
+}
+
+func canManageItem(claims *middleware.UserClaims, item *models.Item) bool {
+	return claims.Role == "admin" || item.SellerID == claims.ID
 }
 
 func (sc *SellerController) GetSellerOrders(w http.ResponseWriter, r *http.Request) {
 	claims, ok := middleware.GetUserClaims(r)
 	if !ok {
-		sc.jsonResp(w, http.StatusUnauthorized, map[string]any{"success": false, "msg": "Unauthorized"})
+		response.Fail(w, r, response.Unauthenticated("Please log in to continue."))
 		return
 	}
 
 	sellerID := claims.ID
 
-	orders, err := models.GetOrdersBySellerID(sellerID, 0)
+	orders, err := sc.store.Orders.GetBySellerID(r.Context(), sellerID, 0)
 	if err != nil {
-		sc.jsonResp(w, http.StatusInternalServerError, map[string]any{"success": false, "msg": "Failed to fetch orders"})
+		response.Fail(w, r, response.Internal("Failed to fetch orders", err))
 		return
 	}
 
-	sc.jsonResp(w, http.StatusOK, map[string]any{"success": true, "msg": "All orders fetched successfully!", "orders": orders})
+	response.OK(w, "All orders fetched successfully!", response.Data{"orders": orders})
 }
 
 func (sc *SellerController) GetSellerStats(w http.ResponseWriter, r *http.Request) {
 	claims, ok := middleware.GetUserClaims(r)
 	if !ok {
-		sc.jsonResp(w, http.StatusUnauthorized, map[string]any{"success": false, "msg": "Unauthorized"})
+		response.Fail(w, r, response.Unauthenticated("Please log in to continue."))
 		return
 	}
 
 	sellerID := claims.ID
 
-	revenue, _ := models.GetSellerRevenue(sellerID)
-	itemCount, _ := models.GetSellerItemCount(sellerID)
-	orderCount, _ := models.GetSellerOrderCount(sellerID)
-	customerCount, _ := models.GetSellerCustomerCount(sellerID)
+	revenue, _ := sc.store.Stats.GetSellerRevenue(r.Context(), sellerID)
+	itemCount, _ := sc.store.Stats.GetSellerItemCount(r.Context(), sellerID)
+	orderCount, _ := sc.store.Stats.GetSellerOrderCount(r.Context(), sellerID)
+	customerCount, _ := sc.store.Stats.GetSellerCustomerCount(r.Context(), sellerID)
 
-	sc.jsonResp(w, http.StatusOK, map[string]any{"success": true, "data": map[string]any{"revenue": revenue, "items": itemCount, "orders": orderCount, "customers": customerCount}})
+	response.OK(w, "", response.Data{"data": map[string]any{"revenue": revenue, "items": itemCount, "orders": orderCount, "customers": customerCount}})
 }
 
 func (sc *SellerController) UpdateOrderItemStatus(w http.ResponseWriter, r *http.Request) {
 	_, ok := middleware.GetUserClaims(r)
 	if !ok {
-		sc.jsonResp(w, http.StatusUnauthorized, map[string]any{"success": false, "msg": "Unauthorized"})
+		response.Fail(w, r, response.Unauthenticated("Please log in to continue."))
 		return
 	}
 
 	type reqBody struct {
-		ID int `json:"id"`
+		ID int `json:"id" path:"id" validate:"required"`
 	}
 
 	var body reqBody
-	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
-		sc.jsonResp(w, http.StatusBadRequest, map[string]any{"success": false, "msg": "Invalid JSON"})
+	if err := bind.JSON(w, r, &body); err != nil {
+		response.Fail(w, r, err)
 		return
 	}
 
-	item, err := models.GetOrderItemByID(body.ID)
+	item, err := sc.store.OrderItems.GetByID(r.Context(), body.ID)
 	if err != nil {
-		sc.jsonResp(w, http.StatusBadRequest, map[string]any{"success": false, "msg": "No such item exists!"})
+		response.Fail(w, r, response.NotFound("Item not found."))
+		return
+	}
+
+	// a scheduled order stays out of the kitchen until it is released
+	if item.Status == "scheduled" {
+		response.Fail(w, r, response.Conflict(response.CodeInvalidState, "This order is scheduled and hasn't been released yet."))
 		return
 	}
 
@@ -360,16 +367,16 @@ func (sc *SellerController) UpdateOrderItemStatus(w http.ResponseWriter, r *http
 		item.Status = "prepared"
 	}
 
-	if err := item.UpdateStatus(item.Status); err != nil {
-		sc.jsonResp(w, http.StatusInternalServerError, map[string]any{"success": false, "msg": "Update failed"})
+	if err := sc.store.OrderItems.UpdateStatus(r.Context(), item, item.Status); err != nil {
+		response.Fail(w, r, response.Internal("Update failed", err))
 		return
 	}
 
-	err = models.SyncStatus(item.OrderID)
+	err = sc.store.Orders.SyncStatus(r.Context(), item.OrderID)
 	if err != nil {
-		sc.jsonResp(w, http.StatusInternalServerError, map[string]any{"success": false, "msg": "Failed to sync status"})
+		response.Fail(w, r, response.Internal("Failed to sync status", err))
 		return
 	}
 
-	sc.jsonResp(w, http.StatusOK, map[string]any{"success": true, "msg": "Item status updated successfully."})
+	response.OK(w, "Item status updated successfully.", nil)
 }
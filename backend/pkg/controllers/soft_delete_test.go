@@ -0,0 +1,180 @@
+package controllers_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/gorilla/mux"
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/Entity069/Zesty-Go/pkg/config"
+	"github.com/Entity069/Zesty-Go/pkg/controllers"
+	"github.com/Entity069/Zesty-Go/pkg/middleware"
+	"github.com/Entity069/Zesty-Go/pkg/models"
+)
+
+// as serves a request with h, signed in as userID with role. id fills the
+// route's {id} when not empty.
+func as(t *testing.T, userID int, role string, h http.HandlerFunc, method, target, id string) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(method, target, nil)
+	if id != "" {
+		req = mux.SetURLVars(req, map[string]string{"id": id})
+	}
+	return serveAs(t, userID, role, h, req)
+}
+
+// serveAs serves req with h, signed in as userID with role.
+func serveAs(t *testing.T, userID int, role string, h http.HandlerFunc, req *http.Request) *httptest.ResponseRecorder {
+	t.Helper()
+	tok, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"id":   userID,
+		"role": role,
+		"exp":  time.Now().Add(time.Hour).Unix(),
+	}).SignedString(testSecret)
+	if err != nil {
+		t.Fatalf("signing token: %v", err)
+	}
+	req.AddCookie(&http.Cookie{Name: "token", Value: tok})
+	rec := httptest.NewRecorder()
+	middleware.NewAuth(testSecret, nil).RoleRequired(role)(h).ServeHTTP(rec, req)
+	return rec
+}
+
+func TestSoftDeleteKeepsHistory(t *testing.T) {
+	f := newFixture(t, 50)
+	ctx := context.Background()
+	sc := controllers.NewSellerController(config.Default(), f.store, nil, nil)
+	ac := controllers.NewAdminController(config.Default(), f.store, nil)
+	admin := &models.User{FirstName: "Ada", LastName: "Admin", Email: "ada@zes.ty", UserType: "admin"}
+	if err := f.store.Users.Create(ctx, admin); err != nil {
+		t.Fatal(err)
+	}
+	sellerID, itemID := f.item.SellerID, strconv.Itoa(f.item.ID)
+	categoryID := strconv.Itoa(f.item.CategoryID)
+	addDosa := `{"itemId": ` + itemID + `, "quantity": 1}`
+
+	f.do(t, f.orders.AddToCart, addDosa)
+	if rec := f.do(t, f.orders.PlaceOrder, ``); rec.Code != http.StatusOK {
+		t.Fatalf("PlaceOrder = %d %s", rec.Code, rec.Body)
+	}
+	f.do(t, f.orders.AddToCart, addDosa)
+
+	if rec := as(t, sellerID+100, "seller", sc.DeleteItem, http.MethodDelete, "/", itemID); rec.Code != http.StatusForbidden {
+		t.Fatalf("deleting another seller's item = %d %s, want 403", rec.Code, rec.Body)
+	}
+	if rec := as(t, sellerID, "seller", sc.DeleteItem, http.MethodDelete, "/", itemID); rec.Code != http.StatusOK {
+		t.Fatalf("DeleteItem = %d %s", rec.Code, rec.Body)
+	}
+	if rec := as(t, sellerID, "seller", sc.DeleteItem, http.MethodDelete, "/", itemID); rec.Code != http.StatusNotFound {
+		t.Fatalf("deleting it again = %d %s, want 404", rec.Code, rec.Body)
+	}
+
+	if items, _ := f.store.Items.GetAll(ctx, 0); len(items) != 0 {
+		t.Errorf("catalog = %+v, want the deleted item left out", items)
+	}
+	if rec := f.do(t, f.orders.AddToCart, addDosa); rec.Code != http.StatusNotFound {
+		t.Errorf("AddToCart of a deleted item = %d %s, want 404", rec.Code, rec.Body)
+	}
+	if rec := f.do(t, f.orders.PlaceOrder, ``); rec.Code != http.StatusConflict {
+		t.Errorf("PlaceOrder with a deleted item in the cart = %d %s, want 409", rec.Code, rec.Body)
+	}
+	if revenue, _ := f.store.Stats.GetTotalRevenue(ctx); revenue != 20 {
+		t.Errorf("revenue = %v, want the order placed before the delete", revenue)
+	}
+	if orders, _ := f.store.Orders.GetByUserID(ctx, f.buyer.ID, 0); len(orders) != 1 || len(orders[0].Items) != 1 {
+		t.Errorf("orders = %+v, want the order and its line kept", orders)
+	}
+
+	rec := as(t, sellerID, "seller", sc.GetSellerItems, http.MethodGet, "/?deleted=1", "")
+	var got struct {
+		Items []models.Item `json:"items"`
+	}
+	json.Unmarshal(rec.Body.Bytes(), &got)
+	if len(got.Items) != 1 || got.Items[0].DeletedAt == nil {
+		t.Fatalf("deleted items = %s", rec.Body)
+	}
+
+	// An item can't come back into a deleted category.
+	if rec := as(t, admin.ID, "admin", ac.DeleteCategory, http.MethodDelete, "/", categoryID); rec.Code != http.StatusOK {
+		t.Fatalf("DeleteCategory = %d %s", rec.Code, rec.Body)
+	}
+	if rec := as(t, sellerID, "seller", sc.RestoreItem, http.MethodPost, "/", itemID); rec.Code != http.StatusConflict {
+		t.Fatalf("restoring into a deleted category = %d %s, want 409", rec.Code, rec.Body)
+	}
+	if rec := as(t, admin.ID, "admin", ac.RestoreCategory, http.MethodPost, "/", categoryID); rec.Code != http.StatusOK {
+		t.Fatalf("RestoreCategory = %d %s", rec.Code, rec.Body)
+	}
+	if rec := as(t, admin.ID, "admin", sc.RestoreItem, http.MethodPost, "/", itemID); rec.Code != http.StatusOK {
+		t.Fatalf("RestoreItem as admin = %d %s", rec.Code, rec.Body)
+	}
+	if _, err := f.store.Items.GetByID(ctx, f.item.ID); err != nil {
+		t.Fatalf("restored item: %v", err)
+	}
+	if rec := as(t, admin.ID, "admin", ac.DeleteCategory, http.MethodDelete, "/", categoryID); rec.Code != http.StatusConflict {
+		t.Fatalf("deleting a category with items = %d %s, want 409", rec.Code, rec.Body)
+	}
+}
+
+func TestDeleteUser(t *testing.T) {
+	f := newFixture(t, 50)
+	ctx := context.Background()
+	ac := controllers.NewAdminController(config.Default(), f.store, nil)
+	auth := controllers.NewAuthController(config.Default(), f.store, nil)
+	admin := &models.User{FirstName: "Ada", LastName: "Admin", Email: "ada@zes.ty", UserType: "admin"}
+	if err := f.store.Users.Create(ctx, admin); err != nil {
+		t.Fatal(err)
+	}
+	hash, _ := bcrypt.GenerateFromPassword([]byte("hunter22"), bcrypt.MinCost)
+	f.store.Users.UpdatePassword(ctx, f.buyer, string(hash))
+	f.store.Users.EmailVerify(ctx, f.buyer)
+	login := func() int {
+		req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"email": "bo@zes.ty", "password": "hunter22"}`))
+		rec := httptest.NewRecorder()
+		auth.Login(rec, req)
+		return rec.Code
+	}
+	if code := login(); code != http.StatusCreated {
+		t.Fatalf("login before the delete = %d", code)
+	}
+
+	buyerID := strconv.Itoa(f.buyer.ID)
+	if rec := as(t, admin.ID, "admin", ac.DeleteUser, http.MethodDelete, "/", strconv.Itoa(admin.ID)); rec.Code != http.StatusBadRequest {
+		t.Fatalf("deleting oneself = %d %s, want 400", rec.Code, rec.Body)
+	}
+	if rec := as(t, admin.ID, "admin", ac.DeleteUser, http.MethodDelete, "/", buyerID); rec.Code != http.StatusOK {
+		t.Fatalf("DeleteUser = %d %s", rec.Code, rec.Body)
+	}
+	if code := login(); code != http.StatusUnauthorized {
+		t.Errorf("login after the delete = %d, want 401", code)
+	}
+	if users, _ := f.store.Users.GetAll(ctx); len(users) != 2 {
+		t.Errorf("users = %d, want the deleted buyer left out", len(users))
+	}
+
+	rec := as(t, admin.ID, "admin", ac.Deleted, http.MethodGet, "/", "")
+	var got struct {
+		Users []models.User `json:"users"`
+	}
+	json.Unmarshal(rec.Body.Bytes(), &got)
+	if len(got.Users) != 1 || got.Users[0].ID != f.buyer.ID {
+		t.Fatalf("deleted = %s", rec.Body)
+	}
+
+	if rec := as(t, admin.ID, "admin", ac.RestoreUser, http.MethodPost, "/", buyerID); rec.Code != http.StatusOK {
+		t.Fatalf("RestoreUser = %d %s", rec.Code, rec.Body)
+	}
+	if rec := as(t, admin.ID, "admin", ac.RestoreUser, http.MethodPost, "/", buyerID); rec.Code != http.StatusNotFound {
+		t.Fatalf("restoring a user who isn't deleted = %d %s, want 404", rec.Code, rec.Body)
+	}
+	if code := login(); code != http.StatusCreated {
+		t.Errorf("login after the restore = %d", code)
+	}
+}
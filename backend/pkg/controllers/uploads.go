@@ -0,0 +1,44 @@
+package controllers
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"mime/multipart"
+
+	"github.com/Entity069/Zesty-Go/pkg/images"
+	"github.com/Entity069/Zesty-Go/pkg/response"
+	"github.com/Entity069/Zesty-Go/pkg/storage"
+)
+
+// saveImage re-encodes an uploaded picture into its variants, stores them
+// under dir and returns the path to record on the item or user. The file's
+// name and declared type are ignored; only its content counts.
+//
+// Nothing deletes uploads inline: a file left behind by a failed insert or a
+// replaced picture is found and reclaimed by uploadgc once no record has
+// pointed at it for the grace period.
+func saveImage(ctx context.Context, blobs storage.BlobStore, dir string, header *multipart.FileHeader) (string, error) {
+	file, err := header.Open()
+	if err != nil {
+		return "", response.Internal("Failed to save the image", err)
+	}
+	defer file.Close()
+
+	files, err := images.Process(file, dir)
+	switch {
+	case errors.Is(err, images.ErrNotImage):
+		return "", response.BadRequest("Invalid file type. Only JPG, PNG, GIF and WebP images are allowed.")
+	case errors.Is(err, images.ErrTooLarge):
+		return "", response.BadRequest("The image is too large. Please upload a smaller picture.")
+	case err != nil:
+		return "", response.Internal("Failed to save the image", err)
+	}
+
+	for _, f := range files {
+		if err := blobs.Put(ctx, f.Key, bytes.NewReader(f.Data), int64(len(f.Data)), f.ContentType); err != nil {
+			return "", response.Internal("Failed to save the image", err)
+		}
+	}
+	return storage.Path(files[len(files)-1].Key), nil
+}
@@ -0,0 +1,152 @@
+package controllers_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"image"
+	"image/color"
+	"image/png"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"github.com/Entity069/Zesty-Go/pkg/config"
+	"github.com/Entity069/Zesty-Go/pkg/controllers"
+	"github.com/Entity069/Zesty-Go/pkg/images"
+	"github.com/Entity069/Zesty-Go/pkg/middleware"
+	"github.com/Entity069/Zesty-Go/pkg/storage"
+)
+
+func pngOf(t *testing.T, w, h int, c color.Color) []byte {
+	t.Helper()
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			img.Set(x, y, c)
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// postItem sends the seller dashboard's multipart form to h as the fixture's
+// seller.
+func (f *fixture) postItem(t *testing.T, h http.HandlerFunc, fields map[string]string, filename string, file []byte) *httptest.ResponseRecorder {
+	t.Helper()
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	for k, v := range fields {
+		mw.WriteField(k, v)
+	}
+	if file != nil {
+		fw, _ := mw.CreateFormFile("itemImage", filename)
+		fw.Write(file)
+	}
+	mw.Close()
+
+	req := httptest.NewRequest(http.MethodPost, "/", &body)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	return f.asSeller(t, h, req)
+}
+
+// asSeller serves req with h as the fixture's seller.
+func (f *fixture) asSeller(t *testing.T, h http.HandlerFunc, req *http.Request) *httptest.ResponseRecorder {
+	t.Helper()
+	tok, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"id":   f.item.SellerID,
+		"role": "seller",
+		"exp":  time.Now().Add(time.Hour).Unix(),
+	}).SignedString(testSecret)
+	if err != nil {
+		t.Fatalf("signing token: %v", err)
+	}
+	req.AddCookie(&http.Cookie{Name: "token", Value: tok})
+	rec := httptest.NewRecorder()
+	middleware.NewAuth(testSecret, nil).SellerRequired(h).ServeHTTP(rec, req)
+	return rec
+}
+
+func storedKeys(t *testing.T, bs storage.BlobStore) []string {
+	t.Helper()
+	var keys []string
+	if err := bs.Walk(context.Background(), "", func(o storage.Object) error {
+		keys = append(keys, o.Key)
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	return keys
+}
+
+func TestItemImageUploads(t *testing.T) {
+	f := newFixture(t, 0)
+	ctx := context.Background()
+	blobs := storage.NewLocal(t.TempDir())
+	sc := controllers.NewSellerController(config.Default(), f.store, blobs, nil)
+	fields := map[string]string{"name": "Idli", "description": "Steamed", "price": "30", "category": strconv.Itoa(f.item.CategoryID), "status": "available"}
+
+	if rec := f.postItem(t, sc.AddItem, fields, "menu.png", []byte("<?php system($_GET['c']); ?>")); rec.Code != http.StatusBadRequest {
+		t.Fatalf("a script named .png: %d %s", rec.Code, rec.Body)
+	}
+	if keys := storedKeys(t, blobs); len(keys) != 0 {
+		t.Fatalf("rejected upload left files: %v", keys)
+	}
+
+	// Two items with the same picture share its files.
+	red := pngOf(t, 600, 400, color.RGBA{R: 200, A: 255})
+	for range 2 {
+		if rec := f.postItem(t, sc.AddItem, fields, "photo.exe", red); rec.Code != http.StatusOK {
+			t.Fatalf("AddItem: %d %s", rec.Code, rec.Body)
+		}
+	}
+	if keys := storedKeys(t, blobs); len(keys) != 3 {
+		t.Fatalf("stored %v, want the 160, 480 and 600 wide files once", keys)
+	}
+
+	items, err := f.store.Items.GetBySellerID(ctx, f.item.SellerID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var added []int
+	for _, it := range items {
+		if it.Name == "Idli" {
+			added = append(added, it.ID)
+		}
+	}
+	first, _ := f.store.Items.GetByID(ctx, added[0])
+	if !strings.HasSuffix(first.Image, "-600w.jpg") {
+		t.Fatalf("image = %q, want the full-size JPEG", first.Image)
+	}
+	raw, _ := json.Marshal(first)
+	var got struct {
+		Srcset string `json:"image_srcset"`
+	}
+	json.Unmarshal(raw, &got)
+	if got.Srcset != images.Srcset(first.Image) || !strings.Contains(got.Srcset, "-160w.jpg 160w") {
+		t.Fatalf("image_srcset = %q", got.Srcset)
+	}
+
+	// Replacing one item's picture keeps the files the other still shows;
+	// only the upload collector deletes anything.
+	fields["id"] = strconv.Itoa(first.ID)
+	if rec := f.postItem(t, sc.UpdateItem, fields, "new.png", pngOf(t, 100, 100, color.RGBA{B: 200, A: 255})); rec.Code != http.StatusOK {
+		t.Fatalf("UpdateItem: %d %s", rec.Code, rec.Body)
+	}
+	paths, _ := images.Variants(first.Image)
+	for _, p := range paths {
+		key, _ := storage.KeyFromPath(p)
+		if _, err := blobs.Stat(ctx, key); err != nil {
+			t.Errorf("%s was removed while another item uses it: %v", key, err)
+		}
+	}
+}
@@ -1,219 +1,180 @@
 package controllers
 
 import (
-	"encoding/json"
-	"fmt"
-	"io"
+	"context"
 	"mime/multipart"
 	"net/http"
-	"os"
-	"path/filepath"
-	"strings"
-	"time"
 
+	"github.com/Entity069/Zesty-Go/pkg/bind"
+	"github.com/Entity069/Zesty-Go/pkg/config"
+	"github.com/Entity069/Zesty-Go/pkg/images"
 	"github.com/Entity069/Zesty-Go/pkg/middleware"
 	"github.com/Entity069/Zesty-Go/pkg/models"
+	"github.com/Entity069/Zesty-Go/pkg/response"
+	"github.com/Entity069/Zesty-Go/pkg/storage"
 	"golang.org/x/crypto/bcrypt"
 )
 
-type UserController struct{}
+type UserController struct {
+	cfg   *config.Config
+	store *models.Store
+	blobs storage.BlobStore
+}
 
-func NewUserController() *UserController {
-	return &UserController{}
+func NewUserController(cfg *config.Config, store *models.Store, blobs storage.BlobStore) *UserController {
+	return &UserController{cfg: cfg, store: store, blobs: blobs}
 }
 
-func (uc *UserController) jsonResp(w http.ResponseWriter, status int, payload any) {
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(status)
-	_ = json.NewEncoder(w).Encode(payload)
+func (uc *UserController) saveProfilePic(ctx context.Context, header *multipart.FileHeader) (string, error) {
+	return saveImage(ctx, uc.blobs, "profile-pics", header)
 }
 
-func (uc *UserController) saveProfilePic(file multipart.File, header *multipart.FileHeader) (string, error) {
-	uploadsDir := filepath.Join(".", "uploads", "profile-pics")
-	if err := os.MkdirAll(uploadsDir, 0755); err != nil {
-		return "", fmt.Errorf("failed to create upload directory: %v", err)
+func (uc *UserController) UpdateUserAddress(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserClaims(r)
+	if !ok {
+		response.Fail(w, r, response.Unauthenticated("Please log in to continue."))
+		return
 	}
 
-	allowed := map[string]bool{
-		".jpg":  true,
-		".jpeg": true,
-		".png":  true,
-		".gif":  true,
-		".webp": true,
-	}
+	userID := claims.ID
 
-	ext := strings.ToLower(filepath.Ext(header.Filename))
-	if !allowed[ext] {
-		return "", fmt.Errorf("invalid file type. Only images are allowed")
+	type reqBody struct {
+		Address string `json:"addr" validate:"required,max=255"`
+	}
+	var body reqBody
+	if err := bind.JSON(w, r, &body); err != nil {
+		response.Fail(w, r, err)
+		return
 	}
 
-	timestamp := time.Now().Unix()
-	filename := fmt.Sprintf("profile_%d%s", timestamp, ext)
-	filePath := filepath.Join(uploadsDir, filename)
-
-	dst, err := os.Create(filePath)
+	user, err := uc.store.Users.GetByID(r.Context(), userID)
 	if err != nil {
-		return "", fmt.Errorf("failed to create file: %v", err)
+		response.Fail(w, r, response.NotFound("User not found"))
+		return
 	}
-	defer dst.Close()
 
-	if _, err := io.Copy(dst, file); err != nil {
-		return "", fmt.Errorf("failed to save file: %v", err)
+	user.Address = body.Address
+	if err := uc.store.Users.Update(r.Context(), user); err != nil {
+		response.Fail(w, r, response.Internal("Update failed", err))
+		return
 	}
 
-	return "/uploads/profile-pics/" + filename, nil
+	response.OK(w, "Address updated successfully.", nil)
 }
 
-func (uc *UserController) UpdateUserAddress(w http.ResponseWriter, r *http.Request) {
+// UpdateUserAllergens replaces the allergens the user wants the cart to warn
+// about. An empty list turns the warnings off.
+func (uc *UserController) UpdateUserAllergens(w http.ResponseWriter, r *http.Request) {
 	claims, ok := middleware.GetUserClaims(r)
 	if !ok {
-		uc.jsonResp(w, http.StatusUnauthorized, map[string]any{"success": false, "msg": "Unauthorized"})
+		response.Fail(w, r, response.Unauthenticated("Please log in to continue."))
 		return
 	}
 
-	userID := claims.ID
-
-	type reqBody struct {
-		Address string `json:"addr"`
+	var body struct {
+		Allergens models.Tags `json:"allergens"`
 	}
-	var body reqBody
-	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
-		uc.jsonResp(w, http.StatusBadRequest, map[string]any{"success": false, "msg": "Invalid JSON"})
+	if err := bind.JSON(w, r, &body); err != nil {
+		response.Fail(w, r, err)
+		return
+	}
+	if err := (dietInput{Allergens: body.Allergens}).check(); err != nil {
+		response.Fail(w, r, err)
 		return
 	}
 
-	user, err := models.GetUserByID(userID)
+	user, err := uc.store.Users.GetByID(r.Context(), claims.ID)
 	if err != nil {
-		uc.jsonResp(w, http.StatusNotFound, map[string]any{"success": false, "msg": "User not found"})
+		response.Fail(w, r, response.NotFound("User not found"))
 		return
 	}
 
-	user.Address = body.Address
-	if err := user.Update(); err != nil {
-		uc.jsonResp(w, http.StatusInternalServerError, map[string]any{"success": false, "msg": "Update failed"})
+	user.Allergens = models.NewTags(body.Allergens...)
+	if err := uc.store.Users.Update(r.Context(), user); err != nil {
+		response.Fail(w, r, response.Internal("Update failed", err))
 		return
 	}
 
-	uc.jsonResp(w, http.StatusOK, map[string]any{"success": true, "msg": "Address updated successfully."})
+	response.OK(w, "Allergens updated successfully.", response.Data{"allergens": user.Allergens})
 }
 
 func (uc *UserController) UpdateUserBalance(w http.ResponseWriter, r *http.Request) {
 	claims, ok := middleware.GetUserClaims(r)
 	if !ok {
-		uc.jsonResp(w, http.StatusUnauthorized, map[string]any{"success": false, "msg": "Unauthorized"})
+		response.Fail(w, r, response.Unauthenticated("Please log in to continue."))
 		return
 	}
 
 	userID := claims.ID
 
 	type reqBody struct {
-		Balance float64 `json:"balance"`
+		Balance float64 `json:"balance" validate:"required,gt=0,max=99999999"`
 	}
 	var body reqBody
-	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
-		uc.jsonResp(w, http.StatusBadRequest, map[string]any{"success": false, "msg": "Invalid JSON"})
+	if err := bind.JSON(w, r, &body); err != nil {
+		response.Fail(w, r, err)
 		return
 	}
 
-	if body.Balance <= 0 || body.Balance > 99999999 {
-		uc.jsonResp(w, http.StatusBadRequest, map[string]any{"success": false, "msg": "Balance should be between 0 and 99,999,999!"})
-		return
-	}
-
-	user, err := models.GetUserByID(userID)
+	user, err := uc.store.Users.GetByID(r.Context(), userID)
 	if err != nil {
-		uc.jsonResp(w, http.StatusNotFound, map[string]any{"success": false, "msg": "User not found"})
+		response.Fail(w, r, response.NotFound("User not found"))
 		return
 	}
 
-	if err := user.UpdateBalance(body.Balance + user.Balance); err != nil {
-		uc.jsonResp(w, http.StatusInternalServerError, map[string]any{"success": false, "msg": "Update failed"})
+	if err := uc.store.Users.AddBalance(r.Context(), user, body.Balance); err != nil {
+		response.Fail(w, r, response.Internal("Update failed", err))
 		return
 	}
 
-	uc.jsonResp(w, http.StatusOK, map[string]any{"success": true, "msg": "Balance added successfully."})
+	response.OK(w, "Balance added successfully.", nil)
 }
 
 func (uc *UserController) UpdateUserDetails(w http.ResponseWriter, r *http.Request) {
 	claims, ok := middleware.GetUserClaims(r)
 	if !ok {
-		uc.jsonResp(w, http.StatusUnauthorized, map[string]any{"success": false, "msg": "Unauthorized"})
+		response.Fail(w, r, response.Unauthenticated("Please log in to continue."))
 		return
 	}
 
 	userID := claims.ID
 
-	contentType := r.Header.Get("Content-Type")
-	isMultipart := strings.Contains(contentType, "multipart/form-data")
-
-	var firstName, lastName, email, address, currentPwd, newPassword string
-	var profilePicPath string
-	var updateProfilePic bool
-
-	if isMultipart {
-		if err := r.ParseMultipartForm(10 << 20); err != nil { // 10MB max
-			uc.jsonResp(w, http.StatusBadRequest, map[string]any{"success": false, "msg": "Failed to parse form data"})
-			return
-		}
-
-		firstName = r.FormValue("first_name")
-		lastName = r.FormValue("last_name")
-		email = r.FormValue("email")
-		address = r.FormValue("addr")
-		currentPwd = r.FormValue("currPwd")
-		newPassword = r.FormValue("newPwd")
-
-		file, header, err := r.FormFile("profilePic")
-		if err == nil {
-			defer file.Close()
-
-			profilePicPath, err = uc.saveProfilePic(file, header)
-			if err != nil {
-				uc.jsonResp(w, http.StatusBadRequest, map[string]any{"success": false, "msg": err.Error()})
-				return
-			}
-			updateProfilePic = true
-		}
-	} else {
-		type reqBody struct {
-			FirstName   string `json:"first_name"`
-			LastName    string `json:"last_name"`
-			Email       string `json:"email"`
-			Address     string `json:"addr"`
-			CurrentPwd  string `json:"currPwd"`
-			NewPassword string `json:"newPwd"`
-		}
-		var body reqBody
-		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
-			uc.jsonResp(w, http.StatusBadRequest, map[string]any{"success": false, "msg": "Invalid JSON"})
-			return
-		}
-
-		firstName = body.FirstName
-		lastName = body.LastName
-		email = body.Email
-		address = body.Address
-		currentPwd = body.CurrentPwd
-		newPassword = body.NewPassword
-	}
-
-	if firstName == "" || lastName == "" || email == "" || currentPwd == "" {
-		uc.jsonResp(w, http.StatusBadRequest, map[string]any{"success": false, "msg": "Please fill in all required fields"})
+	var body struct {
+		FirstName   string                `json:"first_name" form:"first_name" validate:"required,max=255"`
+		LastName    string                `json:"last_name" form:"last_name" validate:"required,max=255"`
+		Email       string                `json:"email" form:"email" validate:"required,email,max=255"`
+		Address     string                `json:"addr" form:"addr" validate:"max=255"`
+		CurrentPwd  string                `json:"currPwd" form:"currPwd" validate:"required"`
+		NewPassword string                `json:"newPwd" form:"newPwd" validate:"password"`
+		ProfilePic  *multipart.FileHeader `json:"-" form:"profilePic"`
+	}
+	if err := bind.Body(w, r, uc.cfg.Uploads.MaxBytes, &body); err != nil {
+		response.Fail(w, r, err)
 		return
 	}
+	firstName, lastName, email, address := body.FirstName, body.LastName, body.Email, body.Address
+	newPassword := body.NewPassword
 
-	user, err := models.GetUserByID(userID)
+	user, err := uc.store.Users.GetByID(r.Context(), userID)
 	if err != nil {
-		uc.jsonResp(w, http.StatusNotFound, map[string]any{"success": false, "msg": "User not found"})
+		response.Fail(w, r, response.NotFound("User not found"))
 		return
 	}
 
-	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(currentPwd)); err != nil {
-		uc.jsonResp(w, http.StatusBadRequest, map[string]any{"success": false, "msg": "Invalid current password"})
+	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(body.CurrentPwd)); err != nil {
+		response.Fail(w, r, response.BadRequest("Invalid current password"))
 		return
 	}
 
-	oldProfilePicPath := user.ProfilePic
+	var profilePicPath string
+	updateProfilePic := body.ProfilePic != nil
+	if updateProfilePic {
+		profilePicPath, err = uc.saveProfilePic(r.Context(), body.ProfilePic)
+		if err != nil {
+			response.Fail(w, r, err)
+			return
+		}
+	}
 
 	user.FirstName = firstName
 	user.LastName = lastName
@@ -224,12 +185,9 @@ func (uc *UserController) UpdateUserDetails(w http.ResponseWriter, r *http.Reque
 	}
 
 	if newPassword != "" {
-		hashedPassword, err := bcrypt.GenerateFromPassword([]byte(newPassword), 10)
+		hashedPassword, err := bcrypt.GenerateFromPassword([]byte(newPassword), uc.cfg.Auth.BcryptCost)
 		if err != nil {
-			if updateProfilePic && profilePicPath != "" {
-				os.Remove(filepath.Join(".", strings.TrimPrefix(profilePicPath, "/")))
-			}
-			uc.jsonResp(w, http.StatusInternalServerError, map[string]any{"success": false, "msg": "Password hashing failed"})
+			response.Fail(w, r, response.Internal("Password hashing failed", err))
 			return
 		}
 		user.Password = string(hashedPassword)
@@ -240,27 +198,15 @@ func (uc *UserController) UpdateUserDetails(w http.ResponseWriter, r *http.Reque
 		user.IsVerified = false
 	}
 
-	if err := user.Update(); err != nil {
-		if updateProfilePic && profilePicPath != "" {
-			os.Remove(filepath.Join(".", strings.TrimPrefix(profilePicPath, "/")))
-		}
-		uc.jsonResp(w, http.StatusInternalServerError, map[string]any{"success": false, "msg": "Update failed"})
+	if err := uc.store.Users.Update(r.Context(), user); err != nil {
+		response.Fail(w, r, response.Internal("Update failed", err))
 		return
 	}
 
-	if updateProfilePic && oldProfilePicPath != "/placeholder.svg" && oldProfilePicPath != profilePicPath && strings.Contains(contentType, "multipart/form-data") {
-		go func() {
-			os.Remove(filepath.Join(".", strings.TrimPrefix(oldProfilePicPath, "/")))
-		}()
-	}
-	response := map[string]any{
-		"success":     true,
-		"msg":         "Profile updated successfully.",
-		"is_verified": user.IsVerified,
-	}
+	data := response.Data{"is_verified": user.IsVerified}
 	if updateProfilePic {
-		response["profile_pic"] = user.ProfilePic
+		data["profile_pic"] = user.ProfilePic
+		data["profile_pic_srcset"] = images.Srcset(user.ProfilePic)
 	}
-
-	uc.jsonResp(w, http.StatusOK, response)
+	response.OK(w, "Profile updated successfully.", data)
 }
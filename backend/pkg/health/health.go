@@ -0,0 +1,197 @@
+// Package health serves the liveness and readiness probes.
+//
+// /healthz only says the process is up and serving HTTP. /readyz runs every
+// registered check with a deadline and reports each one, answering 503 if
+// any fails or once the server has started shutting down, so a load
+// balancer stops sending traffic before the listener goes away. Optional
+// checks are reported but never fail /readyz.
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"os"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Check returns nil when the dependency it looks at is usable.
+type Check func(ctx context.Context) error
+
+type Checker struct {
+	timeout  time.Duration
+	checks   map[string]Check
+	optional map[string]bool
+	draining atomic.Bool
+}
+
+// New returns a Checker whose checks each get timeout to finish.
+func New(timeout time.Duration) *Checker {
+	return &Checker{timeout: timeout, checks: map[string]Check{}, optional: map[string]bool{}}
+}
+
+// Add registers a named readiness check. It isn't safe to call once the
+// Checker is serving.
+func (c *Checker) Add(name string, check Check) {
+	c.checks[name] = check
+}
+
+// AddOptional registers a check for a dependency only some requests need.
+// When it fails it is reported as degraded, and the server stays ready.
+func (c *Checker) AddOptional(name string, check Check) {
+	c.Add(name, check)
+	c.optional[name] = true
+}
+
+// SetDraining makes /readyz fail from now on.
+func (c *Checker) SetDraining() {
+	c.draining.Store(true)
+}
+
+type CheckResult struct {
+	Status     string  `json:"status"`
+	Error      string  `json:"error,omitempty"`
+	DurationMS float64 `json:"duration_ms"`
+}
+
+type Report struct {
+	Status   string                 `json:"status"`
+	Draining bool                   `json:"draining,omitempty"`
+	Checks   map[string]CheckResult `json:"checks"`
+}
+
+// Run executes every check concurrently and reports whether all passed.
+func (c *Checker) Run(ctx context.Context) (Report, bool) {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	names := make([]string, 0, len(c.checks))
+	for name := range c.checks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	results := make([]CheckResult, len(names))
+	var wg sync.WaitGroup
+	for i, name := range names {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			start := time.Now()
+			res := CheckResult{Status: "ok"}
+			if err := c.checks[name](ctx); err != nil {
+				res.Status, res.Error = "fail", err.Error()
+				if c.optional[name] {
+					res.Status = "degraded"
+				}
+			}
+			res.DurationMS = float64(time.Since(start).Microseconds()) / 1000
+			results[i] = res
+		}()
+	}
+	wg.Wait()
+
+	report := Report{Status: "ready", Checks: map[string]CheckResult{}}
+	ok := true
+	for i, name := range names {
+		report.Checks[name] = results[i]
+		if results[i].Status == "fail" {
+			ok = false
+		}
+	}
+	if c.draining.Load() {
+		report.Draining = true
+		ok = false
+	}
+	if !ok {
+		report.Status = "not_ready"
+	}
+	return report, ok
+}
+
+func writeJSON(w http.ResponseWriter, status int, payload any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(payload)
+}
+
+// Liveness answers 200 for as long as the process can serve HTTP.
+func (c *Checker) Liveness(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]any{"status": "ok"})
+}
+
+// Readiness answers 200 with the check breakdown when everything passed,
+// and 503 with the same breakdown otherwise.
+func (c *Checker) Readiness(w http.ResponseWriter, r *http.Request) {
+	report, ok := c.Run(r.Context())
+	status := http.StatusOK
+	if !ok {
+		status = http.StatusServiceUnavailable
+	}
+	writeJSON(w, status, report)
+}
+
+// Cached runs check at most once every ttl and otherwise answers with its
+// last result, for a dependency too costly to look at on every probe.
+// Probes arriving while it runs share that run rather than queueing behind
+// it, and a run cut short by its caller's context isn't kept.
+func Cached(check Check, ttl time.Duration) Check {
+	type run struct {
+		done chan struct{}
+		err  error
+	}
+	var (
+		mu      sync.Mutex
+		last    time.Time
+		err     error
+		running *run
+	)
+	return func(ctx context.Context) error {
+		mu.Lock()
+		if !last.IsZero() && time.Since(last) < ttl {
+			defer mu.Unlock()
+			return err
+		}
+		if r := running; r != nil {
+			mu.Unlock()
+			select {
+			case <-r.done:
+				return r.err
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+		r := &run{done: make(chan struct{})}
+		running = r
+		mu.Unlock()
+
+		r.err = check(ctx)
+
+		mu.Lock()
+		if !errors.Is(r.err, context.Canceled) && !errors.Is(r.err, context.DeadlineExceeded) {
+			err, last = r.err, time.Now()
+		}
+		running = nil
+		mu.Unlock()
+		close(r.done)
+		return r.err
+	}
+}
+
+// DirWritable checks that a file can be created in dir.
+func DirWritable(dir string) Check {
+	return func(ctx context.Context) error {
+		f, err := os.CreateTemp(dir, ".readyz-*")
+		if err != nil {
+			return err
+		}
+		name := f.Name()
+		f.Close()
+		return os.Remove(name)
+	}
+}
@@ -0,0 +1,172 @@
+package health_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Entity069/Zesty-Go/pkg/health"
+)
+
+func readyz(t *testing.T, hc *health.Checker) (int, health.Report) {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	hc.Readiness(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	var report health.Report
+	if err := json.NewDecoder(rec.Body).Decode(&report); err != nil {
+		t.Fatalf("decoding /readyz: %v", err)
+	}
+	return rec.Code, report
+}
+
+func TestReadinessReportsEachCheck(t *testing.T) {
+	hc := health.New(50 * time.Millisecond)
+	hc.Add("database", func(context.Context) error { return nil })
+	hc.Add("email", func(context.Context) error { return errors.New("smtp server unreachable") })
+	hc.Add("slow", func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+
+	code, report := readyz(t, hc)
+	if code != http.StatusServiceUnavailable || report.Status != "not_ready" {
+		t.Fatalf("/readyz = %d %q, want 503 not_ready", code, report.Status)
+	}
+	if report.Checks["database"].Status != "ok" {
+		t.Errorf("database = %+v, want ok", report.Checks["database"])
+	}
+	if c := report.Checks["email"]; c.Status != "fail" || c.Error != "smtp server unreachable" {
+		t.Errorf("email = %+v, want the failure", c)
+	}
+	if c := report.Checks["slow"]; c.Status != "fail" || c.Error != context.DeadlineExceeded.Error() {
+		t.Errorf("slow = %+v, want a deadline failure", c)
+	}
+}
+
+func TestReadinessFlipsWhenDraining(t *testing.T) {
+	hc := health.New(time.Second)
+	hc.Add("database", func(context.Context) error { return nil })
+
+	if code, _ := readyz(t, hc); code != http.StatusOK {
+		t.Fatalf("/readyz = %d before draining, want 200", code)
+	}
+
+	hc.SetDraining()
+	code, report := readyz(t, hc)
+	if code != http.StatusServiceUnavailable || !report.Draining {
+		t.Fatalf("/readyz = %d %+v while draining, want 503 draining", code, report)
+	}
+
+	rec := httptest.NewRecorder()
+	hc.Liveness(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("/healthz = %d while draining, want 200", rec.Code)
+	}
+}
+
+func TestDirWritable(t *testing.T) {
+	dir := t.TempDir()
+	if err := health.DirWritable(dir)(context.Background()); err != nil {
+		t.Fatalf("DirWritable(%s) = %v", dir, err)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+		t.Fatalf("DirWritable left %d file(s) behind", len(entries))
+	}
+	if err := health.DirWritable(filepath.Join(dir, "missing"))(context.Background()); err == nil {
+		t.Fatal("DirWritable on a missing directory succeeded")
+	}
+}
+
+func TestOptionalCheck(t *testing.T) {
+	hc := health.New(time.Second)
+	hc.Add("database", func(context.Context) error { return nil })
+	hc.AddOptional("email", func(context.Context) error { return errors.New("smtp server unreachable") })
+
+	code, report := readyz(t, hc)
+	if code != http.StatusOK || report.Status != "ready" {
+		t.Fatalf("/readyz = %d %q, want 200 ready despite the optional failure", code, report.Status)
+	}
+	if c := report.Checks["email"]; c.Status != "degraded" || c.Error != "smtp server unreachable" {
+		t.Errorf("email = %+v, want it degraded", c)
+	}
+}
+
+func TestCached(t *testing.T) {
+	calls := 0
+	check := health.Cached(func(context.Context) error {
+		calls++
+		return errors.New("down")
+	}, time.Hour)
+	for range 3 {
+		if err := check(context.Background()); err == nil || err.Error() != "down" {
+			t.Fatalf("check = %v, want the cached failure", err)
+		}
+	}
+	if calls != 1 {
+		t.Errorf("check ran %d times within its ttl, want once", calls)
+	}
+}
+
+func TestCachedSharesARunningCheck(t *testing.T) {
+	var calls atomic.Int32
+	release := make(chan struct{})
+	check := health.Cached(func(context.Context) error {
+		calls.Add(1)
+		<-release
+		return nil
+	}, time.Hour)
+
+	var wg sync.WaitGroup
+	for range 3 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := check(context.Background()); err != nil {
+				t.Errorf("check = %v", err)
+			}
+		}()
+	}
+	// A probe that gives up waits for neither the check nor the others.
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	for calls.Load() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	if err := check(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("impatient check = %v, want its deadline", err)
+	}
+
+	close(release)
+	wg.Wait()
+	if n := calls.Load(); n != 1 {
+		t.Errorf("check ran %d times for concurrent probes, want once", n)
+	}
+}
+
+func TestCachedSkipsCancelledRuns(t *testing.T) {
+	calls := 0
+	check := health.Cached(func(ctx context.Context) error {
+		calls++
+		return ctx.Err()
+	}, time.Hour)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := check(ctx); !errors.Is(err, context.Canceled) {
+		t.Fatalf("cancelled check = %v", err)
+	}
+	if err := check(context.Background()); err != nil {
+		t.Errorf("check after a cancelled run = %v, want it run again", err)
+	}
+	if calls != 2 {
+		t.Errorf("check ran %d times, want twice", calls)
+	}
+}
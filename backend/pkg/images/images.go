@@ -0,0 +1,203 @@
+// Package images turns an uploaded picture into the files we serve.
+//
+// An upload is trusted for nothing: the bytes must sniff and decode as a
+// JPEG, PNG, GIF or WebP whatever the file is called. The decoded pixels are
+// turned upright according to any EXIF orientation and re-encoded, which
+// drops EXIF (camera serials, GPS position) and anything else riding along
+// in the file. Each upload becomes up to three widths, thumb, card and full,
+// named after a hash of the upload so the same picture is stored once and
+// can be cached forever:
+//
+//	item-images/3f9a...c2-160w.jpg
+//	item-images/3f9a...c2-480w.jpg
+//	item-images/3f9a...c2-1600w.jpg
+//
+// Records store the path of the widest file; Srcset and Variants recover the
+// others from it.
+package images
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"image"
+	"image/draw"
+	_ "image/gif"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"net/http"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+
+	xdraw "golang.org/x/image/draw"
+	_ "golang.org/x/image/webp"
+)
+
+// Widths of the thumb, card and full variants. Pictures are never scaled
+// up, so a small one may have fewer, narrower variants.
+const (
+	ThumbWidth = 160
+	CardWidth  = 480
+	FullWidth  = 1600
+)
+
+// maxPixels bounds the decoded size, so a small file that claims to be
+// huge can't exhaust memory.
+const maxPixels = 40_000_000
+
+var (
+	ErrNotImage = errors.New("images: not a JPEG, PNG, GIF or WebP image")
+	ErrTooLarge = errors.New("images: picture has too many pixels")
+)
+
+// sniffed lists the content types accepted, as http.DetectContentType names
+// them.
+var sniffed = map[string]bool{
+	"image/jpeg": true,
+	"image/png":  true,
+	"image/gif":  true,
+	"image/webp": true,
+}
+
+// File is one encoded variant.
+type File struct {
+	Key         string
+	Width       int
+	Height      int
+	ContentType string
+	Data        []byte
+}
+
+// Process decodes the picture in r and encodes its variants, keyed under
+// dir. The last file is the full-size one.
+func Process(r io.Reader, dir string) ([]File, error) {
+	raw, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	if !sniffed[http.DetectContentType(raw)] {
+		return nil, ErrNotImage
+	}
+
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(raw))
+	if err != nil {
+		return nil, ErrNotImage
+	}
+	if cfg.Width <= 0 || cfg.Height <= 0 || cfg.Width*cfg.Height > maxPixels {
+		return nil, ErrTooLarge
+	}
+	img, _, err := image.Decode(bytes.NewReader(raw))
+	if err != nil {
+		return nil, ErrNotImage
+	}
+	img = orient(img, exifOrientation(raw))
+
+	sum := sha256.Sum256(raw)
+	base := path.Join(dir, hex.EncodeToString(sum[:16]))
+
+	// Keep transparency as PNG; everything else is a photo as far as we
+	// can tell, and JPEG is far smaller for those.
+	ext, contentType, encode := ".jpg", "image/jpeg", func(w io.Writer, m image.Image) error {
+		return jpeg.Encode(w, m, &jpeg.Options{Quality: 85})
+	}
+	if !opaque(img) {
+		ext, contentType, encode = ".png", "image/png", func(w io.Writer, m image.Image) error {
+			return (&png.Encoder{CompressionLevel: png.BestCompression}).Encode(w, m)
+		}
+	}
+
+	full := min(FullWidth, img.Bounds().Dx())
+	var files []File
+	for _, width := range variantWidths(full) {
+		scaled := scale(img, width)
+		var buf bytes.Buffer
+		if err := encode(&buf, scaled); err != nil {
+			return nil, fmt.Errorf("images: encoding %dw: %w", width, err)
+		}
+		files = append(files, File{
+			Key:         base + "-" + strconv.Itoa(width) + "w" + ext,
+			Width:       width,
+			Height:      scaled.Bounds().Dy(),
+			ContentType: contentType,
+			Data:        buf.Bytes(),
+		})
+	}
+	return files, nil
+}
+
+// variantWidths lists the widths made for a picture whose full variant is
+// full pixels wide, narrowest first.
+func variantWidths(full int) []int {
+	var widths []int
+	for _, w := range []int{ThumbWidth, CardWidth} {
+		if w < full {
+			widths = append(widths, w)
+		}
+	}
+	return append(widths, full)
+}
+
+var variantName = regexp.MustCompile(`^(.*/[0-9a-f]{32})-([0-9]+)w(\.jpg|\.png)$`)
+
+// Variants returns the path of every variant of the picture whose full-size
+// path is p, narrowest first, with their widths. It returns nil for paths
+// Process didn't produce, such as older uploads or external URLs.
+func Variants(p string) (paths []string, widths []int) {
+	m := variantName.FindStringSubmatch(p)
+	if m == nil {
+		return nil, nil
+	}
+	full, err := strconv.Atoi(m[2])
+	if err != nil || full <= 0 {
+		return nil, nil
+	}
+	for _, w := range variantWidths(full) {
+		paths = append(paths, m[1]+"-"+strconv.Itoa(w)+"w"+m[3])
+		widths = append(widths, w)
+	}
+	return paths, widths
+}
+
+// Srcset renders the variants of p as an HTML srcset value, or "" when p
+// has none.
+func Srcset(p string) string {
+	paths, widths := Variants(p)
+	parts := make([]string, len(paths))
+	for i := range paths {
+		parts[i] = paths[i] + " " + strconv.Itoa(widths[i]) + "w"
+	}
+	return strings.Join(parts, ", ")
+}
+
+// scale resizes img to width, keeping its aspect ratio.
+func scale(img image.Image, width int) image.Image {
+	b := img.Bounds()
+	if b.Dx() == width {
+		return img
+	}
+	height := max(1, (b.Dy()*width+b.Dx()/2)/b.Dx())
+	dst := image.NewNRGBA(image.Rect(0, 0, width, height))
+	xdraw.CatmullRom.Scale(dst, dst.Bounds(), img, b, draw.Src, nil)
+	return dst
+}
+
+// opaque reports whether every pixel of img is fully opaque.
+func opaque(img image.Image) bool {
+	if o, ok := img.(interface{ Opaque() bool }); ok {
+		return o.Opaque()
+	}
+	b := img.Bounds()
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			if _, _, _, a := img.At(x, y).RGBA(); a != 0xffff {
+				return false
+			}
+		}
+	}
+	return true
+}
@@ -0,0 +1,220 @@
+package images_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/Entity069/Zesty-Go/pkg/images"
+)
+
+// picture is w×h, red on the left half and blue on the right, with alpha a.
+func picture(w, h int, a uint8) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			c := color.NRGBA{R: 255, A: a}
+			if x >= w/2 {
+				c = color.NRGBA{B: 255, A: a}
+			}
+			img.SetNRGBA(x, y, c)
+		}
+	}
+	return img
+}
+
+func encodePNG(t *testing.T, img image.Image) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// withExif inserts an APP1 segment after the JPEG's SOI marker, carrying
+// the given orientation and a marker string standing in for GPS tags.
+func withExif(jpg []byte, orientation uint16) []byte {
+	var tiff bytes.Buffer
+	tiff.WriteString("MM")
+	binary.Write(&tiff, binary.BigEndian, uint16(42))
+	binary.Write(&tiff, binary.BigEndian, uint32(8))
+	binary.Write(&tiff, binary.BigEndian, uint16(1))
+	binary.Write(&tiff, binary.BigEndian, []uint16{0x0112, 3})
+	binary.Write(&tiff, binary.BigEndian, uint32(1))
+	binary.Write(&tiff, binary.BigEndian, []uint16{orientation, 0})
+	binary.Write(&tiff, binary.BigEndian, uint32(0))
+	tiff.WriteString("GPS 51.5007N 0.1246W")
+
+	seg := append([]byte("Exif\x00\x00"), tiff.Bytes()...)
+	var out bytes.Buffer
+	out.Write(jpg[:2])
+	out.Write([]byte{0xff, 0xe1})
+	binary.Write(&out, binary.BigEndian, uint16(len(seg)+2))
+	out.Write(seg)
+	out.Write(jpg[2:])
+	return out.Bytes()
+}
+
+func widths(files []images.File) []int {
+	var ws []int
+	for _, f := range files {
+		ws = append(ws, f.Width)
+	}
+	return ws
+}
+
+func TestProcessRotatesAndStripsExif(t *testing.T) {
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, picture(40, 20, 255), &jpeg.Options{Quality: 100}); err != nil {
+		t.Fatal(err)
+	}
+	// Orientation 6: the camera was turned, so the picture must be
+	// rotated a quarter turn clockwise to display upright.
+	files, err := images.Process(bytes.NewReader(withExif(buf.Bytes(), 6)), "item-images")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 1 {
+		t.Fatalf("got %d files, want 1", len(files))
+	}
+	f := files[0]
+	if f.ContentType != "image/jpeg" || !strings.HasSuffix(f.Key, "-20w.jpg") {
+		t.Fatalf("file = %s (%s)", f.Key, f.ContentType)
+	}
+	if bytes.Contains(f.Data, []byte("Exif")) || bytes.Contains(f.Data, []byte("GPS")) {
+		t.Fatal("the output still carries the EXIF block")
+	}
+
+	img, err := jpeg.Decode(bytes.NewReader(f.Data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b := img.Bounds(); b.Dx() != 20 || b.Dy() != 40 {
+		t.Fatalf("output is %dx%d, want 20x40", b.Dx(), b.Dy())
+	}
+	// The left (red) half is now the top.
+	if r, _, b, _ := img.At(10, 5).RGBA(); r < 0xc000 || b > 0x4000 {
+		t.Errorf("top is not red: r=%#x b=%#x", r, b)
+	}
+	if r, _, b, _ := img.At(10, 35).RGBA(); b < 0xc000 || r > 0x4000 {
+		t.Errorf("bottom is not blue: r=%#x b=%#x", r, b)
+	}
+}
+
+func TestProcessVariants(t *testing.T) {
+	for _, tc := range []struct {
+		name       string
+		w, h       int
+		want       []int
+		wantHeight int
+	}{
+		{"large is capped", 2400, 1200, []int{160, 480, 1600}, 800},
+		{"small is not upscaled", 300, 200, []int{160, 300}, 200},
+		{"tiny has one size", 100, 100, []int{100}, 100},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			files, err := images.Process(bytes.NewReader(encodePNG(t, picture(tc.w, tc.h, 255))), "item-images")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := widths(files); !slices.Equal(got, tc.want) {
+				t.Fatalf("widths = %v, want %v", got, tc.want)
+			}
+			full := files[len(files)-1]
+			if full.Height != tc.wantHeight {
+				t.Errorf("full height = %d, want %d", full.Height, tc.wantHeight)
+			}
+			// An opaque PNG is a photo as far as we know: JPEG is smaller.
+			if full.ContentType != "image/jpeg" {
+				t.Errorf("content type = %s, want image/jpeg", full.ContentType)
+			}
+			paths, ws := images.Variants("/uploads/" + full.Key)
+			if !slices.Equal(ws, tc.want) || paths[len(paths)-1] != "/uploads/"+full.Key {
+				t.Errorf("Variants(full) = %v %v", paths, ws)
+			}
+			for i, f := range files {
+				if paths[i] != "/uploads/"+f.Key {
+					t.Errorf("Variants()[%d] = %s, want /uploads/%s", i, paths[i], f.Key)
+				}
+			}
+		})
+	}
+}
+
+func TestProcessKeepsTransparency(t *testing.T) {
+	files, err := images.Process(bytes.NewReader(encodePNG(t, picture(50, 50, 128))), "profile-pics")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := files[0]
+	if f.ContentType != "image/png" || !strings.HasPrefix(f.Key, "profile-pics/") || !strings.HasSuffix(f.Key, "-50w.png") {
+		t.Fatalf("file = %s (%s)", f.Key, f.ContentType)
+	}
+}
+
+func TestProcessNamesByContent(t *testing.T) {
+	data := encodePNG(t, picture(30, 30, 255))
+	a, err := images.Process(bytes.NewReader(data), "item-images")
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, _ := images.Process(bytes.NewReader(data), "item-images")
+	c, _ := images.Process(bytes.NewReader(encodePNG(t, picture(31, 30, 255))), "item-images")
+	if a[0].Key != b[0].Key {
+		t.Errorf("same upload got keys %s and %s", a[0].Key, b[0].Key)
+	}
+	if a[0].Key == c[0].Key {
+		t.Errorf("different uploads share key %s", a[0].Key)
+	}
+}
+
+func TestProcessRejects(t *testing.T) {
+	// A PNG header claiming 100000×100000 pixels, with a valid checksum, and
+	// nothing behind it.
+	huge := encodePNG(t, picture(1, 1, 255))
+	binary.BigEndian.PutUint32(huge[16:], 100000)
+	binary.BigEndian.PutUint32(huge[20:], 100000)
+	binary.BigEndian.PutUint32(huge[29:], crc32.ChecksumIEEE(huge[12:29]))
+
+	for name, tc := range map[string]struct {
+		data []byte
+		want error
+	}{
+		"script":       {[]byte("<script>alert(1)</script>"), images.ErrNotImage},
+		"svg":          {[]byte(`<svg xmlns="http://www.w3.org/2000/svg"></svg>`), images.ErrNotImage},
+		"truncated":    {encodePNG(t, picture(8, 8, 255))[:40], images.ErrNotImage},
+		"empty":        {nil, images.ErrNotImage},
+		"pixel bomb":   {huge, images.ErrTooLarge},
+		"pdf as image": {[]byte("%PDF-1.7\n"), images.ErrNotImage},
+	} {
+		t.Run(name, func(t *testing.T) {
+			if _, err := images.Process(bytes.NewReader(tc.data), "item-images"); !errors.Is(err, tc.want) {
+				t.Fatalf("Process = %v, want %v", err, tc.want)
+			}
+		})
+	}
+}
+
+func TestSrcset(t *testing.T) {
+	const hash = "0123456789abcdef0123456789abcdef"
+	for path, want := range map[string]string{
+		"/uploads/item-images/" + hash + "-1600w.jpg": "/uploads/item-images/" + hash + "-160w.jpg 160w, /uploads/item-images/" + hash + "-480w.jpg 480w, /uploads/item-images/" + hash + "-1600w.jpg 1600w",
+		"/uploads/profile-pics/" + hash + "-300w.png": "/uploads/profile-pics/" + hash + "-160w.png 160w, /uploads/profile-pics/" + hash + "-300w.png 300w",
+		"/uploads/item-images/1700000000_pizza.jpg":   "",
+		"/placeholder.svg":                            "",
+		"https://s3.tebi.io/zesty-test/80216737.jpeg": "",
+	} {
+		if got := images.Srcset(path); got != want {
+			t.Errorf("Srcset(%q) = %q, want %q", path, got, want)
+		}
+	}
+}
@@ -0,0 +1,108 @@
+// Package logging sets up the structured logger and carries a request-scoped
+// copy of it through contexts, so anything handling a request (controllers,
+// models) logs with that request's ID attached.
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+	"sync"
+
+	"github.com/Entity069/Zesty-Go/pkg/config"
+)
+
+// New returns a logger writing to w in the configured format and level.
+func New(cfg config.LogConfig, w io.Writer) (*slog.Logger, error) {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(cfg.Level)); err != nil {
+		return nil, fmt.Errorf("logging: level %q: %w", cfg.Level, err)
+	}
+	opts := &slog.HandlerOptions{Level: level}
+
+	switch strings.ToLower(cfg.Format) {
+	case "json":
+		return slog.New(slog.NewJSONHandler(w, opts)), nil
+	case "text":
+		return slog.New(slog.NewTextHandler(w, opts)), nil
+	default:
+		return nil, fmt.Errorf("logging: format must be json or text, got %q", cfg.Format)
+	}
+}
+
+type contextKey struct{}
+
+// request is what the middleware tracks for one request. Handlers further
+// in fill in the route and user once they know them.
+type request struct {
+	id     string
+	logger *slog.Logger
+
+	mu     sync.Mutex
+	route  string
+	userID int
+}
+
+// NewContext returns a context carrying logger for the request with the
+// given ID. The logger is tagged with request_id.
+func NewContext(ctx context.Context, logger *slog.Logger, requestID string) context.Context {
+	return context.WithValue(ctx, contextKey{}, &request{
+		id:     requestID,
+		logger: logger.With("request_id", requestID),
+	})
+}
+
+func fromContext(ctx context.Context) *request {
+	if ctx == nil {
+		return nil
+	}
+	req, _ := ctx.Value(contextKey{}).(*request)
+	return req
+}
+
+// FromContext returns the request's logger, or the default logger outside
+// a request.
+func FromContext(ctx context.Context) *slog.Logger {
+	if req := fromContext(ctx); req != nil {
+		return req.logger
+	}
+	return slog.Default()
+}
+
+// RequestID returns the ID of the request ctx belongs to, or "".
+func RequestID(ctx context.Context) string {
+	if req := fromContext(ctx); req != nil {
+		return req.id
+	}
+	return ""
+}
+
+// SetRoute records the matched route template for the access log.
+func SetRoute(ctx context.Context, route string) {
+	if req := fromContext(ctx); req != nil {
+		req.mu.Lock()
+		req.route = route
+		req.mu.Unlock()
+	}
+}
+
+// SetUserID records the authenticated user for the access log.
+func SetUserID(ctx context.Context, id int) {
+	if req := fromContext(ctx); req != nil {
+		req.mu.Lock()
+		req.userID = id
+		req.mu.Unlock()
+	}
+}
+
+// Details returns the route and user recorded so far.
+func Details(ctx context.Context) (route string, userID int) {
+	if req := fromContext(ctx); req != nil {
+		req.mu.Lock()
+		defer req.mu.Unlock()
+		return req.route, req.userID
+	}
+	return "", 0
+}
@@ -0,0 +1,149 @@
+// Package metrics defines the Prometheus collectors the server exports. They
+// live on their own registry, served by the admin listener rather than the
+// public API.
+package metrics
+
+import (
+	"database/sql"
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "zesty"
+
+var Registry = prometheus.NewRegistry()
+
+var (
+	HTTPRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "request_duration_seconds",
+		Help:      "Time to serve HTTP requests, by route template and status.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+
+	HTTPInFlight = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "requests_in_flight",
+		Help:      "HTTP requests currently being served.",
+	})
+
+	DeprecatedRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "deprecated_requests_total",
+		Help:      "Requests to deprecated routes, by route template. Zero for a while means the route can go.",
+	}, []string{"route"})
+
+	CacheLookups = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "items_cache",
+		Name:      "lookups_total",
+		Help:      "Items cache lookups, by result (hit or miss).",
+	}, []string{"result"})
+
+	EmailsSent = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "email",
+		Name:      "sends_total",
+		Help:      "Outgoing emails, by template and result (sent, failed or logged).",
+	}, []string{"template", "result"})
+
+	OrdersPlaced = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "orders_placed_total",
+		Help:      "Orders placed.",
+	})
+
+	OrdersCancelled = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "orders_cancelled_total",
+		Help:      "Orders cancelled and refunded.",
+	})
+
+	OrdersReleased = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "orders_released_total",
+		Help:      "Scheduled orders released to the sellers' queues.",
+	})
+
+	Revenue = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "revenue_total",
+		Help:      "Value of placed orders, before refunds.",
+	})
+
+	Refunds = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "refunds_total",
+		Help:      "Value refunded on cancelled orders.",
+	})
+
+	UploadsCollected = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "uploads_gc",
+		Name:      "files_total",
+		Help:      "Files the upload collector acted on, by action (quarantined, restored or purged).",
+	}, []string{"action"})
+
+	UploadsReclaimed = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "uploads_gc",
+		Name:      "reclaimed_bytes_total",
+		Help:      "Bytes of unreferenced uploads the collector deleted.",
+	})
+
+	CatalogImageFetches = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "catalog",
+		Name:      "image_fetches_total",
+		Help:      "Pictures named in catalog imports, by outcome (queued, dropped, saved or failed).",
+	}, []string{"result"})
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		HTTPRequestDuration,
+		HTTPInFlight,
+		DeprecatedRequests,
+		CacheLookups,
+		EmailsSent,
+		OrdersPlaced,
+		OrdersCancelled,
+		OrdersReleased,
+		Revenue,
+		Refunds,
+		UploadsCollected,
+		UploadsReclaimed,
+		CatalogImageFetches,
+	)
+	// Start the cache counters at zero so a hit ratio can be computed
+	// before the first miss.
+	CacheLookups.WithLabelValues("hit")
+	CacheLookups.WithLabelValues("miss")
+}
+
+// RegisterDB exports the pool statistics (open, in use, idle, waits) of db.
+func RegisterDB(db *sql.DB) error {
+	return Registry.Register(collectors.NewDBStatsCollector(db, "zesty"))
+}
+
+// CacheLookup counts one items cache lookup.
+func CacheLookup(hit bool) {
+	if hit {
+		CacheLookups.WithLabelValues("hit").Inc()
+	} else {
+		CacheLookups.WithLabelValues("miss").Inc()
+	}
+}
+
+// Handler serves the registry in the Prometheus text format.
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{Registry: Registry})
+}
@@ -2,10 +2,15 @@ package middleware
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"net/http"
+	"slices"
 	"time"
 
-	"github.com/Entity069/Zesty-Go/pkg/config"
+	"github.com/Entity069/Zesty-Go/pkg/logging"
+	"github.com/Entity069/Zesty-Go/pkg/models"
+	"github.com/Entity069/Zesty-Go/pkg/response"
 	"github.com/Entity069/Zesty-Go/pkg/utils"
 	"github.com/golang-jwt/jwt/v5"
 )
@@ -20,12 +25,27 @@ type contextKeyUserType struct{}
 
 var contextKeyUser = contextKeyUserType{}
 
-func ValidateToken(tokenStr string) (*UserClaims, error) {
+// Auth holds what the auth middlewares need to check a session token.
+type Auth struct {
+	secret []byte
+	// users, when set, is checked for the session's user on each request,
+	// so deleting a user ends their sessions rather than leaving them
+	// until the token expires.
+	users models.UserRepo
+}
+
+// NewAuth checks sessions against users, which may be nil to trust a valid
+// token alone.
+func NewAuth(secret []byte, users models.UserRepo) *Auth {
+	return &Auth{secret: secret, users: users}
+}
+
+func ValidateToken(tokenStr string, secret []byte) (*UserClaims, error) {
 	token, err := jwt.ParseWithClaims(tokenStr, &UserClaims{}, func(t *jwt.Token) (any, error) {
 		if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
 			return nil, jwt.ErrTokenUnverifiable
 		}
-		return config.JWTSecret(), nil
+		return secret, nil
 	}, jwt.WithValidMethods([]string{"HS256", "HS384", "HS512"}))
 	if err != nil {
 		return nil, err
@@ -53,81 +73,96 @@ func GetUserClaims(r *http.Request) (*UserClaims, bool) {
 	return c, ok
 }
 
+var (
+	errNotLoggedIn    = response.Unauthenticated("Please log in to continue.")
+	errSessionInvalid = response.New(http.StatusUnauthorized, response.CodeInvalidToken, "Your session has expired. Please log in again.")
+)
+
+// session returns the claims of the request's session, or the error to
+// fail it with. Claims VerifyToken put in the context were checked already.
+func (a *Auth) session(r *http.Request) (*UserClaims, error) {
+	if claims, ok := GetUserClaims(r); ok {
+		return claims, nil
+	}
+
+	tok, err := utils.GetToken(r)
+	if err != nil || tok == "" {
+		return nil, errNotLoggedIn
+	}
+	claims, err := ValidateToken(tok, a.secret)
+	if err != nil {
+		return nil, errSessionInvalid
+	}
+	if a.users == nil {
+		return claims, nil
+	}
+
+	user, err := a.users.GetByID(r.Context(), claims.ID)
+	if errors.Is(err, sql.ErrNoRows) || err == nil && user.DeletedAt != nil {
+		return nil, errSessionInvalid
+	} else if err != nil {
+		return nil, response.Internal("Failed to check your session", err)
+	}
+	return claims, nil
+}
+
 // middleware functions
-func VerifyToken(next http.Handler) http.Handler {
+func (a *Auth) VerifyToken(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		tok, err := utils.GetToken(r)
-		if err != nil || tok == "" {
-			http.Redirect(w, r, "/register", http.StatusUnauthorized)
-			return
-		}
-
-		claims, err := ValidateToken(tok)
+		claims, err := a.session(r)
 		if err != nil {
-			http.Redirect(w, r, "/register", http.StatusUnauthorized)
-
+			response.Fail(w, r, err)
 			return
 		}
 
+		logging.SetUserID(r.Context(), claims.ID)
 		ctx := withUserClaims(r.Context(), claims)
 		next.ServeHTTP(w, r.WithContext(ctx))
 	})
 }
 
-func RedirectIfIn(next http.Handler) http.Handler {
+func (a *Auth) RedirectIfIn(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		tok, err := utils.GetToken(r)
-		if err == nil && tok != "" {
-			if claims, err := ValidateToken(tok); err == nil {
-				var path string
-				switch claims.Role {
-				case "admin":
-					path = "/admin/dashboard"
-				case "seller":
-					path = "/seller/dashboard"
-				default:
-					path = "/home"
-				}
-				http.Redirect(w, r, path, http.StatusFound)
-				return
+		if claims, err := a.session(r); err == nil {
+			var path string
+			switch claims.Role {
+			case "admin":
+				path = "/admin/dashboard"
+			case "seller":
+				path = "/seller/dashboard"
+			default:
+				path = "/home"
 			}
+			http.Redirect(w, r, path, http.StatusFound)
+			return
 		}
 		next.ServeHTTP(w, r)
 	})
 }
 
-func LoginRequired(next http.Handler) http.Handler {
+func (a *Auth) LoginRequired(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		tok, err := utils.GetToken(r)
-		if err != nil || tok == "" {
-			http.Redirect(w, r, "/login", http.StatusFound)
-			return
-		}
-		if _, err := ValidateToken(tok); err != nil {
-			http.Redirect(w, r, "/register", http.StatusUnauthorized)
+		if _, err := a.session(r); err != nil {
+			response.Fail(w, r, err)
 			return
 		}
 		next.ServeHTTP(w, r)
 	})
 }
 
-func RoleRequired(requiredRole string) func(http.Handler) http.Handler {
+// RoleRequired lets through sessions whose role is one of roles.
+func (a *Auth) RoleRequired(roles ...string) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			tok, err := utils.GetToken(r)
-			if err != nil || tok == "" {
-				http.Redirect(w, r, "/login", http.StatusFound)
-				return
-			}
-
-			claims, err := ValidateToken(tok)
+			claims, err := a.session(r)
 			if err != nil {
-				http.Redirect(w, r, "/register", http.StatusUnauthorized)
+				response.Fail(w, r, err)
 				return
 			}
 
-			if claims.Role != requiredRole {
-				http.Error(w, "Forbidden", http.StatusForbidden)
+			logging.SetUserID(r.Context(), claims.ID)
+			if !slices.Contains(roles, claims.Role) {
+				response.Fail(w, r, response.Forbidden("You don't have access to this page."))
 				return
 			}
 
@@ -137,8 +172,14 @@ func RoleRequired(requiredRole string) func(http.Handler) http.Handler {
 	}
 }
 
-var (
-	UserRequired   = RoleRequired("user")
-	AdminRequired  = RoleRequired("admin")
-	SellerRequired = RoleRequired("seller")
-)
+func (a *Auth) UserRequired(next http.Handler) http.Handler {
+	return a.RoleRequired("user")(next)
+}
+
+func (a *Auth) AdminRequired(next http.Handler) http.Handler {
+	return a.RoleRequired("admin")(next)
+}
+
+func (a *Auth) SellerRequired(next http.Handler) http.Handler {
+	return a.RoleRequired("seller")(next)
+}
@@ -0,0 +1,105 @@
+package middleware_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"github.com/Entity069/Zesty-Go/pkg/middleware"
+	"github.com/Entity069/Zesty-Go/pkg/models"
+	"github.com/Entity069/Zesty-Go/pkg/models/memstore"
+)
+
+var authSecret = []byte("test-secret")
+
+func sessionCookie(t *testing.T, role string, exp time.Time) *http.Cookie {
+	t.Helper()
+	tok, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"id": 1, "role": role, "exp": exp.Unix(),
+	}).SignedString(authSecret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &http.Cookie{Name: "token", Value: tok}
+}
+
+func TestAuthFailuresAreJSON(t *testing.T) {
+	auth := middleware.NewAuth(authSecret, nil)
+	h := auth.VerifyToken(auth.LoginRequired(auth.AdminRequired(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))))
+
+	cases := []struct {
+		name   string
+		cookie *http.Cookie
+		status int
+		code   string
+	}{
+		{"no cookie", nil, http.StatusUnauthorized, "unauthenticated"},
+		{"expired", sessionCookie(t, "admin", time.Now().Add(-time.Hour)), http.StatusUnauthorized, "invalid_token"},
+		{"wrong role", sessionCookie(t, "user", time.Now().Add(time.Hour)), http.StatusForbidden, "forbidden"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/api/admin/stats", nil)
+			if tc.cookie != nil {
+				req.AddCookie(tc.cookie)
+			}
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+
+			var body struct {
+				Success bool `json:"success"`
+				Error   struct {
+					Code string `json:"code"`
+				} `json:"error"`
+			}
+			if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+				t.Fatalf("body isn't JSON: %q", rec.Body)
+			}
+			if rec.Code != tc.status || body.Success || body.Error.Code != tc.code {
+				t.Fatalf("got %d %+v, want %d %s", rec.Code, body, tc.status, tc.code)
+			}
+			if loc := rec.Header().Get("Location"); loc != "" {
+				t.Errorf("redirected to %s", loc)
+			}
+		})
+	}
+}
+
+func TestDeletedUsersLoseTheirSessions(t *testing.T) {
+	store := memstore.New()
+	user := &models.User{FirstName: "Bo", Email: "bo@zes.ty", UserType: "user"}
+	if err := store.Users.Create(context.Background(), user); err != nil {
+		t.Fatal(err)
+	}
+	tok, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"id": user.ID, "role": "user", "exp": time.Now().Add(time.Hour).Unix(),
+	}).SignedString(authSecret)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	auth := middleware.NewAuth(authSecret, store.Users)
+	h := auth.VerifyToken(auth.LoginRequired(auth.UserRequired(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))))
+	serve := func() int {
+		req := httptest.NewRequest(http.MethodGet, "/api/home", nil)
+		req.AddCookie(&http.Cookie{Name: "token", Value: tok})
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	if code := serve(); code != http.StatusOK {
+		t.Fatalf("live user = %d, want 200", code)
+	}
+	if err := store.Users.Delete(context.Background(), user.ID); err != nil {
+		t.Fatal(err)
+	}
+	if code := serve(); code != http.StatusUnauthorized {
+		t.Fatalf("deleted user = %d, want 401", code)
+	}
+}
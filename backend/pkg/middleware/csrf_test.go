@@ -0,0 +1,78 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Entity069/Zesty-Go/pkg/middleware"
+)
+
+func TestCSRFProtect(t *testing.T) {
+	csrf := middleware.NewCSRF([]string{"https://app.zes.ty"}, []string{"/api/v1/webhooks/"})
+	h := csrf.Protect(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	tests := []struct {
+		name    string
+		method  string
+		path    string
+		token   string // sent as both cookie and header unless header is set
+		header  string
+		origin  string
+		referer string
+		want    int
+	}{
+		{name: "safe method", method: http.MethodGet, path: "/api/v1/items", want: http.StatusOK},
+		{name: "no token", method: http.MethodPost, path: "/api/v1/orders", want: http.StatusForbidden},
+		{name: "matching token", method: http.MethodPost, path: "/api/v1/orders", token: "abc", want: http.StatusOK},
+		{name: "mismatched token", method: http.MethodDelete, path: "/api/v1/items/1", token: "abc", header: "abd", want: http.StatusForbidden},
+		{name: "exempt path", method: http.MethodPost, path: "/api/v1/webhooks/payments", want: http.StatusOK},
+		{name: "trusted origin", method: http.MethodPost, path: "/api/v1/orders", token: "abc", origin: "https://app.zes.ty", want: http.StatusOK},
+		{name: "same host", method: http.MethodPost, path: "/api/v1/orders", token: "abc", origin: "http://example.com", want: http.StatusOK},
+		{name: "other origin", method: http.MethodPost, path: "/api/v1/orders", token: "abc", origin: "https://evil.example", want: http.StatusForbidden},
+		{name: "null origin", method: http.MethodPost, path: "/api/v1/orders", token: "abc", origin: "null", want: http.StatusForbidden},
+		{name: "referer fallback", method: http.MethodPatch, path: "/api/v1/me", token: "abc", referer: "https://evil.example/page", want: http.StatusForbidden},
+		{name: "trusted referer", method: http.MethodPatch, path: "/api/v1/me", token: "abc", referer: "https://app.zes.ty/profile", want: http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			if tt.token != "" {
+				req.AddCookie(&http.Cookie{Name: middleware.CSRFCookie, Value: tt.token})
+				header := tt.header
+				if header == "" {
+					header = tt.token
+				}
+				req.Header.Set(middleware.CSRFHeader, header)
+			}
+			if tt.origin != "" {
+				req.Header.Set("Origin", tt.origin)
+			}
+			if tt.referer != "" {
+				req.Header.Set("Referer", tt.referer)
+			}
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+			if rec.Code != tt.want {
+				t.Fatalf("status = %d %s, want %d", rec.Code, rec.Body, tt.want)
+			}
+		})
+	}
+}
+
+func TestCSRFTokenKeepsExistingCookie(t *testing.T) {
+	csrf := middleware.NewCSRF(nil, nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/auth/csrf", nil)
+	req.AddCookie(&http.Cookie{Name: middleware.CSRFCookie, Value: "existing"})
+	rec := httptest.NewRecorder()
+	csrf.Token(rec, req)
+
+	if len(rec.Result().Cookies()) != 0 {
+		t.Errorf("a caller with a token shouldn't get a new cookie")
+	}
+	if want := `"csrf_token":"existing"`; !strings.Contains(rec.Body.String(), want) {
+		t.Errorf("body = %s, want it to contain %s", rec.Body, want)
+	}
+}
@@ -0,0 +1,35 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"github.com/Entity069/Zesty-Go/pkg/metrics"
+)
+
+// Deprecated marks a route that has a replacement. Responses carry a
+// Deprecation header (RFC 9745) dated since and a Link to the successor, so
+// clients can find the new route, and each request is counted so we know
+// when the last one has moved over.
+func Deprecated(since time.Time, successor string) func(http.Handler) http.Handler {
+	deprecation := "@" + strconv.FormatInt(since.Unix(), 10)
+	link := "<" + successor + `>; rel="successor-version"`
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			route := "unknown"
+			if cur := mux.CurrentRoute(r); cur != nil {
+				if tpl, err := cur.GetPathTemplate(); err == nil {
+					route = tpl
+				}
+			}
+			metrics.DeprecatedRequests.WithLabelValues(route).Inc()
+
+			w.Header().Set("Deprecation", deprecation)
+			w.Header().Add("Link", link)
+			next.ServeHTTP(w, r)
+		})
+	}
+}
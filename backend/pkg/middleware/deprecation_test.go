@@ -0,0 +1,34 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	"github.com/Entity069/Zesty-Go/pkg/metrics"
+	"github.com/Entity069/Zesty-Go/pkg/middleware"
+)
+
+func TestDeprecatedSetsHeaders(t *testing.T) {
+	since := time.Date(2026, time.October, 19, 0, 0, 0, 0, time.UTC)
+	r := mux.NewRouter()
+	r.Handle("/api/order/item/{id}", middleware.Deprecated(since, "/api/v1/items/{id}")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})))
+
+	before := testutil.ToFloat64(metrics.DeprecatedRequests.WithLabelValues("/api/order/item/{id}"))
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/order/item/7", nil))
+
+	if got, want := rec.Header().Get("Deprecation"), "@1792368000"; got != want {
+		t.Errorf("Deprecation = %q, want %q", got, want)
+	}
+	if got, want := rec.Header().Get("Link"), `</api/v1/items/{id}>; rel="successor-version"`; got != want {
+		t.Errorf("Link = %q, want %q", got, want)
+	}
+	if got := testutil.ToFloat64(metrics.DeprecatedRequests.WithLabelValues("/api/order/item/{id}")) - before; got != 1 {
+		t.Errorf("deprecated_requests_total went up by %v, want 1", got)
+	}
+}
@@ -0,0 +1,99 @@
+package middleware_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+
+	"github.com/Entity069/Zesty-Go/pkg/logging"
+	"github.com/Entity069/Zesty-Go/pkg/middleware"
+)
+
+func newTestServer(buf *bytes.Buffer) http.Handler {
+	logger := slog.New(slog.NewJSONHandler(buf, nil))
+
+	r := mux.NewRouter()
+	r.Use(middleware.RouteName)
+	r.HandleFunc("/api/item/{id}", func(w http.ResponseWriter, r *http.Request) {
+		logging.SetUserID(r.Context(), 7)
+		logging.FromContext(r.Context()).Error("fetching item by ID", "err", "boom")
+		w.WriteHeader(http.StatusTeapot)
+	})
+	return middleware.RequestLogger(logger)(r)
+}
+
+func logLines(t *testing.T, buf *bytes.Buffer) []map[string]any {
+	t.Helper()
+	var lines []map[string]any
+	for _, l := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		var m map[string]any
+		if err := json.Unmarshal([]byte(l), &m); err != nil {
+			t.Fatalf("log line %q is not JSON: %v", l, err)
+		}
+		lines = append(lines, m)
+	}
+	return lines
+}
+
+func TestRequestLoggerEchoesIDAndLogsRequest(t *testing.T) {
+	var buf bytes.Buffer
+	h := newTestServer(&buf)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/item/42", nil)
+	req.Header.Set(middleware.RequestIDHeader, "abc-123")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+
+	if got := rec.Header().Get(middleware.RequestIDHeader); got != "abc-123" {
+		t.Fatalf("X-Request-ID = %q, want abc-123", got)
+	}
+
+	lines := logLines(t, &buf)
+	if len(lines) != 2 {
+		t.Fatalf("got %d log lines, want 2: %s", len(lines), buf.String())
+	}
+	modelLine, access := lines[0], lines[1]
+	if modelLine["request_id"] != "abc-123" {
+		t.Errorf("handler log request_id = %v, want abc-123", modelLine["request_id"])
+	}
+	want := map[string]any{
+		"msg":        "request",
+		"request_id": "abc-123",
+		"method":     "GET",
+		"route":      "/api/item/{id}",
+		"path":       "/api/item/42",
+		"status":     float64(http.StatusTeapot),
+		"user_id":    float64(7),
+	}
+	for k, v := range want {
+		if access[k] != v {
+			t.Errorf("access log %s = %v, want %v", k, access[k], v)
+		}
+	}
+	if _, ok := access["latency_ms"]; !ok {
+		t.Error("access log has no latency_ms")
+	}
+}
+
+func TestRequestLoggerReplacesBadID(t *testing.T) {
+	var buf bytes.Buffer
+	h := newTestServer(&buf)
+
+	for _, id := range []string{"", "has spaces", strings.Repeat("x", 200)} {
+		req := httptest.NewRequest(http.MethodGet, "/nowhere", nil)
+		req.Header.Set(middleware.RequestIDHeader, id)
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+
+		got := rec.Header().Get(middleware.RequestIDHeader)
+		if got == "" || got == id || len(got) != 32 {
+			t.Errorf("incoming %q: X-Request-ID = %q, want a fresh 32-char ID", id, got)
+		}
+	}
+}
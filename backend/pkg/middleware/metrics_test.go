@@ -0,0 +1,39 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	"github.com/Entity069/Zesty-Go/pkg/metrics"
+	"github.com/Entity069/Zesty-Go/pkg/middleware"
+)
+
+func TestMetricsLabelsByRouteTemplate(t *testing.T) {
+	r := mux.NewRouter()
+	r.Use(middleware.Metrics)
+	r.HandleFunc("/api/seller/item/{id}", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	})
+
+	for _, id := range []string{"1", "2", "3"} {
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/api/seller/item/"+id, nil))
+	}
+
+	got := testutil.CollectAndCount(metrics.HTTPRequestDuration)
+	if got != 1 {
+		t.Fatalf("got %d label sets, want 1 (one route template, one status)", got)
+	}
+
+	rec := httptest.NewRecorder()
+	metrics.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	body := rec.Body.String()
+	line := `zesty_http_request_duration_seconds_count{method="GET",route="/api/seller/item/{id}",status="404"} 3`
+	if !strings.Contains(body, line) {
+		t.Fatalf("/metrics is missing %q", line)
+	}
+}
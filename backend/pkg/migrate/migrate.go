@@ -0,0 +1,379 @@
+// Package migrate applies versioned SQL files to MySQL.
+//
+// Files are named NN_description.up.sql / NN_description.down.sql. The
+// current version lives in a one-row table (schema_migrations by default)
+// laid out the same way golang-migrate does it, so databases migrated with
+// that tool carry on from where they were.
+//
+// MySQL commits DDL implicitly, so a migration can't be rolled back if it
+// fails halfway. The version row is marked dirty before a file runs and
+// cleaned once it finishes; a dirty database has to be inspected and
+// repaired by hand, then unlocked with Force.
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultTable tracks the schema version.
+const DefaultTable = "schema_migrations"
+
+// lockTimeout is how long to wait for another process that is migrating
+// the same database, e.g. a second replica booting with auto-migrate on.
+const lockTimeout = 30 * time.Second
+
+type Migration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string
+}
+
+var fileName = regexp.MustCompile(`^(\d+)_(.+)\.(up|down)\.sql$`)
+
+// Load reads every migration in dir, sorted by version. A version without
+// an up file is an error; a missing down file just can't be reverted.
+func Load(fsys fs.FS, dir string) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, fmt.Errorf("migrate: reading %s: %w", dir, err)
+	}
+
+	byVersion := map[int]*Migration{}
+	for _, e := range entries {
+		if e.IsDir() {
+			continue
+		}
+		m := fileName.FindStringSubmatch(e.Name())
+		if m == nil {
+			return nil, fmt.Errorf("migrate: %s: name must look like 01_description.up.sql", e.Name())
+		}
+		version, _ := strconv.Atoi(m[1])
+
+		body, err := fs.ReadFile(fsys, path.Join(dir, e.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("migrate: reading %s: %w", e.Name(), err)
+		}
+
+		mig := byVersion[version]
+		if mig == nil {
+			mig = &Migration{Version: version, Name: m[2]}
+			byVersion[version] = mig
+		} else if mig.Name != m[2] {
+			return nil, fmt.Errorf("migrate: version %d is used by both %q and %q", version, mig.Name, m[2])
+		}
+		if m[3] == "up" {
+			mig.Up = string(body)
+		} else {
+			mig.Down = string(body)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, mig := range byVersion {
+		if mig.Up == "" {
+			return nil, fmt.Errorf("migrate: version %d (%s) has no up file", mig.Version, mig.Name)
+		}
+		migrations = append(migrations, *mig)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
+
+type Migrator struct {
+	db         *sql.DB
+	table      string
+	migrations []Migration
+	// Log, when set, is told about each file as it runs.
+	Log func(format string, args ...any)
+}
+
+// New returns a Migrator for the files in dir, tracked in table.
+func New(db *sql.DB, fsys fs.FS, dir, table string) (*Migrator, error) {
+	migrations, err := Load(fsys, dir)
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{db: db, table: table, migrations: migrations}, nil
+}
+
+// Status is the database's position in the migration list. Version is 0
+// when nothing has been applied.
+type Status struct {
+	Version int
+	Dirty   bool
+	Applied []Migration
+	Pending []Migration
+}
+
+// ErrDirty is returned when a previous migration failed partway through.
+var ErrDirty = errors.New("migrate: database is dirty")
+
+func (m *Migrator) Status(ctx context.Context) (*Status, error) {
+	var st *Status
+	err := m.withLock(ctx, func(conn *sql.Conn) error {
+		version, dirty, err := m.version(ctx, conn)
+		if err != nil {
+			return err
+		}
+		st = &Status{Version: version, Dirty: dirty}
+		for _, mig := range m.migrations {
+			if mig.Version <= version {
+				st.Applied = append(st.Applied, mig)
+			} else {
+				st.Pending = append(st.Pending, mig)
+			}
+		}
+		return nil
+	})
+	return st, err
+}
+
+// Up applies up to n pending migrations, or all of them when n <= 0. It
+// returns how many ran.
+func (m *Migrator) Up(ctx context.Context, n int) (int, error) {
+	applied := 0
+	err := m.withLock(ctx, func(conn *sql.Conn) error {
+		version, dirty, err := m.version(ctx, conn)
+		if err != nil {
+			return err
+		}
+		if dirty {
+			return fmt.Errorf("%w at version %d; fix the schema by hand, then run force", ErrDirty, version)
+		}
+
+		for _, mig := range m.migrations {
+			if mig.Version <= version {
+				continue
+			}
+			if n > 0 && applied == n {
+				break
+			}
+			m.logf("applying %d_%s", mig.Version, mig.Name)
+			if err := m.run(ctx, conn, mig.Up, mig.Version, mig.Version); err != nil {
+				return fmt.Errorf("migrate: %d_%s up: %w", mig.Version, mig.Name, err)
+			}
+			applied++
+		}
+		return nil
+	})
+	return applied, err
+}
+
+// Down reverts up to n applied migrations, newest first, or all of them
+// when n <= 0. It returns how many ran.
+func (m *Migrator) Down(ctx context.Context, n int) (int, error) {
+	reverted := 0
+	err := m.withLock(ctx, func(conn *sql.Conn) error {
+		version, dirty, err := m.version(ctx, conn)
+		if err != nil {
+			return err
+		}
+		if dirty {
+			return fmt.Errorf("%w at version %d; fix the schema by hand, then run force", ErrDirty, version)
+		}
+
+		for i := len(m.migrations) - 1; i >= 0; i-- {
+			mig := m.migrations[i]
+			if mig.Version > version {
+				continue
+			}
+			if n > 0 && reverted == n {
+				break
+			}
+			if mig.Down == "" {
+				return fmt.Errorf("migrate: %d_%s has no down file", mig.Version, mig.Name)
+			}
+			prev := 0
+			if i > 0 {
+				prev = m.migrations[i-1].Version
+			}
+			m.logf("reverting %d_%s", mig.Version, mig.Name)
+			if err := m.run(ctx, conn, mig.Down, mig.Version, prev); err != nil {
+				return fmt.Errorf("migrate: %d_%s down: %w", mig.Version, mig.Name, err)
+			}
+			reverted++
+		}
+		return nil
+	})
+	return reverted, err
+}
+
+// Force records version as applied and clean without running anything. A
+// version of 0 clears the table.
+func (m *Migrator) Force(ctx context.Context, version int) error {
+	if version < 0 {
+		return fmt.Errorf("migrate: force: version must not be negative")
+	}
+	if version != 0 && !m.known(version) {
+		return fmt.Errorf("migrate: force: no migration with version %d", version)
+	}
+	return m.withLock(ctx, func(conn *sql.Conn) error {
+		return m.setVersion(ctx, conn, version, false)
+	})
+}
+
+func (m *Migrator) known(version int) bool {
+	for _, mig := range m.migrations {
+		if mig.Version == version {
+			return true
+		}
+	}
+	return false
+}
+
+// run executes one file. The version is marked dirty at from while the
+// statements run and recorded clean at to once they have all succeeded.
+func (m *Migrator) run(ctx context.Context, conn *sql.Conn, body string, from, to int) error {
+	if err := m.setVersion(ctx, conn, from, true); err != nil {
+		return err
+	}
+	for _, stmt := range SplitStatements(body) {
+		if _, err := conn.ExecContext(ctx, stmt); err != nil {
+			return err
+		}
+	}
+	return m.setVersion(ctx, conn, to, false)
+}
+
+// withLock runs fn on a single connection holding a named MySQL lock, so
+// two processes never migrate the same database at once.
+func (m *Migrator) withLock(ctx context.Context, fn func(conn *sql.Conn) error) error {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("migrate: getting a connection: %w", err)
+	}
+	defer conn.Close()
+
+	lock := "zesty:" + m.table
+	var got sql.NullInt64
+	if err := conn.QueryRowContext(ctx, "SELECT GET_LOCK(?, ?)", lock, int(lockTimeout.Seconds())).Scan(&got); err != nil {
+		return fmt.Errorf("migrate: acquiring lock: %w", err)
+	}
+	if !got.Valid || got.Int64 != 1 {
+		return fmt.Errorf("migrate: another process held the %s lock for over %s", lock, lockTimeout)
+	}
+	defer conn.ExecContext(context.Background(), "SELECT RELEASE_LOCK(?)", lock)
+
+	create := fmt.Sprintf("CREATE TABLE IF NOT EXISTS `%s` (`version` BIGINT NOT NULL PRIMARY KEY, `dirty` BOOLEAN NOT NULL)", m.table)
+	if _, err := conn.ExecContext(ctx, create); err != nil {
+		return fmt.Errorf("migrate: creating %s: %w", m.table, err)
+	}
+	return fn(conn)
+}
+
+// Check reports an error unless every migration has been applied and the
+// database is clean. It reads the version without taking the migration
+// lock, so it is cheap enough for a readiness probe.
+func (m *Migrator) Check(ctx context.Context) error {
+	version, dirty, err := m.version(ctx, m.db)
+	if err != nil {
+		return err
+	}
+	if dirty {
+		return fmt.Errorf("%w at version %d", ErrDirty, version)
+	}
+	pending := 0
+	for _, mig := range m.migrations {
+		if mig.Version > version {
+			pending++
+		}
+	}
+	if pending > 0 {
+		return fmt.Errorf("migrate: at version %d with %d migration(s) pending", version, pending)
+	}
+	return nil
+}
+
+type rowQuerier interface {
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+func (m *Migrator) version(ctx context.Context, conn rowQuerier) (int, bool, error) {
+	var version int
+	var dirty bool
+	err := conn.QueryRowContext(ctx, fmt.Sprintf("SELECT `version`, `dirty` FROM `%s` LIMIT 1", m.table)).Scan(&version, &dirty)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, fmt.Errorf("migrate: reading %s: %w", m.table, err)
+	}
+	// golang-migrate stores -1 for "nothing applied".
+	if version < 0 {
+		version = 0
+	}
+	return version, dirty, nil
+}
+
+func (m *Migrator) setVersion(ctx context.Context, conn *sql.Conn, version int, dirty bool) error {
+	if _, err := conn.ExecContext(ctx, fmt.Sprintf("DELETE FROM `%s`", m.table)); err != nil {
+		return fmt.Errorf("migrate: updating %s: %w", m.table, err)
+	}
+	if version == 0 && !dirty {
+		return nil
+	}
+	if _, err := conn.ExecContext(ctx, fmt.Sprintf("INSERT INTO `%s` (`version`, `dirty`) VALUES (?, ?)", m.table), version, dirty); err != nil {
+		return fmt.Errorf("migrate: updating %s: %w", m.table, err)
+	}
+	return nil
+}
+
+func (m *Migrator) logf(format string, args ...any) {
+	if m.Log != nil {
+		m.Log(format, args...)
+	}
+}
+
+// SplitStatements breaks a file into single statements on semicolons that
+// aren't inside quotes or comments, since the driver runs one at a time.
+func SplitStatements(body string) []string {
+	var stmts []string
+	var cur strings.Builder
+	var quote byte
+
+	flush := func() {
+		if s := strings.TrimSpace(cur.String()); s != "" {
+			stmts = append(stmts, s)
+		}
+		cur.Reset()
+	}
+
+	for i := 0; i < len(body); i++ {
+		c := body[i]
+		switch {
+		case quote != 0:
+			cur.WriteByte(c)
+			if c == '\\' && quote != '`' && i+1 < len(body) {
+				i++
+				cur.WriteByte(body[i])
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"' || c == '`':
+			quote = c
+			cur.WriteByte(c)
+		case c == '-' && strings.HasPrefix(body[i:], "-- "), c == '#':
+			for i < len(body) && body[i] != '\n' {
+				i++
+			}
+			cur.WriteByte('\n')
+		case c == ';':
+			flush()
+		default:
+			cur.WriteByte(c)
+		}
+	}
+	flush()
+	return stmts
+}
@@ -0,0 +1,78 @@
+package migrate_test
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	schema "github.com/Entity069/Zesty-Go/db"
+	"github.com/Entity069/Zesty-Go/pkg/migrate"
+)
+
+func TestSplitStatements(t *testing.T) {
+	got := migrate.SplitStatements(`
+-- categories first
+INSERT INTO categories (name, description) VALUES ('Soups; Stews', 'it''s "hot"; really');
+INSERT INTO notes (body) VALUES ('escaped \'; quote');
+# trailing comment; ignored
+DELETE FROM ` + "`odd;table`" + `;
+`)
+	want := []string{
+		`INSERT INTO categories (name, description) VALUES ('Soups; Stews', 'it''s "hot"; really')`,
+		`INSERT INTO notes (body) VALUES ('escaped \'; quote')`,
+		"DELETE FROM `odd;table`",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("SplitStatements =\n%q\nwant\n%q", got, want)
+	}
+}
+
+func TestLoadOrdersAndPairsFiles(t *testing.T) {
+	fsys := fstest.MapFS{
+		"m/10_add_tags.up.sql":      {Data: []byte("ALTER TABLE items ADD tags TEXT;")},
+		"m/10_add_tags.down.sql":    {Data: []byte("ALTER TABLE items DROP tags;")},
+		"m/2_create_users.up.sql":   {Data: []byte("CREATE TABLE users (id INT);")},
+		"m/2_create_users.down.sql": {Data: []byte("DROP TABLE users;")},
+	}
+	migrations, err := migrate.Load(fsys, "m")
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if len(migrations) != 2 || migrations[0].Version != 2 || migrations[1].Version != 10 {
+		t.Fatalf("Load returned %+v, want versions 2 then 10", migrations)
+	}
+	if migrations[1].Name != "add_tags" || !strings.Contains(migrations[1].Down, "DROP tags") {
+		t.Fatalf("migration 10 = %+v", migrations[1])
+	}
+}
+
+func TestLoadRejectsBadFiles(t *testing.T) {
+	for name, fsys := range map[string]fstest.MapFS{
+		"bad name":      {"m/create_users.up.sql": {Data: []byte("x")}},
+		"no up":         {"m/3_x.down.sql": {Data: []byte("x")}},
+		"version clash": {"m/3_a.up.sql": {Data: []byte("x")}, "m/3_b.up.sql": {Data: []byte("y")}},
+	} {
+		if _, err := migrate.Load(fsys, "m"); err == nil {
+			t.Errorf("%s: Load succeeded, want an error", name)
+		}
+	}
+}
+
+func TestEmbeddedFilesLoad(t *testing.T) {
+	migrations, err := migrate.Load(schema.Migrations, "migrations")
+	if err != nil {
+		t.Fatalf("schema migrations: %v", err)
+	}
+	for _, m := range migrations {
+		if m.Down == "" {
+			t.Errorf("migration %d_%s has no down file", m.Version, m.Name)
+		}
+		if strings.Contains(m.Up, "USE ") || strings.Contains(m.Down, "USE ") {
+			t.Errorf("migration %d_%s names a database; the DSN picks it", m.Version, m.Name)
+		}
+	}
+	if _, err := migrate.Load(schema.Seeds, "seeds"); err != nil {
+		t.Fatalf("seeds: %v", err)
+	}
+}
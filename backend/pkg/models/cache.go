@@ -0,0 +1,153 @@
+package models
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// ItemsCache caches item listings keyed by the limit they were fetched with.
+//
+// A miss returns the cache's generation, which the caller hands back to
+// SetCache with the listing it then loaded. A listing loaded in one
+// generation is never stored in a later one, so one read before an
+// invalidation can't outlive it.
+type ItemsCache interface {
+	SetCache(ctx context.Context, limit int, gen CacheGen, items []*Item)
+	GetFromCache(ctx context.Context, limit int) ([]*Item, CacheGen, bool)
+	// need to invalidate cache when items are created, updated or deleted
+	InvalidateCache(ctx context.Context)
+	CleanExpiredEntries()
+}
+
+// CacheGen is the generation of an ItemsCache at a miss. local counts the
+// in-process invalidations; shared is the Redis generation, or -1 when it
+// couldn't be read.
+type CacheGen struct {
+	local, shared int64
+}
+
+// MemoryItemsCache is the in-process ItemsCache. It is only coherent within a
+// single replica.
+type MemoryItemsCache struct {
+	mu     sync.RWMutex
+	gen    int64
+	items  map[int][]*Item
+	expiry map[int]time.Time
+	// shared is the Redis generation each listing was loaded in, for a
+	// RedisItemsCache to check its local copy against.
+	shared map[int]int64
+	ttl    time.Duration
+}
+
+func NewMemoryItemsCache(ttl time.Duration) *MemoryItemsCache {
+	return &MemoryItemsCache{
+		items:  make(map[int][]*Item),
+		expiry: make(map[int]time.Time),
+		shared: make(map[int]int64),
+		ttl:    ttl,
+	}
+}
+
+func (c *MemoryItemsCache) SetCache(_ context.Context, limit int, gen CacheGen, items []*Item) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if gen.local != c.gen {
+		return
+	}
+
+	itemsCopy := make([]*Item, len(items))
+	copy(itemsCopy, items)
+
+	c.items[limit] = itemsCopy
+	c.expiry[limit] = time.Now().Add(c.ttl)
+	c.shared[limit] = gen.shared
+}
+
+func (c *MemoryItemsCache) GetFromCache(_ context.Context, limit int) ([]*Item, CacheGen, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	gen := CacheGen{local: c.gen}
+	items, exists := c.items[limit]
+	if !exists {
+		return nil, gen, false
+	}
+
+	expiry, exists := c.expiry[limit]
+	if !exists || time.Now().After(expiry) {
+		return nil, gen, false
+	}
+
+	itemsCopy := make([]*Item, len(items))
+	copy(itemsCopy, items)
+	gen.shared = c.shared[limit]
+	return itemsCopy, gen, true
+}
+
+func (c *MemoryItemsCache) InvalidateCache(_ context.Context) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.gen++
+	c.items = make(map[int][]*Item)
+	c.expiry = make(map[int]time.Time)
+	c.shared = make(map[int]int64)
+}
+
+func (c *MemoryItemsCache) CleanExpiredEntries() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	for limit, expiry := range c.expiry {
+		if now.After(expiry) {
+			delete(c.items, limit)
+			delete(c.expiry, limit)
+			delete(c.shared, limit)
+		}
+	}
+}
+
+// txCache is the ItemsCache a transaction's repositories see. Invalidating
+// it only takes note: the shared cache is invalidated once the transaction
+// has committed, as until then another request could read the old rows
+// and cache them again. Nothing read inside the transaction is cached, as
+// it may be rolled back, and once invalidated it no longer serves cached
+// listings. A transaction runs on one goroutine, so it needs no lock.
+type txCache struct {
+	ItemsCache
+	invalidated bool
+}
+
+func (c *txCache) SetCache(context.Context, int, CacheGen, []*Item) {}
+
+func (c *txCache) GetFromCache(ctx context.Context, limit int) ([]*Item, CacheGen, bool) {
+	if c.invalidated {
+		return nil, CacheGen{}, false
+	}
+	return c.ItemsCache.GetFromCache(ctx, limit)
+}
+
+func (c *txCache) InvalidateCache(context.Context) {
+	c.invalidated = true
+}
+
+// committed runs after the transaction commits.
+func (c *txCache) committed(ctx context.Context) {
+	if c.invalidated {
+		c.ItemsCache.InvalidateCache(ctx)
+	}
+}
+
+func StartCacheCleanup(cache ItemsCache, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			cache.CleanExpiredEntries()
+		}
+	}()
+}
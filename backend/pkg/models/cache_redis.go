@@ -0,0 +1,144 @@
+package models
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/Entity069/Zesty-Go/pkg/logging"
+)
+
+const (
+	redisItemsPrefix  = "zesty:items:"
+	redisItemsGenKey  = redisItemsPrefix + "gen"
+	redisItemsChannel = redisItemsPrefix + "invalidate"
+)
+
+// RedisItemsCache shares cached listings between replicas through a
+// Redis-protocol server. Each replica keeps a local copy in front of Redis;
+// invalidations bump a generation counter (orphaning every stored key) and
+// are broadcast over pub/sub so the other replicas drop their local copies.
+// A broadcast can be missed while the subscription is down, so a local copy
+// is also only served while the generation it was loaded in is current.
+type RedisItemsCache struct {
+	client *redis.Client
+	local  *MemoryItemsCache
+	ttl    time.Duration
+	sub    *redis.PubSub
+	done   chan struct{}
+}
+
+func NewRedisItemsCache(ctx context.Context, client *redis.Client, ttl time.Duration) (*RedisItemsCache, error) {
+	sub := client.Subscribe(ctx, redisItemsChannel)
+	// wait for the subscription to be confirmed so no invalidation is missed
+	if _, err := sub.Receive(ctx); err != nil {
+		_ = sub.Close()
+		return nil, fmt.Errorf("models.NewRedisItemsCache: subscribe failed: %w", err)
+	}
+
+	c := &RedisItemsCache{
+		client: client,
+		local:  NewMemoryItemsCache(ttl),
+		ttl:    ttl,
+		sub:    sub,
+		done:   make(chan struct{}),
+	}
+	go c.listen()
+	return c, nil
+}
+
+// listen drops the local copy on each invalidation, and each time the
+// subscription is re-established, as invalidations may have been missed
+// while it was down.
+func (c *RedisItemsCache) listen() {
+	defer close(c.done)
+	for range c.sub.ChannelWithSubscriptions() {
+		c.local.InvalidateCache(context.Background())
+	}
+}
+
+func (c *RedisItemsCache) key(gen int64, limit int) string {
+	return redisItemsPrefix + strconv.FormatInt(gen, 10) + ":" + strconv.Itoa(limit)
+}
+
+// SetCache writes under the generation seen at the miss rather than the
+// current one: if the items were invalidated since, the listing lands on an
+// orphaned key instead of outliving the invalidation.
+func (c *RedisItemsCache) SetCache(ctx context.Context, limit int, gen CacheGen, items []*Item) {
+	c.local.SetCache(ctx, limit, gen, items)
+	if gen.shared < 0 {
+		return
+	}
+
+	payload, err := json.Marshal(items)
+	if err != nil {
+		logging.FromContext(ctx).Error("encoding items for cache", "err", err)
+		return
+	}
+	if err := c.client.Set(ctx, c.key(gen.shared, limit), payload, c.ttl).Err(); err != nil {
+		logging.FromContext(ctx).Error("writing items to cache", "err", err)
+	}
+}
+
+func (c *RedisItemsCache) GetFromCache(ctx context.Context, limit int) ([]*Item, CacheGen, bool) {
+	items, gen, ok := c.local.GetFromCache(ctx, limit)
+
+	shared, err := c.client.Get(ctx, redisItemsGenKey).Int64()
+	if err != nil && !errors.Is(err, redis.Nil) {
+		logging.FromContext(ctx).Error("reading cache generation", "err", err)
+		// with Redis unreachable no other replica can invalidate either
+		if ok {
+			return items, gen, true
+		}
+		gen.shared = -1
+		return nil, gen, false
+	}
+	if ok && gen.shared == shared {
+		return items, gen, true
+	}
+	gen.shared = shared
+	payload, err := c.client.Get(ctx, c.key(gen.shared, limit)).Bytes()
+	if err != nil {
+		if !errors.Is(err, redis.Nil) {
+			logging.FromContext(ctx).Error("reading items from cache", "err", err)
+		}
+		return nil, gen, false
+	}
+
+	if err := json.Unmarshal(payload, &items); err != nil {
+		logging.FromContext(ctx).Error("decoding cached items", "err", err)
+		return nil, gen, false
+	}
+	c.local.SetCache(ctx, limit, gen, items)
+	return items, gen, true
+}
+
+func (c *RedisItemsCache) InvalidateCache(ctx context.Context) {
+	c.local.InvalidateCache(ctx)
+
+	if err := c.client.Incr(ctx, redisItemsGenKey).Err(); err != nil {
+		logging.FromContext(ctx).Error("bumping cache generation", "err", err)
+	}
+	if err := c.client.Publish(ctx, redisItemsChannel, "flush").Err(); err != nil {
+		logging.FromContext(ctx).Error("broadcasting cache invalidation", "err", err)
+	}
+}
+
+// CleanExpiredEntries only sweeps the local copy; Redis expires its keys on
+// its own.
+func (c *RedisItemsCache) CleanExpiredEntries() {
+	c.local.CleanExpiredEntries()
+}
+
+// Close stops listening for invalidations. The client is left open since it
+// is owned by the caller.
+func (c *RedisItemsCache) Close() error {
+	err := c.sub.Close()
+	<-c.done
+	return err
+}
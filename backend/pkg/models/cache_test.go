@@ -0,0 +1,127 @@
+package models_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+
+	"github.com/Entity069/Zesty-Go/pkg/models"
+)
+
+func newReplica(t *testing.T, srv *miniredis.Miniredis) *models.RedisItemsCache {
+	t.Helper()
+	client := redis.NewClient(&redis.Options{Addr: srv.Addr()})
+	t.Cleanup(func() { client.Close() })
+
+	c, err := models.NewRedisItemsCache(context.Background(), client, time.Minute)
+	if err != nil {
+		t.Fatalf("NewRedisItemsCache: %v", err)
+	}
+	t.Cleanup(func() { c.Close() })
+	return c
+}
+
+func TestMemoryItemsCache(t *testing.T) {
+	ctx := context.Background()
+	c := models.NewMemoryItemsCache(time.Minute)
+
+	_, gen, _ := c.GetFromCache(ctx, 3)
+	c.SetCache(ctx, 3, gen, []*models.Item{{ID: 1}, {ID: 2}})
+	got, _, ok := c.GetFromCache(ctx, 3)
+	if !ok || len(got) != 2 {
+		t.Fatalf("GetFromCache(3) = %v, %v; want 2 items", got, ok)
+	}
+	if _, _, ok := c.GetFromCache(ctx, 0); ok {
+		t.Fatal("GetFromCache(0) hit on an unset limit")
+	}
+
+	c.InvalidateCache(ctx)
+	if _, _, ok := c.GetFromCache(ctx, 3); ok {
+		t.Fatal("GetFromCache(3) hit after invalidation")
+	}
+}
+
+func TestRedisItemsCacheSharedAcrossReplicas(t *testing.T) {
+	ctx := context.Background()
+	srv := miniredis.RunT(t)
+	a := newReplica(t, srv)
+	b := newReplica(t, srv)
+
+	_, gen, _ := a.GetFromCache(ctx, 0)
+	a.SetCache(ctx, 0, gen, []*models.Item{{ID: 7, Name: "Dosa"}})
+
+	got, _, ok := b.GetFromCache(ctx, 0)
+	if !ok || len(got) != 1 || got[0].Name != "Dosa" {
+		t.Fatalf("replica b GetFromCache = %v, %v; want the item set on a", got, ok)
+	}
+}
+
+func TestRedisItemsCacheInvalidationBroadcast(t *testing.T) {
+	ctx := context.Background()
+	srv := miniredis.RunT(t)
+	a := newReplica(t, srv)
+	b := newReplica(t, srv)
+
+	_, gen, _ := a.GetFromCache(ctx, 0)
+	a.SetCache(ctx, 0, gen, []*models.Item{{ID: 7}})
+	if _, _, ok := b.GetFromCache(ctx, 0); !ok {
+		t.Fatal("replica b should have warmed its local copy")
+	}
+
+	a.InvalidateCache(ctx)
+
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		if _, _, ok := b.GetFromCache(ctx, 0); !ok {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("replica b still serving items after invalidation on a")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestCacheSkipsListingsLoadedBeforeInvalidation(t *testing.T) {
+	ctx := context.Background()
+	srv := miniredis.RunT(t)
+	a := newReplica(t, srv)
+	b := newReplica(t, srv)
+
+	for name, c := range map[string]models.ItemsCache{"memory": models.NewMemoryItemsCache(time.Minute), "redis": a} {
+		// A listing is loaded, and the items change before it is cached.
+		_, gen, _ := c.GetFromCache(ctx, 0)
+		c.InvalidateCache(ctx)
+		c.SetCache(ctx, 0, gen, []*models.Item{{ID: 7, Name: "stale"}})
+
+		if got, _, ok := c.GetFromCache(ctx, 0); ok {
+			t.Errorf("%s: cached %v, want the stale listing dropped", name, got)
+		}
+	}
+	if got, _, ok := b.GetFromCache(ctx, 0); ok {
+		t.Errorf("another replica got %v, want the stale listing dropped", got)
+	}
+}
+
+func TestRedisItemsCacheMissedBroadcast(t *testing.T) {
+	ctx := context.Background()
+	srv := miniredis.RunT(t)
+	a := newReplica(t, srv)
+
+	_, gen, _ := a.GetFromCache(ctx, 0)
+	a.SetCache(ctx, 0, gen, []*models.Item{{ID: 7}})
+	if _, _, ok := a.GetFromCache(ctx, 0); !ok {
+		t.Fatal("GetFromCache missed a listing just set")
+	}
+
+	// Another replica invalidated while this one wasn't subscribed.
+	if _, err := srv.Incr("zesty:items:gen", 1); err != nil {
+		t.Fatal(err)
+	}
+	if got, _, ok := a.GetFromCache(ctx, 0); ok {
+		t.Fatalf("GetFromCache = %v, want the local copy of an older generation dropped", got)
+	}
+}
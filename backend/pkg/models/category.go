@@ -1,15 +1,51 @@
 package models
 
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/Entity069/Zesty-Go/pkg/images"
+)
+
 type Category struct {
-	ID          int    `json:"id"`
+	ID int `json:"id"`
+	// ParentID is the category this one is listed under, or nil for a
+	// top-level category.
+	ParentID    *int   `json:"parent_id"`
 	Name        string `json:"name"`
 	Description string `json:"description"`
+	// Position orders a category among its siblings, lowest first.
+	Position int    `json:"position"`
+	Image    string `json:"image"`
+	// IsActive is false for a category hidden from customers, along with
+	// everything under it. Admins still see and edit it.
+	IsActive bool `json:"is_active"`
+	// DeletedAt is set while the category is deleted; it is then left out
+	// of listings and lookups.
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
+}
+
+// MarshalJSON adds image_srcset, like Item's.
+func (c Category) MarshalJSON() ([]byte, error) {
+	type category Category
+	return json.Marshal(struct {
+		category
+		ImageSrcset string `json:"image_srcset,omitempty"`
+	}{category(c), images.Srcset(c.Image)})
 }
 
-func (c *Category) Create() error {
-	query := `INSERT INTO categories (name, description) VALUES (?, ?)`
+type sqlCategoryRepo struct {
+	q     Querier
+	cache ItemsCache
+}
+
+const categoryColumns = `id, parent_id, name, description, position, image, is_active, deleted_at`
+
+func (r *sqlCategoryRepo) Create(ctx context.Context, c *Category) error {
+	query := `INSERT INTO categories (parent_id, name, description, position, image, is_active) VALUES (?, ?, ?, ?, ?, ?)`
 
-	result, err := DB.Exec(query, c.Name, c.Description)
+	result, err := r.q.ExecContext(ctx, query, c.ParentID, c.Name, c.Description, c.Position, c.Image, c.IsActive)
 	if err != nil {
 		return err
 	}
@@ -22,39 +58,58 @@ func (c *Category) Create() error {
 	return nil
 }
 
-func (c *Category) Update() error {
-	query := `UPDATE categories SET name = ?, description = ? WHERE id = ?`
-	_, err := DB.Exec(query, c.Name, c.Description, c.ID)
+func (r *sqlCategoryRepo) Update(ctx context.Context, c *Category) error {
+	query := `UPDATE categories SET parent_id = ?, name = ?, description = ?, position = ?, image = ?, is_active = ? WHERE id = ?`
+	_, err := r.q.ExecContext(ctx, query, c.ParentID, c.Name, c.Description, c.Position, c.Image, c.IsActive, c.ID)
+
+	// listings leave out the items of inactive and deleted categories
+	if err == nil {
+		r.cache.InvalidateCache(ctx)
+	}
 	return err
 }
 
-func (c *Category) Delete() error {
-	query := `DELETE FROM categories WHERE id = ?`
-	_, err := DB.Exec(query, c.ID)
+func (r *sqlCategoryRepo) Delete(ctx context.Context, id int) error {
+	query := `UPDATE categories SET deleted_at = NOW() WHERE id = ? AND deleted_at IS NULL`
+	err := execOne(ctx, r.q, query, id)
+	if err == nil {
+		r.cache.InvalidateCache(ctx)
+	}
 	return err
 }
 
-func GetCategoryByID(id int) (*Category, error) {
-	category := &Category{}
-	query := `SELECT id, name, description FROM categories WHERE id = ?`
-
-	err := DB.QueryRow(query, id).Scan(&category.ID, &category.Name, &category.Description)
-	if err != nil {
-		return nil, err
+func (r *sqlCategoryRepo) Restore(ctx context.Context, id int) error {
+	query := `UPDATE categories SET deleted_at = NULL WHERE id = ? AND deleted_at IS NOT NULL`
+	err := execOne(ctx, r.q, query, id)
+	if err == nil {
+		r.cache.InvalidateCache(ctx)
 	}
-	return category, nil
+	return err
+}
+
+func (r *sqlCategoryRepo) GetByID(ctx context.Context, id int) (*Category, error) {
+	query := `SELECT ` + categoryColumns + ` FROM categories WHERE id = ? AND deleted_at IS NULL`
+	return scanCategory(r.q.QueryRowContext(ctx, query, id))
 }
 
-func GetAllCategories(limit int) ([]*Category, error) {
-	query := `SELECT id, name, description FROM categories ORDER BY name`
+func (r *sqlCategoryRepo) GetAll(ctx context.Context, limit int) ([]*Category, error) {
+	query := `SELECT ` + categoryColumns + ` FROM categories WHERE deleted_at IS NULL ORDER BY position, name`
 
 	args := []any{}
 	if limit > 0 {
 		query += " LIMIT ?"
 		args = append(args, limit)
 	}
+	return r.list(ctx, query, args...)
+}
+
+func (r *sqlCategoryRepo) GetDeleted(ctx context.Context) ([]*Category, error) {
+	query := `SELECT ` + categoryColumns + ` FROM categories WHERE deleted_at IS NOT NULL ORDER BY deleted_at DESC`
+	return r.list(ctx, query)
+}
 
-	rows, err := DB.Query(query, args...)
+func (r *sqlCategoryRepo) list(ctx context.Context, query string, args ...any) ([]*Category, error) {
+	rows, err := r.q.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -62,12 +117,110 @@ func GetAllCategories(limit int) ([]*Category, error) {
 
 	var categories []*Category
 	for rows.Next() {
-		category := &Category{}
-		err := rows.Scan(&category.ID, &category.Name, &category.Description)
+		category, err := scanCategory(rows)
 		if err != nil {
 			return nil, err
 		}
 		categories = append(categories, category)
 	}
-	return categories, nil
+	return categories, rows.Err()
+}
+
+func scanCategory(row interface{ Scan(...any) error }) (*Category, error) {
+	c := &Category{}
+	var description *string
+	err := row.Scan(&c.ID, &c.ParentID, &c.Name, &description, &c.Position, &c.Image, &c.IsActive, &c.DeletedAt)
+	if err != nil {
+		return nil, err
+	}
+	if description != nil {
+		c.Description = *description
+	}
+	return c, nil
+}
+
+// CategoryTree indexes a listing of categories by parent, for walking the
+// hierarchy without a query per level.
+type CategoryTree struct {
+	byID     map[int]*Category
+	children map[int][]*Category
+}
+
+// NewCategoryTree indexes categories, as GetAll returns them: siblings keep
+// their order. A category whose parent isn't in the listing is treated as
+// unreachable rather than top-level.
+func NewCategoryTree(categories []*Category) *CategoryTree {
+	t := &CategoryTree{byID: make(map[int]*Category, len(categories)), children: map[int][]*Category{}}
+	for _, c := range categories {
+		t.byID[c.ID] = c
+	}
+	for _, c := range categories {
+		parent := 0
+		if c.ParentID != nil {
+			parent = *c.ParentID
+		}
+		t.children[parent] = append(t.children[parent], c)
+	}
+	return t
+}
+
+// Get returns the category with the ID, or nil.
+func (t *CategoryTree) Get(id int) *Category {
+	return t.byID[id]
+}
+
+// Children lists the categories directly under parentID in display order;
+// 0 lists the top level.
+func (t *CategoryTree) Children(parentID int) []*Category {
+	return t.children[parentID]
+}
+
+// Walk lists the categories under parentID depth first, each followed by
+// its own children. With activeOnly it skips inactive categories and
+// everything under them.
+func (t *CategoryTree) Walk(parentID int, activeOnly bool) []*Category {
+	var out []*Category
+	var walk func(int)
+	walk = func(id int) {
+		for _, c := range t.children[id] {
+			if activeOnly && !c.IsActive {
+				continue
+			}
+			out = append(out, c)
+			walk(c.ID)
+		}
+	}
+	walk(parentID)
+	return out
+}
+
+// Within reports whether id is ancestor or lies somewhere under it.
+func (t *CategoryTree) Within(id, ancestor int) bool {
+	for seen := 0; seen <= len(t.byID); seen++ {
+		if id == ancestor {
+			return true
+		}
+		c := t.byID[id]
+		if c == nil || c.ParentID == nil {
+			return false
+		}
+		id = *c.ParentID
+	}
+	return false
+}
+
+// Active reports whether the category and all its ancestors are active, so
+// customers can see it.
+func (t *CategoryTree) Active(id int) bool {
+	for seen := 0; seen <= len(t.byID); seen++ {
+		c := t.byID[id]
+		if c == nil || !c.IsActive {
+			return false
+		}
+		if c.ParentID == nil {
+			return true
+		}
+		id = *c.ParentID
+	}
+	return false
 }
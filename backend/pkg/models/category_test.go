@@ -0,0 +1,53 @@
+package models_test
+
+import (
+	"testing"
+
+	"github.com/Entity069/Zesty-Go/pkg/models"
+)
+
+func TestCategoryTree(t *testing.T) {
+	parent := func(id int) *int { return &id }
+	// As GetAll lists them: by position, whatever the level.
+	tree := models.NewCategoryTree([]*models.Category{
+		{ID: 1, Name: "Food", Position: 1, IsActive: true},
+		{ID: 4, Name: "Pizza", ParentID: parent(2), Position: 1, IsActive: true},
+		{ID: 2, Name: "Italian", ParentID: parent(1), Position: 1, IsActive: true},
+		{ID: 3, Name: "Drinks", Position: 2, IsActive: true},
+		{ID: 5, Name: "Pasta", ParentID: parent(2), Position: 2, IsActive: false},
+		{ID: 6, Name: "Fresh pasta", ParentID: parent(5), Position: 1, IsActive: true},
+		{ID: 7, Name: "Orphan", ParentID: parent(99), Position: 1, IsActive: true},
+	})
+
+	ids := func(cs []*models.Category) []int {
+		var out []int
+		for _, c := range cs {
+			out = append(out, c.ID)
+		}
+		return out
+	}
+	for name, tc := range map[string]struct{ got, want []int }{
+		"top level":   {ids(tree.Children(0)), []int{1, 3}},
+		"walk":        {ids(tree.Walk(0, false)), []int{1, 2, 4, 5, 6, 3}},
+		"walk active": {ids(tree.Walk(0, true)), []int{1, 2, 4, 3}},
+		"subtree":     {ids(tree.Walk(2, false)), []int{4, 5, 6}},
+	} {
+		if len(tc.got) != len(tc.want) {
+			t.Errorf("%s = %v, want %v", name, tc.got, tc.want)
+			continue
+		}
+		for i := range tc.got {
+			if tc.got[i] != tc.want[i] {
+				t.Errorf("%s = %v, want %v", name, tc.got, tc.want)
+				break
+			}
+		}
+	}
+
+	if !tree.Within(6, 1) || !tree.Within(2, 2) || tree.Within(1, 2) || tree.Within(3, 1) {
+		t.Error("Within got an ancestry wrong")
+	}
+	if !tree.Active(4) || tree.Active(5) || tree.Active(6) || tree.Active(7) || tree.Active(99) {
+		t.Error("Active should need the category and every ancestor active and present")
+	}
+}
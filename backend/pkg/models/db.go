@@ -1,46 +1,304 @@
 package models
 
 import (
+	"context"
 	"database/sql"
+	"errors"
 	"fmt"
+	"runtime"
+	"strings"
 	"time"
 
-	_ "github.com/go-sql-driver/mysql"
+	"github.com/go-sql-driver/mysql"
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
 
 	"github.com/Entity069/Zesty-Go/pkg/config"
+	"github.com/Entity069/Zesty-Go/pkg/logging"
+	"github.com/Entity069/Zesty-Go/pkg/tracing"
 )
 
-var DB *sql.DB
+// Querier is what the repositories run their SQL through. withQueryOptions
+// makes one from either a *sql.DB or a *sql.Tx, so the same repository code
+// works inside and outside a transaction.
+type Querier interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) Row
+}
+
+// Rows and Row are the parts of *sql.Rows and *sql.Row the repositories use.
+type Rows interface {
+	Next() bool
+	Scan(dest ...any) error
+	Close() error
+	Err() error
+}
+
+type Row interface {
+	Scan(dest ...any) error
+	Err() error
+}
+
+// dbConn is what *sql.DB and *sql.Tx have in common.
+type dbConn interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+func Open(cfg config.DBConfig) (*sql.DB, error) {
+	db, err := sql.Open("mysql", cfg.DSN())
+	if err != nil {
+		return nil, fmt.Errorf("models.Open: sql.Open failed: %w", err)
+	}
+
+	db.SetMaxOpenConns(cfg.MaxOpenConns)
+	db.SetMaxIdleConns(cfg.MaxIdleConns)
+	db.SetConnMaxLifetime(cfg.ConnMaxLifetime)
+
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("models.Open: ping failed: %w", err)
+	}
+	return db, nil
+}
+
+// erDeadlock is the error MySQL ends a transaction with when it picks it as
+// the victim of a deadlock.
+const erDeadlock = 1213
 
-func InitDB() {
-	dsn := config.DBConnString()
+// IsDeadlock reports whether err ended a transaction as a deadlock victim.
+// MySQL has rolled it back, and it can simply be run again.
+func IsDeadlock(err error) bool {
+	var myErr *mysql.MySQLError
+	return errors.As(err, &myErr) && myErr.Number == erDeadlock
+}
 
-	var err error
-	DB, err = sql.Open("mysql", dsn)
+// execOne runs an UPDATE meant to change exactly one row, and reports
+// sql.ErrNoRows when it changed none: the row is missing, or already in the
+// state asked for.
+func execOne(ctx context.Context, q Querier, query string, args ...any) error {
+	result, err := q.ExecContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	n, err := result.RowsAffected()
 	if err != nil {
-		panic(fmt.Errorf("models.InitDB: sql.Open failed: %w", err))
+		return err
+	}
+	if n == 0 {
+		return sql.ErrNoRows
 	}
+	return nil
+}
 
-	maxOpen := 25
-	maxIdle := 5
-	maxLife := 5 * time.Minute
+// QueryOptions bounds how long a single statement may run and when it is
+// worth logging. A zero value disables the corresponding behaviour.
+type QueryOptions struct {
+	Timeout       time.Duration
+	SlowThreshold time.Duration
+}
 
-	DB.SetMaxOpenConns(maxOpen)
-	DB.SetMaxIdleConns(maxIdle)
-	DB.SetConnMaxLifetime(maxLife)
+// timedQuerier puts a deadline on every statement, logs the slow and failed
+// ones with the request's logger and traces each in a client span. The
+// deadline derives from the caller's context, so a client going away or
+// the server shutting down still cancels the query early.
+type timedQuerier struct {
+	q    dbConn
+	opts QueryOptions
+}
 
-	if err := DB.Ping(); err != nil {
-		panic(fmt.Errorf("models.InitDB: ping failed: %w", err))
+func withQueryOptions(q dbConn, opts QueryOptions) Querier {
+	return &timedQuerier{q: q, opts: opts}
+}
+
+// deadline applies the per-query timeout. Rows and Row keep reading from the
+// context after the call returns, so for queries it is cancelled once they
+// are done with: when the rows are closed or run out, or the row is scanned.
+func (t *timedQuerier) deadline(ctx context.Context) (context.Context, context.CancelFunc) {
+	if t.opts.Timeout <= 0 {
+		return ctx, func() {}
 	}
+	return context.WithTimeout(ctx, t.opts.Timeout)
+}
 
-	fmt.Printf("db connected (open=%d, idle=%d, max-life=%s)\n",
-		maxOpen, maxIdle, maxLife)
+func (t *timedQuerier) logSlow(ctx context.Context, start time.Time, query string, err error) {
+	elapsed := time.Since(start)
+	switch {
+	case err == nil:
+	case errors.Is(err, context.DeadlineExceeded):
+		logging.FromContext(ctx).Error("query timed out", "elapsed", elapsed, "query", compactSQL(query))
+		return
+	case errors.Is(err, context.Canceled):
+		logging.FromContext(ctx).Info("query cancelled", "elapsed", elapsed, "query", compactSQL(query))
+		return
+	default:
+		logging.FromContext(ctx).Error("query failed", "err", err, "query", compactSQL(query))
+		return
+	}
+	if t.opts.SlowThreshold > 0 && elapsed >= t.opts.SlowThreshold {
+		logging.FromContext(ctx).Warn("slow query", "elapsed", elapsed, "query", compactSQL(query))
+	}
 }
 
-func CloseDB() error {
-	if DB == nil {
-		return nil
+// startSpan opens the span for one statement. It is named after the SQL
+// verb and records the repository method that issued it, which says more
+// about where time goes than the query text alone. Arguments are never
+// recorded, and literals in the text are replaced by sanitizeSQL.
+//
+// For queries the span covers sending the statement and the first result;
+// rows scanned afterwards aren't included.
+func (t *timedQuerier) startSpan(ctx context.Context, query string) (context.Context, trace.Span) {
+	compact := sanitizeSQL(query)
+	op, _, _ := strings.Cut(compact, " ")
+	op = strings.ToUpper(op)
+
+	attrs := []attribute.KeyValue{
+		semconv.DBSystemNameMySQL,
+		semconv.DBOperationName(op),
+		semconv.DBQueryText(compact),
+	}
+	// Skip startSpan and the querier method to reach the repository.
+	if pc, _, _, ok := runtime.Caller(2); ok {
+		if fn := runtime.FuncForPC(pc); fn != nil {
+			attrs = append(attrs, semconv.CodeFunctionName(fn.Name()))
+		}
 	}
-	fmt.Println("closing database connection pool...")
-	return DB.Close()
+	return tracing.Tracer().Start(ctx, op, trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(attrs...))
+}
+
+func (t *timedQuerier) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	ctx, cancel := t.deadline(ctx)
+	defer cancel()
+	ctx, span := t.startSpan(ctx, query)
+	defer span.End()
+
+	start := time.Now()
+	res, err := t.q.ExecContext(ctx, query, args...)
+	t.logSlow(ctx, start, query, err)
+	tracing.SpanError(span, err)
+	return res, err
+}
+
+func (t *timedQuerier) QueryContext(ctx context.Context, query string, args ...any) (Rows, error) {
+	ctx, cancel := t.deadline(ctx)
+	ctx, span := t.startSpan(ctx, query)
+	defer span.End()
+
+	start := time.Now()
+	rows, err := t.q.QueryContext(ctx, query, args...)
+	t.logSlow(ctx, start, query, err)
+	tracing.SpanError(span, err)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	return &cancelRows{Rows: rows, cancel: cancel}, nil
+}
+
+func (t *timedQuerier) QueryRowContext(ctx context.Context, query string, args ...any) Row {
+	ctx, cancel := t.deadline(ctx)
+	ctx, span := t.startSpan(ctx, query)
+	defer span.End()
+
+	start := time.Now()
+	row := t.q.QueryRowContext(ctx, query, args...)
+	t.logSlow(ctx, start, query, row.Err())
+	if err := row.Err(); err != nil && !errors.Is(err, sql.ErrNoRows) {
+		tracing.SpanError(span, err)
+	}
+	return &cancelRow{Row: row, cancel: cancel}
+}
+
+// cancelRows releases its query's deadline once the rows are closed, or
+// have run out and so closed themselves.
+type cancelRows struct {
+	*sql.Rows
+	cancel context.CancelFunc
+}
+
+func (r *cancelRows) Next() bool {
+	if r.Rows.Next() {
+		return true
+	}
+	r.cancel()
+	return false
+}
+
+func (r *cancelRows) Close() error {
+	defer r.cancel()
+	return r.Rows.Close()
+}
+
+// cancelRow releases its query's deadline once the row is scanned.
+type cancelRow struct {
+	*sql.Row
+	cancel context.CancelFunc
+}
+
+func (r *cancelRow) Scan(dest ...any) error {
+	defer r.cancel()
+	return r.Row.Scan(dest...)
+}
+
+// sanitizeSQL compacts query and replaces string and number literals with
+// "?". The repositories pass values as placeholder arguments, but a literal
+// written into the text (a status, a LIMIT) shouldn't reach a trace
+// backend if it ever holds something sensitive.
+func sanitizeSQL(query string) string {
+	query = compactSQL(query)
+	var b strings.Builder
+	b.Grow(len(query))
+	for i := 0; i < len(query); i++ {
+		c := query[i]
+		switch {
+		case c == '`':
+			end := strings.IndexByte(query[i+1:], '`')
+			if end < 0 {
+				b.WriteString(query[i:])
+				return b.String()
+			}
+			b.WriteString(query[i : i+end+2])
+			i += end + 1
+		case c == '\'' || c == '"':
+			j := i + 1
+			for j < len(query) {
+				if query[j] == '\\' {
+					j += 2
+					continue
+				}
+				if query[j] == c {
+					if j+1 < len(query) && query[j+1] == c {
+						j += 2
+						continue
+					}
+					break
+				}
+				j++
+			}
+			b.WriteByte('?')
+			i = j
+		case c >= '0' && c <= '9' && (i == 0 || !isIdentByte(query[i-1])):
+			j := i
+			for j < len(query) && (query[j] >= '0' && query[j] <= '9' || query[j] == '.') {
+				j++
+			}
+			b.WriteByte('?')
+			i = j - 1
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
+
+func isIdentByte(c byte) bool {
+	return c == '_' || c == '$' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9'
+}
+
+// compactSQL folds the indented multi-line queries onto one line for logs.
+func compactSQL(query string) string {
+	return strings.Join(strings.Fields(query), " ")
 }
@@ -0,0 +1,175 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// blockingQuerier stands in for a hung database: every statement waits for
+// its context to end.
+type blockingQuerier struct{}
+
+func (blockingQuerier) ExecContext(ctx context.Context, _ string, _ ...any) (sql.Result, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func (blockingQuerier) QueryContext(ctx context.Context, _ string, _ ...any) (*sql.Rows, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func (blockingQuerier) QueryRowContext(ctx context.Context, _ string, _ ...any) *sql.Row {
+	<-ctx.Done()
+	return &sql.Row{}
+}
+
+func TestQueryTimeout(t *testing.T) {
+	q := withQueryOptions(blockingQuerier{}, QueryOptions{Timeout: 20 * time.Millisecond})
+
+	start := time.Now()
+	_, err := q.ExecContext(context.Background(), "UPDATE users SET balance = 0")
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("ExecContext error = %v, want deadline exceeded", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("ExecContext took %s, want about 20ms", elapsed)
+	}
+}
+
+func TestQueryCancelledWithCaller(t *testing.T) {
+	q := withQueryOptions(blockingQuerier{}, QueryOptions{Timeout: time.Minute})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(10*time.Millisecond, cancel)
+	if _, err := q.QueryContext(ctx, "SELECT 1"); !errors.Is(err, context.Canceled) {
+		t.Fatalf("QueryContext error = %v, want context canceled", err)
+	}
+}
+
+// ctxConnector is a database whose every query returns one row, holding 1.
+// It keeps the context the last query ran with.
+type ctxConnector struct{ last context.Context }
+
+func (c *ctxConnector) Connect(context.Context) (driver.Conn, error) { return ctxConn{c}, nil }
+func (c *ctxConnector) Driver() driver.Driver                        { return nil }
+
+type ctxConn struct{ c *ctxConnector }
+
+func (ctxConn) Prepare(string) (driver.Stmt, error) { return nil, errors.New("not supported") }
+func (ctxConn) Close() error                        { return nil }
+func (ctxConn) Begin() (driver.Tx, error)           { return nil, errors.New("not supported") }
+
+func (c ctxConn) QueryContext(ctx context.Context, _ string, _ []driver.NamedValue) (driver.Rows, error) {
+	c.c.last = ctx
+	return &oneRow{}, nil
+}
+
+type oneRow struct{ done bool }
+
+func (*oneRow) Columns() []string { return []string{"n"} }
+func (*oneRow) Close() error      { return nil }
+
+func (r *oneRow) Next(dest []driver.Value) error {
+	if r.done {
+		return io.EOF
+	}
+	r.done, dest[0] = true, int64(1)
+	return nil
+}
+
+func TestQueryDeadlineReleased(t *testing.T) {
+	connector := &ctxConnector{}
+	db := sql.OpenDB(connector)
+	defer db.Close()
+	q := withQueryOptions(db, QueryOptions{Timeout: time.Minute})
+	ctx := context.Background()
+	var n int
+
+	rows, err := q.QueryContext(ctx, "SELECT 1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if connector.last.Err() != nil {
+		t.Fatal("deadline released before the rows were read")
+	}
+	rows.Close()
+	if connector.last.Err() != context.Canceled {
+		t.Errorf("after rows.Close the deadline is %v, want it released", connector.last.Err())
+	}
+
+	rows, _ = q.QueryContext(ctx, "SELECT 1")
+	for rows.Next() {
+	}
+	if connector.last.Err() != context.Canceled {
+		t.Errorf("after the rows ran out the deadline is %v, want it released", connector.last.Err())
+	}
+
+	if err := q.QueryRowContext(ctx, "SELECT 1").Scan(&n); err != nil || n != 1 {
+		t.Fatalf("Scan = %d, %v", n, err)
+	}
+	if connector.last.Err() != context.Canceled {
+		t.Errorf("after Row.Scan the deadline is %v, want it released", connector.last.Err())
+	}
+}
+
+func TestQuerySpan(t *testing.T) {
+	sr := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(sr)))
+
+	q := withQueryOptions(blockingQuerier{}, QueryOptions{Timeout: 5 * time.Millisecond})
+	q.ExecContext(context.Background(), "UPDATE users SET status = 'banned' WHERE id = ?", 7)
+
+	spans := sr.Ended()
+	if len(spans) != 1 {
+		t.Fatalf("got %d spans, want 1", len(spans))
+	}
+	span := spans[0]
+	if span.Name() != "UPDATE" || span.Status().Code != codes.Error {
+		t.Fatalf("span = %q with status %v, want a failed UPDATE", span.Name(), span.Status().Code)
+	}
+	attrs := map[string]string{}
+	for _, kv := range span.Attributes() {
+		attrs[string(kv.Key)] = kv.Value.Emit()
+	}
+	if got := attrs["db.query.text"]; got != "UPDATE users SET status = ? WHERE id = ?" {
+		t.Errorf("db.query.text = %q", got)
+	}
+	if got := attrs["code.function.name"]; !strings.HasSuffix(got, ".TestQuerySpan") {
+		t.Errorf("code.function.name = %q, want the calling function", got)
+	}
+}
+
+func TestCompactSQL(t *testing.T) {
+	got := compactSQL(`
+		SELECT id
+		FROM   users
+		WHERE  id = ?`)
+	if want := "SELECT id FROM users WHERE id = ?"; got != want {
+		t.Fatalf("compactSQL = %q, want %q", got, want)
+	}
+}
+
+func TestSanitizeSQL(t *testing.T) {
+	cases := map[string]string{
+		"SELECT id FROM orders WHERE status = 'cart' LIMIT 1":              "SELECT id FROM orders WHERE status = ? LIMIT ?",
+		`UPDATE users SET note = 'it''s \'quoted\'', x = "y" WHERE id = ?`: "UPDATE users SET note = ?, x = ? WHERE id = ?",
+		"SELECT `col1`, t2.v FROM `table_2` t2 WHERE price > 10.5":         "SELECT `col1`, t2.v FROM `table_2` t2 WHERE price > ?",
+	}
+	for in, want := range cases {
+		if got := sanitizeSQL(in); got != want {
+			t.Errorf("sanitizeSQL(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
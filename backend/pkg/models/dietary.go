@@ -0,0 +1,156 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// DietTags say who a dish suits. Sellers pick from these; a tag is a
+// promise, so none is inferred from another.
+var DietTags = []string{"vegetarian", "vegan", "eggetarian", "jain", "gluten_free", "dairy_free", "nut_free", "halal"}
+
+// Allergens are the ones menus have to declare.
+var Allergens = []string{
+	"gluten", "crustaceans", "eggs", "fish", "peanuts", "soy", "milk", "tree_nuts",
+	"celery", "mustard", "sesame", "sulphites", "lupin", "molluscs",
+}
+
+// MaxSpiceLevel is the hottest spice level: 0 is not spicy, then mild,
+// medium and hot.
+const MaxSpiceLevel = 3
+
+// Tags is a set of words from one of the vocabularies above, stored as a
+// comma-separated column and sent as a JSON array.
+type Tags []string
+
+// NewTags sorts and deduplicates tags, so equal sets compare and store
+// alike.
+func NewTags(tags ...string) Tags {
+	out := Tags(slices.Clone(tags))
+	slices.Sort(out)
+	return slices.Compact(out)
+}
+
+// Intersect lists the tags in both sets.
+func (t Tags) Intersect(other Tags) Tags {
+	var out Tags
+	for _, tag := range t {
+		if slices.Contains(other, tag) {
+			out = append(out, tag)
+		}
+	}
+	return out
+}
+
+func (t Tags) MarshalJSON() ([]byte, error) {
+	if t == nil {
+		return []byte("[]"), nil
+	}
+	return json.Marshal([]string(t))
+}
+
+func (t *Tags) Scan(src any) error {
+	var s string
+	switch v := src.(type) {
+	case nil:
+	case string:
+		s = v
+	case []byte:
+		s = string(v)
+	default:
+		return fmt.Errorf("models: can't scan %T into Tags", src)
+	}
+	*t = nil
+	for _, tag := range strings.Split(s, ",") {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			*t = append(*t, tag)
+		}
+	}
+	return nil
+}
+
+func (t Tags) Value() (driver.Value, error) {
+	return strings.Join(t, ","), nil
+}
+
+// Nutrition holds the facts a seller knows for one serving. Each is
+// optional, and stored together as a JSON column.
+type Nutrition struct {
+	Calories *int     `json:"calories,omitempty" validate:"min=0,max=10000"`
+	ProteinG *float64 `json:"protein_g,omitempty" validate:"min=0,max=10000"`
+	CarbsG   *float64 `json:"carbs_g,omitempty" validate:"min=0,max=10000"`
+	SugarG   *float64 `json:"sugar_g,omitempty" validate:"min=0,max=10000"`
+	FatG     *float64 `json:"fat_g,omitempty" validate:"min=0,max=10000"`
+	FibreG   *float64 `json:"fibre_g,omitempty" validate:"min=0,max=10000"`
+	SodiumMg *float64 `json:"sodium_mg,omitempty" validate:"min=0,max=100000"`
+}
+
+func (n *Nutrition) Scan(src any) error {
+	switch v := src.(type) {
+	case string:
+		return json.Unmarshal([]byte(v), n)
+	case []byte:
+		return json.Unmarshal(v, n)
+	default:
+		return fmt.Errorf("models: can't scan %T into Nutrition", src)
+	}
+}
+
+func (n Nutrition) Value() (driver.Value, error) {
+	b, err := json.Marshal(n)
+	return string(b), err
+}
+
+// DietFilter narrows an item listing by dietary information. The zero value
+// keeps every item.
+type DietFilter struct {
+	// Diet lists tags an item must all have.
+	Diet Tags
+	// WithoutAllergens lists allergens an item must have none of.
+	WithoutAllergens Tags
+	// MaxSpice and MaxCalories cap the spice level and calories when set.
+	// An item whose calories aren't given doesn't pass a calorie cap.
+	MaxSpice    *int
+	MaxCalories *int
+}
+
+// IsZero reports whether the filter keeps every item.
+func (f DietFilter) IsZero() bool {
+	return len(f.Diet) == 0 && len(f.WithoutAllergens) == 0 && f.MaxSpice == nil && f.MaxCalories == nil
+}
+
+// Match reports whether the item passes the filter.
+func (f DietFilter) Match(i *Item) bool {
+	for _, tag := range f.Diet {
+		if !slices.Contains(i.DietTags, tag) {
+			return false
+		}
+	}
+	if len(i.Allergens.Intersect(f.WithoutAllergens)) > 0 {
+		return false
+	}
+	if f.MaxSpice != nil && i.SpiceLevel > *f.MaxSpice {
+		return false
+	}
+	if f.MaxCalories != nil && (i.Nutrition == nil || i.Nutrition.Calories == nil || *i.Nutrition.Calories > *f.MaxCalories) {
+		return false
+	}
+	return true
+}
+
+// Apply returns the items that pass the filter, in order.
+func (f DietFilter) Apply(items []*Item) []*Item {
+	if f.IsZero() {
+		return items
+	}
+	var out []*Item
+	for _, i := range items {
+		if f.Match(i) {
+			out = append(out, i)
+		}
+	}
+	return out
+}
@@ -0,0 +1,41 @@
+package models_test
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/Entity069/Zesty-Go/pkg/models"
+)
+
+func TestTagsColumn(t *testing.T) {
+	tags := models.NewTags("vegan", "gluten_free", "vegan")
+	v, err := tags.Value()
+	if err != nil || v != "gluten_free,vegan" {
+		t.Fatalf("Value() = %v, %v", v, err)
+	}
+
+	var back models.Tags
+	if err := back.Scan([]byte(" gluten_free,,vegan")); err != nil || strings.Join(back, "|") != "gluten_free|vegan" {
+		t.Fatalf("Scan = %q, %v", back, err)
+	}
+	if err := back.Scan(""); err != nil || back != nil {
+		t.Fatalf("Scan of an empty column = %q, %v", back, err)
+	}
+	if raw, _ := json.Marshal(back); string(raw) != "[]" {
+		t.Fatalf("no tags marshal as %s, want []", raw)
+	}
+}
+
+func TestNutritionColumn(t *testing.T) {
+	var n models.Nutrition
+	if err := n.Scan([]byte(`{"calories": 250, "fat_g": 4.5}`)); err != nil {
+		t.Fatal(err)
+	}
+	if n.Calories == nil || *n.Calories != 250 || n.ProteinG != nil {
+		t.Fatalf("scanned %+v", n)
+	}
+	if v, _ := n.Value(); v != `{"calories":250,"fat_g":4.5}` {
+		t.Fatalf("Value() = %v", v)
+	}
+}
@@ -1,14 +1,22 @@
 package models
 
 import (
-	"fmt"
-	"sync"
+	"context"
+	"encoding/json"
+	"strings"
 	"time"
+
+	"github.com/Entity069/Zesty-Go/pkg/images"
+	"github.com/Entity069/Zesty-Go/pkg/logging"
+	"github.com/Entity069/Zesty-Go/pkg/metrics"
 )
 
 type Item struct {
-	ID          int       `json:"id"`
-	SellerID    int       `json:"seller_id"`
+	ID       int `json:"id"`
+	SellerID int `json:"seller_id"`
+	// SKU is the seller's own code for the item, unique among their items.
+	// Catalog imports match rows to items by it.
+	SKU         string    `json:"sku,omitempty"`
 	Name        string    `json:"name"`
 	Description string    `json:"description"`
 	Price       float64   `json:"price"`
@@ -17,92 +25,69 @@ type Item struct {
 	Image       string    `json:"image"`
 	CreatedAt   time.Time `json:"created_at"`
 	UpdatedAt   time.Time `json:"updated_at"`
+	// DeletedAt is set while the item is deleted. Orders keep showing it,
+	// but it can't be found, listed or bought.
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
+	// DietTags and Allergens come from the DietTags and Allergens
+	// vocabularies; SpiceLevel runs from 0 to MaxSpiceLevel.
+	DietTags   Tags       `json:"diet_tags"`
+	Allergens  Tags       `json:"allergens"`
+	SpiceLevel int        `json:"spice_level"`
+	Nutrition  *Nutrition `json:"nutrition"`
+	// MenuID is the menu whose schedule the item follows when it has none
+	// of its own. MenuSchedule is that menu's schedule, loaded with it.
+	MenuID       *int     `json:"menu_id"`
+	Schedule     Schedule `json:"schedule"`
+	MenuSchedule Schedule `json:"menu_schedule,omitempty"`
+	// Available says whether the item can be ordered right now. It depends
+	// on the time, so repositories leave it unset and handlers fill it in
+	// with AvailableAt as they respond.
+	Available bool `json:"available"`
 	// this fields are for extra fields for some endpoints
 	SellerFirstName string  `json:"seller_fname"`
 	SellerLastName  string  `json:"seller_lname"`
 	CategoryName    string  `json:"cname"`
 	Rating          float64 `json:"rating"`
+	// CategoryDeleted is set in a seller's own listing for an item whose
+	// category was deleted, which customers no longer see.
+	CategoryDeleted bool `json:"category_deleted,omitempty"`
 }
 
-type ItemsCache struct {
-	mu     sync.RWMutex
-	items  map[int][]*Item
-	expiry map[int]time.Time
-	ttl    time.Duration
-}
-
-var itemsCache = &ItemsCache{
-	items:  make(map[int][]*Item),
-	expiry: make(map[int]time.Time),
-	ttl:    5 * time.Minute,
-}
-
-func (c *ItemsCache) SetCache(limit int, items []*Item) {
-	c.mu.Lock()
-	defer c.mu.Unlock()
-
-	itemsCopy := make([]*Item, len(items))
-	copy(itemsCopy, items)
-
-	c.items[limit] = itemsCopy
-	c.expiry[limit] = time.Now().Add(c.ttl)
+// MarshalJSON adds image_srcset, listing the sizes of an uploaded image for
+// the browser to choose from. It is derived from Image, so it isn't stored.
+func (i Item) MarshalJSON() ([]byte, error) {
+	type item Item
+	return json.Marshal(struct {
+		item
+		ImageSrcset string `json:"image_srcset,omitempty"`
+	}{item(i), images.Srcset(i.Image)})
 }
 
-func (c *ItemsCache) GetFromCache(limit int) ([]*Item, bool) {
-	c.mu.RLock()
-	defer c.mu.RUnlock()
-
-	items, exists := c.items[limit]
-	if !exists {
-		return nil, false
+// EffectiveSchedule is the item's own schedule, or else its menu's.
+func (i *Item) EffectiveSchedule() Schedule {
+	if len(i.Schedule) > 0 {
+		return i.Schedule
 	}
-
-	expiry, exists := c.expiry[limit]
-	if !exists || time.Now().After(expiry) {
-		return nil, false
-	}
-
-	itemsCopy := make([]*Item, len(items))
-	copy(itemsCopy, items)
-	return itemsCopy, true
+	return i.MenuSchedule
 }
 
-// need to invalidate cache when items are created, updated or deleted
-func (c *ItemsCache) InvalidateCache() {
-	c.mu.Lock()
-	defer c.mu.Unlock()
-
-	c.items = make(map[int][]*Item)
-	c.expiry = make(map[int]time.Time)
-}
-
-func (c *ItemsCache) CleanExpiredEntries() {
-	c.mu.Lock()
-	defer c.mu.Unlock()
-
-	now := time.Now()
-	for limit, expiry := range c.expiry {
-		if now.After(expiry) {
-			delete(c.items, limit)
-			delete(c.expiry, limit)
-		}
-	}
+// AvailableAt reports whether the item can be ordered at t, in the shop's
+// time zone: its status must be available and t inside its schedule.
+func (i *Item) AvailableAt(t time.Time) bool {
+	return i.Status == "available" && i.EffectiveSchedule().OpenAt(t)
 }
 
-func StartCacheCleanup() {
-	go func() {
-		ticker := time.NewTicker(5 * time.Minute)
-		defer ticker.Stop()
-
-		for range ticker.C {
-			itemsCache.CleanExpiredEntries()
-		}
-	}()
+type sqlItemRepo struct {
+	q     Querier
+	cache ItemsCache
 }
 
-func (i *Item) Create() error {
-	query := `INSERT INTO items (seller_id, name, description, price, category_id, status, image) VALUES (?, ?, ?, ?, ?, ?, ?)`
-	result, err := DB.Exec(query, i.SellerID, i.Name, i.Description, i.Price, i.CategoryID, i.Status, i.Image)
+func (r *sqlItemRepo) Create(ctx context.Context, i *Item) error {
+	query := `INSERT INTO items (seller_id, sku, name, description, price, category_id, status, image, diet_tags, allergens, spice_level, nutrition,
+		menu_id, schedule)
+		VALUES (?, NULLIF(?, ''), ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+	result, err := r.q.ExecContext(ctx, query, i.SellerID, i.SKU, i.Name, i.Description, i.Price, i.CategoryID, i.Status, i.Image,
+		i.DietTags, i.Allergens, i.SpiceLevel, i.Nutrition, i.MenuID, i.Schedule)
 	if err != nil {
 		return err
 	}
@@ -114,46 +99,65 @@ func (i *Item) Create() error {
 
 	i.ID = int(id)
 
-	itemsCache.InvalidateCache()
+	r.cache.InvalidateCache(ctx)
 
 	return nil
 }
 
-func (i *Item) Update() error {
-	query := `UPDATE items SET name = ?, description = ?, price = ?, category_id = ?, status = ?, image = ? WHERE id = ?`
-	_, err := DB.Exec(query, i.Name, i.Description, i.Price, i.CategoryID, i.Status, i.Image, i.ID)
+func (r *sqlItemRepo) Update(ctx context.Context, i *Item) error {
+	query := `UPDATE items SET sku = NULLIF(?, ''), name = ?, description = ?, price = ?, category_id = ?, status = ?, image = ?,
+		diet_tags = ?, allergens = ?, spice_level = ?, nutrition = ?, menu_id = ?, schedule = ? WHERE id = ?`
+	_, err := r.q.ExecContext(ctx, query, i.SKU, i.Name, i.Description, i.Price, i.CategoryID, i.Status, i.Image,
+		i.DietTags, i.Allergens, i.SpiceLevel, i.Nutrition, i.MenuID, i.Schedule, i.ID)
 
 	if err == nil {
-		itemsCache.InvalidateCache()
+		r.cache.InvalidateCache(ctx)
 	}
 
 	return err
 }
 
-func (i *Item) Delete() error {
-	query := `DELETE FROM items WHERE id = ?`
-	_, err := DB.Exec(query, i.ID)
+func (r *sqlItemRepo) Delete(ctx context.Context, id int) error {
+	query := `UPDATE items SET deleted_at = NOW() WHERE id = ? AND deleted_at IS NULL`
+	err := execOne(ctx, r.q, query, id)
 
 	if err == nil {
-		itemsCache.InvalidateCache()
+		r.cache.InvalidateCache(ctx)
 	}
 
 	return err
 }
 
-func GetAllItems(limit int) ([]*Item, error) {
-	if cachedItems, found := itemsCache.GetFromCache(limit); found {
+func (r *sqlItemRepo) Restore(ctx context.Context, id int) error {
+	query := `UPDATE items SET deleted_at = NULL WHERE id = ? AND deleted_at IS NOT NULL`
+	err := execOne(ctx, r.q, query, id)
+
+	if err == nil {
+		r.cache.InvalidateCache(ctx)
+	}
+
+	return err
+}
+
+func (r *sqlItemRepo) GetAll(ctx context.Context, limit int) ([]*Item, error) {
+	cachedItems, gen, found := r.cache.GetFromCache(ctx, limit)
+	metrics.CacheLookup(found)
+	if found {
 		return cachedItems, nil
 	}
 
 	query := `
-	SELECT 
+	SELECT
 		i.id, i.seller_id, i.name, i.description, i.price, i.category_id, i.status, i.image, i.created_at, i.updated_at,
-		c.name AS category_name,
+		i.diet_tags, i.allergens, i.spice_level, i.nutrition, i.menu_id, i.schedule, m.schedule,
+		COALESCE(c.name, '') AS category_name,
 		ROUND(COALESCE(AVG(r.rating), 0), 1) AS rating
 	FROM items i
-	LEFT JOIN categories c ON i.category_id = c.id
+	INNER JOIN users u ON u.id = i.seller_id AND u.deleted_at IS NULL
+	INNER JOIN categories c ON c.id = i.category_id AND c.deleted_at IS NULL AND c.is_active
+	LEFT JOIN menus m ON m.id = i.menu_id
 	LEFT JOIN reviews r ON r.item_id = i.id
+	WHERE i.deleted_at IS NULL
 	GROUP BY i.id
 	`
 
@@ -164,7 +168,7 @@ func GetAllItems(limit int) ([]*Item, error) {
 		args = append(args, limit)
 	}
 
-	rows, err := DB.Query(query, args...)
+	rows, err := r.q.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -173,26 +177,27 @@ func GetAllItems(limit int) ([]*Item, error) {
 	var items []*Item
 	for rows.Next() {
 		item := &Item{}
-		var categoryName string
-		var rating float64
-		err := rows.Scan(&item.ID, &item.SellerID, &item.Name, &item.Description, &item.Price, &item.CategoryID, &item.Status, &item.Image, &item.CreatedAt, &item.UpdatedAt, &categoryName, &rating)
+		err := rows.Scan(&item.ID, &item.SellerID, &item.Name, &item.Description, &item.Price, &item.CategoryID, &item.Status, &item.Image, &item.CreatedAt, &item.UpdatedAt,
+			&item.DietTags, &item.Allergens, &item.SpiceLevel, &item.Nutrition, &item.MenuID, &item.Schedule, &item.MenuSchedule,
+			&item.CategoryName, &item.Rating)
 		if err != nil {
 			return nil, err
 		}
 		items = append(items, item)
 	}
 
-	itemsCache.SetCache(limit, items)
+	r.cache.SetCache(ctx, limit, gen, items)
 
 	return items, nil
 }
 
-func GetItemByID(id int) (*Item, error) {
+func (r *sqlItemRepo) GetByID(ctx context.Context, id int) (*Item, error) {
 	item := &Item{}
 	query := `
 		SELECT
 			i.id		  		AS id,
 			i.seller_id  		AS sid,
+			COALESCE(i.sku, '')	AS sku,
 			i.name       		AS name,
 			i.image     		AS image,
 			i.description 		AS description,
@@ -201,6 +206,13 @@ func GetItemByID(id int) (*Item, error) {
 			i.status     		AS status,
 			i.created_at 		AS created_at,
 			i.updated_at 		AS updated_at,
+			i.diet_tags  		AS diet_tags,
+			i.allergens  		AS allergens,
+			i.spice_level		AS spice_level,
+			i.nutrition  		AS nutrition,
+			i.menu_id    		AS menu_id,
+			i.schedule   		AS schedule,
+			m.schedule   		AS menu_schedule,
 			u.first_name 		AS fname,
 			u.last_name  		AS lname,
 			c.name      		AS cname,
@@ -209,16 +221,19 @@ func GetItemByID(id int) (*Item, error) {
 		INNER JOIN users      u ON i.seller_id   = u.id
 		INNER JOIN categories c ON i.category_id = c.id
 		LEFT  JOIN reviews    r ON r.item_id     = i.id
-		WHERE i.id = ?
+		LEFT  JOIN menus      m ON m.id          = i.menu_id
+		WHERE i.id = ? AND i.deleted_at IS NULL AND u.deleted_at IS NULL AND c.deleted_at IS NULL
 		GROUP BY
-			i.id, i.seller_id, i.name, i.image, i.description, i.price, i.category_id, i.status, i.created_at, i.updated_at,
+			i.id, i.seller_id, i.sku, i.name, i.image, i.description, i.price, i.category_id, i.status, i.created_at, i.updated_at,
+			i.diet_tags, i.allergens, i.spice_level, i.nutrition, i.menu_id, i.schedule, m.schedule,
 			u.first_name, u.last_name,
 			c.name
     `
 
-	err := DB.QueryRow(query, id).Scan(
+	err := r.q.QueryRowContext(ctx, query, id).Scan(
 		&item.ID,
 		&item.SellerID,
+		&item.SKU,
 		&item.Name,
 		&item.Image,
 		&item.Description,
@@ -227,31 +242,53 @@ func GetItemByID(id int) (*Item, error) {
 		&item.Status,
 		&item.CreatedAt,
 		&item.UpdatedAt,
+		&item.DietTags,
+		&item.Allergens,
+		&item.SpiceLevel,
+		&item.Nutrition,
+		&item.MenuID,
+		&item.Schedule,
+		&item.MenuSchedule,
 		&item.SellerFirstName,
 		&item.SellerLastName,
 		&item.CategoryName,
 		&item.Rating,
 	)
 	if err != nil {
-		fmt.Println("Error fetching item by ID:", err)
+		logging.FromContext(ctx).Error("fetching item by ID", "err", err)
 		return nil, err
 	}
 	return item, nil
 }
 
-func GetItemsBySellerID(sellerID int) ([]*Item, error) {
+func (r *sqlItemRepo) GetBySellerID(ctx context.Context, sellerID int) ([]*Item, error) {
+	return r.sellerItems(ctx, "i.seller_id = ? AND i.deleted_at IS NULL", sellerID)
+}
+
+func (r *sqlItemRepo) GetDeleted(ctx context.Context, sellerID int) ([]*Item, error) {
+	if sellerID == 0 {
+		return r.sellerItems(ctx, "i.deleted_at IS NOT NULL")
+	}
+	return r.sellerItems(ctx, "i.seller_id = ? AND i.deleted_at IS NOT NULL", sellerID)
+}
+
+// sellerItems lists the items matching where with everything a seller's
+// dashboard shows.
+func (r *sqlItemRepo) sellerItems(ctx context.Context, where string, args ...any) ([]*Item, error) {
 	query := `
-    SELECT 
-        i.id, i.seller_id, i.name, i.description, i.price, i.category_id, i.status, i.image, i.created_at, i.updated_at,
-        c.name AS category_name,
+    SELECT
+        i.id, i.seller_id, COALESCE(i.sku, ''), i.name, i.description, i.price, i.category_id, i.status, i.image, i.created_at, i.updated_at, i.deleted_at,
+        i.diet_tags, i.allergens, i.spice_level, i.nutrition, i.menu_id, i.schedule, m.schedule,
+        COALESCE(c.name, '') AS category_name, c.deleted_at IS NOT NULL AS category_deleted,
         ROUND(COALESCE(AVG(r.rating), 0), 1) AS rating
     FROM items i
     LEFT JOIN categories c ON i.category_id = c.id
+    LEFT JOIN menus m ON m.id = i.menu_id
     LEFT JOIN reviews r ON r.item_id = i.id
-    WHERE i.seller_id = ?
+    WHERE ` + where + `
     GROUP BY i.id
     `
-	rows, err := DB.Query(query, sellerID)
+	rows, err := r.q.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -260,11 +297,10 @@ func GetItemsBySellerID(sellerID int) ([]*Item, error) {
 	var items []*Item
 	for rows.Next() {
 		item := &Item{}
-		var categoryName string
-		var rating float64
-		err := rows.Scan(&item.ID, &item.SellerID, &item.Name, &item.Description, &item.Price,
-			&item.CategoryID, &item.Status, &item.Image, &item.CreatedAt, &item.UpdatedAt,
-			&categoryName, &rating)
+		err := rows.Scan(&item.ID, &item.SellerID, &item.SKU, &item.Name, &item.Description, &item.Price,
+			&item.CategoryID, &item.Status, &item.Image, &item.CreatedAt, &item.UpdatedAt, &item.DeletedAt,
+			&item.DietTags, &item.Allergens, &item.SpiceLevel, &item.Nutrition, &item.MenuID, &item.Schedule, &item.MenuSchedule,
+			&item.CategoryName, &item.CategoryDeleted, &item.Rating)
 		if err != nil {
 			return nil, err
 		}
@@ -273,50 +309,38 @@ func GetItemsBySellerID(sellerID int) ([]*Item, error) {
 	return items, nil
 }
 
-func IncrementCartItem(orderID, itemID, delta int) error {
-	_, err := DB.Exec(`INSERT INTO order_items (order_id, item_id, quantity, unit_price)
-		VALUES (?, ?, ?, (SELECT price FROM items WHERE id = ?))
-		ON DUPLICATE KEY UPDATE quantity = quantity + VALUES(quantity)`,
-		orderID, itemID, delta, itemID)
-	return err
-}
-
-func DecrementCartItem(orderID, itemID, delta int) error {
-	_, err := DB.Exec(`UPDATE order_items SET quantity = quantity - ? WHERE order_id = ? AND item_id = ?`,
-		delta, orderID, itemID)
+func (r *sqlItemRepo) GetByCategoryID(ctx context.Context, categoryIDs ...int) ([]*Item, error) {
+	if len(categoryIDs) == 0 {
+		return nil, nil
+	}
+	args := make([]any, len(categoryIDs))
+	for i, id := range categoryIDs {
+		args[i] = id
+	}
+	query := `
+	SELECT i.id, i.name, i.description, i.price, i.status, i.image, i.diet_tags, i.allergens, i.spice_level, i.nutrition,
+		i.menu_id, i.schedule, m.schedule
+	FROM items i
+	INNER JOIN users u ON u.id = i.seller_id AND u.deleted_at IS NULL
+	INNER JOIN categories c ON c.id = i.category_id AND c.deleted_at IS NULL
+	LEFT JOIN menus m ON m.id = i.menu_id
+	WHERE i.category_id IN (?` + strings.Repeat(", ?", len(categoryIDs)-1) + `) AND i.deleted_at IS NULL
+	ORDER BY i.id`
+	rows, err := r.q.QueryContext(ctx, query, args...)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	_, _ = DB.Exec(`DELETE FROM order_items WHERE order_id = ? AND item_id = ? AND quantity <= 0`,
-		orderID, itemID)
-	_, _ = DB.Exec(`DELETE FROM orders WHERE id = ? AND status = 'cart' AND
-		(SELECT COUNT(*) FROM order_items WHERE order_id = ?) = 0`, orderID, orderID)
-	return nil
-}
-
-func UserBought(userID, itemID int) (bool, error) {
-	var count int
-	err := DB.QueryRow(`SELECT COUNT(*) FROM orders o
-		JOIN order_items oi ON oi.order_id = o.id
-		WHERE o.user_id = ? AND oi.item_id = ? AND o.status = 'delivered'`,
-		userID, itemID).Scan(&count)
-	return count > 0, err
-}
-
-func UserReviewed(userID, itemID int) (bool, error) {
-	var count int
-	err := DB.QueryRow(`SELECT COUNT(*) FROM reviews WHERE user_id = ? AND item_id = ?`,
-		userID, itemID).Scan(&count)
-	return count > 0, err
-}
-
-func InsertReview(userID, itemID, rating int) error {
-	_, err := DB.Exec(`INSERT INTO reviews (user_id, item_id, rating) VALUES (?, ?, ?)`,
-		userID, itemID, rating)
-	return err
-}
+	defer rows.Close()
 
-func MarkDelivered(orderID int) error {
-	_, err := DB.Exec(`UPDATE order_items SET status = 'delivered' WHERE order_id = ?`, orderID)
-	return err
+	var items []*Item
+	for rows.Next() {
+		item := &Item{}
+		err := rows.Scan(&item.ID, &item.Name, &item.Description, &item.Price, &item.Status, &item.Image,
+			&item.DietTags, &item.Allergens, &item.SpiceLevel, &item.Nutrition, &item.MenuID, &item.Schedule, &item.MenuSchedule)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	return items, nil
 }
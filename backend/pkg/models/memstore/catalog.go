@@ -0,0 +1,563 @@
+package memstore
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Entity069/Zesty-Go/pkg/models"
+)
+
+type userRepo struct{ d *db }
+
+func (r *userRepo) Create(_ context.Context, u *models.User) error {
+	r.d.mu.Lock()
+	defer r.d.mu.Unlock()
+
+	for _, existing := range r.d.users {
+		if existing.Email == u.Email {
+			return fmt.Errorf("memstore: duplicate email %q", u.Email)
+		}
+	}
+	u.ID = r.d.nextID()
+	u.CreatedAt = time.Now()
+	u.UpdatedAt = u.CreatedAt
+	cp := *u
+	r.d.users[u.ID] = &cp
+	return nil
+}
+
+func (r *userRepo) Update(_ context.Context, u *models.User) error {
+	r.d.mu.Lock()
+	defer r.d.mu.Unlock()
+
+	stored, ok := r.d.users[u.ID]
+	if !ok {
+		return nil
+	}
+	password := stored.Password
+	cp := *u
+	cp.Password = password
+	cp.DeletedAt = stored.DeletedAt
+	cp.CreatedAt = stored.CreatedAt
+	cp.UpdatedAt = time.Now()
+	r.d.users[u.ID] = &cp
+	return nil
+}
+
+func (r *userRepo) set(id int, fn func(*models.User)) {
+	r.d.mu.Lock()
+	defer r.d.mu.Unlock()
+
+	if stored, ok := r.d.users[id]; ok {
+		fn(stored)
+		stored.UpdatedAt = time.Now()
+	}
+}
+
+func (r *userRepo) UpdatePassword(_ context.Context, u *models.User, newPassword string) error {
+	r.set(u.ID, func(s *models.User) { s.Password = newPassword })
+	u.Password = newPassword
+	return nil
+}
+
+func (r *userRepo) UpdateBalance(_ context.Context, u *models.User, newBalance float64) error {
+	if newBalance < 0 {
+		return fmt.Errorf("memstore: balance check constraint violated")
+	}
+	r.set(u.ID, func(s *models.User) { s.Balance = newBalance })
+	u.Balance = newBalance
+	return nil
+}
+
+func (r *userRepo) AddBalance(_ context.Context, u *models.User, delta float64) error {
+	r.d.mu.Lock()
+	defer r.d.mu.Unlock()
+
+	stored, ok := r.d.users[u.ID]
+	if !ok {
+		return errNoRows
+	}
+	if stored.Balance+delta < 0 {
+		return fmt.Errorf("memstore: balance check constraint violated")
+	}
+	stored.Balance += delta
+	stored.UpdatedAt = time.Now()
+	u.Balance = stored.Balance
+	return nil
+}
+
+func (r *userRepo) Delete(_ context.Context, id int) error {
+	r.d.mu.Lock()
+	defer r.d.mu.Unlock()
+
+	u, ok := r.d.users[id]
+	if !ok {
+		return errNoRows
+	}
+	return softDelete(&u.DeletedAt)
+}
+
+func (r *userRepo) Restore(_ context.Context, id int) error {
+	r.d.mu.Lock()
+	defer r.d.mu.Unlock()
+
+	u, ok := r.d.users[id]
+	if !ok {
+		return errNoRows
+	}
+	return restore(&u.DeletedAt)
+}
+
+func (r *userRepo) EmailVerify(_ context.Context, u *models.User) error {
+	r.set(u.ID, func(s *models.User) { s.IsVerified = true })
+	u.IsVerified = true
+	return nil
+}
+
+func (r *userRepo) GetByID(_ context.Context, id int) (*models.User, error) {
+	r.d.mu.Lock()
+	defer r.d.mu.Unlock()
+
+	u, ok := r.d.users[id]
+	if !ok {
+		return nil, errNoRows
+	}
+	cp := *u
+	return &cp, nil
+}
+
+func (r *userRepo) GetByEmail(_ context.Context, email string) (*models.User, error) {
+	r.d.mu.Lock()
+	defer r.d.mu.Unlock()
+
+	for _, u := range r.d.users {
+		if u.Email == email {
+			cp := *u
+			return &cp, nil
+		}
+	}
+	return nil, errNoRows
+}
+
+func (r *userRepo) GetAll(_ context.Context) ([]*models.User, error) {
+	r.d.mu.Lock()
+	defer r.d.mu.Unlock()
+
+	var users []*models.User
+	for _, id := range sortedKeys(r.d.users) {
+		if u := r.d.users[id]; u.DeletedAt == nil {
+			cp := *u
+			users = append(users, &cp)
+		}
+	}
+	newest(users, func(u *models.User) time.Time { return u.CreatedAt }, func(u *models.User) int { return u.ID })
+	return users, nil
+}
+
+func (r *userRepo) GetDeleted(_ context.Context) ([]*models.User, error) {
+	r.d.mu.Lock()
+	defer r.d.mu.Unlock()
+
+	var users []*models.User
+	for _, id := range sortedKeys(r.d.users) {
+		if u := r.d.users[id]; u.DeletedAt != nil {
+			cp := *u
+			users = append(users, &cp)
+		}
+	}
+	newest(users, func(u *models.User) time.Time { return *u.DeletedAt }, func(u *models.User) int { return u.ID })
+	return users, nil
+}
+
+type itemRepo struct{ d *db }
+
+func (r *itemRepo) Create(_ context.Context, i *models.Item) error {
+	r.d.mu.Lock()
+	defer r.d.mu.Unlock()
+
+	i.ID = r.d.nextID()
+	i.CreatedAt = time.Now()
+	i.UpdatedAt = i.CreatedAt
+	cp := *i
+	r.d.items[i.ID] = &cp
+	return nil
+}
+
+func (r *itemRepo) Update(_ context.Context, i *models.Item) error {
+	r.d.mu.Lock()
+	defer r.d.mu.Unlock()
+
+	stored, ok := r.d.items[i.ID]
+	if !ok {
+		return nil
+	}
+	stored.SKU = i.SKU
+	stored.Name = i.Name
+	stored.Description = i.Description
+	stored.Price = i.Price
+	stored.CategoryID = i.CategoryID
+	stored.Status = i.Status
+	stored.Image = i.Image
+	stored.DietTags = i.DietTags
+	stored.Allergens = i.Allergens
+	stored.SpiceLevel = i.SpiceLevel
+	stored.Nutrition = i.Nutrition
+	stored.MenuID = i.MenuID
+	stored.Schedule = i.Schedule
+	stored.UpdatedAt = time.Now()
+	return nil
+}
+
+func (r *itemRepo) Delete(_ context.Context, id int) error {
+	r.d.mu.Lock()
+	defer r.d.mu.Unlock()
+
+	i, ok := r.d.items[id]
+	if !ok {
+		return errNoRows
+	}
+	return softDelete(&i.DeletedAt)
+}
+
+func (r *itemRepo) Restore(_ context.Context, id int) error {
+	r.d.mu.Lock()
+	defer r.d.mu.Unlock()
+
+	i, ok := r.d.items[id]
+	if !ok {
+		return errNoRows
+	}
+	return restore(&i.DeletedAt)
+}
+
+// listed reports whether an item shows up in the catalog: neither it nor
+// its seller is deleted. Callers hold the lock.
+func (d *db) listed(i *models.Item) bool {
+	seller, ok := d.users[i.SellerID]
+	return i.DeletedAt == nil && ok && seller.DeletedAt == nil
+}
+
+// shelved reports whether an item's category is missing or deleted or,
+// with inactive, hidden from customers. Callers hold the lock.
+func (d *db) shelved(i *models.Item, inactive bool) bool {
+	c, ok := d.categories[i.CategoryID]
+	return !ok || c.DeletedAt != nil || inactive && !c.IsActive
+}
+
+// rating mirrors ROUND(COALESCE(AVG(r.rating), 0), 1). Callers hold the lock.
+func (d *db) rating(itemID int) float64 {
+	var sum, n int
+	for _, rv := range d.reviews {
+		if rv.itemID == itemID {
+			sum += rv.rating
+			n++
+		}
+	}
+	if n == 0 {
+		return 0
+	}
+	return math.Round(float64(sum)/float64(n)*10) / 10
+}
+
+// menuSchedule mirrors LEFT JOIN menus. Callers hold the lock.
+func (d *db) menuSchedule(i *models.Item) models.Schedule {
+	if i.MenuID == nil {
+		return nil
+	}
+	if m, ok := d.menus[*i.MenuID]; ok {
+		return m.Schedule
+	}
+	return nil
+}
+
+// listing copies only the columns the SQL list queries scan. Callers hold
+// the lock.
+func (d *db) listing(i *models.Item) *models.Item {
+	return &models.Item{
+		ID: i.ID, SellerID: i.SellerID, Name: i.Name, Description: i.Description, Price: i.Price,
+		CategoryID: i.CategoryID, Status: i.Status, Image: i.Image, CreatedAt: i.CreatedAt, UpdatedAt: i.UpdatedAt,
+		DietTags: i.DietTags, Allergens: i.Allergens, SpiceLevel: i.SpiceLevel, Nutrition: i.Nutrition,
+		MenuID: i.MenuID, Schedule: i.Schedule, MenuSchedule: d.menuSchedule(i),
+	}
+}
+
+func (r *itemRepo) GetAll(_ context.Context, limit int) ([]*models.Item, error) {
+	r.d.mu.Lock()
+	defer r.d.mu.Unlock()
+
+	var items []*models.Item
+	for _, id := range sortedKeys(r.d.items) {
+		if i := r.d.items[id]; r.d.listed(i) && !r.d.shelved(i, true) {
+			item := r.d.listing(i)
+			item.CategoryName = r.d.categories[i.CategoryID].Name
+			item.Rating = r.d.rating(i.ID)
+			items = append(items, item)
+		}
+	}
+	return limitSlice(items, limit), nil
+}
+
+func (r *itemRepo) GetByID(_ context.Context, id int) (*models.Item, error) {
+	r.d.mu.Lock()
+	defer r.d.mu.Unlock()
+
+	i, ok := r.d.items[id]
+	if !ok || !r.d.listed(i) {
+		return nil, errNoRows
+	}
+	if r.d.shelved(i, false) {
+		return nil, errNoRows
+	}
+	seller := r.d.users[i.SellerID]
+	category := r.d.categories[i.CategoryID]
+
+	cp := *i
+	cp.MenuSchedule = r.d.menuSchedule(i)
+	cp.SellerFirstName = seller.FirstName
+	cp.SellerLastName = seller.LastName
+	cp.CategoryName = category.Name
+	cp.Rating = r.d.rating(i.ID)
+	return &cp, nil
+}
+
+func (r *itemRepo) GetBySellerID(_ context.Context, sellerID int) ([]*models.Item, error) {
+	return r.sellerItems(func(i *models.Item) bool {
+		return i.SellerID == sellerID && i.DeletedAt == nil
+	}), nil
+}
+
+func (r *itemRepo) GetDeleted(_ context.Context, sellerID int) ([]*models.Item, error) {
+	return r.sellerItems(func(i *models.Item) bool {
+		return (sellerID == 0 || i.SellerID == sellerID) && i.DeletedAt != nil
+	}), nil
+}
+
+func (r *itemRepo) sellerItems(keep func(*models.Item) bool) []*models.Item {
+	r.d.mu.Lock()
+	defer r.d.mu.Unlock()
+
+	var items []*models.Item
+	for _, id := range sortedKeys(r.d.items) {
+		if i := r.d.items[id]; keep(i) {
+			item := r.d.listing(i)
+			item.SKU = i.SKU
+			item.DeletedAt = i.DeletedAt
+			if c, ok := r.d.categories[i.CategoryID]; ok {
+				item.CategoryName = c.Name
+				item.CategoryDeleted = c.DeletedAt != nil
+			}
+			item.Rating = r.d.rating(i.ID)
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+func (r *itemRepo) GetByCategoryID(_ context.Context, categoryIDs ...int) ([]*models.Item, error) {
+	r.d.mu.Lock()
+	defer r.d.mu.Unlock()
+
+	var items []*models.Item
+	for _, id := range sortedKeys(r.d.items) {
+		if i := r.d.items[id]; slices.Contains(categoryIDs, i.CategoryID) && r.d.listed(i) && !r.d.shelved(i, false) {
+			items = append(items, &models.Item{
+				ID: i.ID, Name: i.Name, Description: i.Description, Price: i.Price, Status: i.Status, Image: i.Image,
+				DietTags: i.DietTags, Allergens: i.Allergens, SpiceLevel: i.SpiceLevel, Nutrition: i.Nutrition,
+				MenuID: i.MenuID, Schedule: i.Schedule, MenuSchedule: r.d.menuSchedule(i),
+			})
+		}
+	}
+	return items, nil
+}
+
+type menuRepo struct{ d *db }
+
+func (r *menuRepo) Create(_ context.Context, m *models.Menu) error {
+	r.d.mu.Lock()
+	defer r.d.mu.Unlock()
+
+	for _, existing := range r.d.menus {
+		if existing.SellerID == m.SellerID && existing.Name == m.Name {
+			return fmt.Errorf("memstore: duplicate menu %q", m.Name)
+		}
+	}
+	m.ID = r.d.nextID()
+	m.CreatedAt = time.Now()
+	m.UpdatedAt = m.CreatedAt
+	cp := *m
+	r.d.menus[m.ID] = &cp
+	return nil
+}
+
+func (r *menuRepo) Update(_ context.Context, m *models.Menu) error {
+	r.d.mu.Lock()
+	defer r.d.mu.Unlock()
+
+	if stored, ok := r.d.menus[m.ID]; ok {
+		stored.Name = m.Name
+		stored.Schedule = m.Schedule
+		stored.UpdatedAt = time.Now()
+	}
+	return nil
+}
+
+// Delete mirrors ON DELETE SET NULL on items.menu_id.
+func (r *menuRepo) Delete(_ context.Context, id int) error {
+	r.d.mu.Lock()
+	defer r.d.mu.Unlock()
+
+	if _, ok := r.d.menus[id]; !ok {
+		return errNoRows
+	}
+	delete(r.d.menus, id)
+	for _, i := range r.d.items {
+		if i.MenuID != nil && *i.MenuID == id {
+			i.MenuID = nil
+		}
+	}
+	return nil
+}
+
+func (r *menuRepo) GetByID(_ context.Context, id int) (*models.Menu, error) {
+	r.d.mu.Lock()
+	defer r.d.mu.Unlock()
+
+	m, ok := r.d.menus[id]
+	if !ok {
+		return nil, errNoRows
+	}
+	cp := *m
+	return &cp, nil
+}
+
+func (r *menuRepo) GetBySellerID(_ context.Context, sellerID int) ([]*models.Menu, error) {
+	r.d.mu.Lock()
+	defer r.d.mu.Unlock()
+
+	var menus []*models.Menu
+	for _, id := range sortedKeys(r.d.menus) {
+		if m := r.d.menus[id]; m.SellerID == sellerID {
+			cp := *m
+			menus = append(menus, &cp)
+		}
+	}
+	slices.SortStableFunc(menus, func(a, b *models.Menu) int { return strings.Compare(a.Name, b.Name) })
+	return menus, nil
+}
+
+type categoryRepo struct{ d *db }
+
+func (r *categoryRepo) Create(_ context.Context, c *models.Category) error {
+	r.d.mu.Lock()
+	defer r.d.mu.Unlock()
+
+	for _, existing := range r.d.categories {
+		if existing.Name == c.Name && existing.DeletedAt == nil {
+			return fmt.Errorf("memstore: duplicate category %q", c.Name)
+		}
+	}
+	c.ID = r.d.nextID()
+	cp := *c
+	r.d.categories[c.ID] = &cp
+	return nil
+}
+
+func (r *categoryRepo) Update(_ context.Context, c *models.Category) error {
+	r.d.mu.Lock()
+	defer r.d.mu.Unlock()
+
+	if stored, ok := r.d.categories[c.ID]; ok {
+		cp := *c
+		cp.DeletedAt = stored.DeletedAt
+		r.d.categories[c.ID] = &cp
+	}
+	return nil
+}
+
+func (r *categoryRepo) Delete(_ context.Context, id int) error {
+	r.d.mu.Lock()
+	defer r.d.mu.Unlock()
+
+	c, ok := r.d.categories[id]
+	if !ok {
+		return errNoRows
+	}
+	return softDelete(&c.DeletedAt)
+}
+
+func (r *categoryRepo) Restore(_ context.Context, id int) error {
+	r.d.mu.Lock()
+	defer r.d.mu.Unlock()
+
+	c, ok := r.d.categories[id]
+	if !ok {
+		return errNoRows
+	}
+	return restore(&c.DeletedAt)
+}
+
+func (r *categoryRepo) GetByID(_ context.Context, id int) (*models.Category, error) {
+	r.d.mu.Lock()
+	defer r.d.mu.Unlock()
+
+	c, ok := r.d.categories[id]
+	if !ok || c.DeletedAt != nil {
+		return nil, errNoRows
+	}
+	cp := *c
+	return &cp, nil
+}
+
+func (r *categoryRepo) GetAll(_ context.Context, limit int) ([]*models.Category, error) {
+	r.d.mu.Lock()
+	defer r.d.mu.Unlock()
+
+	var categories []*models.Category
+	for _, id := range sortedKeys(r.d.categories) {
+		if c := r.d.categories[id]; c.DeletedAt == nil {
+			cp := *c
+			categories = append(categories, &cp)
+		}
+	}
+	sortByPosition(categories)
+	return limitSlice(categories, limit), nil
+}
+
+func (r *categoryRepo) GetDeleted(_ context.Context) ([]*models.Category, error) {
+	r.d.mu.Lock()
+	defer r.d.mu.Unlock()
+
+	var categories []*models.Category
+	for _, id := range sortedKeys(r.d.categories) {
+		if c := r.d.categories[id]; c.DeletedAt != nil {
+			cp := *c
+			categories = append(categories, &cp)
+		}
+	}
+	newest(categories, func(c *models.Category) time.Time { return *c.DeletedAt }, func(c *models.Category) int { return c.ID })
+	return categories, nil
+}
+
+// softDelete and restore mirror the UPDATE ... WHERE deleted_at IS [NOT]
+// NULL statements, which change nothing and report sql.ErrNoRows for a row
+// already in that state. Callers hold the lock.
+func softDelete(deletedAt **time.Time) error {
+	if *deletedAt != nil {
+		return errNoRows
+	}
+	now := time.Now()
+	*deletedAt = &now
+	return nil
+}
+
+func restore(deletedAt **time.Time) error {
+	if *deletedAt == nil {
+		return errNoRows
+	}
+	*deletedAt = nil
+	return nil
+}
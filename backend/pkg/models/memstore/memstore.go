@@ -0,0 +1,104 @@
+// Package memstore is an in-memory implementation of the models repositories
+// for unit tests. It mirrors the behaviour of the SQL queries closely enough
+// for controller tests, but has no transactions: Store.WithTx simply runs the
+// callback, and nothing is rolled back on error.
+package memstore
+
+import (
+	"database/sql"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/Entity069/Zesty-Go/pkg/models"
+)
+
+type review struct {
+	userID, itemID, rating int
+}
+
+type db struct {
+	mu sync.Mutex
+
+	lastID     int
+	users      map[int]*models.User
+	items      map[int]*models.Item
+	categories map[int]*models.Category
+	menus      map[int]*models.Menu
+	orders     map[int]*models.Order
+	orderItems map[int]*models.OrderItem
+	payments   map[int]*models.Payment
+	wallet     []*models.WalletTransaction
+	reviews    []review
+}
+
+func (d *db) nextID() int {
+	d.lastID++
+	return d.lastID
+}
+
+// New returns an empty Store backed by maps.
+func New() *models.Store {
+	d := &db{
+		users:      map[int]*models.User{},
+		items:      map[int]*models.Item{},
+		categories: map[int]*models.Category{},
+		menus:      map[int]*models.Menu{},
+		orders:     map[int]*models.Order{},
+		orderItems: map[int]*models.OrderItem{},
+		payments:   map[int]*models.Payment{},
+	}
+	return &models.Store{
+		Users:      &userRepo{d},
+		Items:      &itemRepo{d},
+		Categories: &categoryRepo{d},
+		Menus:      &menuRepo{d},
+		Orders:     &orderRepo{d},
+		OrderItems: &orderItemRepo{d},
+		Reviews:    &reviewRepo{d},
+		Payments:   &paymentRepo{d},
+		Wallet:     &walletRepo{d},
+		Stats:      &statsRepo{d},
+		Uploads:    &uploadRepo{d},
+	}
+}
+
+// sortedKeys returns map keys in ascending order so results are stable.
+func sortedKeys[V any](m map[int]V) []int {
+	keys := make([]int, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Ints(keys)
+	return keys
+}
+
+func limitSlice[T any](s []T, limit int) []T {
+	if limit > 0 && len(s) > limit {
+		return s[:limit]
+	}
+	return s
+}
+
+func newest[T any](s []T, created func(T) time.Time, id func(T) int) {
+	sort.SliceStable(s, func(i, j int) bool {
+		ci, cj := created(s[i]), created(s[j])
+		if !ci.Equal(cj) {
+			return ci.After(cj)
+		}
+		return id(s[i]) > id(s[j])
+	})
+}
+
+var errNoRows = sql.ErrNoRows
+
+// sortByPosition mirrors ORDER BY position, name.
+func sortByPosition(categories []*models.Category) {
+	sort.SliceStable(categories, func(i, j int) bool {
+		a, b := categories[i], categories[j]
+		if a.Position != b.Position {
+			return a.Position < b.Position
+		}
+		return a.Name < b.Name
+	})
+}
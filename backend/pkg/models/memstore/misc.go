@@ -0,0 +1,333 @@
+package memstore
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Entity069/Zesty-Go/pkg/models"
+)
+
+type reviewRepo struct{ d *db }
+
+func (r *reviewRepo) UserBought(_ context.Context, userID, itemID int) (bool, error) {
+	r.d.mu.Lock()
+	defer r.d.mu.Unlock()
+
+	for _, oi := range r.d.orderItems {
+		if oi.ItemID != itemID {
+			continue
+		}
+		if o, ok := r.d.orders[oi.OrderID]; ok && o.UserID == userID && o.Status == "delivered" {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (r *reviewRepo) UserReviewed(_ context.Context, userID, itemID int) (bool, error) {
+	r.d.mu.Lock()
+	defer r.d.mu.Unlock()
+
+	for _, rv := range r.d.reviews {
+		if rv.userID == userID && rv.itemID == itemID {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (r *reviewRepo) Insert(_ context.Context, userID, itemID, rating int) error {
+	r.d.mu.Lock()
+	defer r.d.mu.Unlock()
+
+	r.d.reviews = append(r.d.reviews, review{userID: userID, itemID: itemID, rating: rating})
+	return nil
+}
+
+type paymentRepo struct{ d *db }
+
+func (r *paymentRepo) Create(_ context.Context, p *models.Payment) error {
+	r.d.mu.Lock()
+	defer r.d.mu.Unlock()
+
+	p.ID = r.d.nextID()
+	cp := *p
+	r.d.payments[p.ID] = &cp
+	return nil
+}
+
+func (r *paymentRepo) set(id int, fn func(*models.Payment)) {
+	r.d.mu.Lock()
+	defer r.d.mu.Unlock()
+
+	if stored, ok := r.d.payments[id]; ok {
+		fn(stored)
+	}
+}
+
+func (r *paymentRepo) Update(_ context.Context, p *models.Payment) error {
+	r.set(p.ID, func(s *models.Payment) {
+		s.PayeeID, s.Amount, s.Discount, s.IsPaid = p.PayeeID, p.Amount, p.Discount, p.IsPaid
+	})
+	return nil
+}
+
+func (r *paymentRepo) MarkAsPaid(_ context.Context, p *models.Payment) error {
+	r.set(p.ID, func(s *models.Payment) { s.IsPaid = true })
+	p.IsPaid = true
+	return nil
+}
+
+func (r *paymentRepo) MarkAsUnpaid(_ context.Context, p *models.Payment) error {
+	r.set(p.ID, func(s *models.Payment) { s.IsPaid = false })
+	p.IsPaid = false
+	return nil
+}
+
+func (r *paymentRepo) UpdateAmount(_ context.Context, p *models.Payment, amount float64) error {
+	r.set(p.ID, func(s *models.Payment) { s.Amount = amount })
+	p.Amount = amount
+	return nil
+}
+
+func (r *paymentRepo) Delete(_ context.Context, id int) error {
+	r.d.mu.Lock()
+	defer r.d.mu.Unlock()
+	delete(r.d.payments, id)
+	return nil
+}
+
+func (r *paymentRepo) GetByID(_ context.Context, id int) (*models.Payment, error) {
+	r.d.mu.Lock()
+	defer r.d.mu.Unlock()
+
+	p, ok := r.d.payments[id]
+	if !ok {
+		return nil, errNoRows
+	}
+	cp := *p
+	return &cp, nil
+}
+
+func (r *paymentRepo) GetByOrderID(_ context.Context, orderID int) (*models.Payment, error) {
+	r.d.mu.Lock()
+	defer r.d.mu.Unlock()
+
+	for _, id := range sortedKeys(r.d.payments) {
+		if p := r.d.payments[id]; p.OrderID == orderID {
+			cp := *p
+			return &cp, nil
+		}
+	}
+	return nil, errNoRows
+}
+
+func (r *paymentRepo) GetAll(_ context.Context) ([]*models.Payment, error) {
+	r.d.mu.Lock()
+	defer r.d.mu.Unlock()
+
+	var payments []*models.Payment
+	keys := sortedKeys(r.d.payments)
+	for i := len(keys) - 1; i >= 0; i-- {
+		cp := *r.d.payments[keys[i]]
+		payments = append(payments, &cp)
+	}
+	return payments, nil
+}
+
+type walletRepo struct{ d *db }
+
+func (r *walletRepo) Create(_ context.Context, t *models.WalletTransaction) error {
+	r.d.mu.Lock()
+	defer r.d.mu.Unlock()
+
+	t.ID = r.d.nextID()
+	t.CreatedAt = time.Now()
+	cp := *t
+	r.d.wallet = append(r.d.wallet, &cp)
+	return nil
+}
+
+func (r *walletRepo) GetByUserID(_ context.Context, userID int, limit int) ([]*models.WalletTransaction, error) {
+	r.d.mu.Lock()
+	defer r.d.mu.Unlock()
+
+	txns := []*models.WalletTransaction{}
+	for _, t := range r.d.wallet {
+		if t.UserID == userID {
+			cp := *t
+			txns = append(txns, &cp)
+		}
+	}
+	newest(txns, func(t *models.WalletTransaction) time.Time { return t.CreatedAt }, func(t *models.WalletTransaction) int { return t.ID })
+	return limitSlice(txns, limit), nil
+}
+
+type statsRepo struct{ d *db }
+
+// sellerLines walks the order lines of placed orders, optionally restricted
+// to one seller. Callers hold the lock.
+func (d *db) sellerLines(sellerID int, fn func(o *models.Order, oi *models.OrderItem)) {
+	for _, oi := range d.orderItems {
+		o, ok := d.orders[oi.OrderID]
+		if !ok || o.Status == "cart" {
+			continue
+		}
+		if sellerID != 0 {
+			item, ok := d.items[oi.ItemID]
+			if !ok || item.SellerID != sellerID {
+				continue
+			}
+		}
+		fn(o, oi)
+	}
+}
+
+func (r *statsRepo) GetSellerRevenue(_ context.Context, sellerID int) (float64, error) {
+	r.d.mu.Lock()
+	defer r.d.mu.Unlock()
+
+	var revenue float64
+	r.d.sellerLines(sellerID, func(_ *models.Order, oi *models.OrderItem) {
+		revenue += float64(oi.Quantity) * oi.UnitPrice
+	})
+	return revenue, nil
+}
+
+func (r *statsRepo) GetSellerItemCount(_ context.Context, sellerID int) (int, error) {
+	r.d.mu.Lock()
+	defer r.d.mu.Unlock()
+
+	count := 0
+	for _, i := range r.d.items {
+		if i.SellerID == sellerID && i.DeletedAt == nil {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (r *statsRepo) GetSellerOrderCount(_ context.Context, sellerID int) (int, error) {
+	r.d.mu.Lock()
+	defer r.d.mu.Unlock()
+
+	orders := map[int]bool{}
+	r.d.sellerLines(sellerID, func(o *models.Order, _ *models.OrderItem) { orders[o.ID] = true })
+	return len(orders), nil
+}
+
+func (r *statsRepo) GetSellerCustomerCount(_ context.Context, sellerID int) (int, error) {
+	r.d.mu.Lock()
+	defer r.d.mu.Unlock()
+
+	customers := map[int]bool{}
+	r.d.sellerLines(sellerID, func(o *models.Order, _ *models.OrderItem) { customers[o.UserID] = true })
+	return len(customers), nil
+}
+
+func (r *statsRepo) GetTotalRevenue(ctx context.Context) (float64, error) {
+	return r.GetSellerRevenue(ctx, 0)
+}
+
+func (r *statsRepo) countOrders(keep func(*models.Order) bool) int {
+	r.d.mu.Lock()
+	defer r.d.mu.Unlock()
+
+	count := 0
+	for _, o := range r.d.orders {
+		if keep(o) {
+			count++
+		}
+	}
+	return count
+}
+
+func (r *statsRepo) GetTotalOrders(_ context.Context) (int, error) {
+	return r.countOrders(func(o *models.Order) bool { return o.Status != "cart" }), nil
+}
+
+func (r *statsRepo) GetPendingOrdersCount(_ context.Context) (int, error) {
+	return r.countOrders(func(o *models.Order) bool {
+		return o.Status != "delivered" && o.Status != "cancelled" && o.Status != "cart"
+	}), nil
+}
+
+func (r *statsRepo) countUsers(userType string) int {
+	r.d.mu.Lock()
+	defer r.d.mu.Unlock()
+
+	count := 0
+	for _, u := range r.d.users {
+		if u.UserType == userType && u.DeletedAt == nil {
+			count++
+		}
+	}
+	return count
+}
+
+func (r *statsRepo) GetTotalCustomers(_ context.Context) (int, error) {
+	return r.countUsers("user"), nil
+}
+
+func (r *statsRepo) GetTotalSellers(_ context.Context) (int, error) {
+	return r.countUsers("seller"), nil
+}
+
+func (r *statsRepo) GetTotalItems(_ context.Context) (int, error) {
+	r.d.mu.Lock()
+	defer r.d.mu.Unlock()
+
+	count := 0
+	for _, i := range r.d.items {
+		if i.DeletedAt == nil {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (r *statsRepo) GetTotalCategories(_ context.Context) (int, error) {
+	r.d.mu.Lock()
+	defer r.d.mu.Unlock()
+
+	count := 0
+	for _, c := range r.d.categories {
+		if c.DeletedAt == nil {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (r *statsRepo) GetTotalReviews(_ context.Context) (int, error) {
+	r.d.mu.Lock()
+	defer r.d.mu.Unlock()
+	return len(r.d.reviews), nil
+}
+
+type uploadRepo struct{ d *db }
+
+func (r *uploadRepo) Referenced(_ context.Context) ([]string, error) {
+	r.d.mu.Lock()
+	defer r.d.mu.Unlock()
+	seen := map[string]bool{}
+	for _, i := range r.d.items {
+		seen[i.Image] = true
+	}
+	for _, c := range r.d.categories {
+		seen[c.Image] = true
+	}
+	for _, u := range r.d.users {
+		seen[u.ProfilePic] = true
+	}
+	var paths []string
+	for p := range seen {
+		if strings.HasPrefix(p, "/uploads/") {
+			paths = append(paths, p)
+		}
+	}
+	sort.Strings(paths)
+	return paths, nil
+}
@@ -0,0 +1,467 @@
+package memstore
+
+import (
+	"context"
+	"time"
+
+	"github.com/Entity069/Zesty-Go/pkg/models"
+)
+
+type orderRepo struct{ d *db }
+
+// lines returns the order's items in insertion order. When sellerID is
+// non-zero only that seller's items are kept. Callers hold the lock.
+func (d *db) lines(orderID int, withName bool, sellerID int) ([]models.OrderItem, float64) {
+	lines := []models.OrderItem{}
+	var total float64
+	for _, id := range sortedKeys(d.orderItems) {
+		oi := d.orderItems[id]
+		if oi.OrderID != orderID {
+			continue
+		}
+		item := d.items[oi.ItemID]
+		if sellerID != 0 && (item == nil || item.SellerID != sellerID) {
+			continue
+		}
+		line := *oi
+		if withName && item != nil {
+			line.Name = item.Name
+		}
+		lines = append(lines, line)
+		total += float64(oi.Quantity) * oi.UnitPrice
+	}
+	return lines, total
+}
+
+func (d *db) placed(o *models.Order, sellerID int) *models.Order {
+	cp := *o
+	cp.Items, cp.TotalAmount = d.lines(o.ID, true, sellerID)
+	return &cp
+}
+
+func (r *orderRepo) Create(_ context.Context, o *models.Order) error {
+	r.d.mu.Lock()
+	defer r.d.mu.Unlock()
+
+	o.ID = r.d.nextID()
+	o.CreatedAt = time.Now()
+	o.UpdatedAt = o.CreatedAt
+	cp := *o
+	cp.Items = nil
+	r.d.orders[o.ID] = &cp
+	return nil
+}
+
+func (r *orderRepo) set(id int, fn func(*models.Order)) {
+	r.d.mu.Lock()
+	defer r.d.mu.Unlock()
+
+	if stored, ok := r.d.orders[id]; ok {
+		fn(stored)
+		stored.UpdatedAt = time.Now()
+	}
+}
+
+func (r *orderRepo) Update(_ context.Context, o *models.Order) error {
+	r.set(o.ID, func(s *models.Order) { s.Status, s.Message = o.Status, o.Message })
+	return nil
+}
+
+func (r *orderRepo) UpdateStatus(_ context.Context, o *models.Order, status string) error {
+	r.set(o.ID, func(s *models.Order) { s.Status = status })
+	o.Status = status
+	return nil
+}
+
+func (r *orderRepo) UpdateMessage(_ context.Context, o *models.Order, message string) error {
+	r.set(o.ID, func(s *models.Order) { s.Message = message })
+	o.Message = message
+	return nil
+}
+
+func (r *orderRepo) Delete(_ context.Context, id int) error {
+	r.d.mu.Lock()
+	defer r.d.mu.Unlock()
+
+	delete(r.d.orders, id)
+	for oiID, oi := range r.d.orderItems {
+		if oi.OrderID == id {
+			delete(r.d.orderItems, oiID)
+		}
+	}
+	return nil
+}
+
+func (r *orderRepo) Cancel(_ context.Context, o *models.Order) error {
+	r.d.mu.Lock()
+	defer r.d.mu.Unlock()
+
+	stored, ok := r.d.orders[o.ID]
+	if !ok || stored.Status != o.Status {
+		return errNoRows
+	}
+	stored.Status = "cancelled"
+	stored.UpdatedAt = time.Now()
+	o.Status = "cancelled"
+	return nil
+}
+
+func (r *orderRepo) PurgeCarts(_ context.Context, before time.Time) (int64, error) {
+	r.d.mu.Lock()
+	defer r.d.mu.Unlock()
+
+	var purged int64
+	for id, o := range r.d.orders {
+		if o.Status != "cart" || !o.UpdatedAt.Before(before) {
+			continue
+		}
+		delete(r.d.orders, id)
+		for oiID, oi := range r.d.orderItems {
+			if oi.OrderID == id {
+				delete(r.d.orderItems, oiID)
+			}
+		}
+		purged++
+	}
+	return purged, nil
+}
+
+func (r *orderRepo) Schedule(_ context.Context, o *models.Order, deliverAt time.Time) error {
+	at := deliverAt.UTC()
+	r.set(o.ID, func(s *models.Order) { s.Status, s.DeliverAt = "scheduled", &at })
+	o.Status, o.DeliverAt = "scheduled", &deliverAt
+	return nil
+}
+
+func (r *orderRepo) SlotCounts(_ context.Context, from, until time.Time) (map[int64]int, error) {
+	r.d.mu.Lock()
+	defer r.d.mu.Unlock()
+
+	counts := map[int64]int{}
+	for _, o := range r.d.orders {
+		if o.DeliverAt == nil || o.Status == "cart" || o.Status == "cancelled" {
+			continue
+		}
+		if !o.DeliverAt.Before(from) && o.DeliverAt.Before(until) {
+			counts[o.DeliverAt.Unix()]++
+		}
+	}
+	return counts, nil
+}
+
+// LockSlotCounts needs no lock of its own: every call holds the store's.
+func (r *orderRepo) LockSlotCounts(ctx context.Context, from, until time.Time) (map[int64]int, error) {
+	return r.SlotCounts(ctx, from, until)
+}
+
+func (r *orderRepo) Release(_ context.Context, dueBy time.Time) (int64, error) {
+	r.d.mu.Lock()
+	defer r.d.mu.Unlock()
+
+	var released int64
+	for id, o := range r.d.orders {
+		if o.Status != "scheduled" || o.DeliverAt.After(dueBy) {
+			continue
+		}
+		o.Status, o.UpdatedAt = "ordered", time.Now()
+		for _, oi := range r.d.orderItems {
+			if oi.OrderID == id && oi.Status == "scheduled" {
+				oi.Status = "ordered"
+			}
+		}
+		released++
+	}
+	return released, nil
+}
+
+func (r *orderRepo) GetByID(_ context.Context, id int) (*models.Order, error) {
+	r.d.mu.Lock()
+	defer r.d.mu.Unlock()
+
+	o, ok := r.d.orders[id]
+	if !ok || o.Status == "cart" {
+		return nil, nil
+	}
+	return r.d.placed(o, 0), nil
+}
+
+func (r *orderRepo) GetByUserID(_ context.Context, userID int, limit int) ([]*models.Order, error) {
+	r.d.mu.Lock()
+	defer r.d.mu.Unlock()
+
+	orders := []*models.Order{}
+	for _, id := range sortedKeys(r.d.orders) {
+		if o := r.d.orders[id]; o.UserID == userID && o.Status != "cart" {
+			orders = append(orders, r.d.placed(o, 0))
+		}
+	}
+	newest(orders, func(o *models.Order) time.Time { return o.CreatedAt }, func(o *models.Order) int { return o.ID })
+	return limitSlice(orders, limit), nil
+}
+
+func (r *orderRepo) GetBySellerID(_ context.Context, sellerID int, limit int) ([]*models.Order, error) {
+	r.d.mu.Lock()
+	defer r.d.mu.Unlock()
+
+	orders := []*models.Order{}
+	for _, id := range sortedKeys(r.d.orders) {
+		o := r.d.orders[id]
+		if o.Status == "cart" || o.Status == "cancelled" || o.Status == "scheduled" {
+			continue
+		}
+		order := r.d.placed(o, sellerID)
+		if len(order.Items) == 0 {
+			continue
+		}
+		if u, ok := r.d.users[o.UserID]; ok {
+			order.FirstName, order.LastName, order.Email, order.Address = u.FirstName, u.LastName, u.Email, u.Address
+		}
+		orders = append(orders, order)
+	}
+	newest(orders, func(o *models.Order) time.Time { return o.CreatedAt }, func(o *models.Order) int { return o.ID })
+	return limitSlice(orders, limit), nil
+}
+
+func (r *orderRepo) GetAll(_ context.Context) ([]*models.Order, error) {
+	r.d.mu.Lock()
+	defer r.d.mu.Unlock()
+
+	orders := []*models.Order{}
+	for _, id := range sortedKeys(r.d.orders) {
+		o := r.d.orders[id]
+		if o.Status == "cart" {
+			continue
+		}
+		cp := *o
+		_, cp.TotalAmount = r.d.lines(o.ID, false, 0)
+		if u, ok := r.d.users[o.UserID]; ok {
+			cp.FirstName, cp.LastName, cp.Email, cp.Address = u.FirstName, u.LastName, u.Email, u.Address
+		}
+		orders = append(orders, &cp)
+	}
+	newest(orders, func(o *models.Order) time.Time { return o.CreatedAt }, func(o *models.Order) int { return o.ID })
+	return orders, nil
+}
+
+// cart returns the user's cart order, or nil. Callers hold the lock.
+func (d *db) cart(userID int) *models.Order {
+	var cart *models.Order
+	for _, id := range sortedKeys(d.orders) {
+		if o := d.orders[id]; o.UserID == userID && o.Status == "cart" {
+			cart = o
+		}
+	}
+	return cart
+}
+
+func (r *orderRepo) GetCartByUserID(_ context.Context, userID int) (*models.Order, error) {
+	r.d.mu.Lock()
+	defer r.d.mu.Unlock()
+
+	cart := r.d.cart(userID)
+	if cart == nil {
+		return nil, errNoRows
+	}
+	cp := *cart
+	cp.Items, cp.TotalAmount = r.d.lines(cart.ID, false, 0)
+	return &cp, nil
+}
+
+func (r *orderRepo) CreateOrGetCart(ctx context.Context, userID int) (*models.Order, error) {
+	cart, err := r.GetCartByUserID(ctx, userID)
+	if err == nil {
+		return cart, nil
+	}
+
+	newCart := &models.Order{
+		UserID: userID,
+		Status: "cart",
+	}
+	if err := r.Create(ctx, newCart); err != nil {
+		return nil, err
+	}
+	return newCart, nil
+}
+
+func (r *orderRepo) AddItemToCart(ctx context.Context, userID int, itemID int, quantity int) (*models.OrderItem, error) {
+	cart, err := r.CreateOrGetCart(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	r.d.mu.Lock()
+	defer r.d.mu.Unlock()
+
+	item, ok := r.d.items[itemID]
+	if !ok {
+		return nil, errNoRows
+	}
+
+	for _, oi := range r.d.orderItems {
+		if oi.OrderID == cart.ID && oi.ItemID == itemID {
+			oi.Quantity += quantity
+			r.d.touchCart(cart.ID)
+			cp := *oi
+			return &cp, nil
+		}
+	}
+
+	oi := &models.OrderItem{
+		ID:        r.d.nextID(),
+		OrderID:   cart.ID,
+		ItemID:    itemID,
+		Quantity:  quantity,
+		UnitPrice: item.Price,
+		Status:    "cart",
+	}
+	r.d.orderItems[oi.ID] = oi
+	r.d.touchCart(cart.ID)
+	cp := *oi
+	return &cp, nil
+}
+
+// IncrementCartItem raises the item's line or adds one, as the SQL repo's
+// upsert does.
+func (r *orderRepo) IncrementCartItem(_ context.Context, orderID, itemID, delta int) error {
+	r.d.mu.Lock()
+	defer r.d.mu.Unlock()
+
+	for _, oi := range r.d.orderItems {
+		if oi.OrderID == orderID && oi.ItemID == itemID {
+			oi.Quantity += delta
+			r.d.touchCart(orderID)
+			return nil
+		}
+	}
+	item, ok := r.d.items[itemID]
+	if !ok {
+		return errNoRows
+	}
+	id := r.d.nextID()
+	r.d.orderItems[id] = &models.OrderItem{
+		ID: id, OrderID: orderID, ItemID: itemID, Quantity: delta, UnitPrice: item.Price, Status: "cart",
+	}
+	r.d.touchCart(orderID)
+	return nil
+}
+
+func (r *orderRepo) DecrementCartItem(_ context.Context, orderID, itemID, delta int) error {
+	r.d.mu.Lock()
+	defer r.d.mu.Unlock()
+
+	remaining := 0
+	for id, oi := range r.d.orderItems {
+		if oi.OrderID != orderID {
+			continue
+		}
+		if oi.ItemID == itemID {
+			oi.Quantity -= delta
+			if oi.Quantity <= 0 {
+				delete(r.d.orderItems, id)
+				continue
+			}
+		}
+		remaining++
+	}
+	if o, ok := r.d.orders[orderID]; ok && o.Status == "cart" && remaining == 0 {
+		delete(r.d.orders, orderID)
+	}
+	r.d.touchCart(orderID)
+	return nil
+}
+
+// touchCart mirrors the SQL repo's bump of a cart's updated_at when its
+// lines change. Callers hold the lock.
+func (d *db) touchCart(orderID int) {
+	if o, ok := d.orders[orderID]; ok && o.Status == "cart" {
+		o.UpdatedAt = time.Now()
+	}
+}
+
+func (r *orderRepo) CalculateCartTotal(_ context.Context, cartID int) (float64, error) {
+	r.d.mu.Lock()
+	defer r.d.mu.Unlock()
+
+	_, total := r.d.lines(cartID, false, 0)
+	return total, nil
+}
+
+func (r *orderRepo) SyncStatus(_ context.Context, orderID int) error {
+	r.d.mu.Lock()
+	defer r.d.mu.Unlock()
+
+	o, ok := r.d.orders[orderID]
+	if !ok || o.Status == "cart" {
+		return errNoRows
+	}
+	var statuses []string
+	for _, id := range sortedKeys(r.d.orderItems) {
+		if oi := r.d.orderItems[id]; oi.OrderID == orderID {
+			statuses = append(statuses, oi.Status)
+		}
+	}
+	o.Status = models.OrderStatusFromItems(statuses)
+	o.UpdatedAt = time.Now()
+	return nil
+}
+
+func (r *orderRepo) MarkDelivered(_ context.Context, orderID int) error {
+	r.d.mu.Lock()
+	defer r.d.mu.Unlock()
+
+	for _, oi := range r.d.orderItems {
+		if oi.OrderID == orderID {
+			oi.Status = "delivered"
+		}
+	}
+	return nil
+}
+
+type orderItemRepo struct{ d *db }
+
+func (r *orderItemRepo) Create(_ context.Context, oi *models.OrderItem) error {
+	r.d.mu.Lock()
+	defer r.d.mu.Unlock()
+
+	oi.ID = r.d.nextID()
+	cp := *oi
+	cp.Name = ""
+	r.d.orderItems[oi.ID] = &cp
+	r.d.touchCart(oi.OrderID)
+	return nil
+}
+
+func (r *orderItemRepo) UpdateStatus(_ context.Context, oi *models.OrderItem, status string) error {
+	r.d.mu.Lock()
+	defer r.d.mu.Unlock()
+
+	if stored, ok := r.d.orderItems[oi.ID]; ok {
+		stored.Status = status
+	}
+	oi.Status = status
+	return nil
+}
+
+func (r *orderItemRepo) Reprice(_ context.Context, oi *models.OrderItem, quantity int, unitPrice float64) error {
+	r.d.mu.Lock()
+	defer r.d.mu.Unlock()
+
+	if stored, ok := r.d.orderItems[oi.ID]; ok {
+		stored.Quantity, stored.UnitPrice = quantity, unitPrice
+	}
+	oi.Quantity, oi.UnitPrice = quantity, unitPrice
+	r.d.touchCart(oi.OrderID)
+	return nil
+}
+
+func (r *orderItemRepo) GetByID(_ context.Context, id int) (*models.OrderItem, error) {
+	r.d.mu.Lock()
+	defer r.d.mu.Unlock()
+
+	oi, ok := r.d.orderItems[id]
+	if !ok {
+		return nil, errNoRows
+	}
+	cp := *oi
+	return &cp, nil
+}
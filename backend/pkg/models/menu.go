@@ -0,0 +1,95 @@
+package models
+
+import (
+	"context"
+	"time"
+)
+
+// Menu is a named schedule a seller shares between items, such as a
+// breakfast menu served until eleven. Items with a schedule of their own
+// ignore their menu's.
+type Menu struct {
+	ID        int       `json:"id"`
+	SellerID  int       `json:"seller_id"`
+	Name      string    `json:"name"`
+	Schedule  Schedule  `json:"schedule"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// sqlMenuRepo invalidates the items cache on every change, since listings
+// carry the schedules of the menus their items are on.
+type sqlMenuRepo struct {
+	q     Querier
+	cache ItemsCache
+}
+
+const menuColumns = `id, seller_id, name, schedule, created_at, updated_at`
+
+func (r *sqlMenuRepo) Create(ctx context.Context, m *Menu) error {
+	query := `INSERT INTO menus (seller_id, name, schedule) VALUES (?, ?, ?)`
+	result, err := r.q.ExecContext(ctx, query, m.SellerID, m.Name, m.Schedule)
+	if err != nil {
+		return err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	m.ID = int(id)
+	return nil
+}
+
+func (r *sqlMenuRepo) Update(ctx context.Context, m *Menu) error {
+	query := `UPDATE menus SET name = ?, schedule = ? WHERE id = ?`
+	_, err := r.q.ExecContext(ctx, query, m.Name, m.Schedule, m.ID)
+
+	if err == nil {
+		r.cache.InvalidateCache(ctx)
+	}
+
+	return err
+}
+
+func (r *sqlMenuRepo) Delete(ctx context.Context, id int) error {
+	err := execOne(ctx, r.q, `DELETE FROM menus WHERE id = ?`, id)
+
+	if err == nil {
+		r.cache.InvalidateCache(ctx)
+	}
+
+	return err
+}
+
+func (r *sqlMenuRepo) GetByID(ctx context.Context, id int) (*Menu, error) {
+	query := `SELECT ` + menuColumns + ` FROM menus WHERE id = ?`
+	return scanMenu(r.q.QueryRowContext(ctx, query, id))
+}
+
+func (r *sqlMenuRepo) GetBySellerID(ctx context.Context, sellerID int) ([]*Menu, error) {
+	query := `SELECT ` + menuColumns + ` FROM menus WHERE seller_id = ? ORDER BY name`
+	rows, err := r.q.QueryContext(ctx, query, sellerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var menus []*Menu
+	for rows.Next() {
+		menu, err := scanMenu(rows)
+		if err != nil {
+			return nil, err
+		}
+		menus = append(menus, menu)
+	}
+	return menus, rows.Err()
+}
+
+func scanMenu(row interface{ Scan(...any) error }) (*Menu, error) {
+	m := &Menu{}
+	if err := row.Scan(&m.ID, &m.SellerID, &m.Name, &m.Schedule, &m.CreatedAt, &m.UpdatedAt); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
@@ -1,5 +1,7 @@
 package models
 
+import "context"
+
 type OrderItem struct {
 	ID        int     `json:"id"`
 	OrderID   int     `json:"order_id"`
@@ -10,9 +12,13 @@ type OrderItem struct {
 	Status    string  `json:"status"`
 }
 
-func (oi *OrderItem) Create() error {
+type sqlOrderItemRepo struct {
+	q Querier
+}
+
+func (r *sqlOrderItemRepo) Create(ctx context.Context, oi *OrderItem) error {
 	query := `INSERT INTO order_items (order_id, item_id, quantity, unit_price, status) VALUES (?, ?, ?, ?, ?)`
-	result, err := DB.Exec(query, oi.OrderID, oi.ItemID, oi.Quantity, oi.UnitPrice, oi.Status)
+	result, err := r.q.ExecContext(ctx, query, oi.OrderID, oi.ItemID, oi.Quantity, oi.UnitPrice, oi.Status)
 	if err != nil {
 		return err
 	}
@@ -23,45 +29,34 @@ func (oi *OrderItem) Create() error {
 	}
 
 	oi.ID = int(id)
-	return nil
+	return touchCart(ctx, r.q, oi.OrderID)
 }
 
-func (oi *OrderItem) UpdateStatus(status string) error {
+func (r *sqlOrderItemRepo) UpdateStatus(ctx context.Context, oi *OrderItem, status string) error {
 	query := `UPDATE order_items SET status = ? WHERE id = ?`
-	_, err := DB.Exec(query, status, oi.ID)
+	_, err := r.q.ExecContext(ctx, query, status, oi.ID)
 	if err == nil {
 		oi.Status = status
 	}
 	return err
 }
 
-func GetOrderItemByID(id int) (*OrderItem, error) {
+func (r *sqlOrderItemRepo) Reprice(ctx context.Context, oi *OrderItem, quantity int, unitPrice float64) error {
+	query := `UPDATE order_items SET quantity = ?, unit_price = ? WHERE id = ?`
+	if _, err := r.q.ExecContext(ctx, query, quantity, unitPrice, oi.ID); err != nil {
+		return err
+	}
+	oi.Quantity, oi.UnitPrice = quantity, unitPrice
+	return touchCart(ctx, r.q, oi.OrderID)
+}
+
+func (r *sqlOrderItemRepo) GetByID(ctx context.Context, id int) (*OrderItem, error) {
 	orderItem := &OrderItem{}
 	query := `SELECT id, order_id, item_id, quantity, unit_price, status FROM order_items WHERE id = ?`
-	err := DB.QueryRow(query, id).Scan(&orderItem.ID, &orderItem.OrderID, &orderItem.ItemID,
+	err := r.q.QueryRowContext(ctx, query, id).Scan(&orderItem.ID, &orderItem.OrderID, &orderItem.ItemID,
 		&orderItem.Quantity, &orderItem.UnitPrice, &orderItem.Status)
 	if err != nil {
 		return nil, err
 	}
 	return orderItem, nil
 }
-
-func GetItemsByCategoryID(categoryID int) ([]*Item, error) {
-	query := `SELECT id, name, description, price, image FROM items WHERE category_id = ?`
-	rows, err := DB.Query(query, categoryID)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-
-	var items []*Item
-	for rows.Next() {
-		item := &Item{}
-		err := rows.Scan(&item.ID, &item.Name, &item.Description, &item.Price, &item.Image)
-		if err != nil {
-			return nil, err
-		}
-		items = append(items, item)
-	}
-	return items, nil
-}
@@ -1,10 +1,14 @@
 package models
 
 import (
+	"context"
+	"database/sql"
 	"encoding/json"
 	"fmt"
 	"slices"
 	"time"
+
+	"github.com/Entity069/Zesty-Go/pkg/logging"
 )
 
 type Order struct {
@@ -16,6 +20,9 @@ type Order struct {
 	UpdatedAt   time.Time   `json:"updated_at"`
 	TotalAmount float64     `json:"total_amount"`
 	Items       []OrderItem `json:"items"`
+	// DeliverAt is the slot a scheduled order is due in, and nil for one
+	// wanted as soon as possible.
+	DeliverAt *time.Time `json:"deliver_at"`
 	// extra fields for some endpoints
 	FirstName string `json:"first_name"`
 	LastName  string `json:"last_name"`
@@ -23,10 +30,15 @@ type Order struct {
 	Address   string `json:"address"`
 }
 
-func (o *Order) Create() error {
+type sqlOrderRepo struct {
+	q     Querier
+	cache ItemsCache
+}
+
+func (r *sqlOrderRepo) Create(ctx context.Context, o *Order) error {
 	query := `INSERT INTO orders (user_id, status, message) VALUES (?, ?, ?)`
 
-	result, err := DB.Exec(query, o.UserID, o.Status, o.Message)
+	result, err := r.q.ExecContext(ctx, query, o.UserID, o.Status, o.Message)
 	if err != nil {
 		return err
 	}
@@ -39,41 +51,106 @@ func (o *Order) Create() error {
 	return nil
 }
 
-func (o *Order) Update() error {
+func (r *sqlOrderRepo) Update(ctx context.Context, o *Order) error {
 	query := `UPDATE orders SET status = ?, message = ? WHERE id = ?`
-	_, err := DB.Exec(query, o.Status, o.Message, o.ID)
+	_, err := r.q.ExecContext(ctx, query, o.Status, o.Message, o.ID)
 	return err
 }
 
-func (o *Order) UpdateStatus(status string) error {
+func (r *sqlOrderRepo) UpdateStatus(ctx context.Context, o *Order, status string) error {
 	query := `UPDATE orders SET status = ? WHERE id = ?`
-	_, err := DB.Exec(query, status, o.ID)
+	_, err := r.q.ExecContext(ctx, query, status, o.ID)
 	if err == nil {
 		o.Status = status
 	}
 	return err
 }
 
-func (o *Order) UpdateMessage(message string) error {
+func (r *sqlOrderRepo) UpdateMessage(ctx context.Context, o *Order, message string) error {
 	query := `UPDATE orders SET message = ? WHERE id = ?`
-	_, err := DB.Exec(query, message, o.ID)
+	_, err := r.q.ExecContext(ctx, query, message, o.ID)
 	if err == nil {
 		o.Message = message
 	}
 	return err
 }
 
-func (o *Order) Delete() error {
+func (r *sqlOrderRepo) Delete(ctx context.Context, id int) error {
 	query := `DELETE FROM orders WHERE id = ?`
-	_, err := DB.Exec(query, o.ID)
+	_, err := r.q.ExecContext(ctx, query, id)
 	return err
 }
 
-func (o *Order) Cancel() error {
-	return o.UpdateStatus("cancelled")
+func (r *sqlOrderRepo) Cancel(ctx context.Context, o *Order) error {
+	query := `UPDATE orders SET status = 'cancelled' WHERE id = ? AND status = ?`
+	if err := execOne(ctx, r.q, query, o.ID, o.Status); err != nil {
+		return err
+	}
+	o.Status = "cancelled"
+	return nil
+}
+
+func (r *sqlOrderRepo) PurgeCarts(ctx context.Context, before time.Time) (int64, error) {
+	query := `DELETE FROM orders WHERE status = 'cart' AND updated_at < ?`
+	result, err := r.q.ExecContext(ctx, query, before)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+func (r *sqlOrderRepo) Schedule(ctx context.Context, o *Order, deliverAt time.Time) error {
+	query := `UPDATE orders SET status = 'scheduled', deliver_at = ? WHERE id = ?`
+	if _, err := r.q.ExecContext(ctx, query, deliverAt.UTC(), o.ID); err != nil {
+		return err
+	}
+	o.Status, o.DeliverAt = "scheduled", &deliverAt
+	return nil
+}
+
+func (r *sqlOrderRepo) SlotCounts(ctx context.Context, from, until time.Time) (map[int64]int, error) {
+	return r.slotCounts(ctx, from, until, "")
+}
+
+func (r *sqlOrderRepo) LockSlotCounts(ctx context.Context, from, until time.Time) (map[int64]int, error) {
+	return r.slotCounts(ctx, from, until, " FOR UPDATE")
+}
+
+func (r *sqlOrderRepo) slotCounts(ctx context.Context, from, until time.Time, lock string) (map[int64]int, error) {
+	query := `SELECT deliver_at FROM orders
+		WHERE deliver_at >= ? AND deliver_at < ? AND status NOT IN ('cart', 'cancelled')` + lock
+	rows, err := r.q.QueryContext(ctx, query, from.UTC(), until.UTC())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := map[int64]int{}
+	for rows.Next() {
+		var at time.Time
+		if err := rows.Scan(&at); err != nil {
+			return nil, err
+		}
+		counts[at.Unix()]++
+	}
+	return counts, rows.Err()
+}
+
+func (r *sqlOrderRepo) Release(ctx context.Context, dueBy time.Time) (int64, error) {
+	_, err := r.q.ExecContext(ctx, `UPDATE order_items oi JOIN orders o ON o.id = oi.order_id
+		SET oi.status = 'ordered'
+		WHERE o.status = 'scheduled' AND o.deliver_at <= ? AND oi.status = 'scheduled'`, dueBy.UTC())
+	if err != nil {
+		return 0, err
+	}
+	result, err := r.q.ExecContext(ctx, `UPDATE orders SET status = 'ordered' WHERE status = 'scheduled' AND deliver_at <= ?`, dueBy.UTC())
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
 }
 
-func GetOrderByID(id int) (*Order, error) {
+func (r *sqlOrderRepo) GetByID(ctx context.Context, id int) (*Order, error) {
 	query := `
 			SELECT
 			o.id            AS order_id,
@@ -82,6 +159,7 @@ func GetOrderByID(id int) (*Order, error) {
 			o.message       AS message,
 			o.created_at    AS created_at,
 			o.updated_at    AS updated_at,
+			o.deliver_at    AS deliver_at,
 			COALESCE(SUM(oi.quantity * oi.unit_price), 0) AS total_amount,
 			CASE 
 				WHEN COUNT(oi.id) > 0 THEN
@@ -89,6 +167,7 @@ func GetOrderByID(id int) (*Order, error) {
 						JSON_OBJECT(
 							'id',         oi.id,
 							'order_id',   oi.order_id,
+							'item_id',    oi.item_id,
 							'name',    	  i.name,
 							'quantity',   oi.quantity,
 							'unit_price', oi.unit_price,
@@ -101,10 +180,10 @@ func GetOrderByID(id int) (*Order, error) {
 		LEFT JOIN order_items oi ON oi.order_id = o.id
 		LEFT JOIN items i        ON i.id        = oi.item_id
 		WHERE o.id = ? AND o.status <> 'cart'
-		GROUP BY o.id, o.user_id, o.status, o.message, o.created_at, o.updated_at
+		GROUP BY o.id, o.user_id, o.status, o.message, o.created_at, o.updated_at, o.deliver_at
 		ORDER BY o.created_at DESC
 		LIMIT 1`
-	rows, err := DB.Query(query, id)
+	rows, err := r.q.QueryContext(ctx, query, id)
 	if err != nil {
 		return nil, err
 	}
@@ -114,7 +193,7 @@ func GetOrderByID(id int) (*Order, error) {
 	for rows.Next() {
 		order := &Order{}
 		var itemsJSON *string
-		err := rows.Scan(&order.ID, &order.UserID, &order.Status, &order.Message, &order.CreatedAt, &order.UpdatedAt, &order.TotalAmount, &itemsJSON)
+		err := rows.Scan(&order.ID, &order.UserID, &order.Status, &order.Message, &order.CreatedAt, &order.UpdatedAt, &order.DeliverAt, &order.TotalAmount, &itemsJSON)
 		if err != nil {
 			return nil, err
 		}
@@ -133,7 +212,7 @@ func GetOrderByID(id int) (*Order, error) {
 	return orders[0], nil
 }
 
-func GetOrdersByUserID(userID int, limit int) ([]*Order, error) {
+func (r *sqlOrderRepo) GetByUserID(ctx context.Context, userID int, limit int) ([]*Order, error) {
 	query := `
 			SELECT
 			o.id            AS order_id,
@@ -142,6 +221,7 @@ func GetOrdersByUserID(userID int, limit int) ([]*Order, error) {
 			o.message       AS message,
 			o.created_at    AS created_at,
 			o.updated_at    AS updated_at,
+			o.deliver_at    AS deliver_at,
 			COALESCE(SUM(oi.quantity * oi.unit_price), 0) AS total_amount,
 			CASE 
 				WHEN COUNT(oi.id) > 0 THEN
@@ -149,6 +229,7 @@ func GetOrdersByUserID(userID int, limit int) ([]*Order, error) {
 						JSON_OBJECT(
 							'id',         oi.id,
 							'order_id',   oi.order_id,
+							'item_id',    oi.item_id,
 							'name',    	  i.name,
 							'quantity',   oi.quantity,
 							'unit_price', oi.unit_price,
@@ -161,7 +242,7 @@ func GetOrdersByUserID(userID int, limit int) ([]*Order, error) {
 		LEFT JOIN order_items oi ON oi.order_id = o.id
 		LEFT JOIN items i        ON i.id        = oi.item_id
 		WHERE o.user_id = ? AND o.status <> 'cart'
-		GROUP BY o.id, o.user_id, o.status, o.message, o.created_at, o.updated_at
+		GROUP BY o.id, o.user_id, o.status, o.message, o.created_at, o.updated_at, o.deliver_at
 		ORDER BY o.created_at DESC`
 
 	args := []any{}
@@ -169,7 +250,7 @@ func GetOrdersByUserID(userID int, limit int) ([]*Order, error) {
 		query += " LIMIT ?"
 		args = append(args, limit)
 	}
-	rows, err := DB.Query(query, append([]any{userID}, args...)...)
+	rows, err := r.q.QueryContext(ctx, query, append([]any{userID}, args...)...)
 	if err != nil {
 		return nil, err
 	}
@@ -179,7 +260,7 @@ func GetOrdersByUserID(userID int, limit int) ([]*Order, error) {
 	for rows.Next() {
 		order := &Order{}
 		var itemsJSON *string
-		err := rows.Scan(&order.ID, &order.UserID, &order.Status, &order.Message, &order.CreatedAt, &order.UpdatedAt, &order.TotalAmount, &itemsJSON)
+		err := rows.Scan(&order.ID, &order.UserID, &order.Status, &order.Message, &order.CreatedAt, &order.UpdatedAt, &order.DeliverAt, &order.TotalAmount, &itemsJSON)
 		if err != nil {
 			return nil, err
 		}
@@ -198,13 +279,14 @@ func GetOrdersByUserID(userID int, limit int) ([]*Order, error) {
 	return orders, nil
 }
 
-func GetOrdersBySellerID(sellerID int, limit int) ([]*Order, error) {
+func (r *sqlOrderRepo) GetBySellerID(ctx context.Context, sellerID int, limit int) ([]*Order, error) {
 	query := `
 		SELECT
 		o.id               AS id,
 		o.created_at       AS created_at,
 		o.status           AS status,
 		o.message          AS message,
+		o.deliver_at       AS deliver_at,
 		SUM(oi.quantity * oi.unit_price) AS total_amount,
 		c.id               AS cid,
 		c.first_name       AS cfname,
@@ -227,8 +309,8 @@ func GetOrdersBySellerID(sellerID int, limit int) ([]*Order, error) {
 		JOIN order_items AS oi ON oi.order_id = o.id
 		JOIN items AS i  ON i.id = oi.item_id
 		JOIN users AS c ON c.id = o.user_id
-		WHERE i.seller_id = ? AND o.status <> 'cancelled' AND o.status <> 'cart'
-		GROUP BY o.id, o.created_at, o.status, o.message, c.id, c.first_name, c.last_name, c.email, c.address
+		WHERE i.seller_id = ? AND o.status NOT IN ('cancelled', 'cart', 'scheduled')
+		GROUP BY o.id, o.created_at, o.status, o.message, o.deliver_at, c.id, c.first_name, c.last_name, c.email, c.address
 		ORDER BY o.created_at DESC`
 
 	args := []any{}
@@ -236,7 +318,7 @@ func GetOrdersBySellerID(sellerID int, limit int) ([]*Order, error) {
 		query += " LIMIT ?"
 		args = append(args, limit)
 	}
-	rows, err := DB.Query(query, append([]any{sellerID}, args...)...)
+	rows, err := r.q.QueryContext(ctx, query, append([]any{sellerID}, args...)...)
 	if err != nil {
 		return nil, err
 	}
@@ -248,7 +330,7 @@ func GetOrdersBySellerID(sellerID int, limit int) ([]*Order, error) {
 		var itemsJSON *string
 
 		err := rows.Scan(&order.ID, &order.CreatedAt, &order.Status, &order.Message,
-			&order.TotalAmount,
+			&order.DeliverAt, &order.TotalAmount,
 			&order.UserID, &order.FirstName, &order.LastName, &order.Email, &order.Address, &itemsJSON)
 		if err != nil {
 			return nil, err
@@ -269,7 +351,7 @@ func GetOrdersBySellerID(sellerID int, limit int) ([]*Order, error) {
 	return orders, nil
 }
 
-func GetAllOrders() ([]*Order, error) {
+func (r *sqlOrderRepo) GetAll(ctx context.Context) ([]*Order, error) {
 	query := `
 	SELECT 
 		o.id 			AS id,
@@ -278,6 +360,7 @@ func GetAllOrders() ([]*Order, error) {
 		o.message 		AS message,
 		o.created_at 	AS created_at,
 		o.updated_at 	AS updated_at,
+		o.deliver_at 	AS deliver_at,
 		u.first_name 	AS first_name,
 		u.last_name 	AS last_name,
 		u.email 		AS email,
@@ -287,10 +370,10 @@ func GetAllOrders() ([]*Order, error) {
 	LEFT JOIN users u ON u.id = o.user_id
 	LEFT JOIN order_items oi ON oi.order_id = o.id
 	WHERE o.status <> 'cart'
-	GROUP BY o.id, u.first_name, u.last_name, u.email, u.address, o.status
+	GROUP BY o.id, u.first_name, u.last_name, u.email, u.address, o.status, o.deliver_at
 	ORDER BY o.created_at DESC`
 
-	rows, err := DB.Query(query)
+	rows, err := r.q.QueryContext(ctx, query)
 	if err != nil {
 		return nil, err
 	}
@@ -299,7 +382,7 @@ func GetAllOrders() ([]*Order, error) {
 	var orders []*Order
 	for rows.Next() {
 		order := &Order{}
-		err := rows.Scan(&order.ID, &order.UserID, &order.Status, &order.Message, &order.CreatedAt, &order.UpdatedAt, &order.FirstName, &order.LastName, &order.Email, &order.Address, &order.TotalAmount)
+		err := rows.Scan(&order.ID, &order.UserID, &order.Status, &order.Message, &order.CreatedAt, &order.UpdatedAt, &order.DeliverAt, &order.FirstName, &order.LastName, &order.Email, &order.Address, &order.TotalAmount)
 		if err != nil {
 			return nil, err
 		}
@@ -311,7 +394,7 @@ func GetAllOrders() ([]*Order, error) {
 	return orders, nil
 }
 
-func GetCartByUserID(userID int) (*Order, error) {
+func (r *sqlOrderRepo) GetCartByUserID(ctx context.Context, userID int) (*Order, error) {
 	cart := &Order{}
 	var itemsJSON *string
 	var totalAmount *float64
@@ -346,7 +429,7 @@ func GetCartByUserID(userID int) (*Order, error) {
 		GROUP BY o.id, o.user_id, o.status, o.message, o.created_at, o.updated_at
 		ORDER BY o.created_at DESC`
 
-	err := DB.QueryRow(query, userID).Scan(
+	err := r.q.QueryRowContext(ctx, query, userID).Scan(
 		&cart.ID,
 		&cart.UserID,
 		&cart.Status,
@@ -376,8 +459,8 @@ func GetCartByUserID(userID int) (*Order, error) {
 	return cart, nil
 }
 
-func CreateOrGetCart(userID int) (*Order, error) {
-	cart, err := GetCartByUserID(userID)
+func (r *sqlOrderRepo) CreateOrGetCart(ctx context.Context, userID int) (*Order, error) {
+	cart, err := r.GetCartByUserID(ctx, userID)
 	if err == nil {
 		return cart, nil
 	}
@@ -387,7 +470,7 @@ func CreateOrGetCart(userID int) (*Order, error) {
 		Status: "cart",
 	}
 
-	err = newCart.Create()
+	err = r.Create(ctx, newCart)
 	if err != nil {
 		return nil, err
 	}
@@ -395,9 +478,9 @@ func CreateOrGetCart(userID int) (*Order, error) {
 	return newCart, nil
 }
 
-func SyncStatus(orderID int) error {
+func (r *sqlOrderRepo) SyncStatus(ctx context.Context, orderID int) error {
 	query := `SELECT status FROM order_items WHERE order_id = ?`
-	rows, err := DB.Query(query, orderID)
+	rows, err := r.q.QueryContext(ctx, query, orderID)
 	if err != nil {
 		return err
 	}
@@ -412,6 +495,21 @@ func SyncStatus(orderID int) error {
 		statuses = append(statuses, status)
 	}
 
+	newStatus := OrderStatusFromItems(statuses)
+
+	order, err := r.GetByID(ctx, orderID)
+	if err != nil {
+		return err
+	}
+	if order == nil {
+		return sql.ErrNoRows
+	}
+	return r.UpdateStatus(ctx, order, newStatus)
+}
+
+// OrderStatusFromItems derives an order's status from the statuses of its
+// items.
+func OrderStatusFromItems(statuses []string) string {
 	var newStatus string
 	allSame := func(slice []string, value string) bool {
 		for _, s := range slice {
@@ -422,7 +520,9 @@ func SyncStatus(orderID int) error {
 		return true
 	}
 
-	if allSame(statuses, "ordered") {
+	if allSame(statuses, "scheduled") {
+		newStatus = "scheduled"
+	} else if allSame(statuses, "ordered") {
 		newStatus = "ordered"
 	} else if allSame(statuses, "preparing") {
 		newStatus = "preparing"
@@ -437,31 +537,27 @@ func SyncStatus(orderID int) error {
 		}
 	}
 
-	order, err := GetOrderByID(orderID)
-	if err != nil {
-		return err
-	}
-	return order.UpdateStatus(newStatus)
+	return newStatus
 }
 
-func CalculateCartTotal(cartID int) (float64, error) {
+func (r *sqlOrderRepo) CalculateCartTotal(ctx context.Context, cartID int) (float64, error) {
 	query := `SELECT SUM(oi.unit_price * oi.quantity) FROM order_items oi WHERE oi.order_id = ?`
 	var total float64
-	err := DB.QueryRow(query, cartID).Scan(&total)
+	err := r.q.QueryRowContext(ctx, query, cartID).Scan(&total)
 	if err != nil {
-		fmt.Println("Error calculating cart total:", err)
+		logging.FromContext(ctx).Error("calculating cart total", "err", err)
 		return 0, err
 	}
 	return total, nil
 }
 
-func AddItemToCart(userID int, itemID int, quantity int) (*OrderItem, error) {
-	cart, err := CreateOrGetCart(userID)
+func (r *sqlOrderRepo) AddItemToCart(ctx context.Context, userID int, itemID int, quantity int) (*OrderItem, error) {
+	cart, err := r.CreateOrGetCart(ctx, userID)
 	if err != nil {
 		return nil, err
 	}
 
-	item, err := GetItemByID(itemID)
+	item, err := (&sqlItemRepo{q: r.q, cache: r.cache}).GetByID(ctx, itemID)
 	if err != nil {
 		return nil, err
 	}
@@ -475,10 +571,13 @@ func AddItemToCart(userID int, itemID int, quantity int) (*OrderItem, error) {
 
 	if existingItem != nil {
 		existingItem.Quantity += quantity
-		query := `UPDATE order_items SET quantity = ? WHERE id = ?`
-		_, err = DB.Exec(query, existingItem.Quantity, existingItem.ID)
+		query := `UPDATE order_items SET quantity = quantity + ? WHERE id = ?`
+		_, err = r.q.ExecContext(ctx, query, quantity, existingItem.ID)
+		if err == nil {
+			err = touchCart(ctx, r.q, cart.ID)
+		}
 		if err != nil {
-			fmt.Println("Error updating existing item in cart:", err)
+			logging.FromContext(ctx).Error("updating existing item in cart", "err", err)
 			return nil, err
 		}
 		return existingItem, nil
@@ -491,12 +590,62 @@ func AddItemToCart(userID int, itemID int, quantity int) (*OrderItem, error) {
 			Status:    "cart",
 		}
 
-		err = orderItem.Create()
+		// another request may have added the line since the cart was read
+		query := `INSERT INTO order_items (order_id, item_id, quantity, unit_price, status) VALUES (?, ?, ?, ?, ?)
+			ON DUPLICATE KEY UPDATE id = LAST_INSERT_ID(id), quantity = quantity + VALUES(quantity)`
+		result, err := r.q.ExecContext(ctx, query, orderItem.OrderID, orderItem.ItemID, orderItem.Quantity, orderItem.UnitPrice, orderItem.Status)
+		if err == nil {
+			var id int64
+			if id, err = result.LastInsertId(); err == nil {
+				orderItem.ID = int(id)
+				err = touchCart(ctx, r.q, cart.ID)
+			}
+		}
 		if err != nil {
-			fmt.Println("Error creating new order item:", err)
+			logging.FromContext(ctx).Error("creating new order item", "err", err)
 			return nil, err
 		}
 
 		return orderItem, nil
 	}
 }
+
+// IncrementCartItem raises the quantity of the item's line in the cart, or
+// adds a line at the item's price if there is none. A cart has one line per
+// item (order_items_order_item), so two increments racing can't both add
+// one.
+func (r *sqlOrderRepo) IncrementCartItem(ctx context.Context, orderID, itemID, delta int) error {
+	_, err := r.q.ExecContext(ctx, `INSERT INTO order_items (order_id, item_id, quantity, unit_price)
+		VALUES (?, ?, ?, (SELECT price FROM items WHERE id = ?))
+		ON DUPLICATE KEY UPDATE quantity = quantity + VALUES(quantity)`,
+		orderID, itemID, delta, itemID)
+	if err != nil {
+		return err
+	}
+	return touchCart(ctx, r.q, orderID)
+}
+
+func (r *sqlOrderRepo) DecrementCartItem(ctx context.Context, orderID, itemID, delta int) error {
+	_, err := r.q.ExecContext(ctx, `UPDATE order_items SET quantity = quantity - ? WHERE order_id = ? AND item_id = ?`,
+		delta, orderID, itemID)
+	if err != nil {
+		return err
+	}
+	_, _ = r.q.ExecContext(ctx, `DELETE FROM order_items WHERE order_id = ? AND item_id = ? AND quantity <= 0`,
+		orderID, itemID)
+	_, _ = r.q.ExecContext(ctx, `DELETE FROM orders WHERE id = ? AND status = 'cart' AND
+		(SELECT COUNT(*) FROM order_items WHERE order_id = ?) = 0`, orderID, orderID)
+	return touchCart(ctx, r.q, orderID)
+}
+
+// touchCart marks a cart as changed, which PurgeCarts goes by: changing
+// only its lines leaves the orders row, and its updated_at, as it was.
+func touchCart(ctx context.Context, q Querier, orderID int) error {
+	_, err := q.ExecContext(ctx, `UPDATE orders SET updated_at = NOW() WHERE id = ? AND status = 'cart'`, orderID)
+	return err
+}
+
+func (r *sqlOrderRepo) MarkDelivered(ctx context.Context, orderID int) error {
+	_, err := r.q.ExecContext(ctx, `UPDATE order_items SET status = 'delivered' WHERE order_id = ?`, orderID)
+	return err
+}
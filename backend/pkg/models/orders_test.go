@@ -0,0 +1,72 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"testing"
+	"time"
+)
+
+// recordingQuerier records the statements run against it. Each UPDATE
+// reports that it changed updated rows.
+type recordingQuerier struct {
+	blockingQuerier
+	updated int64
+	stmts   []string
+}
+
+type rowsAffected int64
+
+func (n rowsAffected) LastInsertId() (int64, error) { return 0, nil }
+func (n rowsAffected) RowsAffected() (int64, error) { return int64(n), nil }
+
+func (q *recordingQuerier) ExecContext(_ context.Context, query string, _ ...any) (sql.Result, error) {
+	q.stmts = append(q.stmts, strings.Fields(query)[0])
+	if strings.HasPrefix(query, "UPDATE") {
+		return rowsAffected(q.updated), nil
+	}
+	return rowsAffected(1), nil
+}
+
+func TestIncrementCartItem(t *testing.T) {
+	// One upsert whether or not the line exists, so two increments of a
+	// missing line can't both insert it; then the cart is touched.
+	q := &recordingQuerier{}
+	if err := (&sqlOrderRepo{q: withQueryOptions(q, QueryOptions{})}).IncrementCartItem(context.Background(), 1, 2, 3); err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(q.stmts, " "); got != "INSERT UPDATE" {
+		t.Errorf("ran %s, want INSERT UPDATE", got)
+	}
+}
+
+// queryRecorder keeps the last query run against it, which fails.
+type queryRecorder struct {
+	blockingQuerier
+	query string
+}
+
+func (q *queryRecorder) QueryContext(_ context.Context, query string, _ ...any) (*sql.Rows, error) {
+	q.query = query
+	return nil, sql.ErrConnDone
+}
+
+func TestSlotCountsLocking(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	for _, tc := range []struct {
+		name  string
+		count func(r *sqlOrderRepo) (map[int64]int, error)
+		lock  bool
+	}{
+		{"SlotCounts", func(r *sqlOrderRepo) (map[int64]int, error) { return r.SlotCounts(ctx, now, now) }, false},
+		{"LockSlotCounts", func(r *sqlOrderRepo) (map[int64]int, error) { return r.LockSlotCounts(ctx, now, now) }, true},
+	} {
+		q := &queryRecorder{}
+		tc.count(&sqlOrderRepo{q: withQueryOptions(q, QueryOptions{})})
+		if got := strings.HasSuffix(q.query, "FOR UPDATE"); got != tc.lock {
+			t.Errorf("%s locks = %v, want %v: %s", tc.name, got, tc.lock, q.query)
+		}
+	}
+}
@@ -1,5 +1,7 @@
 package models
 
+import "context"
+
 type Payment struct {
 	ID       int     `json:"id"`
 	PayeeID  int     `json:"payee_id"`
@@ -9,10 +11,14 @@ type Payment struct {
 	IsPaid   bool    `json:"is_paid"`
 }
 
-func (p *Payment) Create() error {
+type sqlPaymentRepo struct {
+	q Querier
+}
+
+func (r *sqlPaymentRepo) Create(ctx context.Context, p *Payment) error {
 	query := `INSERT INTO payments (payee_id, order_id, amount, discount, is_paid) VALUES (?, ?, ?, ?, ?)`
 
-	result, err := DB.Exec(query, p.PayeeID, p.OrderID, p.Amount, p.Discount, p.IsPaid)
+	result, err := r.q.ExecContext(ctx, query, p.PayeeID, p.OrderID, p.Amount, p.Discount, p.IsPaid)
 	if err != nil {
 		return err
 	}
@@ -25,41 +31,42 @@ func (p *Payment) Create() error {
 	return nil
 }
 
-func (p *Payment) Update() error {
+func (r *sqlPaymentRepo) Update(ctx context.Context, p *Payment) error {
 	query := `UPDATE payments SET payee_id = ?, amount = ?, discount = ?, is_paid = ? WHERE id = ?`
-	_, err := DB.Exec(query, p.PayeeID, p.Amount, p.Discount, p.IsPaid, p.ID)
+	_, err := r.q.ExecContext(ctx, query, p.PayeeID, p.Amount, p.Discount, p.IsPaid, p.ID)
 	return err
 }
 
-func (p *Payment) MarkAsPaid() error {
+func (r *sqlPaymentRepo) MarkAsPaid(ctx context.Context, p *Payment) error {
 	query := `UPDATE payments SET is_paid = true WHERE id = ?`
-	_, err := DB.Exec(query, p.ID)
+	_, err := r.q.ExecContext(ctx, query, p.ID)
 	if err == nil {
 		p.IsPaid = true
 	}
 	return err
 }
 
-func (p *Payment) MarkAsUnpaid() error {
+func (r *sqlPaymentRepo) MarkAsUnpaid(ctx context.Context, p *Payment) error {
 	query := `UPDATE payments SET is_paid = false WHERE id = ?`
-	_, err := DB.Exec(query, p.ID)
+	_, err := r.q.ExecContext(ctx, query, p.ID)
 	if err == nil {
 		p.IsPaid = false
 	}
 	return err
 }
 
-func (p *Payment) UpdateAmount(amount float64) error {
+func (r *sqlPaymentRepo) UpdateAmount(ctx context.Context, p *Payment, amount float64) error {
 	query := `UPDATE payments SET amount = ? WHERE id = ?`
-	_, err := DB.Exec(query, amount, p.ID)
+	_, err := r.q.ExecContext(ctx, query, amount, p.ID)
 	if err == nil {
 		p.Amount = amount
 	}
 	return err
 }
-func (p *Payment) Delete() error {
+
+func (r *sqlPaymentRepo) Delete(ctx context.Context, id int) error {
 	query := `DELETE FROM payments WHERE id = ?`
-	_, err := DB.Exec(query, p.ID)
+	_, err := r.q.ExecContext(ctx, query, id)
 	return err
 }
 
@@ -70,32 +77,32 @@ func (p *Payment) GetFinalAmt() float64 {
 	return p.Amount
 }
 
-func GetPaymentByID(id int) (*Payment, error) {
+func (r *sqlPaymentRepo) GetByID(ctx context.Context, id int) (*Payment, error) {
 	payment := &Payment{}
 	query := `SELECT id, payee_id, order_id, amount, discount, is_paid FROM payments WHERE id = ?`
 
-	err := DB.QueryRow(query, id).Scan(&payment.ID, &payment.PayeeID, &payment.OrderID, &payment.Amount, &payment.Discount, &payment.IsPaid)
+	err := r.q.QueryRowContext(ctx, query, id).Scan(&payment.ID, &payment.PayeeID, &payment.OrderID, &payment.Amount, &payment.Discount, &payment.IsPaid)
 	if err != nil {
 		return nil, err
 	}
 	return payment, nil
 }
 
-func GetPaymentByOrderID(orderID int) (*Payment, error) {
+func (r *sqlPaymentRepo) GetByOrderID(ctx context.Context, orderID int) (*Payment, error) {
 	payment := &Payment{}
 	query := `SELECT id, payee_id, order_id, amount, discount, is_paid FROM payments WHERE order_id = ?`
 
-	err := DB.QueryRow(query, orderID).Scan(&payment.ID, &payment.PayeeID, &payment.OrderID, &payment.Amount, &payment.Discount, &payment.IsPaid)
+	err := r.q.QueryRowContext(ctx, query, orderID).Scan(&payment.ID, &payment.PayeeID, &payment.OrderID, &payment.Amount, &payment.Discount, &payment.IsPaid)
 	if err != nil {
 		return nil, err
 	}
 	return payment, nil
 }
 
-func GetAllPayments() ([]*Payment, error) {
+func (r *sqlPaymentRepo) GetAll(ctx context.Context) ([]*Payment, error) {
 	query := `SELECT id, payee_id, order_id, amount, discount, is_paid FROM payments ORDER BY id DESC`
 
-	rows, err := DB.Query(query)
+	rows, err := r.q.QueryContext(ctx, query)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,29 @@
+package models
+
+import "context"
+
+type sqlReviewRepo struct {
+	q Querier
+}
+
+func (r *sqlReviewRepo) UserBought(ctx context.Context, userID, itemID int) (bool, error) {
+	var count int
+	err := r.q.QueryRowContext(ctx, `SELECT COUNT(*) FROM orders o
+		JOIN order_items oi ON oi.order_id = o.id
+		WHERE o.user_id = ? AND oi.item_id = ? AND o.status = 'delivered'`,
+		userID, itemID).Scan(&count)
+	return count > 0, err
+}
+
+func (r *sqlReviewRepo) UserReviewed(ctx context.Context, userID, itemID int) (bool, error) {
+	var count int
+	err := r.q.QueryRowContext(ctx, `SELECT COUNT(*) FROM reviews WHERE user_id = ? AND item_id = ?`,
+		userID, itemID).Scan(&count)
+	return count > 0, err
+}
+
+func (r *sqlReviewRepo) Insert(ctx context.Context, userID, itemID, rating int) error {
+	_, err := r.q.ExecContext(ctx, `INSERT INTO reviews (user_id, item_id, rating) VALUES (?, ?, ?)`,
+		userID, itemID, rating)
+	return err
+}
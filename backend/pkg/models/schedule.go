@@ -0,0 +1,165 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Weekdays are the day names windows take, indexed by time.Weekday.
+var Weekdays = []string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}
+
+const dateLayout = "2006-01-02"
+
+// Window is one stretch of time an item can be ordered in. Every part is
+// optional: no days means every day, no From or Until means from midnight or
+// until midnight, and no StartsOn or EndsOn leaves the dates open.
+//
+// A window whose Until is earlier than its From runs overnight, and the part
+// after midnight belongs to the day it started on: {Days: [fri], From:
+// 22:00, Until: 02:00} is open into Saturday morning.
+type Window struct {
+	Days []string `json:"days,omitempty"`
+	// From and Until are HH:MM in the shop's time zone; Until may be 24:00.
+	From  string `json:"from,omitempty"`
+	Until string `json:"until,omitempty"`
+	// StartsOn and EndsOn are YYYY-MM-DD, both included.
+	StartsOn string `json:"starts_on,omitempty"`
+	EndsOn   string `json:"ends_on,omitempty"`
+}
+
+// Schedule is the windows an item or menu is available in, stored as a
+// JSON column. An empty schedule is always open.
+type Schedule []Window
+
+// Validate reports the first malformed window, numbered from 1.
+func (s Schedule) Validate() error {
+	for n, w := range s {
+		if err := w.validate(); err != nil {
+			return fmt.Errorf("window %d: %w", n+1, err)
+		}
+	}
+	return nil
+}
+
+func (w Window) validate() error {
+	for _, day := range w.Days {
+		if !slices.Contains(Weekdays, day) {
+			return fmt.Errorf("%q is not one of: %s", day, strings.Join(Weekdays, ", "))
+		}
+	}
+	from, err := clock(w.From, 0)
+	if err != nil || from == 24*60 {
+		return fmt.Errorf("from must be a time between 00:00 and 23:59, got %q", w.From)
+	}
+	until, err := clock(w.Until, 24*60)
+	if err != nil {
+		return fmt.Errorf("until must be a time between 00:00 and 24:00, got %q", w.Until)
+	}
+	if from == until {
+		return fmt.Errorf("from and until are both %s", w.From)
+	}
+	var starts, ends time.Time
+	if w.StartsOn != "" {
+		if starts, err = time.Parse(dateLayout, w.StartsOn); err != nil {
+			return fmt.Errorf("starts_on must be a date like 2026-01-31, got %q", w.StartsOn)
+		}
+	}
+	if w.EndsOn != "" {
+		if ends, err = time.Parse(dateLayout, w.EndsOn); err != nil {
+			return fmt.Errorf("ends_on must be a date like 2026-01-31, got %q", w.EndsOn)
+		}
+	}
+	if !starts.IsZero() && !ends.IsZero() && ends.Before(starts) {
+		return fmt.Errorf("ends_on %s is before starts_on %s", w.EndsOn, w.StartsOn)
+	}
+	return nil
+}
+
+// clock parses HH:MM into minutes since midnight, or returns blank when s
+// is empty.
+func clock(s string, blank int) (int, error) {
+	if s == "" {
+		return blank, nil
+	}
+	var h, m int
+	if n, err := fmt.Sscanf(s, "%2d:%2d", &h, &m); err != nil || n != 2 || len(s) != 5 {
+		return 0, fmt.Errorf("models: bad time %q", s)
+	}
+	if h < 0 || m < 0 || m > 59 || h > 24 || (h == 24 && m > 0) {
+		return 0, fmt.Errorf("models: bad time %q", s)
+	}
+	return h*60 + m, nil
+}
+
+// OpenAt reports whether t falls in any of the windows. t should already be
+// in the shop's time zone; its wall clock is what is compared.
+func (s Schedule) OpenAt(t time.Time) bool {
+	if len(s) == 0 {
+		return true
+	}
+	for _, w := range s {
+		if w.openAt(t) {
+			return true
+		}
+	}
+	return false
+}
+
+func (w Window) openAt(t time.Time) bool {
+	from, _ := clock(w.From, 0)
+	until, _ := clock(w.Until, 24*60)
+	now := t.Hour()*60 + t.Minute()
+	if from < until {
+		return from <= now && now < until && w.onDay(t)
+	}
+	switch {
+	case now >= from:
+		return w.onDay(t)
+	case now < until:
+		return w.onDay(t.AddDate(0, 0, -1))
+	}
+	return false
+}
+
+// onDay reports whether the window runs on the day t falls on.
+func (w Window) onDay(t time.Time) bool {
+	date := t.Format(dateLayout)
+	if (w.StartsOn != "" && date < w.StartsOn) || (w.EndsOn != "" && date > w.EndsOn) {
+		return false
+	}
+	return len(w.Days) == 0 || slices.Contains(w.Days, Weekdays[t.Weekday()])
+}
+
+func (s Schedule) MarshalJSON() ([]byte, error) {
+	if s == nil {
+		return []byte("[]"), nil
+	}
+	return json.Marshal([]Window(s))
+}
+
+func (s *Schedule) Scan(src any) error {
+	switch v := src.(type) {
+	case nil:
+		*s = nil
+		return nil
+	case string:
+		return json.Unmarshal([]byte(v), s)
+	case []byte:
+		return json.Unmarshal(v, s)
+	default:
+		return fmt.Errorf("models: can't scan %T into Schedule", src)
+	}
+}
+
+// Value stores an empty schedule as NULL.
+func (s Schedule) Value() (driver.Value, error) {
+	if len(s) == 0 {
+		return nil, nil
+	}
+	b, err := json.Marshal([]Window(s))
+	return string(b), err
+}
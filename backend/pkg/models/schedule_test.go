@@ -0,0 +1,89 @@
+package models_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Entity069/Zesty-Go/pkg/models"
+)
+
+func TestScheduleOpenAt(t *testing.T) {
+	kolkata, err := time.LoadLocation("Asia/Kolkata")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 2026-03-06 is a Friday.
+	at := func(day int, clock string) time.Time {
+		c, _ := time.Parse("15:04", clock)
+		return time.Date(2026, 3, day, c.Hour(), c.Minute(), 0, 0, kolkata)
+	}
+	breakfast := models.Schedule{{From: "07:00", Until: "11:00"}}
+	lateWeekend := models.Schedule{{Days: []string{"fri", "sat"}, From: "22:00", Until: "02:00"}}
+	lent := models.Schedule{{StartsOn: "2026-03-01", EndsOn: "2026-03-06"}}
+
+	for _, tc := range []struct {
+		name     string
+		schedule models.Schedule
+		t        time.Time
+		want     bool
+	}{
+		{"no schedule", nil, at(6, "03:00"), true},
+		{"breakfast opens", breakfast, at(6, "07:00"), true},
+		{"breakfast closes", breakfast, at(6, "11:00"), false},
+		{"before breakfast", breakfast, at(6, "06:59"), false},
+		{"friday night", lateWeekend, at(6, "23:30"), true},
+		{"saturday small hours", lateWeekend, at(7, "01:59"), true},
+		{"saturday closes", lateWeekend, at(7, "02:00"), false},
+		{"thursday small hours", lateWeekend, at(5, "01:00"), false},
+		{"monday small hours after sunday", lateWeekend, at(9, "01:00"), false},
+		{"last day", lent, at(6, "23:59"), true},
+		{"after the last day", lent, at(7, "00:00"), false},
+		{"before the first day", lent, at(1, "00:00").Add(-time.Minute), false},
+		{"either window", append(breakfast, lent...), at(8, "08:00"), true},
+	} {
+		if got := tc.schedule.OpenAt(tc.t); got != tc.want {
+			t.Errorf("%s: OpenAt(%s) = %v, want %v", tc.name, tc.t.Format("Mon 15:04"), got, tc.want)
+		}
+	}
+
+	item := models.Item{Status: "available", MenuSchedule: breakfast}
+	if item.AvailableAt(at(6, "12:00")) {
+		t.Error("an item on the breakfast menu is available at noon")
+	}
+	item.Schedule = models.Schedule{{From: "12:00"}}
+	if !item.AvailableAt(at(6, "12:00")) {
+		t.Error("the item's own schedule should override its menu's")
+	}
+	item.Status = "unavailable"
+	if item.AvailableAt(at(6, "12:00")) {
+		t.Error("an unavailable item is available in its window")
+	}
+}
+
+func TestScheduleValidate(t *testing.T) {
+	for _, w := range []models.Window{
+		{Days: []string{"friday"}},
+		{From: "7:00"},
+		{From: "24:00"},
+		{Until: "24:01"},
+		{From: "10:00", Until: "10:00"},
+		{StartsOn: "2026-02-30"},
+		{StartsOn: "2026-03-02", EndsOn: "2026-03-01"},
+	} {
+		if err := (models.Schedule{w}).Validate(); err == nil {
+			t.Errorf("%+v passed validation", w)
+		}
+	}
+	ok := models.Schedule{{Days: []string{"sun"}, From: "18:00", Until: "24:00", StartsOn: "2026-03-01", EndsOn: "2026-03-01"}}
+	if err := ok.Validate(); err != nil {
+		t.Errorf("Validate(%+v) = %v", ok, err)
+	}
+
+	if v, _ := models.Schedule(nil).Value(); v != nil {
+		t.Errorf("an empty schedule stores as %v, want NULL", v)
+	}
+	var back models.Schedule
+	if err := back.Scan([]byte(`[{"from":"07:00"}]`)); err != nil || len(back) != 1 || back[0].From != "07:00" {
+		t.Errorf("Scan = %+v, %v", back, err)
+	}
+}
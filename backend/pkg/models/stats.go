@@ -1,6 +1,12 @@
 package models
 
-func GetSellerRevenue(sellerID int) (float64, error) {
+import "context"
+
+type sqlStatsRepo struct {
+	q Querier
+}
+
+func (r *sqlStatsRepo) GetSellerRevenue(ctx context.Context, sellerID int) (float64, error) {
 	var revenue float64
 	query := `
     SELECT COALESCE(SUM(oi.unit_price * oi.quantity), 0) AS revenue
@@ -9,18 +15,18 @@ func GetSellerRevenue(sellerID int) (float64, error) {
     JOIN orders o ON oi.order_id = o.id
     WHERE i.seller_id = ? AND o.status <> 'cart'
     `
-	err := DB.QueryRow(query, sellerID).Scan(&revenue)
+	err := r.q.QueryRowContext(ctx, query, sellerID).Scan(&revenue)
 	return revenue, err
 }
 
-func GetSellerItemCount(sellerID int) (int, error) {
+func (r *sqlStatsRepo) GetSellerItemCount(ctx context.Context, sellerID int) (int, error) {
 	var count int
-	query := `SELECT COUNT(*) FROM items WHERE seller_id = ?`
-	err := DB.QueryRow(query, sellerID).Scan(&count)
+	query := `SELECT COUNT(*) FROM items WHERE seller_id = ? AND deleted_at IS NULL`
+	err := r.q.QueryRowContext(ctx, query, sellerID).Scan(&count)
 	return count, err
 }
 
-func GetSellerOrderCount(sellerID int) (int, error) {
+func (r *sqlStatsRepo) GetSellerOrderCount(ctx context.Context, sellerID int) (int, error) {
 	var count int
 	query := `
     SELECT COUNT(DISTINCT o.id) AS orders
@@ -29,11 +35,11 @@ func GetSellerOrderCount(sellerID int) (int, error) {
     JOIN orders o ON oi.order_id = o.id
     WHERE i.seller_id = ? AND o.status <> 'cart'
     `
-	err := DB.QueryRow(query, sellerID).Scan(&count)
+	err := r.q.QueryRowContext(ctx, query, sellerID).Scan(&count)
 	return count, err
 }
 
-func GetSellerCustomerCount(sellerID int) (int, error) {
+func (r *sqlStatsRepo) GetSellerCustomerCount(ctx context.Context, sellerID int) (int, error) {
 	var count int
 	query := `
     SELECT COUNT(DISTINCT o.user_id) AS customers
@@ -42,11 +48,11 @@ func GetSellerCustomerCount(sellerID int) (int, error) {
     JOIN orders o ON oi.order_id = o.id
     WHERE i.seller_id = ? AND o.status <> 'cart'
     `
-	err := DB.QueryRow(query, sellerID).Scan(&count)
+	err := r.q.QueryRowContext(ctx, query, sellerID).Scan(&count)
 	return count, err
 }
 
-func GetTotalRevenue() (float64, error) {
+func (r *sqlStatsRepo) GetTotalRevenue(ctx context.Context) (float64, error) {
 	var revenue float64
 	query := `
 	SELECT COALESCE(SUM(oi.unit_price * oi.quantity), 0) AS revenue
@@ -54,67 +60,67 @@ func GetTotalRevenue() (float64, error) {
 	JOIN orders o ON oi.order_id = o.id
 	WHERE o.status <> 'cart'
 	`
-	err := DB.QueryRow(query).Scan(&revenue)
+	err := r.q.QueryRowContext(ctx, query).Scan(&revenue)
 	return revenue, err
 }
 
-func GetTotalOrders() (int, error) {
+func (r *sqlStatsRepo) GetTotalOrders(ctx context.Context) (int, error) {
 	var count int
 	query := `
 	SELECT COUNT(*) AS orders FROM orders WHERE orders.status <> 'cart'
 	`
-	err := DB.QueryRow(query).Scan(&count)
+	err := r.q.QueryRowContext(ctx, query).Scan(&count)
 	return count, err
 }
 
-func GetTotalCustomers() (int, error) {
+func (r *sqlStatsRepo) GetTotalCustomers(ctx context.Context) (int, error) {
 	var count int
 	query := `
-	SELECT COUNT(*) FROM users WHERE user_type='user'
+	SELECT COUNT(*) FROM users WHERE user_type='user' AND deleted_at IS NULL
 	`
-	err := DB.QueryRow(query).Scan(&count)
+	err := r.q.QueryRowContext(ctx, query).Scan(&count)
 	return count, err
 }
 
-func GetTotalSellers() (int, error) {
+func (r *sqlStatsRepo) GetTotalSellers(ctx context.Context) (int, error) {
 	var count int
 	query := `
-	SELECT COUNT(*) FROM users WHERE user_type='seller'
+	SELECT COUNT(*) FROM users WHERE user_type='seller' AND deleted_at IS NULL
 	`
-	err := DB.QueryRow(query).Scan(&count)
+	err := r.q.QueryRowContext(ctx, query).Scan(&count)
 	return count, err
 }
 
-func GetTotalItems() (int, error) {
+func (r *sqlStatsRepo) GetTotalItems(ctx context.Context) (int, error) {
 	var count int
-	query := `SELECT COUNT(*) FROM items`
-	err := DB.QueryRow(query).Scan(&count)
+	query := `SELECT COUNT(*) FROM items WHERE deleted_at IS NULL`
+	err := r.q.QueryRowContext(ctx, query).Scan(&count)
 	return count, err
 }
 
-func GetTotalCategories() (int, error) {
+func (r *sqlStatsRepo) GetTotalCategories(ctx context.Context) (int, error) {
 	var count int
 	query := `
-	SELECT COUNT(*) FROM categories
+	SELECT COUNT(*) FROM categories WHERE deleted_at IS NULL
 	`
-	err := DB.QueryRow(query).Scan(&count)
+	err := r.q.QueryRowContext(ctx, query).Scan(&count)
 	return count, err
 }
 
-func GetPendingOrdersCount() (int, error) {
+func (r *sqlStatsRepo) GetPendingOrdersCount(ctx context.Context) (int, error) {
 	var count int
 	query := `
 	SELECT COUNT(*) FROM orders WHERE status <> 'delivered' AND status <> 'cancelled' AND status <> 'cart'
 	`
-	err := DB.QueryRow(query).Scan(&count)
+	err := r.q.QueryRowContext(ctx, query).Scan(&count)
 	return count, err
 }
 
-func GetTotalReviews() (int, error) {
+func (r *sqlStatsRepo) GetTotalReviews(ctx context.Context) (int, error) {
 	var count int
 	query := `
 	SELECT COUNT(*) FROM reviews
 	`
-	err := DB.QueryRow(query).Scan(&count)
+	err := r.q.QueryRowContext(ctx, query).Scan(&count)
 	return count, err
 }
@@ -0,0 +1,279 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// Deleting a user, item or category only sets its deleted_at, so the orders
+// and reviews that point at it keep their history; Restore clears it. Both
+// return sql.ErrNoRows when there is no such row in the state they change.
+// Listings leave deleted rows out, and GetDeleted lists only those.
+
+type UserRepo interface {
+	Create(ctx context.Context, u *User) error
+	Update(ctx context.Context, u *User) error
+	UpdatePassword(ctx context.Context, u *User, newPassword string) error
+	UpdateBalance(ctx context.Context, u *User, newBalance float64) error
+	// AddBalance adds delta to the stored balance, not to u.Balance, and
+	// sets u.Balance to the result. Inside a transaction the row then
+	// stays locked until it ends.
+	AddBalance(ctx context.Context, u *User, delta float64) error
+	Delete(ctx context.Context, id int) error
+	Restore(ctx context.Context, id int) error
+	EmailVerify(ctx context.Context, u *User) error
+	// GetByID and GetByEmail find deleted users too, with DeletedAt set:
+	// their email stays taken and refunds still reach them. Anything that
+	// signs a user in must check DeletedAt.
+	GetByID(ctx context.Context, id int) (*User, error)
+	GetByEmail(ctx context.Context, email string) (*User, error)
+	GetAll(ctx context.Context) ([]*User, error)
+	GetDeleted(ctx context.Context) ([]*User, error)
+}
+
+type ItemRepo interface {
+	Create(ctx context.Context, i *Item) error
+	Update(ctx context.Context, i *Item) error
+	Delete(ctx context.Context, id int) error
+	Restore(ctx context.Context, id int) error
+	// GetAll, GetByID and GetByCategoryID also leave out the items of
+	// deleted sellers. They leave out the items of deleted categories, and
+	// GetAll those of inactive ones too; GetByCategoryID leaves activeness
+	// to the caller's CategoryTree.
+	GetAll(ctx context.Context, limit int) ([]*Item, error)
+	GetByID(ctx context.Context, id int) (*Item, error)
+	// GetBySellerID keeps the items of deleted categories, with
+	// CategoryDeleted set: they are still the seller's, and an import must
+	// match them by SKU.
+	GetBySellerID(ctx context.Context, sellerID int) ([]*Item, error)
+	// GetByCategoryID lists the items in any of the categories.
+	GetByCategoryID(ctx context.Context, categoryIDs ...int) ([]*Item, error)
+	// GetDeleted lists the deleted items of a seller, or of everyone when
+	// sellerID is 0.
+	GetDeleted(ctx context.Context, sellerID int) ([]*Item, error)
+}
+
+type CategoryRepo interface {
+	Create(ctx context.Context, c *Category) error
+	Update(ctx context.Context, c *Category) error
+	Delete(ctx context.Context, id int) error
+	Restore(ctx context.Context, id int) error
+	GetByID(ctx context.Context, id int) (*Category, error)
+	// GetAll lists categories at every level, inactive ones included,
+	// ordered by position; NewCategoryTree arranges them.
+	GetAll(ctx context.Context, limit int) ([]*Category, error)
+	GetDeleted(ctx context.Context) ([]*Category, error)
+}
+
+type MenuRepo interface {
+	Create(ctx context.Context, m *Menu) error
+	Update(ctx context.Context, m *Menu) error
+	// Delete removes the menu for good; its items fall back to being
+	// always available, unless they have a schedule of their own.
+	Delete(ctx context.Context, id int) error
+	GetByID(ctx context.Context, id int) (*Menu, error)
+	GetBySellerID(ctx context.Context, sellerID int) ([]*Menu, error)
+}
+
+type OrderRepo interface {
+	Create(ctx context.Context, o *Order) error
+	Update(ctx context.Context, o *Order) error
+	UpdateStatus(ctx context.Context, o *Order, status string) error
+	UpdateMessage(ctx context.Context, o *Order, message string) error
+	Delete(ctx context.Context, id int) error
+	// Cancel cancels o only while it is still in o.Status, and returns
+	// sql.ErrNoRows when someone changed it since o was read.
+	Cancel(ctx context.Context, o *Order) error
+	// PurgeCarts deletes carts untouched since before and reports how many.
+	PurgeCarts(ctx context.Context, before time.Time) (int64, error)
+	// Schedule places the order for delivery at deliverAt, holding it out
+	// of the sellers' queues until it is released.
+	Schedule(ctx context.Context, o *Order, deliverAt time.Time) error
+	// SlotCounts counts the placed, uncancelled orders due in [from,
+	// until), keyed by the Unix time of their slot.
+	SlotCounts(ctx context.Context, from, until time.Time) (map[int64]int, error)
+	// LockSlotCounts is SlotCounts for use inside a transaction: the rows
+	// read, and the gaps between them, stay locked until it ends, so two
+	// orders can't both take a slot's last place.
+	LockSlotCounts(ctx context.Context, from, until time.Time) (map[int64]int, error)
+	// Release moves scheduled orders due by dueBy, and their lines, to
+	// ordered and reports how many orders it moved.
+	Release(ctx context.Context, dueBy time.Time) (int64, error)
+	// GetByID returns (nil, nil) when there is no placed order with that ID.
+	GetByID(ctx context.Context, id int) (*Order, error)
+	GetByUserID(ctx context.Context, userID int, limit int) ([]*Order, error)
+	GetBySellerID(ctx context.Context, sellerID int, limit int) ([]*Order, error)
+	GetAll(ctx context.Context) ([]*Order, error)
+	// GetCartByUserID returns sql.ErrNoRows when the user has no cart.
+	GetCartByUserID(ctx context.Context, userID int) (*Order, error)
+	CreateOrGetCart(ctx context.Context, userID int) (*Order, error)
+	AddItemToCart(ctx context.Context, userID int, itemID int, quantity int) (*OrderItem, error)
+	IncrementCartItem(ctx context.Context, orderID, itemID, delta int) error
+	DecrementCartItem(ctx context.Context, orderID, itemID, delta int) error
+	CalculateCartTotal(ctx context.Context, cartID int) (float64, error)
+	SyncStatus(ctx context.Context, orderID int) error
+	MarkDelivered(ctx context.Context, orderID int) error
+}
+
+type OrderItemRepo interface {
+	Create(ctx context.Context, oi *OrderItem) error
+	UpdateStatus(ctx context.Context, oi *OrderItem, status string) error
+	// Reprice sets a line's quantity and the unit price it is charged at.
+	Reprice(ctx context.Context, oi *OrderItem, quantity int, unitPrice float64) error
+	GetByID(ctx context.Context, id int) (*OrderItem, error)
+}
+
+type ReviewRepo interface {
+	UserBought(ctx context.Context, userID, itemID int) (bool, error)
+	UserReviewed(ctx context.Context, userID, itemID int) (bool, error)
+	Insert(ctx context.Context, userID, itemID, rating int) error
+}
+
+type PaymentRepo interface {
+	Create(ctx context.Context, p *Payment) error
+	Update(ctx context.Context, p *Payment) error
+	MarkAsPaid(ctx context.Context, p *Payment) error
+	MarkAsUnpaid(ctx context.Context, p *Payment) error
+	UpdateAmount(ctx context.Context, p *Payment, amount float64) error
+	Delete(ctx context.Context, id int) error
+	GetByID(ctx context.Context, id int) (*Payment, error)
+	GetByOrderID(ctx context.Context, orderID int) (*Payment, error)
+	GetAll(ctx context.Context) ([]*Payment, error)
+}
+
+type WalletRepo interface {
+	Create(ctx context.Context, t *WalletTransaction) error
+	GetByUserID(ctx context.Context, userID int, limit int) ([]*WalletTransaction, error)
+}
+
+type UploadRepo interface {
+	// Referenced lists the distinct /uploads/ paths that items, categories
+	// and users point at.
+	Referenced(ctx context.Context) ([]string, error)
+}
+
+type StatsRepo interface {
+	GetSellerRevenue(ctx context.Context, sellerID int) (float64, error)
+	GetSellerItemCount(ctx context.Context, sellerID int) (int, error)
+	GetSellerOrderCount(ctx context.Context, sellerID int) (int, error)
+	GetSellerCustomerCount(ctx context.Context, sellerID int) (int, error)
+	GetTotalRevenue(ctx context.Context) (float64, error)
+	GetTotalOrders(ctx context.Context) (int, error)
+	GetTotalCustomers(ctx context.Context) (int, error)
+	GetTotalSellers(ctx context.Context) (int, error)
+	GetTotalItems(ctx context.Context) (int, error)
+	GetTotalCategories(ctx context.Context) (int, error)
+	GetPendingOrdersCount(ctx context.Context) (int, error)
+	GetTotalReviews(ctx context.Context) (int, error)
+}
+
+// Store bundles the repositories. Controllers receive one through their
+// constructors instead of reaching for a package-level connection.
+type Store struct {
+	Users      UserRepo
+	Items      ItemRepo
+	Categories CategoryRepo
+	Menus      MenuRepo
+	Orders     OrderRepo
+	OrderItems OrderItemRepo
+	Reviews    ReviewRepo
+	Payments   PaymentRepo
+	Wallet     WalletRepo
+	Stats      StatsRepo
+	Uploads    UploadRepo
+
+	db    *sql.DB
+	cache ItemsCache
+	opts  QueryOptions
+}
+
+// NewStore returns a Store whose repositories run against db, with every
+// statement bounded by opts.
+func NewStore(db *sql.DB, cache ItemsCache, opts QueryOptions) *Store {
+	s := newStore(db, cache, opts)
+	s.db = db
+	return s
+}
+
+func newStore(conn dbConn, cache ItemsCache, opts QueryOptions) *Store {
+	q := withQueryOptions(conn, opts)
+	return &Store{
+		Users:      &sqlUserRepo{q: q, cache: cache},
+		Items:      &sqlItemRepo{q: q, cache: cache},
+		Categories: &sqlCategoryRepo{q: q, cache: cache},
+		Menus:      &sqlMenuRepo{q: q, cache: cache},
+		Orders:     &sqlOrderRepo{q: q, cache: cache},
+		OrderItems: &sqlOrderItemRepo{q: q},
+		Reviews:    &sqlReviewRepo{q: q},
+		Payments:   &sqlPaymentRepo{q: q},
+		Wallet:     &sqlWalletRepo{q: q},
+		Stats:      &sqlStatsRepo{q: q},
+		Uploads:    &sqlUploadRepo{q: q},
+		cache:      cache,
+		opts:       opts,
+	}
+}
+
+// DB is the underlying pool, or nil for stores that aren't backed by SQL.
+func (s *Store) DB() *sql.DB {
+	return s.db
+}
+
+// WithTx runs fn with a Store whose repositories share one transaction. The
+// transaction is committed if fn returns nil and rolled back otherwise. The
+// items cache is invalidated only after a commit (see txCache).
+//
+// Stores that aren't backed by SQL (the in-memory fakes) have nothing to
+// begin, so fn simply runs against s itself.
+func (s *Store) WithTx(ctx context.Context, fn func(tx *Store) error) error {
+	if s.db == nil {
+		return fn(s)
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("models.WithTx: begin failed: %w", err)
+	}
+	return s.runTx(ctx, tx, fn)
+}
+
+// RetryTx is WithTx for an fn that is safe to run again. When the database
+// ends the transaction as a deadlock victim, fn runs again in a new one, up
+// to attempts times in all.
+func (s *Store) RetryTx(ctx context.Context, attempts int, fn func(tx *Store) error) error {
+	return retryDeadlocks(attempts, func() error { return s.WithTx(ctx, fn) })
+}
+
+func retryDeadlocks(attempts int, run func() error) error {
+	var err error
+	for range attempts {
+		if err = run(); !IsDeadlock(err) {
+			return err
+		}
+	}
+	return err
+}
+
+// txConn is the part of *sql.Tx that runTx uses.
+type txConn interface {
+	dbConn
+	Commit() error
+	Rollback() error
+}
+
+func (s *Store) runTx(ctx context.Context, tx txConn, fn func(tx *Store) error) error {
+	cache := &txCache{ItemsCache: s.cache}
+	if err := fn(newStore(tx, cache, s.opts)); err != nil {
+		if rbErr := tx.Rollback(); rbErr != nil {
+			return fmt.Errorf("%w (rollback failed: %v)", err, rbErr)
+		}
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	cache.committed(ctx)
+	return nil
+}
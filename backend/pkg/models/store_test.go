@@ -0,0 +1,112 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+// fakeTx is a transaction over a recordingQuerier.
+type fakeTx struct {
+	recordingQuerier
+	commitErr error
+}
+
+func (tx *fakeTx) Commit() error   { return tx.commitErr }
+func (tx *fakeTx) Rollback() error { return nil }
+
+func TestWithTxInvalidatesAfterCommit(t *testing.T) {
+	ctx := context.Background()
+	failed := errors.New("failed")
+	for _, tc := range []struct {
+		name             string
+		fnErr, commitErr error
+		wantCached       bool
+	}{
+		{"committed", nil, nil, false},
+		{"rolled back", failed, nil, true},
+		{"commit failed", nil, failed, true},
+	} {
+		cache := NewMemoryItemsCache(time.Hour)
+		cache.SetCache(ctx, 0, CacheGen{}, []*Item{{ID: 1}})
+		s := &Store{cache: cache}
+
+		err := s.runTx(ctx, &fakeTx{recordingQuerier: recordingQuerier{updated: 1}, commitErr: tc.commitErr}, func(tx *Store) error {
+			if err := tx.Items.Delete(ctx, 1); err != nil {
+				return err
+			}
+			if _, _, ok := cache.GetFromCache(ctx, 0); !ok {
+				t.Errorf("%s: cache invalidated before the commit", tc.name)
+			}
+			if _, _, ok := tx.cache.GetFromCache(ctx, 0); ok {
+				t.Errorf("%s: the transaction still reads the listing it changed from the cache", tc.name)
+			}
+			return tc.fnErr
+		})
+		if wantErr := tc.fnErr != nil || tc.commitErr != nil; (err != nil) != wantErr {
+			t.Errorf("%s: runTx = %v", tc.name, err)
+		}
+		if _, _, ok := cache.GetFromCache(ctx, 0); ok != tc.wantCached {
+			t.Errorf("%s: cached after the transaction = %v, want %v", tc.name, ok, tc.wantCached)
+		}
+	}
+}
+
+func TestCategoryWritesInvalidate(t *testing.T) {
+	ctx := context.Background()
+	for name, write := range map[string]func(CategoryRepo) error{
+		"update":  func(r CategoryRepo) error { return r.Update(ctx, &Category{ID: 1}) },
+		"delete":  func(r CategoryRepo) error { return r.Delete(ctx, 1) },
+		"restore": func(r CategoryRepo) error { return r.Restore(ctx, 1) },
+	} {
+		cache := NewMemoryItemsCache(time.Hour)
+		cache.SetCache(ctx, 0, CacheGen{}, []*Item{{ID: 1}})
+		s := newStore(&recordingQuerier{updated: 1}, cache, QueryOptions{})
+
+		if err := write(s.Categories); err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if _, _, ok := cache.GetFromCache(ctx, 0); ok {
+			t.Errorf("%s: listing still cached after the category changed", name)
+		}
+	}
+}
+
+func TestRetryDeadlocks(t *testing.T) {
+	ctx := context.Background()
+	deadlock := &mysql.MySQLError{Number: 1213, Message: "Deadlock found when trying to get lock"}
+	s := &Store{cache: NewMemoryItemsCache(time.Hour)}
+
+	for _, tc := range []struct {
+		name      string
+		deadlocks int
+		wantRuns  int
+		wantErr   bool
+	}{
+		{"no deadlock", 0, 1, false},
+		{"one deadlock", 1, 2, false},
+		{"deadlocked every time", 5, 3, true},
+	} {
+		runs := 0
+		err := retryDeadlocks(3, func() error {
+			return s.runTx(ctx, &fakeTx{}, func(tx *Store) error {
+				runs++
+				if runs <= tc.deadlocks {
+					// as a handler wraps it
+					return fmt.Errorf("scheduling: %w", deadlock)
+				}
+				return nil
+			})
+		})
+		if runs != tc.wantRuns || (err != nil) != tc.wantErr {
+			t.Errorf("%s: ran %d times with %v, want %d runs", tc.name, runs, err, tc.wantRuns)
+		}
+	}
+	if err := retryDeadlocks(3, func() error { return errors.New("other") }); IsDeadlock(err) || err == nil {
+		t.Errorf("other error = %v, want it returned as is", err)
+	}
+}
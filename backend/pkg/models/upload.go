@@ -0,0 +1,30 @@
+package models
+
+import "context"
+
+type sqlUploadRepo struct {
+	q Querier
+}
+
+// Referenced lists every stored path an item, category or user points at, including
+// paths several records share.
+func (r *sqlUploadRepo) Referenced(ctx context.Context) ([]string, error) {
+	query := `SELECT image FROM items WHERE image LIKE '/uploads/%'
+		UNION SELECT image FROM categories WHERE image LIKE '/uploads/%'
+		UNION SELECT profile_pic FROM users WHERE profile_pic LIKE '/uploads/%'`
+	rows, err := r.q.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var paths []string
+	for rows.Next() {
+		var p string
+		if err := rows.Scan(&p); err != nil {
+			return nil, err
+		}
+		paths = append(paths, p)
+	}
+	return paths, rows.Err()
+}
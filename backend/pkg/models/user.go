@@ -1,7 +1,11 @@
 package models
 
 import (
+	"context"
+	"encoding/json"
 	"time"
+
+	"github.com/Entity069/Zesty-Go/pkg/images"
 )
 
 type User struct {
@@ -17,12 +21,32 @@ type User struct {
 	IsVerified bool      `json:"is_verified"`
 	CreatedAt  time.Time `json:"created_at"`
 	UpdatedAt  time.Time `json:"updated_at"`
+	// Allergens are the ones the user wants to be warned about.
+	Allergens Tags `json:"allergens"`
+	// DeletedAt is set while the account is deleted. A deleted user can't
+	// sign in but their orders, payments and reviews remain.
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
+}
+
+// MarshalJSON adds profile_pic_srcset, like Item's image_srcset.
+func (u User) MarshalJSON() ([]byte, error) {
+	type user User
+	return json.Marshal(struct {
+		user
+		ProfilePicSrcset string `json:"profile_pic_srcset,omitempty"`
+	}{user(u), images.Srcset(u.ProfilePic)})
+}
+
+type sqlUserRepo struct {
+	q Querier
+	// cache holds item listings, which leave out deleted sellers' items.
+	cache ItemsCache
 }
 
-func (u *User) Create() error {
-	query := `INSERT INTO users (profile_pic, first_name, last_name, user_type, password, email, address, balance, is_verified) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`
+func (r *sqlUserRepo) Create(ctx context.Context, u *User) error {
+	query := `INSERT INTO users (profile_pic, first_name, last_name, user_type, password, email, address, allergens, balance, is_verified) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
 
-	result, err := DB.Exec(query, u.ProfilePic, u.FirstName, u.LastName, u.UserType, u.Password, u.Email, u.Address, u.Balance, u.IsVerified)
+	result, err := r.q.ExecContext(ctx, query, u.ProfilePic, u.FirstName, u.LastName, u.UserType, u.Password, u.Email, u.Address, u.Allergens, u.Balance, u.IsVerified)
 	if err != nil {
 		return err
 	}
@@ -35,55 +59,75 @@ func (u *User) Create() error {
 	return nil
 }
 
-func (u *User) Update() error {
-	query := `UPDATE users SET profile_pic = ?, first_name = ?, last_name = ?, user_type = ?, email = ?, address = ?, balance = ?, is_verified = ? WHERE id = ?`
+func (r *sqlUserRepo) Update(ctx context.Context, u *User) error {
+	query := `UPDATE users SET profile_pic = ?, first_name = ?, last_name = ?, user_type = ?, email = ?, address = ?, allergens = ?, balance = ?, is_verified = ? WHERE id = ?`
 
-	_, err := DB.Exec(query, u.ProfilePic, u.FirstName, u.LastName, u.UserType,
-		u.Email, u.Address, u.Balance, u.IsVerified, u.ID)
+	_, err := r.q.ExecContext(ctx, query, u.ProfilePic, u.FirstName, u.LastName, u.UserType,
+		u.Email, u.Address, u.Allergens, u.Balance, u.IsVerified, u.ID)
 	return err
 }
 
-func (u *User) UpdatePassword(newPassword string) error {
+func (r *sqlUserRepo) UpdatePassword(ctx context.Context, u *User, newPassword string) error {
 	query := `UPDATE users SET password = ? WHERE id = ?`
-	_, err := DB.Exec(query, newPassword, u.ID)
+	_, err := r.q.ExecContext(ctx, query, newPassword, u.ID)
 	if err == nil {
 		u.Password = newPassword
 	}
 	return err
 }
 
-func (u *User) UpdateBalance(newBalance float64) error {
+func (r *sqlUserRepo) UpdateBalance(ctx context.Context, u *User, newBalance float64) error {
 	query := `UPDATE users SET balance = ? WHERE id = ?`
-	_, err := DB.Exec(query, newBalance, u.ID)
+	_, err := r.q.ExecContext(ctx, query, newBalance, u.ID)
 	if err == nil {
 		u.Balance = newBalance
 	}
 	return err
 }
 
-func (u *User) Delete() error {
-	query := `DELETE FROM users WHERE id = ?`
-	_, err := DB.Exec(query, u.ID)
+func (r *sqlUserRepo) AddBalance(ctx context.Context, u *User, delta float64) error {
+	query := `UPDATE users SET balance = balance + ? WHERE id = ?`
+	if err := execOne(ctx, r.q, query, delta, u.ID); err != nil {
+		return err
+	}
+	return r.q.QueryRowContext(ctx, `SELECT balance FROM users WHERE id = ?`, u.ID).Scan(&u.Balance)
+}
+
+func (r *sqlUserRepo) Delete(ctx context.Context, id int) error {
+	query := `UPDATE users SET deleted_at = NOW() WHERE id = ? AND deleted_at IS NULL`
+	err := execOne(ctx, r.q, query, id)
+	if err == nil {
+		r.cache.InvalidateCache(ctx)
+	}
 	return err
 }
 
-func (u *User) EmailVerify() error {
+func (r *sqlUserRepo) Restore(ctx context.Context, id int) error {
+	query := `UPDATE users SET deleted_at = NULL WHERE id = ? AND deleted_at IS NOT NULL`
+	err := execOne(ctx, r.q, query, id)
+	if err == nil {
+		r.cache.InvalidateCache(ctx)
+	}
+	return err
+}
+
+func (r *sqlUserRepo) EmailVerify(ctx context.Context, u *User) error {
 	query := `UPDATE users SET is_verified = true WHERE id = ?`
-	_, err := DB.Exec(query, u.ID)
+	_, err := r.q.ExecContext(ctx, query, u.ID)
 	if err == nil {
 		u.IsVerified = true
 	}
 	return err
 }
 
-func GetUserByID(id int) (*User, error) {
+func (r *sqlUserRepo) GetByID(ctx context.Context, id int) (*User, error) {
 	user := &User{}
-	query := `SELECT id, profile_pic, first_name, last_name, user_type, password, email, address, balance, is_verified, created_at, updated_at FROM users WHERE id = ?`
+	query := `SELECT id, profile_pic, first_name, last_name, user_type, password, email, address, allergens, balance, is_verified, created_at, updated_at, deleted_at FROM users WHERE id = ?`
 
-	err := DB.QueryRow(query, id).Scan(
+	err := r.q.QueryRowContext(ctx, query, id).Scan(
 		&user.ID, &user.ProfilePic, &user.FirstName, &user.LastName, &user.UserType,
-		&user.Password, &user.Email, &user.Address, &user.Balance, &user.IsVerified,
-		&user.CreatedAt, &user.UpdatedAt,
+		&user.Password, &user.Email, &user.Address, &user.Allergens, &user.Balance, &user.IsVerified,
+		&user.CreatedAt, &user.UpdatedAt, &user.DeletedAt,
 	)
 	if err != nil {
 		return nil, err
@@ -91,14 +135,14 @@ func GetUserByID(id int) (*User, error) {
 	return user, nil
 }
 
-func GetUserByEmail(email string) (*User, error) {
+func (r *sqlUserRepo) GetByEmail(ctx context.Context, email string) (*User, error) {
 	user := &User{}
-	query := `SELECT id, profile_pic, first_name, last_name, user_type, password, email, address, balance, is_verified, created_at, updated_at FROM users WHERE email = ?`
+	query := `SELECT id, profile_pic, first_name, last_name, user_type, password, email, address, allergens, balance, is_verified, created_at, updated_at, deleted_at FROM users WHERE email = ?`
 
-	err := DB.QueryRow(query, email).Scan(
+	err := r.q.QueryRowContext(ctx, query, email).Scan(
 		&user.ID, &user.ProfilePic, &user.FirstName, &user.LastName, &user.UserType,
-		&user.Password, &user.Email, &user.Address, &user.Balance, &user.IsVerified,
-		&user.CreatedAt, &user.UpdatedAt,
+		&user.Password, &user.Email, &user.Address, &user.Allergens, &user.Balance, &user.IsVerified,
+		&user.CreatedAt, &user.UpdatedAt, &user.DeletedAt,
 	)
 	if err != nil {
 		return nil, err
@@ -106,10 +150,18 @@ func GetUserByEmail(email string) (*User, error) {
 	return user, nil
 }
 
-func GetAllUsers() ([]*User, error) {
-	query := `SELECT id, profile_pic, first_name, last_name, user_type, password, email, address, balance, is_verified, created_at, updated_at FROM users ORDER BY created_at DESC`
+func (r *sqlUserRepo) GetAll(ctx context.Context) ([]*User, error) {
+	query := `SELECT id, profile_pic, first_name, last_name, user_type, password, email, address, allergens, balance, is_verified, created_at, updated_at, deleted_at FROM users WHERE deleted_at IS NULL ORDER BY created_at DESC`
+	return r.list(ctx, query)
+}
+
+func (r *sqlUserRepo) GetDeleted(ctx context.Context) ([]*User, error) {
+	query := `SELECT id, profile_pic, first_name, last_name, user_type, password, email, address, allergens, balance, is_verified, created_at, updated_at, deleted_at FROM users WHERE deleted_at IS NOT NULL ORDER BY deleted_at DESC`
+	return r.list(ctx, query)
+}
 
-	rows, err := DB.Query(query)
+func (r *sqlUserRepo) list(ctx context.Context, query string) ([]*User, error) {
+	rows, err := r.q.QueryContext(ctx, query)
 	if err != nil {
 		return nil, err
 	}
@@ -120,8 +172,8 @@ func GetAllUsers() ([]*User, error) {
 		user := &User{}
 		err := rows.Scan(
 			&user.ID, &user.ProfilePic, &user.FirstName, &user.LastName, &user.UserType,
-			&user.Password, &user.Email, &user.Address, &user.Balance, &user.IsVerified,
-			&user.CreatedAt, &user.UpdatedAt,
+			&user.Password, &user.Email, &user.Address, &user.Allergens, &user.Balance, &user.IsVerified,
+			&user.CreatedAt, &user.UpdatedAt, &user.DeletedAt,
 		)
 		if err != nil {
 			return nil, err
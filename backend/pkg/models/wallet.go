@@ -0,0 +1,63 @@
+package models
+
+import (
+	"context"
+	"time"
+)
+
+// WalletTransaction is one manual adjustment to a user's balance, kept so
+// there is a record of who was credited or debited and why.
+type WalletTransaction struct {
+	ID           int       `json:"id"`
+	UserID       int       `json:"user_id"`
+	Amount       float64   `json:"amount"`
+	BalanceAfter float64   `json:"balance_after"`
+	Reason       string    `json:"reason"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+type sqlWalletRepo struct {
+	q Querier
+}
+
+func (r *sqlWalletRepo) Create(ctx context.Context, t *WalletTransaction) error {
+	query := `INSERT INTO wallet_transactions (user_id, amount, balance_after, reason) VALUES (?, ?, ?, ?)`
+
+	result, err := r.q.ExecContext(ctx, query, t.UserID, t.Amount, t.BalanceAfter, t.Reason)
+	if err != nil {
+		return err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	t.ID = int(id)
+	t.CreatedAt = time.Now()
+	return nil
+}
+
+func (r *sqlWalletRepo) GetByUserID(ctx context.Context, userID int, limit int) ([]*WalletTransaction, error) {
+	query := `SELECT id, user_id, amount, balance_after, reason, created_at FROM wallet_transactions WHERE user_id = ? ORDER BY created_at DESC, id DESC`
+	args := []any{userID}
+	if limit > 0 {
+		query += ` LIMIT ?`
+		args = append(args, limit)
+	}
+
+	rows, err := r.q.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	txns := []*WalletTransaction{}
+	for rows.Next() {
+		t := &WalletTransaction{}
+		if err := rows.Scan(&t.ID, &t.UserID, &t.Amount, &t.BalanceAfter, &t.Reason, &t.CreatedAt); err != nil {
+			return nil, err
+		}
+		txns = append(txns, t)
+	}
+	return txns, rows.Err()
+}
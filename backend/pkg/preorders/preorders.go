@@ -0,0 +1,58 @@
+// Package preorders releases scheduled orders to the sellers.
+//
+// An order placed for a later delivery slot is paid for straight away but
+// held as scheduled, out of the sellers' queues, so no one starts cooking it
+// hours early. Release moves every scheduled order due within the lead time,
+// and its lines, to ordered, after which it is handled like any other.
+//
+// Runs are idempotent, so several replicas running the scheduler, or the
+// server and `zestyctl order release`, at once is harmless.
+package preorders
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/Entity069/Zesty-Go/pkg/metrics"
+	"github.com/Entity069/Zesty-Go/pkg/models"
+)
+
+// Release releases the orders whose slot starts within lead of now and
+// reports how many there were.
+func Release(ctx context.Context, store *models.Store, lead time.Duration) (int64, error) {
+	var released int64
+	err := store.WithTx(ctx, func(tx *models.Store) error {
+		var err error
+		released, err = tx.Orders.Release(ctx, time.Now().Add(lead))
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+	metrics.OrdersReleased.Add(float64(released))
+	return released, nil
+}
+
+// Start runs Release every interval until ctx is cancelled.
+func Start(ctx context.Context, store *models.Store, interval, lead time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+			released, err := Release(ctx, store, lead)
+			switch {
+			case err != nil:
+				slog.Error("releasing scheduled orders", "err", err)
+			case released > 0:
+				slog.Info("released scheduled orders", "orders", released)
+			}
+		}
+	}()
+}
@@ -0,0 +1,61 @@
+package preorders_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Entity069/Zesty-Go/pkg/models"
+	"github.com/Entity069/Zesty-Go/pkg/models/memstore"
+	"github.com/Entity069/Zesty-Go/pkg/preorders"
+)
+
+func TestRelease(t *testing.T) {
+	ctx := context.Background()
+	store := memstore.New()
+
+	seller := &models.User{FirstName: "Sam", Email: "sam@zes.ty", UserType: "seller"}
+	buyer := &models.User{FirstName: "Bo", Email: "bo@zes.ty", UserType: "user"}
+	store.Users.Create(ctx, seller)
+	store.Users.Create(ctx, buyer)
+	item := &models.Item{SellerID: seller.ID, Name: "Dosa", Price: 20, Status: "available"}
+	store.Items.Create(ctx, item)
+
+	// schedule places an order due in, its line held with it.
+	schedule := func(in time.Duration) *models.Order {
+		t.Helper()
+		line, err := store.Orders.AddItemToCart(ctx, buyer.ID, item.ID, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		store.OrderItems.UpdateStatus(ctx, line, "scheduled")
+		cart, _ := store.Orders.GetCartByUserID(ctx, buyer.ID)
+		if err := store.Orders.Schedule(ctx, cart, time.Now().Add(in)); err != nil {
+			t.Fatal(err)
+		}
+		return cart
+	}
+	soon, later := schedule(30*time.Minute), schedule(3*time.Hour)
+
+	if queue, _ := store.Orders.GetBySellerID(ctx, seller.ID, 0); len(queue) != 0 {
+		t.Fatalf("the seller sees %d held order(s)", len(queue))
+	}
+
+	released, err := preorders.Release(ctx, store, time.Hour)
+	if err != nil || released != 1 {
+		t.Fatalf("Release = %d, %v; want the one order due within the hour", released, err)
+	}
+	if o, _ := store.Orders.GetByID(ctx, soon.ID); o.Status != "ordered" || o.Items[0].Status != "ordered" {
+		t.Errorf("released order %+v, want it and its line ordered", o)
+	}
+	if o, _ := store.Orders.GetByID(ctx, later.ID); o.Status != "scheduled" {
+		t.Errorf("an order due in 3h is %s, want it still held", o.Status)
+	}
+	if queue, _ := store.Orders.GetBySellerID(ctx, seller.ID, 0); len(queue) != 1 || queue[0].ID != soon.ID {
+		t.Errorf("seller queue %+v, want only the released order", queue)
+	}
+
+	if released, _ := preorders.Release(ctx, store, time.Hour); released != 0 {
+		t.Errorf("a second run released %d more", released)
+	}
+}
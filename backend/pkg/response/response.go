@@ -0,0 +1,203 @@
+// Package response writes the JSON bodies every handler returns.
+//
+// Successes look like {"success": true, "msg": "...", ...data}. Failures
+// carry the same success/msg pair, which the frontend already reads, plus
+// an "error" object with a stable machine-readable code, any per-field
+// problems and the request ID to quote in a bug report:
+//
+//	{
+//	  "success": false,
+//	  "msg": "Order not found.",
+//	  "error": {"code": "not_found", "message": "Order not found.", "request_id": "..."}
+//	}
+//
+// Handlers build an *Error with one of the constructors and hand it to
+// Fail, or return one from deeper code (a transaction closure, say) and let
+// Fail find it with errors.As.
+package response
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/Entity069/Zesty-Go/pkg/logging"
+)
+
+// Code identifies a kind of failure. Clients branch on it; the message is
+// for people and may change.
+type Code string
+
+const (
+	CodeBadRequest          Code = "bad_request"
+	CodeInvalidJSON         Code = "invalid_json"
+	CodeValidation          Code = "validation_failed"
+	CodeTooLarge            Code = "too_large"
+	CodeUnauthenticated     Code = "unauthenticated"
+	CodeInvalidCredentials  Code = "invalid_credentials"
+	CodeEmailNotVerified    Code = "email_not_verified"
+	CodeInvalidToken        Code = "invalid_token"
+	CodeForbidden           Code = "forbidden"
+	CodeCSRF                Code = "csrf_failed"
+	CodeNotFound            Code = "not_found"
+	CodeMethodNotAllowed    Code = "method_not_allowed"
+	CodeConflict            Code = "conflict"
+	CodeInsufficientBalance Code = "insufficient_balance"
+	CodeInvalidState        Code = "invalid_state"
+	CodeInternal            Code = "internal"
+)
+
+// FieldError is one problem with one input field.
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// Error is a failure to report to the client. Err is the underlying cause;
+// it is logged for 5xx responses and never sent.
+type Error struct {
+	Status  int
+	Code    Code
+	Message string
+	Fields  []FieldError
+	Err     error
+}
+
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return e.Message + ": " + e.Err.Error()
+	}
+	return e.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// New returns an Error with the given status, code and message.
+func New(status int, code Code, msg string) *Error {
+	return &Error{Status: status, Code: code, Message: msg}
+}
+
+func BadRequest(msg string) *Error {
+	return New(http.StatusBadRequest, CodeBadRequest, msg)
+}
+
+// InvalidJSON reports a body that couldn't be decoded.
+func InvalidJSON(err error) *Error {
+	e := New(http.StatusBadRequest, CodeInvalidJSON, "The request body is not valid JSON.")
+	e.Err = err
+	return e
+}
+
+// Validation reports input that decoded but isn't acceptable.
+func Validation(fields ...FieldError) *Error {
+	e := New(http.StatusBadRequest, CodeValidation, "Some fields are invalid.")
+	e.Fields = fields
+	return e
+}
+
+func Unauthenticated(msg string) *Error {
+	return New(http.StatusUnauthorized, CodeUnauthenticated, msg)
+}
+
+func Forbidden(msg string) *Error {
+	return New(http.StatusForbidden, CodeForbidden, msg)
+}
+
+func NotFound(msg string) *Error {
+	return New(http.StatusNotFound, CodeNotFound, msg)
+}
+
+func Conflict(code Code, msg string) *Error {
+	return New(http.StatusConflict, code, msg)
+}
+
+// Internal reports a server-side failure. msg is shown to the user; err is
+// only logged.
+func Internal(msg string, err error) *Error {
+	e := New(http.StatusInternalServerError, CodeInternal, msg)
+	e.Err = err
+	return e
+}
+
+// Data is the payload merged into a success body next to success and msg.
+type Data map[string]any
+
+// JSON writes v as the body with the given status.
+func JSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// Success writes a success body. msg may be empty.
+func Success(w http.ResponseWriter, status int, msg string, data Data) {
+	body := make(map[string]any, len(data)+2)
+	for k, v := range data {
+		body[k] = v
+	}
+	body["success"] = true
+	if msg != "" {
+		body["msg"] = msg
+	}
+	JSON(w, status, body)
+}
+
+// OK writes a 200 success body.
+func OK(w http.ResponseWriter, msg string, data Data) {
+	Success(w, http.StatusOK, msg, data)
+}
+
+// Created writes a 201 success body.
+func Created(w http.ResponseWriter, msg string, data Data) {
+	Success(w, http.StatusCreated, msg, data)
+}
+
+type errorBody struct {
+	Code      Code         `json:"code"`
+	Message   string       `json:"message"`
+	Fields    []FieldError `json:"fields,omitempty"`
+	RequestID string       `json:"request_id,omitempty"`
+}
+
+// Fail writes the error envelope for err. An err that isn't (and doesn't
+// wrap) an *Error is treated as an unexpected internal failure. Server-side
+// failures are logged with their cause.
+func Fail(w http.ResponseWriter, r *http.Request, err error) {
+	var e *Error
+	if !errors.As(err, &e) {
+		e = Internal("Something went wrong. Please try again.", err)
+	}
+
+	ctx := r.Context()
+	if e.Status >= http.StatusInternalServerError {
+		logging.FromContext(ctx).Error(e.Message, "code", e.Code, "err", e.Err)
+	}
+
+	JSON(w, e.Status, map[string]any{
+		"success": false,
+		"msg":     e.Message,
+		"error": errorBody{
+			Code:      e.Code,
+			Message:   e.Message,
+			Fields:    e.Fields,
+			RequestID: logging.RequestID(ctx),
+		},
+	})
+}
+
+// NotFoundHandler answers requests that match no route.
+func NotFoundHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		Fail(w, r, NotFound("No such endpoint."))
+	})
+}
+
+// MethodNotAllowedHandler answers requests whose path exists under another
+// method.
+func MethodNotAllowedHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		Fail(w, r, New(http.StatusMethodNotAllowed, CodeMethodNotAllowed, "This endpoint doesn't accept "+r.Method+" requests."))
+	})
+}
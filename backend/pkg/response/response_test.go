@@ -0,0 +1,85 @@
+package response_test
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Entity069/Zesty-Go/pkg/logging"
+	"github.com/Entity069/Zesty-Go/pkg/response"
+)
+
+type envelope struct {
+	Success bool   `json:"success"`
+	Msg     string `json:"msg"`
+	Error   struct {
+		Code      response.Code         `json:"code"`
+		Message   string                `json:"message"`
+		Fields    []response.FieldError `json:"fields"`
+		RequestID string                `json:"request_id"`
+	} `json:"error"`
+}
+
+func fail(t *testing.T, err error) (*httptest.ResponseRecorder, envelope) {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodPost, "/", nil)
+	req = req.WithContext(logging.NewContext(req.Context(), slog.New(slog.DiscardHandler), "req-1"))
+	rec := httptest.NewRecorder()
+	response.Fail(rec, req, err)
+
+	var env envelope
+	if err := json.Unmarshal(rec.Body.Bytes(), &env); err != nil {
+		t.Fatalf("body isn't JSON: %v\n%s", err, rec.Body)
+	}
+	return rec, env
+}
+
+func TestFailWritesEnvelope(t *testing.T) {
+	rec, env := fail(t, response.Validation(response.FieldError{Field: "email", Message: "must be an email address"}))
+
+	if rec.Code != http.StatusBadRequest || rec.Header().Get("Content-Type") != "application/json" {
+		t.Fatalf("got %d %q, want a 400 JSON response", rec.Code, rec.Header().Get("Content-Type"))
+	}
+	if env.Success || env.Msg == "" || env.Msg != env.Error.Message {
+		t.Errorf("success/msg = %v/%q, want false and the error message", env.Success, env.Msg)
+	}
+	if env.Error.Code != response.CodeValidation || env.Error.RequestID != "req-1" {
+		t.Errorf("error = %+v", env.Error)
+	}
+	if len(env.Error.Fields) != 1 || env.Error.Fields[0].Field != "email" {
+		t.Errorf("fields = %+v", env.Error.Fields)
+	}
+}
+
+func TestFailFindsWrappedError(t *testing.T) {
+	wrapped := fmt.Errorf("placing order: %w", response.Conflict(response.CodeInvalidState, "Already cancelled."))
+	rec, env := fail(t, wrapped)
+	if rec.Code != http.StatusConflict || env.Error.Code != response.CodeInvalidState {
+		t.Fatalf("got %d %q, want 409 invalid_state", rec.Code, env.Error.Code)
+	}
+}
+
+func TestFailHidesUnknownErrors(t *testing.T) {
+	rec, env := fail(t, errors.New("dial tcp 10.0.0.5:3306: connection refused"))
+	if rec.Code != http.StatusInternalServerError || env.Error.Code != response.CodeInternal {
+		t.Fatalf("got %d %q, want 500 internal", rec.Code, env.Error.Code)
+	}
+	if env.Msg == "" || env.Msg == "dial tcp 10.0.0.5:3306: connection refused" {
+		t.Errorf("msg = %q, want a generic message", env.Msg)
+	}
+}
+
+func TestSuccessMergesData(t *testing.T) {
+	rec := httptest.NewRecorder()
+	response.Created(rec, "Done.", response.Data{"id": 7})
+
+	var body map[string]any
+	json.Unmarshal(rec.Body.Bytes(), &body)
+	if rec.Code != http.StatusCreated || body["success"] != true || body["msg"] != "Done." || body["id"] != float64(7) {
+		t.Fatalf("got %d %v", rec.Code, body)
+	}
+}
@@ -0,0 +1,142 @@
+// Package storage keeps uploaded files (item images, profile pictures) in a
+// BlobStore: a directory on local disk, or an S3-compatible bucket so files
+// survive container rebuilds and are shared between replicas.
+//
+// Files are addressed by key, a slash-separated relative path such as
+// "item-images/1700000000_dosa.jpg". The database stores them as
+// URLPrefix+key and the router serves that prefix from the store, so the
+// stored paths don't depend on the backend.
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/Entity069/Zesty-Go/pkg/config"
+)
+
+// URLPrefix is where the API serves stored files.
+const URLPrefix = "/uploads/"
+
+// QuarantinePrefix is where the upload collector sets files aside before
+// deleting them. Handler doesn't serve it.
+const QuarantinePrefix = "quarantine/"
+
+// ErrNotFound is returned for a key with nothing stored under it.
+var ErrNotFound = errors.New("storage: object not found")
+
+// Object describes one stored file.
+type Object struct {
+	Key     string    `json:"key"`
+	Size    int64     `json:"size"`
+	ModTime time.Time `json:"mod_time"`
+}
+
+type BlobStore interface {
+	// Put stores size bytes from r under key, replacing anything there.
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error
+	// Open returns the file under key, or ErrNotFound.
+	Open(ctx context.Context, key string) (io.ReadCloser, error)
+	// Stat describes the file under key, or returns ErrNotFound.
+	Stat(ctx context.Context, key string) (Object, error)
+	// Delete removes key. Deleting a missing key is not an error.
+	Delete(ctx context.Context, key string) error
+	// Walk calls fn for every file whose key starts with prefix, stopping at
+	// the first error fn returns.
+	Walk(ctx context.Context, prefix string, fn func(Object) error) error
+	// SignedURL returns a URL that fetches key straight from the backend
+	// until expiry, without going through the API.
+	SignedURL(ctx context.Context, key string, expiry time.Duration) (string, error)
+	// Check reports whether the store can be written to, for /readyz.
+	Check(ctx context.Context) error
+}
+
+// New opens the store the configuration selects.
+func New(ctx context.Context, cfg config.UploadsConfig) (BlobStore, error) {
+	switch cfg.Backend {
+	case "local":
+		return NewLocal(cfg.Dir), nil
+	case "s3":
+		return NewS3(ctx, cfg.S3)
+	default:
+		return nil, fmt.Errorf("storage: unknown backend %q", cfg.Backend)
+	}
+}
+
+// Path is the URL path the API serves key at, as stored in the database.
+func Path(key string) string {
+	return URLPrefix + key
+}
+
+// KeyFromPath is the inverse of Path. It reports false for anything the
+// store doesn't hold, such as the placeholder image or an external URL.
+func KeyFromPath(p string) (string, bool) {
+	key, ok := strings.CutPrefix(p, URLPrefix)
+	if !ok {
+		return "", false
+	}
+	key, err := CleanKey(key)
+	return key, err == nil
+}
+
+// CleanKey rejects keys that could escape the store: empty, absolute, or
+// containing "..".
+func CleanKey(key string) (string, error) {
+	clean := path.Clean("/" + key)[1:]
+	if clean == "" || clean != key || strings.Contains(key, `\`) {
+		return "", fmt.Errorf("storage: invalid key %q", key)
+	}
+	return clean, nil
+}
+
+// Handler serves stored files under URLPrefix (mount it with StripPrefix).
+// Local files are served directly; for a bucket the client is redirected
+// to a presigned URL valid for expiry. Quarantined files are not found.
+func Handler(bs BlobStore, expiry time.Duration) http.Handler {
+	return hideQuarantine(handler(bs, expiry))
+}
+
+func hideQuarantine(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		dir := strings.TrimPrefix(path.Clean("/"+r.URL.Path), "/") + "/"
+		if strings.HasPrefix(dir, QuarantinePrefix) {
+			http.NotFound(w, r)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func handler(bs BlobStore, expiry time.Duration) http.Handler {
+	if l, ok := bs.(*Local); ok {
+		return http.FileServer(http.Dir(l.dir))
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+			return
+		}
+		key, err := CleanKey(r.URL.Path)
+		if err != nil {
+			http.NotFound(w, r)
+			return
+		}
+		// Signing needs no round trip to the bucket; a missing key is the
+		// bucket's 404 to report.
+		url, err := bs.SignedURL(r.Context(), key, expiry)
+		if err != nil {
+			http.Error(w, http.StatusText(http.StatusBadGateway), http.StatusBadGateway)
+			return
+		}
+		// Let browsers reuse the redirect for a while, but not past the
+		// signature's expiry.
+		w.Header().Set("Cache-Control", fmt.Sprintf("private, max-age=%d", int(expiry.Seconds()/2)))
+		http.Redirect(w, r, url, http.StatusFound)
+	})
+}
@@ -0,0 +1,203 @@
+package storage_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/johannesboyne/gofakes3"
+	"github.com/johannesboyne/gofakes3/backend/s3mem"
+
+	"github.com/Entity069/Zesty-Go/pkg/config"
+	"github.com/Entity069/Zesty-Go/pkg/storage"
+)
+
+// newS3 starts an in-memory S3 stand-in with an empty bucket and returns a
+// store pointed at it.
+func newS3(t *testing.T) storage.BlobStore {
+	t.Helper()
+	backend := s3mem.New()
+	if err := backend.CreateBucket("zesty"); err != nil {
+		t.Fatal(err)
+	}
+	srv := httptest.NewServer(gofakes3.New(backend).Server())
+	t.Cleanup(srv.Close)
+
+	u, _ := url.Parse(srv.URL)
+	bs, err := storage.New(context.Background(), config.UploadsConfig{
+		Backend: "s3",
+		S3: config.S3Config{
+			Endpoint:  u.Host,
+			Region:    "us-east-1",
+			Bucket:    "zesty",
+			AccessKey: "test",
+			SecretKey: "testtesttest",
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return bs
+}
+
+func backends(t *testing.T) map[string]storage.BlobStore {
+	return map[string]storage.BlobStore{
+		"local": storage.NewLocal(t.TempDir()),
+		"s3":    newS3(t),
+	}
+}
+
+func put(t *testing.T, bs storage.BlobStore, key, content string) {
+	t.Helper()
+	if err := bs.Put(context.Background(), key, strings.NewReader(content), int64(len(content)), "image/png"); err != nil {
+		t.Fatalf("Put(%s): %v", key, err)
+	}
+}
+
+func TestBlobStore(t *testing.T) {
+	ctx := context.Background()
+	for name, bs := range backends(t) {
+		t.Run(name, func(t *testing.T) {
+			if err := bs.Check(ctx); err != nil {
+				t.Fatalf("Check: %v", err)
+			}
+			if _, err := bs.Stat(ctx, "item-images/a.png"); !errors.Is(err, storage.ErrNotFound) {
+				t.Fatalf("Stat before Put = %v, want ErrNotFound", err)
+			}
+
+			put(t, bs, "item-images/a.png", "aaaa")
+			put(t, bs, "item-images/b.png", "bb")
+			put(t, bs, "profile-pics/c.png", "c")
+
+			obj, err := bs.Stat(ctx, "item-images/a.png")
+			if err != nil || obj.Size != 4 {
+				t.Fatalf("Stat = %+v, %v; want size 4", obj, err)
+			}
+
+			rc, err := bs.Open(ctx, "item-images/b.png")
+			if err != nil {
+				t.Fatalf("Open: %v", err)
+			}
+			got, _ := io.ReadAll(rc)
+			rc.Close()
+			if string(got) != "bb" {
+				t.Fatalf("Open read %q, want %q", got, "bb")
+			}
+
+			var keys []string
+			err = bs.Walk(ctx, "item-images/", func(o storage.Object) error {
+				keys = append(keys, o.Key)
+				return nil
+			})
+			sort.Strings(keys)
+			if err != nil || strings.Join(keys, ",") != "item-images/a.png,item-images/b.png" {
+				t.Fatalf("Walk(item-images/) = %v, %v", keys, err)
+			}
+
+			if err := bs.Delete(ctx, "item-images/a.png"); err != nil {
+				t.Fatalf("Delete: %v", err)
+			}
+			if err := bs.Delete(ctx, "item-images/a.png"); err != nil {
+				t.Fatalf("deleting a missing key: %v", err)
+			}
+			if _, err := bs.Open(ctx, "item-images/a.png"); !errors.Is(err, storage.ErrNotFound) {
+				t.Fatalf("Open after Delete = %v, want ErrNotFound", err)
+			}
+
+			for _, key := range []string{"", "../etc/passwd", "/abs.png", "a/../../b.png"} {
+				if err := bs.Put(ctx, key, strings.NewReader("x"), 1, ""); err == nil {
+					t.Errorf("Put(%q) succeeded, want an invalid key error", key)
+				}
+			}
+		})
+	}
+}
+
+func TestLocalWalkMissingDir(t *testing.T) {
+	bs := storage.NewLocal(filepath.Join(t.TempDir(), "never-created"))
+	err := bs.Walk(context.Background(), "", func(storage.Object) error {
+		t.Fatal("walked a file in a missing directory")
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Walk = %v, want nil", err)
+	}
+}
+
+func TestHandler(t *testing.T) {
+	t.Run("local serves the file", func(t *testing.T) {
+		dir := t.TempDir()
+		bs := storage.NewLocal(dir)
+		put(t, bs, "item-images/a.png", "local")
+		h := http.StripPrefix(storage.URLPrefix, storage.Handler(bs, time.Hour))
+
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/uploads/item-images/a.png", nil))
+		if rec.Code != http.StatusOK || rec.Body.String() != "local" {
+			t.Fatalf("GET = %d %q", rec.Code, rec.Body)
+		}
+		if _, err := os.Stat(filepath.Join(dir, "item-images", "a.png")); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("quarantine is hidden", func(t *testing.T) {
+		for name, bs := range backends(t) {
+			put(t, bs, storage.QuarantinePrefix+"item-images/a.png", "orphan")
+			h := http.StripPrefix(storage.URLPrefix, storage.Handler(bs, time.Hour))
+			for _, target := range []string{"/uploads/quarantine/item-images/a.png", "/uploads/quarantine/", "/uploads/item-images/../quarantine/item-images/a.png"} {
+				rec := httptest.NewRecorder()
+				h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+				if rec.Code != http.StatusNotFound {
+					t.Errorf("%s: GET %s = %d, want 404", name, target, rec.Code)
+				}
+			}
+		}
+	})
+
+	t.Run("s3 redirects to a presigned URL", func(t *testing.T) {
+		bs := newS3(t)
+		put(t, bs, "item-images/a.png", "remote")
+		h := http.StripPrefix(storage.URLPrefix, storage.Handler(bs, time.Hour))
+
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/uploads/item-images/a.png", nil))
+		loc := rec.Header().Get("Location")
+		if rec.Code != http.StatusFound || !strings.Contains(loc, "X-Amz-Signature=") || !strings.Contains(loc, "X-Amz-Expires=3600") {
+			t.Fatalf("GET = %d, Location %q; want a redirect to a presigned URL", rec.Code, loc)
+		}
+
+		resp, err := http.Get(loc)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		if resp.StatusCode != http.StatusOK || string(body) != "remote" {
+			t.Fatalf("following the redirect = %d %q", resp.StatusCode, body)
+		}
+	})
+}
+
+func TestKeyFromPath(t *testing.T) {
+	for path, want := range map[string]string{
+		"/uploads/item-images/a.png": "item-images/a.png",
+		"/placeholder.svg":           "",
+		"https://s3.tebi.io/x.png":   "",
+		"/uploads/../secrets":        "",
+	} {
+		got, ok := storage.KeyFromPath(path)
+		if got != want || ok != (want != "") {
+			t.Errorf("KeyFromPath(%q) = %q, %v; want %q", path, got, ok, want)
+		}
+	}
+}
@@ -0,0 +1,54 @@
+package tracing_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"github.com/Entity069/Zesty-Go/pkg/tracing"
+)
+
+func TestTransportPropagatesTraceContext(t *testing.T) {
+	sr := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(sr)))
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+
+	var got string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Get("traceparent")
+	}))
+	defer srv.Close()
+
+	ctx, parent := tracing.Tracer().Start(t.Context(), "webhook")
+	req, _ := http.NewRequestWithContext(ctx, http.MethodPost, srv.URL+"/hook?sig=secret", nil)
+	client := &http.Client{Transport: tracing.Transport(nil)}
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	parent.End()
+
+	spans := sr.Ended()
+	if len(spans) != 2 {
+		t.Fatalf("got %d spans, want the client span and its parent", len(spans))
+	}
+	span := spans[0]
+	want := "00-" + span.SpanContext().TraceID().String() + "-" + span.SpanContext().SpanID().String() + "-01"
+	if got != want {
+		t.Errorf("traceparent = %q, want %q", got, want)
+	}
+	if span.Parent().SpanID() != parent.SpanContext().SpanID() {
+		t.Error("client span isn't a child of the caller's span")
+	}
+	for _, kv := range span.Attributes() {
+		if kv.Key == "url.full" && kv.Value.AsString() != srv.URL+"/hook" {
+			t.Errorf("url.full = %q, want the query stripped", kv.Value.AsString())
+		}
+	}
+}
@@ -0,0 +1,225 @@
+// Package uploadgc reclaims uploaded files that no item or user points at.
+//
+// Handlers never delete uploads themselves: pictures are named after their
+// content and may be shared, and a file is stored before the record that
+// points at it is saved. Instead Run compares the blob store with the paths
+// in the database and, in two steps, gets rid of what nothing references:
+//
+//  1. A file unreferenced and older than the grace period is moved under
+//     storage.QuarantinePrefix, where it is no longer served.
+//  2. A quarantined file still unreferenced after the retention period is
+//     deleted. One that is referenced again, say because an item was
+//     pointed back at its old picture, is moved back instead.
+//
+// Runs are idempotent, so several replicas running the collector, or the
+// server and `zestyctl uploads gc`, at once is harmless.
+package uploadgc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"mime"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/Entity069/Zesty-Go/pkg/images"
+	"github.com/Entity069/Zesty-Go/pkg/logging"
+	"github.com/Entity069/Zesty-Go/pkg/metrics"
+	"github.com/Entity069/Zesty-Go/pkg/models"
+	"github.com/Entity069/Zesty-Go/pkg/storage"
+)
+
+// Prefixes are the directories uploads are saved under. Nothing else in the
+// store is touched.
+var Prefixes = []string{"item-images/", "category-images/", "profile-pics/"}
+
+type Options struct {
+	Grace     time.Duration
+	Retention time.Duration
+	// DryRun reports what would happen without moving or deleting anything.
+	DryRun bool
+}
+
+// Report sums up a run.
+type Report struct {
+	Scanned          int   `json:"scanned"`
+	Referenced       int   `json:"referenced"`
+	InGrace          int   `json:"in_grace"`
+	Quarantined      int   `json:"quarantined"`
+	QuarantinedBytes int64 `json:"quarantined_bytes"`
+	Restored         int   `json:"restored"`
+	Purged           int   `json:"purged"`
+	ReclaimedBytes   int64 `json:"reclaimed_bytes"`
+	// Failed counts files that couldn't be moved or deleted; they are
+	// logged and retried on the next run.
+	Failed int `json:"failed"`
+}
+
+// Busy reports whether the run changed, or would have changed, anything.
+func (r Report) Busy() bool {
+	return r.Quarantined+r.Restored+r.Purged+r.Failed > 0
+}
+
+func (r Report) String() string {
+	return fmt.Sprintf("scanned %d file(s): %d referenced, %d in grace, quarantined %d (%d bytes), restored %d, purged %d (reclaimed %d bytes), %d failed",
+		r.Scanned, r.Referenced, r.InGrace, r.Quarantined, r.QuarantinedBytes, r.Restored, r.Purged, r.ReclaimedBytes, r.Failed)
+}
+
+// Run makes one pass over the store.
+func Run(ctx context.Context, store *models.Store, blobs storage.BlobStore, opts Options) (Report, error) {
+	var rep Report
+	log := logging.FromContext(ctx)
+	now := time.Now()
+
+	// Read the references before listing files: a file saved after this
+	// point is newer than the grace period, so it can't be mistaken for an
+	// orphan.
+	refs, err := referencedKeys(ctx, store)
+	if err != nil {
+		return rep, fmt.Errorf("uploadgc: listing references: %w", err)
+	}
+
+	var orphans []storage.Object
+	for _, prefix := range Prefixes {
+		err := blobs.Walk(ctx, prefix, func(o storage.Object) error {
+			rep.Scanned++
+			switch {
+			case refs[o.Key]:
+				rep.Referenced++
+			case now.Sub(o.ModTime) < opts.Grace:
+				rep.InGrace++
+			default:
+				orphans = append(orphans, o)
+			}
+			return nil
+		})
+		if err != nil {
+			return rep, fmt.Errorf("uploadgc: listing %s: %w", prefix, err)
+		}
+	}
+
+	var quarantined []storage.Object
+	err = blobs.Walk(ctx, storage.QuarantinePrefix, func(o storage.Object) error {
+		quarantined = append(quarantined, o)
+		return nil
+	})
+	if err != nil {
+		return rep, fmt.Errorf("uploadgc: listing %s: %w", storage.QuarantinePrefix, err)
+	}
+
+	fail := func(action, key string, err error) {
+		rep.Failed++
+		log.Warn("upload collector: "+action, slog.String("key", key), slog.Any("error", err))
+	}
+
+	for _, o := range orphans {
+		if !opts.DryRun {
+			if err := move(ctx, blobs, o, storage.QuarantinePrefix+o.Key); err != nil {
+				fail("quarantining", o.Key, err)
+				continue
+			}
+			metrics.UploadsCollected.WithLabelValues("quarantined").Inc()
+		}
+		rep.Quarantined++
+		rep.QuarantinedBytes += o.Size
+	}
+
+	for _, o := range quarantined {
+		original := strings.TrimPrefix(o.Key, storage.QuarantinePrefix)
+		switch {
+		case refs[original]:
+			if !opts.DryRun {
+				if err := move(ctx, blobs, o, original); err != nil {
+					fail("restoring", o.Key, err)
+					continue
+				}
+				metrics.UploadsCollected.WithLabelValues("restored").Inc()
+			}
+			rep.Restored++
+		case now.Sub(o.ModTime) >= opts.Retention:
+			if !opts.DryRun {
+				if err := blobs.Delete(ctx, o.Key); err != nil {
+					fail("purging", o.Key, err)
+					continue
+				}
+				metrics.UploadsCollected.WithLabelValues("purged").Inc()
+				metrics.UploadsReclaimed.Add(float64(o.Size))
+			}
+			rep.Purged++
+			rep.ReclaimedBytes += o.Size
+		}
+	}
+	return rep, nil
+}
+
+// Start runs the collector every interval until ctx is done, logging each
+// run that did something.
+func Start(ctx context.Context, store *models.Store, blobs storage.BlobStore, interval time.Duration, opts Options) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+			rep, err := Run(ctx, store, blobs, opts)
+			switch {
+			case err != nil:
+				slog.Error("upload collector", "err", err)
+			case rep.Busy():
+				slog.Info("upload collector", "report", rep)
+			}
+		}
+	}()
+}
+
+// referencedKeys returns the store keys of every file a record points at,
+// with all the sizes of each picture.
+func referencedKeys(ctx context.Context, store *models.Store) (map[string]bool, error) {
+	paths, err := store.Uploads.Referenced(ctx)
+	if err != nil {
+		return nil, err
+	}
+	keys := make(map[string]bool, len(paths))
+	for _, p := range paths {
+		variants, _ := images.Variants(p)
+		for _, v := range append(variants, p) {
+			if key, ok := storage.KeyFromPath(v); ok {
+				keys[key] = true
+			}
+		}
+	}
+	return keys, nil
+}
+
+// move copies o to key and deletes the original. If the original was
+// rewritten meanwhile, by an upload of the same picture, it is kept.
+func move(ctx context.Context, blobs storage.BlobStore, o storage.Object, key string) error {
+	r, err := blobs.Open(ctx, o.Key)
+	if err != nil {
+		return err
+	}
+	err = blobs.Put(ctx, key, r, o.Size, mime.TypeByExtension(path.Ext(key)))
+	r.Close()
+	if err != nil {
+		return err
+	}
+	// Listings and Stat may report times at different precisions (S3's
+	// Last-Modified header has whole seconds), so compare at that.
+	current, err := blobs.Stat(ctx, o.Key)
+	switch {
+	case errors.Is(err, storage.ErrNotFound):
+		return nil
+	case err != nil:
+		return err
+	case current.ModTime.Truncate(time.Second).After(o.ModTime.Truncate(time.Second)):
+		return nil
+	}
+	return blobs.Delete(ctx, o.Key)
+}
@@ -0,0 +1,153 @@
+package uploadgc_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Entity069/Zesty-Go/pkg/models"
+	"github.com/Entity069/Zesty-Go/pkg/models/memstore"
+	"github.com/Entity069/Zesty-Go/pkg/storage"
+	"github.com/Entity069/Zesty-Go/pkg/uploadgc"
+)
+
+const hash = "0123456789abcdef0123456789abcdef"
+
+type fixture struct {
+	dir   string
+	blobs storage.BlobStore
+	store *models.Store
+}
+
+func newFixture(t *testing.T) *fixture {
+	dir := t.TempDir()
+	return &fixture{dir: dir, blobs: storage.NewLocal(dir), store: memstore.New()}
+}
+
+// put stores key with a modification time age ago.
+func (f *fixture) put(t *testing.T, key string, age time.Duration) {
+	t.Helper()
+	if err := f.blobs.Put(context.Background(), key, strings.NewReader(key), int64(len(key)), ""); err != nil {
+		t.Fatal(err)
+	}
+	then := time.Now().Add(-age)
+	if err := os.Chtimes(filepath.Join(f.dir, filepath.FromSlash(key)), then, then); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func (f *fixture) exists(t *testing.T, key string) bool {
+	t.Helper()
+	_, err := f.blobs.Stat(context.Background(), key)
+	if err != nil && !errors.Is(err, storage.ErrNotFound) {
+		t.Fatal(err)
+	}
+	return err == nil
+}
+
+func (f *fixture) run(t *testing.T, dryRun bool) uploadgc.Report {
+	t.Helper()
+	rep, err := uploadgc.Run(context.Background(), f.store, f.blobs, uploadgc.Options{Grace: time.Hour, Retention: 24 * time.Hour, DryRun: dryRun})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return rep
+}
+
+func TestRun(t *testing.T) {
+	f := newFixture(t)
+	ctx := context.Background()
+	old := 48 * time.Hour
+
+	// An item shows a resized picture; all three sizes are in use.
+	item := &models.Item{Name: "Dosa", Image: "/uploads/item-images/" + hash + "-1600w.jpg"}
+	if err := f.store.Items.Create(ctx, item); err != nil {
+		t.Fatal(err)
+	}
+	for _, w := range []string{"160", "480", "1600"} {
+		f.put(t, "item-images/"+hash+"-"+w+"w.jpg", old)
+	}
+	// So does a user, with a picture from before resizing.
+	if err := f.store.Users.Create(ctx, &models.User{Email: "a@zes.ty", ProfilePic: "/uploads/profile-pics/profile_1.png"}); err != nil {
+		t.Fatal(err)
+	}
+	f.put(t, "profile-pics/profile_1.png", old)
+
+	f.put(t, "item-images/replaced.jpg", old)        // orphaned
+	f.put(t, "item-images/just-uploaded.jpg", 0)     // orphaned, but maybe not for long
+	f.put(t, "quarantine/item-images/gone.jpg", old) // quarantined long enough
+	f.put(t, "quarantine/item-images/recent.jpg", time.Hour)
+	f.put(t, "exports/report.csv", old) // not an upload directory
+
+	if rep := f.run(t, true); rep.Quarantined != 1 || rep.Purged != 1 || !f.exists(t, "item-images/replaced.jpg") || !f.exists(t, "quarantine/item-images/gone.jpg") {
+		t.Fatalf("dry run: %+v, or it changed files", rep)
+	}
+
+	rep := f.run(t, false)
+	want := uploadgc.Report{Scanned: 6, Referenced: 4, InGrace: 1, Quarantined: 1, QuarantinedBytes: int64(len("item-images/replaced.jpg")),
+		Purged: 1, ReclaimedBytes: int64(len("quarantine/item-images/gone.jpg"))}
+	if rep != want {
+		t.Fatalf("report = %+v\nwant     %+v", rep, want)
+	}
+	for key, want := range map[string]bool{
+		"item-images/" + hash + "-160w.jpg":   true,
+		"item-images/" + hash + "-1600w.jpg":  true,
+		"profile-pics/profile_1.png":          true,
+		"item-images/just-uploaded.jpg":       true,
+		"exports/report.csv":                  true,
+		"item-images/replaced.jpg":            false,
+		"quarantine/item-images/replaced.jpg": true,
+		"quarantine/item-images/gone.jpg":     false,
+		"quarantine/item-images/recent.jpg":   true,
+	} {
+		if got := f.exists(t, key); got != want {
+			t.Errorf("%s exists = %v, want %v", key, got, want)
+		}
+	}
+
+	// Pointing the item back at the quarantined picture brings it back.
+	item.Image = "/uploads/item-images/replaced.jpg"
+	if err := f.store.Items.Update(ctx, item); err != nil {
+		t.Fatal(err)
+	}
+	if rep := f.run(t, false); rep.Restored != 1 {
+		t.Fatalf("report = %+v, want 1 restored", rep)
+	}
+	if !f.exists(t, "item-images/replaced.jpg") || f.exists(t, "quarantine/item-images/replaced.jpg") {
+		t.Fatal("the picture was not moved back out of quarantine")
+	}
+}
+
+func TestRunIsIdempotent(t *testing.T) {
+	f := newFixture(t)
+	f.put(t, "item-images/orphan.jpg", 48*time.Hour)
+	f.run(t, false)
+	if rep := f.run(t, false); rep.Busy() {
+		t.Fatalf("second run did something: %+v", rep)
+	}
+}
+
+func TestRunCategoryImages(t *testing.T) {
+	f := newFixture(t)
+	ctx := context.Background()
+	old := 48 * time.Hour
+	category := &models.Category{Name: "Breakfast", Image: "/uploads/category-images/" + hash + "-1600w.jpg"}
+	if err := f.store.Categories.Create(ctx, category); err != nil {
+		t.Fatal(err)
+	}
+	for _, w := range []string{"160", "480", "1600"} {
+		f.put(t, "category-images/"+hash+"-"+w+"w.jpg", old)
+	}
+	f.put(t, "category-images/replaced.jpg", old)
+
+	if rep := f.run(t, false); rep.Scanned != 4 || rep.Referenced != 3 || rep.Quarantined != 1 {
+		t.Errorf("report %+v, want the category's picture kept and the old one quarantined", rep)
+	}
+	if !f.exists(t, "category-images/"+hash+"-480w.jpg") || !f.exists(t, "quarantine/category-images/replaced.jpg") {
+		t.Error("category images weren't collected like the others")
+	}
+}
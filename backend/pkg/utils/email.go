@@ -2,14 +2,55 @@ package utils
 
 import (
 	"bytes"
+	"context"
+	"errors"
 	"fmt"
 	"html/template"
+	"net"
 	"net/smtp"
+	"path/filepath"
+	"strings"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 
 	"github.com/Entity069/Zesty-Go/pkg/config"
+	"github.com/Entity069/Zesty-Go/pkg/logging"
+	"github.com/Entity069/Zesty-Go/pkg/metrics"
+	"github.com/Entity069/Zesty-Go/pkg/tracing"
 )
 
-func SendEmail(to, subject, templatePath string, data interface{}) error {
+type Mailer struct {
+	cfg config.EmailConfig
+}
+
+func NewMailer(cfg config.EmailConfig) *Mailer {
+	return &Mailer{cfg: cfg}
+}
+
+// SendEmail renders templatePath with data and mails it to to. The send is
+// traced as a child of ctx; the recipient isn't recorded on the span.
+func (m *Mailer) SendEmail(ctx context.Context, to, subject, templatePath string, data interface{}) error {
+	name := strings.TrimSuffix(filepath.Base(templatePath), filepath.Ext(templatePath))
+	ctx, span := tracing.Tracer().Start(ctx, "email.send", trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(
+		attribute.String("email.template", name),
+		attribute.String("email.backend", m.cfg.Backend),
+	))
+	defer span.End()
+
+	result, err := "sent", m.send(ctx, to, subject, templatePath, data)
+	tracing.SpanError(span, err)
+	switch {
+	case err != nil:
+		result = "failed"
+	case m.cfg.Backend == "log":
+		result = "logged"
+	}
+	metrics.EmailsSent.WithLabelValues(name, result).Inc()
+	return err
+}
+
+func (m *Mailer) send(ctx context.Context, to, subject, templatePath string, data interface{}) error {
 	tmpl, err := template.ParseFiles(templatePath)
 	if err != nil {
 		return fmt.Errorf("parsing template failed: %w", err)
@@ -20,11 +61,15 @@ func SendEmail(to, subject, templatePath string, data interface{}) error {
 		return fmt.Errorf("executing template failed: %w", err)
 	}
 
-	emailCfg := config.LoadEmailConfig()
-	auth := smtp.PlainAuth("", emailCfg.Address, emailCfg.Password, emailCfg.Host)
+	if m.cfg.Backend == "log" {
+		logging.FromContext(ctx).Info("email not sent (log backend)", "to", to, "subject", subject, "body", body.String())
+		return nil
+	}
+
+	auth := smtp.PlainAuth("", m.cfg.Address, m.cfg.Password, m.cfg.Host)
 
 	headers := map[string]string{
-		"From":         emailCfg.Address,
+		"From":         m.cfg.Address,
 		"To":           to,
 		"Subject":      subject,
 		"MIME-Version": "1.0",
@@ -38,9 +83,29 @@ func SendEmail(to, subject, templatePath string, data interface{}) error {
 	msg.WriteString("\r\n")
 	msg.Write(body.Bytes())
 
-	addr := fmt.Sprintf("%s:%s", emailCfg.Host, emailCfg.Port)
-	if err := smtp.SendMail(addr, auth, emailCfg.Address, []string{to}, msg.Bytes()); err != nil {
+	addr := fmt.Sprintf("%s:%s", m.cfg.Host, m.cfg.Port)
+	if err := smtp.SendMail(addr, auth, m.cfg.Address, []string{to}, msg.Bytes()); err != nil {
 		return fmt.Errorf("send mail failed: %w", err)
 	}
 	return nil
 }
+
+// errUnreachable is all Check says about a failed dial: the readiness report
+// is public, and the dial error names the server.
+var errUnreachable = errors.New("smtp server unreachable")
+
+// Check reports whether mail can go out: always for the log backend, and
+// when the SMTP server accepts a connection otherwise. Why a connection
+// failed is logged rather than returned.
+func (m *Mailer) Check(ctx context.Context) error {
+	if m.cfg.Backend == "log" {
+		return nil
+	}
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", net.JoinHostPort(m.cfg.Host, m.cfg.Port))
+	if err != nil {
+		logging.FromContext(ctx).Warn("smtp readiness check", "err", err)
+		return errUnreachable
+	}
+	return conn.Close()
+}
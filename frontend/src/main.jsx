@@ -2,10 +2,13 @@ import React from "react"
 import ReactDOM from "react-dom/client"
 import { BrowserRouter } from "react-router-dom"
 import App from "./App.jsx"
+import { installCsrf } from "./utils/csrf.js"
 import "bootstrap/dist/css/bootstrap.min.css"
 import "@fortawesome/fontawesome-free/css/all.min.css"
 import "./index.css"
 
+installCsrf()
+
 ReactDOM.createRoot(document.getElementById("root")).render(
   <BrowserRouter>
     <App />
@@ -1,7 +1,7 @@
 "use client"
 
 import { useState, useEffect } from "react"
-import { Container, Table, Badge, Button } from "react-bootstrap"
+import { Container, Table, Badge, Button, Form, Alert } from "react-bootstrap"
 import { useNavigate } from "react-router-dom"
 import Layout from "../../components/Layout"
 import { useToast } from "../../context/ToastContext"
@@ -10,6 +10,9 @@ import { intcomma } from "../../utils/helpers"
 const ManageItems = () => {
   const [items, setItems] = useState([])
   const [loading, setLoading] = useState(true)
+  const [importFile, setImportFile] = useState(null)
+  const [report, setReport] = useState(null)
+  const [importing, setImporting] = useState(false)
   const { showSuccess, showError } = useToast()
   const navigate = useNavigate()
 
@@ -45,6 +48,95 @@ const ManageItems = () => {
     }
   }
 
+  // sendImport uploads the chosen file; a dry run only reports what it would do.
+  const sendImport = async (dryRun) => {
+    const form = new FormData()
+    form.append("file", importFile)
+    setImporting(true)
+    try {
+      const response = await fetch(`/api/v1/seller/items/import${dryRun ? "?dry_run=1" : ""}`, {
+        method: "POST",
+        credentials: "include",
+        body: form,
+      })
+      const data = await response.json()
+      if (!data.success) {
+        showError("Import Failed", data.msg)
+        return
+      }
+      if (dryRun) {
+        setReport(data.report)
+        return
+      }
+      showSuccess("Items Imported", data.msg)
+      setImportFile(null)
+      setReport(null)
+      fetchItems()
+    } catch (error) {
+      showError("Error", "Something went wrong. Please try again.")
+    } finally {
+      setImporting(false)
+    }
+  }
+
+  useEffect(() => {
+    setReport(null)
+    if (importFile) {
+      sendImport(true)
+    }
+  }, [importFile])
+
+  const toolbar = (
+    <div className="food-card mb-4">
+      <div className="card-body d-flex flex-wrap align-items-center gap-2">
+        <Form.Control
+          type="file"
+          accept=".csv,.json"
+          style={{ maxWidth: "320px" }}
+          key={importFile ? "chosen" : "empty"}
+          onChange={(e) => setImportFile(e.target.files[0] || null)}
+        />
+        <Button
+          variant="primary"
+          disabled={!report || report.problems.length > 0 || importing}
+          onClick={() => sendImport(false)}
+        >
+          <i className="fas fa-file-import me-2"></i>Import
+        </Button>
+        <div className="ms-auto d-flex gap-2">
+          <Button variant="outline-secondary" href="/api/v1/seller/items/export?format=csv">
+            <i className="fas fa-file-csv me-2"></i>Export CSV
+          </Button>
+          <Button variant="outline-secondary" href="/api/v1/seller/items/export?format=json">
+            <i className="fas fa-file-code me-2"></i>Export JSON
+          </Button>
+        </div>
+      </div>
+      {report && (
+        <div className="card-body pt-0">
+          {report.problems.length > 0 ? (
+            <Alert variant="danger" className="mb-0">
+              <strong>Fix these before importing:</strong>
+              <ul className="mb-0">
+                {report.problems.map((p, i) => (
+                  <li key={i}>
+                    Row {p.row}
+                    {p.sku && ` (${p.sku})`}: {p.field} {p.message}
+                  </li>
+                ))}
+              </ul>
+            </Alert>
+          ) : (
+            <Alert variant="info" className="mb-0">
+              {report.created} to create, {report.updated} to update, {report.unchanged} unchanged
+              {report.images_queued > 0 && `; ${report.images_queued} picture(s) will be fetched afterwards`}.
+            </Alert>
+          )}
+        </div>
+      )}
+    </div>
+  )
+
   const handleEditItem = (itemId) => {
     navigate(`/seller/edit-items/${itemId}`)
   }
@@ -79,6 +171,7 @@ const ManageItems = () => {
     return (
       <Layout>
         <Container fluid className="p-4">
+          {toolbar}
           <div className="text-center py-5">
             <i className="fas fa-utensils fs-1 text-muted mb-3"></i>
             <h4 className="text-muted">No items found</h4>
@@ -97,6 +190,7 @@ const ManageItems = () => {
   return (
     <Layout>
       <Container fluid className="p-4">
+        {toolbar}
         <div className="food-card">
           <div className="card-body">
             <div className="table-responsive">
@@ -104,6 +198,7 @@ const ManageItems = () => {
                 <thead>
                   <tr className="text-center">
                     <th>Image</th>
+                    <th>SKU</th>
                     <th>Name</th>
                     <th>Description</th>
                     <th>Category</th>
@@ -124,12 +219,16 @@ const ManageItems = () => {
                           style={{ width: "120px", height: "80px", objectFit: "cover" }}
                         />
                       </td>
+                      <td className="text-muted small">{item.sku || "—"}</td>
                       <td>
                         <strong>{item.name}</strong>
                       </td>
                       <td className="text-muted small">{item.description}</td>
                       <td>
-                        <Badge bg="info">{item.cname}</Badge>
+                        <Badge bg={item.category_deleted ? "secondary" : "info"}>
+                          {item.cname}
+                          {item.category_deleted && " (deleted)"}
+                        </Badge>
                       </td>
                       <td>₹{intcomma(item.price)}</td>
                       <td>
@@ -93,7 +93,13 @@ const CategoryItems = () => {
                   style={{ cursor: "pointer" }}
                   onClick={() => navigate(`/item/${item.id}`)}
                 >
-                  <Card.Img variant="top" src={item.image} alt={item.name} />
+                  <Card.Img
+                    variant="top"
+                    src={item.image}
+                    srcSet={item.image_srcset}
+                    sizes="(min-width: 992px) 33vw, (min-width: 768px) 50vw, 100vw"
+                    alt={item.name}
+                  />
                   <Card.Body>
                     <div className="d-flex align-items-center mb-2">
                       <div className="rating me-2">
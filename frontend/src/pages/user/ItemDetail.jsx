@@ -192,6 +192,8 @@ const ItemDetail = () => {
               <div className="card-body p-0">
                 <Card.Img
                   src={item.image}
+                  srcSet={item.image_srcset}
+                  sizes="(min-width: 992px) 50vw, 100vw"
                   alt={item.name}
                   className="w-100"
                   style={{ height: "400px", objectFit: "cover", borderRadius: "16px" }}
@@ -0,0 +1,36 @@
+const CSRF_COOKIE = "csrf_token"
+const CSRF_HEADER = "X-CSRF-Token"
+const SAFE_METHODS = ["GET", "HEAD", "OPTIONS", "TRACE"]
+
+function readCookie(name) {
+  const match = document.cookie.split("; ").find((c) => c.startsWith(name + "="))
+  return match ? decodeURIComponent(match.slice(name.length + 1)) : ""
+}
+
+async function csrfToken(fetchFn) {
+  const token = readCookie(CSRF_COOKIE)
+  if (token) {
+    return token
+  }
+  const response = await fetchFn("/api/v1/auth/csrf", { credentials: "include" })
+  const data = await response.json()
+  return data.csrf_token
+}
+
+// installCsrf makes every state-changing request to the API carry the CSRF
+// token the backend checks, so pages can keep calling fetch directly.
+export function installCsrf() {
+  const fetchFn = window.fetch.bind(window)
+
+  window.fetch = async (input, init = {}) => {
+    const url = typeof input === "string" ? input : input.url
+    const method = (init.method || (typeof input === "string" ? "GET" : input.method) || "GET").toUpperCase()
+    if (SAFE_METHODS.includes(method) || !new URL(url, window.location.href).pathname.startsWith("/api/")) {
+      return fetchFn(input, init)
+    }
+
+    const headers = new Headers(init.headers || (typeof input === "string" ? undefined : input.headers))
+    headers.set(CSRF_HEADER, await csrfToken(fetchFn))
+    return fetchFn(input, { ...init, headers })
+  }
+}